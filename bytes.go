@@ -379,3 +379,103 @@ func (Bytes65) Generate(r *rand.Rand, size int) reflect.Value {
 	v, _ := quick.Value(reflect.TypeOf([65]byte{}), r)
 	return reflect.ValueOf(NewBytes65(v.Interface().([65]byte)))
 }
+
+// BytesN represents a static-sized byte array of any length. It is used for
+// static-sized byte arrays that are not 32-byte arrays or 65-byte arrays (e.g.
+// 20-byte addresses, or 64-byte signatures). Unlike Bytes, the length of the
+// byte array is part of its type, so it is not marshaled in binary.
+type BytesN []byte
+
+// NewBytesN copies an existing raw slice of bytes into a static-sized byte
+// array with the same length.
+func NewBytesN(x []byte) BytesN {
+	copied := make(BytesN, len(x))
+	copy(copied, x)
+	return copied
+}
+
+// Type returns the static-sized byte array type.
+func (x BytesN) Type() Type {
+	return typeBytesN{N: uint32(len(x))}
+}
+
+// Bytes returns a copy of the byte array as a dynamic byte slice.
+func (x BytesN) Bytes() []byte {
+	copied := make([]byte, len(x))
+	copy(copied, x)
+	return copied
+}
+
+// Equal returns true when x is equal to y. Otherwise, it returns false.
+func (x BytesN) Equal(y BytesN) bool {
+	return bytes.Equal([]byte(x), []byte(y))
+}
+
+// SizeHint returns the number of bytes required to represent the byte array in
+// binary.
+func (x BytesN) SizeHint() int {
+	return len(x)
+}
+
+// Marshal the byte array to binary.
+func (x BytesN) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	n := len(x)
+	if len(buf) < n || rem < n {
+		return buf, rem, surge.ErrUnexpectedEndOfBuffer
+	}
+	copy(buf, x)
+	return buf[n:], rem - n, nil
+}
+
+// Unmarshal the byte array from binary. Because the length of the byte array
+// is not marshaled, the byte array must already have the expected length.
+func (x *BytesN) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	n := len(*x)
+	if len(buf) < n || rem < n {
+		return buf, rem, surge.ErrUnexpectedEndOfBuffer
+	}
+	copy(*x, buf[:n])
+	return buf[n:], rem - n, nil
+}
+
+// MarshalJSON marshals the byte array to JSON. This is done by encoding the
+// bytes into a base64 raw URL encoded string.
+func (x BytesN) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString([]byte(x)))
+}
+
+// UnmarshalJSON unmarshals the byte array from JSON. This is done by decoding
+// the bytes from a base64 raw URL encoded string. Because the length of the
+// byte array is part of its type, the byte array must already have the
+// expected length.
+func (x *BytesN) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(str)
+	if err != nil {
+		return err
+	}
+	if len(data) != len(*x) {
+		return fmt.Errorf("expected len=%v, got len=%v", len(*x), len(data))
+	}
+	copy(*x, data)
+	return nil
+}
+
+// String returns a base64 raw URL encoding of the bytes.
+func (x BytesN) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(x))
+}
+
+// Generate a random static-sized byte array. This method is implemented for
+// use in quick tests. See https://golang.org/pkg/testing/quick/#Generator for
+// more information.
+func (BytesN) Generate(r *rand.Rand, size int) reflect.Value {
+	data := make([]byte, r.Intn(size+1))
+	if _, err := r.Read(data); err != nil {
+		panic(err)
+	}
+	return reflect.ValueOf(BytesN(data))
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/renproject/pack"
 	"github.com/renproject/pack/packutil"
+	"github.com/renproject/surge"
 	"github.com/renproject/surge/surgeutil"
 
 	. "github.com/onsi/ginkgo"
@@ -353,4 +354,101 @@ var _ = Describe("Bytes", func() {
 			Expect(pack.NewBytes65([65]byte{}).Type().Kind()).To(Equal(pack.KindBytes65))
 		})
 	})
+
+	Context("when getting type information for static-sized byte arrays", func() {
+		It("should return the static-sized byte array type with the same length", func() {
+			x := pack.NewBytesN(make([]byte, 20))
+			Expect(x.Type().Kind()).To(Equal(pack.KindBytesN))
+			Expect(x.Type().Equals(pack.NewBytesN(make([]byte, 20)).Type())).To(BeTrue())
+			Expect(x.Type().Equals(pack.NewBytesN(make([]byte, 33)).Type())).To(BeFalse())
+		})
+	})
+
+	Context("when fuzzing static-sized byte arrays", func() {
+		It("should not panic", func() {
+			Expect(func() { surgeutil.Fuzz(reflect.TypeOf(pack.BytesN{})) }).ToNot(Panic())
+			Expect(func() { packutil.JSONFuzz(reflect.TypeOf(pack.BytesN{})) }).ToNot(Panic())
+		})
+	})
+
+	Context("when marshaling static-sized byte arrays", func() {
+		Context("when the buffer is too small", func() {
+			It("should return itself", func() {
+				for trial := 0; trial < numTrials; trial++ {
+					Expect(surgeutil.MarshalBufTooSmall(reflect.TypeOf(pack.BytesN{}))).To(Succeed())
+				}
+			})
+		})
+
+		Context("when the remaining memory quota is too small", func() {
+			It("should return itself", func() {
+				for trial := 0; trial < numTrials; trial++ {
+					Expect(surgeutil.MarshalRemTooSmall(reflect.TypeOf(pack.BytesN{}))).To(Succeed())
+				}
+			})
+		})
+	})
+
+	Context("when marshaling and unmarshaling static-sized byte arrays", func() {
+		It("should return itself", func() {
+			f := func(x pack.BytesN) bool {
+				data, err := surge.ToBinary(x)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal([]byte(x)))
+				y, _, _, err := x.Type().UnmarshalValue(data, len(data))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(y).To(Equal(x))
+
+				data, err = json.Marshal(x)
+				Expect(err).ToNot(HaveOccurred())
+				y, err = x.Type().UnmarshalValueJSON(data)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(y).To(Equal(x))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when unmarshaling a static-sized byte array", func() {
+		Context("when the buffer is too small", func() {
+			It("should return an error", func() {
+				t := pack.NewBytesN(make([]byte, 20)).Type()
+				_, _, _, err := t.UnmarshalValue(make([]byte, 19), 20)
+				Expect(err).To(HaveOccurred())
+				_, _, _, err = t.UnmarshalValue(make([]byte, 20), 19)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("when the string represents an array with a different length", func() {
+			It("should return an error", func() {
+				t := pack.NewBytesN(make([]byte, 20)).Type()
+				data, err := json.Marshal(base64.RawURLEncoding.EncodeToString(make([]byte, 21)))
+				Expect(err).ToNot(HaveOccurred())
+				_, err = t.UnmarshalValueJSON(data)
+				Expect(err).To(HaveOccurred())
+
+				x := make(pack.BytesN, 20)
+				Expect(x.UnmarshalJSON(data)).ToNot(Succeed())
+			})
+		})
+	})
+
+	Context("when encoding and decoding static-sized Go byte arrays", func() {
+		It("should equal itself", func() {
+			f := func(x [20]byte) bool {
+				v, err := pack.Encode(x)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(v).To(Equal(pack.NewBytesN(x[:])))
+				y := [20]byte{}
+				Expect(pack.Decode(&y, v)).To(Succeed())
+				Expect(y).To(Equal(x))
+				z := [33]byte{}
+				Expect(pack.Decode(&z, v)).ToNot(Succeed())
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
 })
@@ -0,0 +1,386 @@
+// Command pack inspects values that have been marshaled by pack. It reads from
+// a file, or from standard input, and supports the following commands:
+//
+//  pack json [-in format] [file]             print a Typed value as JSON
+//  pack binary [-out format] [file]          convert a Typed value from JSON to binary
+//  pack type [-in format] [file]             print the type of a Typed value
+//  pack validate -type schema [-in format] [file]
+//                                            check that a value has the given type
+//  pack dump [-type schema] [-in format] [file]
+//                                            print an annotated hex dump of a value
+//
+// Binary input can be raw, hex (with or without a "0x" prefix), or base64, and
+// the format is detected automatically unless it is given with the -in flag.
+// Input that is entirely printable ASCII (ignoring whitespace) is decoded as
+// hex if it is valid hex, and otherwise as base64 if it is valid base64. All
+// other input is used as raw binary.
+// When binary input cannot be unmarshaled, the byte offset at which
+// unmarshaling failed is reported (after decoding hex or base64).
+//
+// For example:
+//
+//  pack json -in hex message.hex
+//  pack validate -type "struct { amount: u256, to: bytes32 }" value.bin
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/surge"
+)
+
+// A command reads the input and writes its output.
+type command struct {
+	usage string
+	run   func(flags *flag.FlagSet, args []string, stdin io.Reader, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"json":     {"json [-in auto|binary|hex|base64] [file]", runJSON},
+	"binary":   {"binary [-out binary|hex|base64] [file]", runBinary},
+	"type":     {"type [-in auto|binary|hex|base64|json] [file]", runType},
+	"validate": {"validate -type schema [-in auto|binary|hex|base64|json] [file]", runValidate},
+	"dump":     {"dump [-type schema] [-in auto|binary|hex|base64] [file]", runDump},
+}
+
+// commandNames are the names of the commands, in the order that they are
+// listed in the usage.
+var commandNames = []string{"json", "binary", "type", "validate", "dump"}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		fmt.Fprintf(os.Stderr, "pack: %v\n", err)
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+// errUsage is returned when the command line is invalid.
+var errUsage = errors.New("invalid usage")
+
+// run the command named by the first argument.
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		return errUsage
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		printUsage(os.Stderr)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: pack %v\n", cmd.usage)
+		flags.PrintDefaults()
+		if flags.Lookup("in") != nil {
+			fmt.Fprintf(flags.Output(), "\n%v", autoFormatUsage)
+		}
+	}
+	return cmd.run(flags, args[1:], stdin, stdout)
+}
+
+// autoFormatUsage describes how the format of the input is detected when the
+// -in flag is "auto".
+const autoFormatUsage = `With -in auto, input starting with '{', '[', or '"' is JSON (for commands
+that accept JSON). Otherwise, input that is entirely printable ASCII is
+decoded as hex, then as base64, and all other input is raw binary.
+`
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage:\n")
+	for _, name := range commandNames {
+		fmt.Fprintf(w, "  pack %v\n", commands[name].usage)
+	}
+	fmt.Fprintf(w, "\n%v", autoFormatUsage)
+}
+
+func runJSON(flags *flag.FlagSet, args []string, stdin io.Reader, stdout io.Writer) error {
+	in := flags.String("in", "auto", "format of the input (auto, binary, hex, or base64)")
+	input, err := parseInput(flags, args, stdin)
+	if err != nil {
+		return err
+	}
+	data, err := decodeBinary(input, *in)
+	if err != nil {
+		return err
+	}
+	typed, err := unmarshalTyped(data)
+	if err != nil {
+		return err
+	}
+	output, err := json.MarshalIndent(typed, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling json: %w", err)
+	}
+	_, err = fmt.Fprintf(stdout, "%s\n", output)
+	return err
+}
+
+func runBinary(flags *flag.FlagSet, args []string, stdin io.Reader, stdout io.Writer) error {
+	out := flags.String("out", "binary", "format of the output (binary, hex, or base64)")
+	input, err := parseInput(flags, args, stdin)
+	if err != nil {
+		return err
+	}
+	typed, err := unmarshalTypedJSON(input)
+	if err != nil {
+		return err
+	}
+	data, err := surge.ToBinary(typed)
+	if err != nil {
+		return fmt.Errorf("marshaling binary: %w", err)
+	}
+	switch *out {
+	case "binary":
+		_, err = stdout.Write(data)
+	case "hex":
+		_, err = fmt.Fprintf(stdout, "%v\n", hex.EncodeToString(data))
+	case "base64":
+		_, err = fmt.Fprintf(stdout, "%v\n", base64.StdEncoding.EncodeToString(data))
+	default:
+		return fmt.Errorf("unknown output format %q", *out)
+	}
+	return err
+}
+
+func runType(flags *flag.FlagSet, args []string, stdin io.Reader, stdout io.Writer) error {
+	in := flags.String("in", "auto", "format of the input (auto, binary, hex, base64, or json)")
+	input, err := parseInput(flags, args, stdin)
+	if err != nil {
+		return err
+	}
+	var t pack.Type
+	if isJSON(input, *in) {
+		typed, err := unmarshalTypedJSON(input)
+		if err != nil {
+			return err
+		}
+		t = typed.Type()
+	} else {
+		data, err := decodeBinary(input, *in)
+		if err != nil {
+			return err
+		}
+		// Only the type is needed, so the value is not unmarshaled.
+		if _, _, err := pack.DefaultDecodeOptions.UnmarshalType(&t, data, len(data)); err != nil {
+			return fmt.Errorf("unmarshaling type: %w", err)
+		}
+	}
+	_, err = fmt.Fprintf(stdout, "%v\n", pack.FormatType(t))
+	return err
+}
+
+func runValidate(flags *flag.FlagSet, args []string, stdin io.Reader, stdout io.Writer) error {
+	schema := flags.String("type", "", "schema of the type of the value (required)")
+	in := flags.String("in", "auto", "format of the input (auto, binary, hex, base64, or json)")
+	input, err := parseInput(flags, args, stdin)
+	if err != nil {
+		return err
+	}
+	if *schema == "" {
+		flags.Usage()
+		return errUsage
+	}
+	t, err := pack.ParseType(*schema)
+	if err != nil {
+		return err
+	}
+	if isJSON(input, *in) {
+		if err := checkJSON(input); err != nil {
+			return err
+		}
+		if _, err := pack.DefaultDecodeOptions.UnmarshalValueJSON(t, input); err != nil {
+			return fmt.Errorf("unmarshaling value: %w", err)
+		}
+	} else {
+		data, err := decodeBinary(input, *in)
+		if err != nil {
+			return err
+		}
+		_, buf, _, err := pack.DefaultDecodeOptions.UnmarshalValue(t, data, len(data))
+		if err != nil {
+			return fmt.Errorf("unmarshaling value: %w", err)
+		}
+		if len(buf) > 0 {
+			return fmt.Errorf("unexpected %v bytes at offset %v", len(buf), len(data)-len(buf))
+		}
+	}
+	_, err = fmt.Fprintf(stdout, "ok\n")
+	return err
+}
+
+func runDump(flags *flag.FlagSet, args []string, stdin io.Reader, stdout io.Writer) error {
+	schema := flags.String("type", "", "schema of the type of the value (default the type of a Typed value)")
+	in := flags.String("in", "auto", "format of the input (auto, binary, hex, or base64)")
+	input, err := parseInput(flags, args, stdin)
+	if err != nil {
+		return err
+	}
+	data, err := decodeBinary(input, *in)
+	if err != nil {
+		return err
+	}
+	if *schema != "" {
+		t, err := pack.ParseType(*schema)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(stdout, pack.Dump(t, data))
+		return err
+	}
+
+	// Without a schema, the input is a Typed value, and its type is read from
+	// the start of the input. The value is dumped with offsets relative to the
+	// end of the type.
+	var t pack.Type
+	buf, _, err := pack.DefaultDecodeOptions.UnmarshalType(&t, data, len(data))
+	if err != nil {
+		return fmt.Errorf("unmarshaling type: %w", err)
+	}
+	_, err = fmt.Fprintf(stdout, "type: %v\nvalue at offset %v:\n%v", pack.FormatType(t), len(data)-len(buf), pack.Dump(t, buf))
+	return err
+}
+
+// parseInput parses the flags and returns the contents of the file named by
+// the remaining argument, or of stdin when there is no remaining argument.
+func parseInput(flags *flag.FlagSet, args []string, stdin io.Reader) ([]byte, error) {
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	switch flags.NArg() {
+	case 0:
+		return ioutil.ReadAll(stdin)
+	case 1:
+		return ioutil.ReadFile(flags.Arg(0))
+	default:
+		flags.Usage()
+		return nil, errUsage
+	}
+}
+
+// isJSON returns true if the input is JSON. When the format is "auto", JSON is
+// detected by its first non-space character.
+func isJSON(input []byte, format string) bool {
+	if format != "auto" {
+		return format == "json"
+	}
+	trimmed := bytes.TrimSpace(input)
+	return len(trimmed) > 0 && strings.IndexByte("{[\"", trimmed[0]) >= 0
+}
+
+// decodeBinary returns the binary data encoded by the input. When the format
+// is "auto", input that is entirely printable ASCII is decoded as hex if it is
+// valid hex, otherwise as base64 if it is valid base64. All other input is used
+// as raw binary.
+func decodeBinary(input []byte, format string) ([]byte, error) {
+	trimmed := strings.TrimSpace(string(input))
+	switch format {
+	case "auto":
+		if !isText(input) {
+			return input, nil
+		}
+		if data, err := hex.DecodeString(strings.TrimPrefix(trimmed, "0x")); err == nil && trimmed != "" {
+			return data, nil
+		}
+		if data, err := base64.StdEncoding.DecodeString(trimmed); err == nil && trimmed != "" {
+			return data, nil
+		}
+		return input, nil
+	case "binary":
+		return input, nil
+	case "hex":
+		data, err := hex.DecodeString(strings.TrimPrefix(trimmed, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("decoding hex: %w", err)
+		}
+		return data, nil
+	case "base64":
+		data, err := base64.StdEncoding.DecodeString(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("decoding base64: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unknown input format %q", format)
+	}
+}
+
+// isText returns true if every byte of the input is printable ASCII or
+// whitespace, so that it can be hex or base64.
+func isText(input []byte) bool {
+	for _, b := range input {
+		if (b < 0x20 || b > 0x7e) && b != '\t' && b != '\n' && b != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+// unmarshalTyped unmarshals a Typed value from binary. Errors include the byte
+// offset at which unmarshaling failed, relative to the start of the data.
+func unmarshalTyped(data []byte) (pack.Typed, error) {
+	var t pack.Type
+	buf, _, err := pack.DefaultDecodeOptions.UnmarshalType(&t, data, len(data))
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling type: %w", err)
+	}
+	v, rest, _, err := pack.DefaultDecodeOptions.UnmarshalValue(t, buf, len(buf))
+	if err != nil {
+		// The offset is relative to the start of the value, which follows
+		// the type.
+		decodeErr := new(pack.DecodeError)
+		if errors.As(err, &decodeErr) && decodeErr.Offset >= 0 {
+			decodeErr.Offset += len(data) - len(buf)
+		}
+		return nil, fmt.Errorf("unmarshaling value: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected %v bytes at offset %v", len(rest), len(data)-len(rest))
+	}
+	s, ok := v.(pack.Struct)
+	if !ok {
+		return nil, fmt.Errorf("expected kind \"struct\", got kind \"%v\"", t.Kind())
+	}
+	return pack.Typed(s), nil
+}
+
+// unmarshalTypedJSON unmarshals a Typed value from JSON.
+func unmarshalTypedJSON(input []byte) (pack.Typed, error) {
+	if err := checkJSON(input); err != nil {
+		return nil, err
+	}
+	typed := pack.Typed{}
+	if err := json.Unmarshal(input, &typed); err != nil {
+		return nil, fmt.Errorf("unmarshaling json: %w", err)
+	}
+	return typed, nil
+}
+
+// checkJSON returns an error, with the byte offset of the first syntax error,
+// if the input is not valid JSON.
+func checkJSON(input []byte) error {
+	raw := json.RawMessage{}
+	err := json.Unmarshal(input, &raw)
+	syntaxErr := new(json.SyntaxError)
+	if errors.As(err, &syntaxErr) {
+		return fmt.Errorf("invalid json at offset %v: %v", syntaxErr.Offset, err)
+	}
+	return err
+}
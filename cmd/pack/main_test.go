@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/surge"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// runString runs the command with the input, and returns its output.
+func runString(input []byte, args ...string) (string, error) {
+	stdout := new(bytes.Buffer)
+	err := run(args, bytes.NewReader(input), stdout)
+	return stdout.String(), err
+}
+
+var _ = Describe("Pack", func() {
+
+	numTrials := 100
+
+	typed := pack.NewTyped(
+		"amount", pack.NewU64(42),
+		"memo", pack.NewString("hello"),
+	)
+	data, err := surge.ToBinary(typed)
+	if err != nil {
+		panic(err)
+	}
+	jsonData, err := json.MarshalIndent(typed, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+
+	Context("when printing a typed value as json", func() {
+		It("should accept binary, hex, and base64", func() {
+			for _, input := range [][]byte{
+				data,
+				[]byte(hex.EncodeToString(data)),
+				[]byte("0x" + hex.EncodeToString(data) + "\n"),
+				[]byte(base64.StdEncoding.EncodeToString(data)),
+			} {
+				output, err := runString(input, "json")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output).To(Equal(string(jsonData) + "\n"))
+			}
+
+			output, err := runString([]byte(hex.EncodeToString(data)), "json", "-in", "hex")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output).To(Equal(string(jsonData) + "\n"))
+		})
+
+		It("should report the offset of errors", func() {
+			_, err := runString(data[:len(data)-1], "json", "-in", "binary")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(fmt.Sprintf("unmarshaling value: decoding .memo (string) at offset %v: unexpected end of buffer", len(data)-5)))
+
+			_, err = runString(data[:3], "json", "-in", "binary")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("unmarshaling type: decoding . (struct) at offset 1:"))
+
+			_, err = runString(append(data, 0), "json", "-in", "binary")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(fmt.Sprintf("unexpected 1 bytes at offset %v", len(data))))
+		})
+
+		It("should wrap decode errors", func() {
+			_, err := runString(data[:len(data)-1], "json", "-in", "binary")
+			Expect(errors.Is(err, surge.ErrUnexpectedEndOfBuffer)).To(BeTrue())
+			decodeErr := new(pack.DecodeError)
+			Expect(errors.As(err, &decodeErr)).To(BeTrue())
+			Expect(decodeErr.Path).To(Equal(".memo"))
+			Expect(decodeErr.Offset).To(Equal(len(data) - 5))
+		})
+	})
+
+	Context("when detecting the format of binary input", func() {
+		It("should only decode printable input as hex or base64", func() {
+			for _, input := range []string{"abcd", " abcd\n", "0xabcd\r\n"} {
+				output, err := decodeBinary([]byte(input), "auto")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output).To(Equal([]byte{0xab, 0xcd}))
+			}
+
+			output, err := decodeBinary([]byte("q80="), "auto")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output).To(Equal([]byte{0xab, 0xcd}))
+
+			for _, input := range []string{"\vabcd", "abcd\x00", "\x00q80=", "abcd\xff"} {
+				output, err := decodeBinary([]byte(input), "auto")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output).To(Equal([]byte(input)))
+			}
+		})
+	})
+
+	Context("when converting json to binary", func() {
+		It("should return the binary", func() {
+			output, err := runString(jsonData, "binary")
+			Expect(err).ToNot(HaveOccurred())
+			Expect([]byte(output)).To(Equal(data))
+
+			output, err = runString(jsonData, "binary", "-out", "hex")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output).To(Equal(hex.EncodeToString(data) + "\n"))
+
+			output, err = runString(jsonData, "binary", "-out", "base64")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output).To(Equal(base64.StdEncoding.EncodeToString(data) + "\n"))
+		})
+
+		It("should report the offset of syntax errors", func() {
+			_, err := runString([]byte(`{"t": }`), "binary")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("invalid json at offset 7:"))
+		})
+	})
+
+	Context("when converting random typed values", func() {
+		It("should return the same value", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < numTrials; trial++ {
+				typed := pack.Typed{}.Generate(r, 10).Interface().(pack.Typed)
+				data, err := surge.ToBinary(typed)
+				Expect(err).ToNot(HaveOccurred())
+
+				jsonOutput, err := runString(data, "json", "-in", "binary")
+				Expect(err).ToNot(HaveOccurred())
+				binaryOutput, err := runString([]byte(jsonOutput), "binary")
+				Expect(err).ToNot(HaveOccurred())
+				Expect([]byte(binaryOutput)).To(Equal(data))
+			}
+		})
+	})
+
+	Context("when printing the type", func() {
+		It("should return the schema", func() {
+			schema := pack.FormatType(typed.Type()) + "\n"
+			for _, input := range [][]byte{data, []byte(hex.EncodeToString(data)), jsonData} {
+				output, err := runString(input, "type")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output).To(Equal(schema))
+			}
+		})
+	})
+
+	Context("when validating a value", func() {
+		schema := "struct { amount: u64, memo: string }"
+
+		It("should accept values of the type", func() {
+			value, err := surge.ToBinary(pack.Struct(typed))
+			Expect(err).ToNot(HaveOccurred())
+			output, err := runString(value, "validate", "-type", schema, "-in", "binary")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output).To(Equal("ok\n"))
+
+			output, err = runString([]byte(`{"amount": "42", "memo": "hello"}`), "validate", "-type", schema)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output).To(Equal("ok\n"))
+		})
+
+		It("should reject values of other types", func() {
+			value, err := surge.ToBinary(pack.Struct(typed))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = runString(value, "validate", "-type", "struct { amount: u64, memo: string, ok: bool }", "-in", "binary")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(fmt.Sprintf("unmarshaling value: decoding .ok (bool) at offset %v: unexpected end of buffer", len(value))))
+
+			_, err = runString(value, "validate", "-type", "struct { amount: u64 }", "-in", "binary")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("unexpected %v bytes at offset 8", len(value)-8))
+
+			_, err = runString([]byte(`{"amount": "42"}`), "validate", "-type", schema)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when dumping a value", func() {
+		It("should print the regions of the value", func() {
+			value, err := surge.ToBinary(pack.Struct(typed))
+			Expect(err).ToNot(HaveOccurred())
+			dump := pack.Dump(typed.Type(), value)
+
+			output, err := runString(value, "dump", "-type", "struct { amount: u64, memo: string }", "-in", "binary")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output).To(Equal(dump))
+
+			output, err = runString([]byte(hex.EncodeToString(data)), "dump")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output).To(Equal(fmt.Sprintf("type: %v\nvalue at offset %v:\n%v", pack.FormatType(typed.Type()), len(data)-len(value), dump)))
+		})
+	})
+
+	Context("when the command line is invalid", func() {
+		It("should return an error", func() {
+			_, err := runString(nil)
+			Expect(err).To(HaveOccurred())
+			_, err = runString(nil, "unknown")
+			Expect(err).To(HaveOccurred())
+			_, err = runString(nil, "validate")
+			Expect(err).To(HaveOccurred())
+			_, err = runString(nil, "json", "a", "b")
+			Expect(err).To(HaveOccurred())
+			_, err = runString(data, "json", "-in", "xml")
+			Expect(err).To(HaveOccurred())
+			_, err = runString(jsonData, "binary", "-out", "xml")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
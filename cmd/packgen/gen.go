@@ -0,0 +1,1411 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	packPath  = "github.com/renproject/pack"
+	surgePath = "github.com/renproject/surge"
+)
+
+// kind is the kind of a Go type that is supported by the generator.
+type kind int
+
+const (
+	kindBool kind = iota
+	kindUint
+	kindInt
+	kindString
+	kindBytes
+	kindByteArray
+	kindValue
+	kindStruct
+	kindList
+	kindOptional
+	kindTuple
+	kindMap
+)
+
+// goType is a Go type that is supported by the generator.
+type goType struct {
+	kind kind
+	// expr is the Go expression for the type, as it must be written in the
+	// generated file.
+	expr string
+	// bits in the integer, for uints and ints.
+	bits int
+	// n is the length of the array, for byte arrays and tuples.
+	n int
+	// name of the pack value type (e.g. "U256"), or the name of the generated
+	// struct type.
+	name string
+	// elem is the element type of lists, optionals, and tuples, and the value
+	// type of maps.
+	elem *goType
+	// key is the key type of maps.
+	key *goType
+}
+
+// packValueSizes are the pack value types that can be used as field types,
+// mapped to the number of bytes in their binary representation (or -1 when
+// the number of bytes is not fixed).
+var packValueSizes = map[string]int{
+	"Bool":    1,
+	"U8":      1,
+	"U16":     2,
+	"U32":     4,
+	"U64":     8,
+	"U128":    16,
+	"U256":    32,
+	"I8":      1,
+	"I16":     2,
+	"I32":     4,
+	"I64":     8,
+	"I128":    16,
+	"I256":    32,
+	"String":  -1,
+	"Bytes":   -1,
+	"Bytes32": 32,
+	"Bytes65": 65,
+}
+
+// builtinTypes are the predeclared Go types that can be used as field types.
+var builtinTypes = map[string]goType{
+	"bool":    {kind: kindBool},
+	"uint8":   {kind: kindUint, bits: 8},
+	"byte":    {kind: kindUint, bits: 8},
+	"uint16":  {kind: kindUint, bits: 16},
+	"uint32":  {kind: kindUint, bits: 32},
+	"uint64":  {kind: kindUint, bits: 64},
+	"uint":    {kind: kindUint, bits: 64},
+	"uintptr": {kind: kindUint, bits: 64},
+	"int8":    {kind: kindInt, bits: 8},
+	"int16":   {kind: kindInt, bits: 16},
+	"int32":   {kind: kindInt, bits: 32},
+	"rune":    {kind: kindInt, bits: 32},
+	"int64":   {kind: kindInt, bits: 64},
+	"int":     {kind: kindInt, bits: 64},
+	"string":  {kind: kindString},
+}
+
+// typeDecl is a type declared in the package.
+type typeDecl struct {
+	spec *ast.TypeSpec
+	// packName is the name of the pack import in the file that declares the
+	// type, or the empty string if the file does not import pack.
+	packName string
+}
+
+// field is a field of a generated struct.
+type field struct {
+	goName string
+	name   string
+	t      *goType
+}
+
+// scope determines how generated statements refer to the buffer being
+// marshaled to, and how they return errors.
+type scope struct {
+	// buf and rem are the names of the buffer and remaining memory quota.
+	buf, rem string
+	// rets are the values returned before the error (e.g. "buf, rem, ").
+	rets string
+	// prefix is prepended to returned errors.
+	prefix string
+	// path is the Go expression for the path of the value, relative to the
+	// struct, split into parts that are joined when the expression is written
+	// (see pathExpr). When it is not empty, returned errors are wrapped in a
+	// pack.DecodeError with this path, kind, and offset, instead of being
+	// prefixed.
+	path []string
+	// kind is the Go expression for the pack kind of the value.
+	kind string
+	// offset is the Go expression for the offset of the returned errors, or
+	// "-1" when the offset is not known.
+	offset string
+}
+
+// at returns the scope of a value that is nested in the value of this scope,
+// and has the path of this scope followed by the given parts.
+func (s scope) at(parts ...string) scope {
+	s.path = append(append([]string{}, s.path...), parts...)
+	return s
+}
+
+type generator struct {
+	decls   map[string]typeDecl
+	structs map[string][]field
+	// methods are the names of the methods of each type declared in the
+	// package.
+	methods   map[string]map[string]bool
+	resolving map[string]bool
+	imports   map[string]bool
+	out       bytes.Buffer
+	// errUsed is true when the statements written since it was last reset
+	// use the err variable.
+	errUsed bool
+	// origUsed is true when the statements written since it was last reset
+	// use the orig variable, which stores the buffer being unmarshaled from.
+	origUsed bool
+	// tmps is the number of temporary variables written since it was last
+	// reset.
+	tmps int
+}
+
+// generate the methods for the named struct types, which must be declared in
+// the given files, and return the formatted Go source.
+func generate(pkgName string, files []*ast.File, typeNames []string) ([]byte, error) {
+	g := &generator{
+		decls:     map[string]typeDecl{},
+		structs:   map[string][]field{},
+		methods:   map[string]map[string]bool{},
+		resolving: map[string]bool{},
+		imports:   map[string]bool{},
+	}
+	for _, file := range files {
+		packName := ""
+		for _, imp := range file.Imports {
+			if path, _ := strconv.Unquote(imp.Path.Value); path == packPath {
+				packName = "pack"
+				if imp.Name != nil {
+					packName = imp.Name.Name
+				}
+			}
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				if decl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					spec := spec.(*ast.TypeSpec)
+					g.decls[spec.Name.Name] = typeDecl{spec: spec, packName: packName}
+				}
+			case *ast.FuncDecl:
+				if decl.Recv == nil || len(decl.Recv.List) != 1 {
+					continue
+				}
+				recv := decl.Recv.List[0].Type
+				if star, ok := recv.(*ast.StarExpr); ok {
+					recv = star.X
+				}
+				if ident, ok := recv.(*ast.Ident); ok {
+					if g.methods[ident.Name] == nil {
+						g.methods[ident.Name] = map[string]bool{}
+					}
+					g.methods[ident.Name][decl.Name.Name] = true
+				}
+			}
+		}
+	}
+
+	// Mark all types before resolving any fields, so that structs can refer
+	// to structs that are declared later.
+	for _, name := range typeNames {
+		decl, ok := g.decls[name]
+		if !ok {
+			return nil, fmt.Errorf("type %v not found", name)
+		}
+		if _, ok := decl.spec.Type.(*ast.StructType); !ok || decl.spec.Assign.IsValid() {
+			return nil, fmt.Errorf("type %v: expected struct", name)
+		}
+		if _, ok := g.structs[name]; ok {
+			return nil, fmt.Errorf("type %v: duplicate type", name)
+		}
+		g.structs[name] = nil
+	}
+	for _, name := range typeNames {
+		fields, err := g.structFields(name)
+		if err != nil {
+			return nil, fmt.Errorf("type %v: %v", name, err)
+		}
+		g.structs[name] = fields
+	}
+	for _, name := range typeNames {
+		if err := g.checkRecursion(name, nil); err != nil {
+			return nil, fmt.Errorf("type %v: %v", name, err)
+		}
+	}
+
+	body := bytes.Buffer{}
+	for _, name := range typeNames {
+		g.out.Reset()
+		g.writeStruct(name)
+		body.Write(g.out.Bytes())
+	}
+
+	src := bytes.Buffer{}
+	fmt.Fprintf(&src, "// Code generated by packgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %v\n\n", pkgName)
+	fmt.Fprintf(&src, "import (\n")
+	imports := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		imports = append(imports, path)
+	}
+	sort.Strings(imports)
+	for _, path := range imports {
+		if !strings.Contains(path, ".") {
+			fmt.Fprintf(&src, "%q\n", path)
+		}
+	}
+	fmt.Fprintf(&src, "\n")
+	for _, path := range imports {
+		if strings.Contains(path, ".") {
+			fmt.Fprintf(&src, "%q\n", path)
+		}
+	}
+	fmt.Fprintf(&src, ")\n")
+	src.Write(body.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated source: %v", err)
+	}
+	return formatted, nil
+}
+
+// structFields resolves the fields of a struct type. Field names are taken
+// from pack and json tags in the same way as pack.Encode.
+func (g *generator) structFields(name string) ([]field, error) {
+	decl := g.decls[name]
+	fields := []field{}
+	names := map[string]bool{}
+	for _, f := range decl.spec.Type.(*ast.StructType).Fields.List {
+		if len(f.Names) == 0 {
+			return nil, fmt.Errorf("embedded field %v: not supported", exprString(f.Type))
+		}
+		for _, ident := range f.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			fieldName, err := parseTag(f.Tag)
+			if err != nil {
+				return nil, fmt.Errorf("field %v: %v", ident.Name, err)
+			}
+			if fieldName == "-" {
+				continue
+			}
+			if fieldName == "" {
+				fieldName = ident.Name
+			}
+			if names[fieldName] {
+				return nil, fmt.Errorf("field %v: duplicate name %q", ident.Name, fieldName)
+			}
+			names[fieldName] = true
+			t, err := g.resolve(f.Type, decl.packName)
+			if err != nil {
+				return nil, fmt.Errorf("field %v: %v", ident.Name, err)
+			}
+			fields = append(fields, field{goName: ident.Name, name: fieldName, t: t})
+		}
+	}
+	return fields, nil
+}
+
+// parseTag returns the name in the pack tag, or the json tag if there is no
+// pack tag. It returns "-" if the field must be ignored, and the empty string if
+// the name is not set. Names are taken from json tags in the same way as
+// pack.Encode: fields with no name in their json tag (including fields with no
+// tag) are ignored. An error is returned for tag options that change the
+// encoding of the field.
+func parseTag(lit *ast.BasicLit) (string, error) {
+	if lit == nil {
+		return "-", nil
+	}
+	raw, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", fmt.Errorf("malformed tag: %v", err)
+	}
+	tag, isPack := reflect.StructTag(raw).Lookup("pack")
+	if !isPack {
+		return parseJSONTag(reflect.StructTag(raw).Get("json"))
+	}
+	tags := strings.Split(tag, ",")
+	if tags[0] == "-" && len(tags) == 1 {
+		return "-", nil
+	}
+	for _, option := range tags[1:] {
+		if option != "" {
+			return "", fmt.Errorf("unexpected option %q: tag options are not supported", option)
+		}
+	}
+	return tags[0], nil
+}
+
+// parseJSONTag returns the name in a json tag, in the same way as parseTag.
+func parseJSONTag(tag string) (string, error) {
+	tags := strings.Split(tag, ",")
+	for _, option := range tags[1:] {
+		if option == "tuple" {
+			return "", fmt.Errorf("unexpected option %q: tag options are not supported", option)
+		}
+	}
+	for _, name := range tags {
+		if name == "" {
+			return "-", nil
+		}
+		if name != "omitempty" {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+// resolve the Go type of a field. The name of the pack import in the file that
+// declares the field is needed to recognise pack value types.
+func (g *generator) resolve(expr ast.Expr, packName string) (*goType, error) {
+	switch expr := expr.(type) {
+	case *ast.ParenExpr:
+		return g.resolve(expr.X, packName)
+
+	case *ast.Ident:
+		if t, ok := builtinTypes[expr.Name]; ok {
+			t.expr = expr.Name
+			return &t, nil
+		}
+		return g.resolveNamed(expr.Name)
+
+	case *ast.SelectorExpr:
+		if x, ok := expr.X.(*ast.Ident); ok && packName != "" && x.Name == packName {
+			if _, ok := packValueSizes[expr.Sel.Name]; ok {
+				return &goType{kind: kindValue, expr: "pack." + expr.Sel.Name, name: expr.Sel.Name}, nil
+			}
+		}
+		return nil, fmt.Errorf("unsupported type %v", exprString(expr))
+
+	case *ast.StarExpr:
+		elem, err := g.resolve(expr.X, packName)
+		if err != nil {
+			return nil, err
+		}
+		return &goType{kind: kindOptional, expr: "*" + elem.expr, elem: elem}, nil
+
+	case *ast.ArrayType:
+		elem, err := g.resolve(expr.Elt, packName)
+		if err != nil {
+			return nil, err
+		}
+		isByte := elem.kind == kindUint && elem.bits == 8
+		if isByte && elem.expr != "byte" && elem.expr != "uint8" {
+			return nil, fmt.Errorf("unsupported type %v", exprString(expr))
+		}
+		if expr.Len == nil {
+			if isByte {
+				return &goType{kind: kindBytes, expr: "[]byte"}, nil
+			}
+			return &goType{kind: kindList, expr: "[]" + elem.expr, elem: elem}, nil
+		}
+		lit, ok := expr.Len.(*ast.BasicLit)
+		if !ok || lit.Kind != token.INT {
+			return nil, fmt.Errorf("unsupported type %v: array length must be an integer literal", exprString(expr))
+		}
+		n, err := strconv.ParseInt(lit.Value, 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported type %v: %v", exprString(expr), err)
+		}
+		if isByte {
+			return &goType{kind: kindByteArray, expr: fmt.Sprintf("[%d]byte", n), n: int(n)}, nil
+		}
+		return &goType{kind: kindTuple, expr: fmt.Sprintf("[%d]%v", n, elem.expr), n: int(n), elem: elem}, nil
+
+	case *ast.MapType:
+		key, err := g.resolve(expr.Key, packName)
+		if err != nil {
+			return nil, err
+		}
+		value, err := g.resolve(expr.Value, packName)
+		if err != nil {
+			return nil, err
+		}
+		return &goType{kind: kindMap, expr: "map[" + key.expr + "]" + value.expr, key: key, elem: value}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported type %v", exprString(expr))
+	}
+}
+
+// resolveNamed resolves a type that is declared in the package.
+func (g *generator) resolveNamed(name string) (*goType, error) {
+	decl, ok := g.decls[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %v", name)
+	}
+	if _, ok := g.structs[name]; ok {
+		return &goType{kind: kindStruct, expr: name, name: name}, nil
+	}
+	if _, ok := decl.spec.Type.(*ast.StructType); ok {
+		return nil, fmt.Errorf("unsupported type %v: struct types must also be generated", name)
+	}
+	if g.methods[name]["PackEncode"] || g.methods[name]["PackDecode"] {
+		return nil, fmt.Errorf("unsupported type %v: types that implement PackEncoder or PackDecoder are not supported", name)
+	}
+	if g.resolving[name] {
+		return nil, fmt.Errorf("unsupported type %v: recursive types are not supported", name)
+	}
+	g.resolving[name] = true
+	defer delete(g.resolving, name)
+
+	t, err := g.resolve(decl.spec.Type, decl.packName)
+	if err != nil {
+		return nil, err
+	}
+	if decl.spec.Assign.IsValid() {
+		// Aliases are identical to the aliased type.
+		return t, nil
+	}
+	switch t.kind {
+	case kindValue, kindStruct:
+		// Defined types do not have the methods of their underlying type.
+		return nil, fmt.Errorf("unsupported type %v: the underlying type cannot be %v", name, t.expr)
+	}
+	named := *t
+	named.expr = name
+	return &named, nil
+}
+
+// checkRecursion returns an error if a struct type contains itself, because
+// pack types cannot be recursive.
+func (g *generator) checkRecursion(name string, path []string) error {
+	for _, parent := range path {
+		if parent == name {
+			return fmt.Errorf("recursive types are not supported: %v", strings.Join(append(path, name), " -> "))
+		}
+	}
+	for _, f := range g.structs[name] {
+		for _, child := range structsIn(f.t) {
+			if err := g.checkRecursion(child, append(path, name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// structsIn returns the names of the generated structs in a type.
+func structsIn(t *goType) []string {
+	if t == nil {
+		return nil
+	}
+	if t.kind == kindStruct {
+		return []string{t.name}
+	}
+	return append(structsIn(t.key), structsIn(t.elem)...)
+}
+
+// fixedSize returns the number of bytes in the binary representation of all
+// values of the type, and true, or false if the number of bytes is not fixed.
+func (g *generator) fixedSize(t *goType) (int, bool) {
+	switch t.kind {
+	case kindBool:
+		return 1, true
+	case kindUint, kindInt:
+		return t.bits / 8, true
+	case kindByteArray:
+		return t.n, true
+	case kindValue:
+		n := packValueSizes[t.name]
+		return n, n >= 0
+	case kindStruct:
+		total := 0
+		for _, f := range g.structs[t.name] {
+			n, ok := g.fixedSize(f.t)
+			if !ok {
+				return 0, false
+			}
+			total += n
+		}
+		return total, true
+	case kindTuple:
+		n, ok := g.fixedSize(t.elem)
+		return n * t.n, ok
+	}
+	return 0, false
+}
+
+// isZeroSize returns true if values of the type have no binary
+// representation.
+func (g *generator) isZeroSize(t *goType) bool {
+	n, ok := g.fixedSize(t)
+	return ok && n == 0
+}
+
+// packType returns the Go expression for the pack type of a Go type.
+func (g *generator) packType(t *goType) string {
+	switch t.kind {
+	case kindBool:
+		return "pack.TypeBool()"
+	case kindUint:
+		return fmt.Sprintf("pack.TypeU%d()", t.bits)
+	case kindInt:
+		return fmt.Sprintf("pack.TypeI%d()", t.bits)
+	case kindString:
+		return "pack.TypeString()"
+	case kindBytes:
+		return "pack.TypeBytes()"
+	case kindByteArray:
+		switch t.n {
+		case 32:
+			return "pack.TypeBytes32()"
+		case 65:
+			return "pack.TypeBytes65()"
+		}
+		return fmt.Sprintf("pack.TypeBytesN(%d)", t.n)
+	case kindValue:
+		return "pack.Type" + t.name + "()"
+	case kindStruct:
+		return typeVar(t.name)
+	case kindList:
+		return "pack.ListType(" + g.packType(t.elem) + ")"
+	case kindOptional:
+		return "pack.OptionalType(" + g.packType(t.elem) + ")"
+	case kindTuple:
+		elems := make([]string, t.n)
+		for i := range elems {
+			elems[i] = g.packType(t.elem)
+		}
+		return "pack.TupleType(" + strings.Join(elems, ", ") + ")"
+	case kindMap:
+		return "pack.MapType(" + g.packType(t.key) + ", " + g.packType(t.elem) + ")"
+	}
+	panic(fmt.Sprintf("unexpected kind %v", t.kind))
+}
+
+// typeVar returns the name of the variable that stores the pack type of a
+// generated struct.
+func typeVar(name string) string {
+	return "packType" + strings.Title(name)
+}
+
+// writeStruct writes the generated methods for a struct type.
+func (g *generator) writeStruct(name string) {
+	fields := g.structs[name]
+	g.imports[packPath] = true
+
+	g.w("")
+	g.w("// " + typeVar(name) + " is the pack type of " + name + ".")
+	g.w("var " + typeVar(name) + " = pack.StructType(")
+	for _, f := range fields {
+		g.w(strconv.Quote(f.name) + ", " + g.packType(f.t) + ",")
+	}
+	g.w(")")
+
+	g.w("")
+	g.w("// Type returns the pack type of " + name + ".")
+	g.w("func (" + name + ") Type() pack.Type {")
+	g.w("return " + typeVar(name))
+	g.w("}")
+
+	g.w("")
+	g.w("// SizeHint returns the number of bytes required to represent " + name + " in")
+	g.w("// binary.")
+	g.w("func (x " + name + ") SizeHint() int {")
+	g.tmps = 0
+	fixed := 0
+	for _, f := range fields {
+		if n, ok := g.fixedSize(f.t); ok {
+			fixed += n
+		}
+	}
+	g.w(fmt.Sprintf("size := %d", fixed))
+	for _, f := range fields {
+		if _, ok := g.fixedSize(f.t); !ok {
+			g.writeSizeHint(f.t, "x."+f.goName, "size")
+		}
+	}
+	g.w("return size")
+	g.w("}")
+
+	g.w("")
+	g.w("// Marshal " + name + " to binary.")
+	g.w("func (x " + name + ") Marshal(buf []byte, rem int) ([]byte, int, error) {")
+	g.writeBody(func() {
+		for _, f := range fields {
+			g.writeMarshal(f.t, "x."+f.goName, scope{buf: "buf", rem: "rem", rets: "buf, rem, "})
+		}
+	})
+	g.w("return buf, rem, nil")
+	g.w("}")
+
+	g.w("")
+	g.w("// Unmarshal " + name + " from binary, using pack.DefaultDecodeOptions to")
+	g.w("// limit the resources that can be consumed. Errors are returned as a")
+	g.w("// *pack.DecodeError.")
+	g.w("func (x *" + name + ") Unmarshal(buf []byte, rem int) ([]byte, int, error) {")
+	g.w("state := pack.DefaultDecodeOptions.NewDecodeState()")
+	g.w("limited := state.CapRem(rem)")
+	g.w("rest, limitedRem, err := x.unmarshalWithState(buf, limited, state)")
+	g.w("return rest, rem - (limited - limitedRem), err")
+	g.w("}")
+
+	g.w("")
+	g.w("// unmarshalWithState unmarshals " + name + " from binary, enforcing the limits")
+	g.w("// of the decode state across all levels of nesting.")
+	g.w("func (x *" + name + ") unmarshalWithState(buf []byte, rem int, state *pack.DecodeState) ([]byte, int, error) {")
+	g.writeBody(func() {
+		root := scope{buf: "buf", rem: "rem", rets: "buf, rem, ", path: []string{strconv.Quote(".")}, kind: "pack.KindStruct", offset: "len(orig)-len(buf)"}
+		g.writeEnter(root)
+		g.w("defer state.Leave()")
+		g.errUsed = true
+		g.w(fmt.Sprintf("if err = state.AllocStruct(%d); err != nil {", len(fields)))
+		g.w(g.fail(root, "err"))
+		g.w("}")
+		for _, f := range fields {
+			g.writeUnmarshal(f.t, "x."+f.goName, scope{buf: "buf", rem: "rem", rets: "buf, rem, ", path: []string{strconv.Quote(fieldPath(f.name))}, offset: "len(orig)-len(buf)"})
+		}
+	})
+	g.w("return buf, rem, nil")
+	g.w("}")
+
+	g.w("")
+	g.w("// MarshalJSON marshals " + name + " to JSON, in the same way as its pack")
+	g.w("// value.")
+	g.w("func (x " + name + ") MarshalJSON() ([]byte, error) {")
+	g.w("v, err := x.PackEncode()")
+	g.w("if err != nil {")
+	g.w("return nil, err")
+	g.w("}")
+	g.w("return v.MarshalJSON()")
+	g.w("}")
+
+	g.w("")
+	g.w("// UnmarshalJSON unmarshals " + name + " from JSON, in the same way as its")
+	g.w("// pack value.")
+	g.w("func (x *" + name + ") UnmarshalJSON(data []byte) error {")
+	g.w("v, err := " + typeVar(name) + ".UnmarshalValueJSON(data)")
+	g.w("if err != nil {")
+	g.w("return err")
+	g.w("}")
+	g.w("return x.PackDecode(v)")
+	g.w("}")
+
+	g.w("")
+	g.w("// PackEncode encodes " + name + " as a pack struct.")
+	g.w("func (x " + name + ") PackEncode() (pack.Value, error) {")
+	g.writeBody(func() {
+		g.w("v := pack.Struct{")
+		for _, f := range fields {
+			g.w("{Name: " + strconv.Quote(f.name) + "},")
+		}
+		g.w("}")
+		for i, f := range fields {
+			g.writeEncode(f.t, "x."+f.goName, fmt.Sprintf("v[%d].Value", i), scope{rets: "nil, ", prefix: fmt.Sprintf("encoding %q: ", f.goName)})
+		}
+	})
+	g.w("return v, nil")
+	g.w("}")
+
+	g.imports["fmt"] = true
+	root := scope{path: []string{strconv.Quote(".")}, kind: "pack.KindStruct", offset: "-1"}
+	g.w("")
+	g.w("// PackDecode decodes " + name + " from a pack struct. Errors are returned as")
+	g.w("// a *pack.DecodeError.")
+	g.w("func (x *" + name + ") PackDecode(v pack.Value) error {")
+	g.w("var s pack.Struct")
+	g.w("switch v := v.(type) {")
+	g.w("case " + name + ":")
+	g.w("*x = v")
+	g.w("return nil")
+	g.w("case pack.Struct:")
+	g.w("s = v")
+	g.w("case pack.Typed:")
+	g.w("s = pack.Struct(v)")
+	g.w("default:")
+	g.w(g.failf(root, "unexpected value of type %T", "v"))
+	g.w("}")
+	g.w(fmt.Sprintf("if len(s) != %d {", len(fields)))
+	g.w(g.failf(root, "expected fields=%v, got fields=%v", strconv.Itoa(len(fields)), "len(s)"))
+	g.w("}")
+	g.tmps = 0
+	for i, f := range fields {
+		g.w(fmt.Sprintf("if s[%d].Name != %q {", i, f.name))
+		g.w(g.failf(root, "expected field %q, got field %q", strconv.Quote(f.name), fmt.Sprintf("s[%d].Name", i)))
+		g.w("}")
+		g.writeDecode(f.t, fmt.Sprintf("s[%d].Value", i), "x."+f.goName, scope{path: []string{strconv.Quote(fieldPath(f.name))}, offset: "-1"})
+	}
+	g.w("return nil")
+	g.w("}")
+
+	g.imports["math/rand"] = true
+	g.imports["reflect"] = true
+	g.w("")
+	g.w("// Generate a random " + name + ". This method is implemented for use in quick")
+	g.w("// tests. See https://golang.org/pkg/testing/quick/#Generator for more")
+	g.w("// information.")
+	g.w("func (" + name + ") Generate(r *rand.Rand, size int) reflect.Value {")
+	g.w("x := " + name + "{}")
+	g.w("if err := x.PackDecode(pack.GenerateFromType(r, size, " + typeVar(name) + ")); err != nil {")
+	g.w("panic(err)")
+	g.w("}")
+	g.w("return reflect.ValueOf(x)")
+	g.w("}")
+}
+
+// writeBody writes the statements of a method that may use the err, and orig,
+// variables, declaring the variables that are used.
+func (g *generator) writeBody(f func()) {
+	out := g.out
+	g.out = bytes.Buffer{}
+	g.errUsed = false
+	g.origUsed = false
+	g.tmps = 0
+	f()
+	body := g.out
+	g.out = out
+	if g.origUsed {
+		g.w("orig := buf")
+	}
+	if g.errUsed {
+		g.w("var err error")
+	}
+	g.out.Write(body.Bytes())
+}
+
+// writeSizeHint writes statements that add the number of bytes required to
+// represent expr in binary to dst.
+func (g *generator) writeSizeHint(t *goType, expr, dst string) {
+	if n, ok := g.fixedSize(t); ok {
+		if n > 0 {
+			g.w(fmt.Sprintf("%v += %d", dst, n))
+		}
+		return
+	}
+	switch t.kind {
+	case kindString:
+		g.imports[surgePath] = true
+		g.w(dst + " += surge.SizeHintString(" + convert("string", t, expr) + ")")
+	case kindBytes:
+		g.imports[surgePath] = true
+		g.w(dst + " += surge.SizeHintBytes(" + convert("[]byte", t, expr) + ")")
+	case kindValue, kindStruct:
+		g.w(dst + " += " + expr + ".SizeHint()")
+	case kindList:
+		if n, ok := g.fixedSize(t.elem); ok {
+			g.w(fmt.Sprintf("%v += 4 + len(%v)*%d", dst, expr, n))
+			return
+		}
+		e := g.tmp("e")
+		g.w(dst + " += 4")
+		g.w("for _, " + e + " := range " + expr + " {")
+		g.writeSizeHint(t.elem, e, dst)
+		g.w("}")
+	case kindOptional:
+		g.w(dst + " += 1")
+		g.w("if " + expr + " != nil {")
+		g.writeSizeHint(t.elem, "(*"+expr+")", dst)
+		g.w("}")
+	case kindTuple:
+		i := g.tmp("i")
+		g.w("for " + i + " := range " + expr + " {")
+		g.writeSizeHint(t.elem, expr+"["+i+"]", dst)
+		g.w("}")
+	case kindMap:
+		g.w(dst + " += 4")
+		keySize, keyFixed := g.fixedSize(t.key)
+		valueSize, valueFixed := g.fixedSize(t.elem)
+		if keyFixed && valueFixed {
+			g.w(fmt.Sprintf("%v += len(%v)*%d", dst, expr, keySize+valueSize))
+			return
+		}
+		k, v := "_", ""
+		if keyFixed {
+			g.w(fmt.Sprintf("%v += len(%v)*%d", dst, expr, keySize))
+		} else {
+			k = g.tmp("k")
+		}
+		if valueFixed {
+			g.w(fmt.Sprintf("%v += len(%v)*%d", dst, expr, valueSize))
+			g.w("for " + k + " := range " + expr + " {")
+		} else {
+			v = g.tmp("v")
+			g.w("for " + k + ", " + v + " := range " + expr + " {")
+		}
+		if !keyFixed {
+			g.writeSizeHint(t.key, k, dst)
+		}
+		if !valueFixed {
+			g.writeSizeHint(t.elem, v, dst)
+		}
+		g.w("}")
+	}
+}
+
+// writeMarshal writes statements that marshal expr to binary.
+func (g *generator) writeMarshal(t *goType, expr string, s scope) {
+	call := func(fn, arg string) {
+		g.imports[surgePath] = true
+		g.w("if " + s.buf + ", " + s.rem + ", err = surge." + fn + "(" + arg + ", " + s.buf + ", " + s.rem + "); err != nil {")
+		g.w(g.fail(s, "err"))
+		g.w("}")
+	}
+	switch t.kind {
+	case kindBool:
+		call("MarshalBool", convert("bool", t, expr))
+	case kindUint:
+		call(fmt.Sprintf("MarshalU%d", t.bits), convert(fmt.Sprintf("uint%d", t.bits), t, expr))
+	case kindInt:
+		call(fmt.Sprintf("MarshalI%d", t.bits), convert(fmt.Sprintf("int%d", t.bits), t, expr))
+	case kindString:
+		call("MarshalString", convert("string", t, expr))
+	case kindBytes:
+		call("MarshalBytes", convert("[]byte", t, expr))
+	case kindByteArray:
+		n := strconv.Itoa(t.n)
+		g.writeCheckLen(n, s)
+		g.w("copy(" + s.buf + ", " + expr + "[:])")
+		g.w(s.buf + ", " + s.rem + " = " + s.buf + "[" + n + ":], " + s.rem + "-" + n)
+	case kindValue, kindStruct:
+		g.errUsed = true
+		g.w("if " + s.buf + ", " + s.rem + ", err = " + expr + ".Marshal(" + s.buf + ", " + s.rem + "); err != nil {")
+		g.w(g.fail(s, "err"))
+		g.w("}")
+	case kindList:
+		call("MarshalLen", "uint32(len("+expr+"))")
+		e := g.tmp("e")
+		g.w("for _, " + e + " := range " + expr + " {")
+		g.writeMarshal(t.elem, e, s)
+		g.w("}")
+	case kindOptional:
+		call("MarshalBool", expr+" != nil")
+		g.w("if " + expr + " != nil {")
+		g.writeMarshal(t.elem, "(*"+expr+")", s)
+		g.w("}")
+	case kindTuple:
+		i := g.tmp("i")
+		g.w("for " + i + " := range " + expr + " {")
+		g.writeMarshal(t.elem, expr+"["+i+"]", s)
+		g.w("}")
+	case kindMap:
+		g.writeMarshalMap(t, expr, s)
+	}
+}
+
+// writeMarshalMap writes statements that marshal a map to binary. Entries are
+// sorted by the binary representation of their keys, in the same way as
+// pack.Map.
+func (g *generator) writeMarshalMap(t *goType, expr string, s scope) {
+	g.imports["bytes"] = true
+	g.imports["sort"] = true
+	g.imports[surgePath] = true
+	entry, entries := g.tmp("entry"), g.tmp("entries")
+	k, v := g.tmp("k"), g.tmp("v")
+	size, keyBuf, b, r := g.tmp("size"), g.tmp("keyBuf"), g.tmp("buf"), g.tmp("rem")
+	g.w("type " + entry + " struct {")
+	g.w("key []byte")
+	g.w("value " + t.elem.expr)
+	g.w("}")
+	g.w(entries + " := make([]" + entry + ", 0, len(" + expr + "))")
+	g.w("for " + k + ", " + v + " := range " + expr + " {")
+	if n, ok := g.fixedSize(t.key); ok {
+		g.w(fmt.Sprintf("%v := %d", size, n))
+	} else {
+		g.w(size + " := 0")
+		g.writeSizeHint(t.key, k, size)
+	}
+	g.w(keyBuf + " := make([]byte, " + size + ")")
+	g.w(b + ", " + r + " := " + keyBuf + ", " + size)
+	g.writeMarshal(t.key, k, scope{buf: b, rem: r, rets: s.rets, prefix: s.prefix})
+	g.w(entries + " = append(" + entries + ", " + entry + "{key: " + keyBuf + ", value: " + v + "})")
+	g.w("}")
+	g.w("sort.Slice(" + entries + ", func(i, j int) bool {")
+	g.w("return bytes.Compare(" + entries + "[i].key, " + entries + "[j].key) < 0")
+	g.w("})")
+	g.errUsed = true
+	g.w("if " + s.buf + ", " + s.rem + ", err = surge.MarshalLen(uint32(len(" + entries + ")), " + s.buf + ", " + s.rem + "); err != nil {")
+	g.w(g.fail(s, "err"))
+	g.w("}")
+	e := g.tmp("e")
+	g.w("for _, " + e + " := range " + entries + " {")
+	g.writeCheckLen("len("+e+".key)", s)
+	g.w("copy(" + s.buf + ", " + e + ".key)")
+	g.w(s.buf + ", " + s.rem + " = " + s.buf + "[len(" + e + ".key):], " + s.rem + "-len(" + e + ".key)")
+	g.writeMarshal(t.elem, e+".value", s)
+	g.w("}")
+}
+
+// writeUnmarshal writes statements that unmarshal expr from binary.
+func (g *generator) writeUnmarshal(t *goType, expr string, s scope) {
+	s.kind = packKind(t)
+	call := func(fn, basic string) {
+		g.imports[surgePath] = true
+		target := expr
+		if !sameType(basic, t) {
+			target = g.tmp("x")
+			g.w("var " + target + " " + basic)
+		}
+		g.errUsed = true
+		g.w("if buf, rem, err = surge." + fn + "(&" + target + ", buf, rem); err != nil {")
+		g.w(g.fail(s, "err"))
+		g.w("}")
+		if target != expr {
+			g.w(expr + " = " + t.expr + "(" + target + ")")
+		}
+	}
+	switch t.kind {
+	case kindBool:
+		call("UnmarshalBool", "bool")
+	case kindUint:
+		call(fmt.Sprintf("UnmarshalU%d", t.bits), fmt.Sprintf("uint%d", t.bits))
+	case kindInt:
+		call(fmt.Sprintf("UnmarshalI%d", t.bits), fmt.Sprintf("int%d", t.bits))
+	case kindString:
+		call("UnmarshalString", "string")
+	case kindBytes:
+		call("UnmarshalBytes", "[]byte")
+	case kindByteArray:
+		n := strconv.Itoa(t.n)
+		g.writeCheckLen(n, s)
+		g.w("copy(" + expr + "[:], buf[:" + n + "])")
+		g.w("buf, rem = buf[" + n + ":], rem-" + n)
+	case kindValue:
+		g.errUsed = true
+		g.w("if buf, rem, err = " + expr + ".Unmarshal(buf, rem); err != nil {")
+		g.w(g.fail(s, "err"))
+		g.w("}")
+	case kindStruct:
+		g.errUsed = true
+		g.w("if buf, rem, err = " + expr + ".unmarshalWithState(buf, rem, state); err != nil {")
+		g.w(g.fail(s, "err"))
+		g.w("}")
+	case kindList:
+		g.writeEnter(s)
+		n := g.writeUnmarshalLen(s, "AllocList", t.elem)
+		g.w(expr + " = make(" + t.expr + ", " + n + ")")
+		i := g.tmp("i")
+		g.w("for " + i + " := range " + expr + " {")
+		g.writeUnmarshal(t.elem, expr+"["+i+"]", g.indexScope(s, i))
+		g.w("}")
+		g.w("state.Leave()")
+	case kindOptional:
+		g.writeEnter(s)
+		some := g.tmp("some")
+		g.w("var " + some + " bool")
+		g.errUsed = true
+		g.w("if buf, rem, err = surge.UnmarshalBool(&" + some + ", buf, rem); err != nil {")
+		g.w(g.fail(s, "err"))
+		g.w("}")
+		g.w("if " + some + " {")
+		g.w(expr + " = new(" + t.elem.expr + ")")
+		g.writeUnmarshal(t.elem, "(*"+expr+")", s)
+		g.w("} else {")
+		g.w(expr + " = nil")
+		g.w("}")
+		g.w("state.Leave()")
+	case kindTuple:
+		g.writeEnter(s)
+		g.errUsed = true
+		g.w("if err = state.AllocList(" + strconv.Itoa(t.n) + "); err != nil {")
+		g.w(g.fail(s, "err"))
+		g.w("}")
+		i := g.tmp("i")
+		g.w("for " + i + " := range " + expr + " {")
+		g.writeUnmarshal(t.elem, expr+"["+i+"]", g.indexScope(s, i))
+		g.w("}")
+		g.w("state.Leave()")
+	case kindMap:
+		g.imports["bytes"] = true
+		g.writeEnter(s)
+		n := g.writeUnmarshalLen(s, "AllocMap", t.key, t.elem)
+		g.w(expr + " = make(" + t.expr + ", " + n + ")")
+		prev, i := g.tmp("prev"), g.tmp("i")
+		g.w("var " + prev + " []byte")
+		g.w("for " + i + " := uint32(0); " + i + " < " + n + "; " + i + "++ {")
+		// Keep track of the binary representation of the key, so that maps
+		// that are not in canonical order can be rejected.
+		keyBuf, keyRem, key := g.tmp("keyBuf"), g.tmp("keyRem"), g.tmp("key")
+		k, v := g.tmp("k"), g.tmp("v")
+		g.w(keyBuf + ", " + keyRem + " := buf, rem")
+		g.w("var " + k + " " + t.key.expr)
+		g.writeUnmarshal(t.key, k, g.indexScope(s, "int("+i+")").at(strconv.Quote(".key")))
+		g.w(key + " := " + keyBuf + "[:len(" + keyBuf + ")-len(buf)]")
+		g.w("if " + i + " > 0 && bytes.Compare(" + prev + ", " + key + ") >= 0 {")
+		// The error is returned at the start of the key, because the key is
+		// the cause of the error.
+		order := g.indexScope(s, "int("+i+")").at(strconv.Quote(".key"))
+		order.rets = keyBuf + ", " + keyRem + ", "
+		order.offset = "len(orig)-len(" + keyBuf + ")"
+		g.w(g.failf(order, "non-canonical order"))
+		g.w("}")
+		g.w(prev + " = " + key)
+		g.w("var " + v + " " + t.elem.expr)
+		g.writeUnmarshal(t.elem, v, g.indexScope(s, "int("+i+")").at(strconv.Quote(".value")))
+		g.w(expr + "[" + k + "] = " + v)
+		g.w("}")
+		g.w("state.Leave()")
+	}
+}
+
+// writeEnter writes statements that enter a nested value, returning an error
+// if this exceeds the maximum depth. The caller must write a call to
+// state.Leave once the value has been unmarshaled.
+func (g *generator) writeEnter(s scope) {
+	g.errUsed = true
+	g.w("if err = state.Enter(); err != nil {")
+	g.w(g.fail(s, "err"))
+	g.w("}")
+}
+
+// writeUnmarshalLen writes statements that unmarshal the length of a list or
+// map, and returns the name of the variable that stores the length. The length
+// is checked against the limits of the decode state, and against the remaining
+// bytes in the buffer (unless elements have no binary representation). The
+// memory needed to store the elements is then charged by calling the alloc
+// method of the decode state.
+func (g *generator) writeUnmarshalLen(s scope, alloc string, elems ...*goType) string {
+	g.imports[surgePath] = true
+	n := g.tmp("n")
+	g.w("var " + n + " uint32")
+	g.errUsed = true
+	g.w("if buf, rem, err = surge.UnmarshalU32(&" + n + ", buf, rem); err != nil {")
+	g.w(g.fail(s, "err"))
+	g.w("}")
+	g.w("if err = state.CheckListLen(" + n + "); err != nil {")
+	g.w(g.fail(s, "err"))
+	g.w("}")
+	for _, elem := range elems {
+		if !g.isZeroSize(elem) {
+			g.w("if uint64(" + n + ") > uint64(len(buf)) || uint64(" + n + ") > uint64(rem) {")
+			g.w(g.fail(s, "surge.ErrUnexpectedEndOfBuffer"))
+			g.w("}")
+			break
+		}
+	}
+	g.w("if err = state." + alloc + "(int(" + n + ")); err != nil {")
+	g.w(g.fail(s, "err"))
+	g.w("}")
+	return n
+}
+
+// writeCheckLen writes a statement that returns an error if the buffer, or the
+// remaining memory quota, is shorter than n.
+func (g *generator) writeCheckLen(n string, s scope) {
+	g.imports[surgePath] = true
+	g.w("if len(" + s.buf + ") < " + n + " || " + s.rem + " < " + n + " {")
+	g.w(g.fail(s, "surge.ErrUnexpectedEndOfBuffer"))
+	g.w("}")
+}
+
+// writeEncode writes statements that encode expr as a pack value, and assign
+// it to dst.
+func (g *generator) writeEncode(t *goType, expr, dst string, s scope) {
+	switch t.kind {
+	case kindBool:
+		g.w(dst + " = pack.NewBool(" + convert("bool", t, expr) + ")")
+	case kindUint:
+		g.w(fmt.Sprintf("%v = pack.NewU%d(%v)", dst, t.bits, convert(fmt.Sprintf("uint%d", t.bits), t, expr)))
+	case kindInt:
+		g.w(fmt.Sprintf("%v = pack.NewI%d(%v)", dst, t.bits, convert(fmt.Sprintf("int%d", t.bits), t, expr)))
+	case kindString:
+		g.w(dst + " = pack.NewString(" + convert("string", t, expr) + ")")
+	case kindBytes:
+		g.w(dst + " = pack.NewBytes(" + convert("[]byte", t, expr) + ")")
+	case kindByteArray:
+		switch t.n {
+		case 32:
+			g.w(dst + " = pack.NewBytes32(" + convert("[32]byte", t, expr) + ")")
+		case 65:
+			g.w(dst + " = pack.NewBytes65(" + convert("[65]byte", t, expr) + ")")
+		default:
+			g.w(dst + " = pack.NewBytesN(" + expr + "[:])")
+		}
+	case kindValue:
+		g.w(dst + " = " + expr)
+	case kindStruct:
+		g.errUsed = true
+		g.w("if " + dst + ", err = " + expr + ".PackEncode(); err != nil {")
+		g.w(g.fail(s, "err"))
+		g.w("}")
+	case kindList:
+		elems, i := g.tmp("elems"), g.tmp("i")
+		g.w(elems + " := make([]pack.Value, len(" + expr + "))")
+		g.w("for " + i + " := range " + expr + " {")
+		g.writeEncode(t.elem, expr+"["+i+"]", elems+"["+i+"]", s)
+		g.w("}")
+		g.w(dst + " = pack.List{T: " + g.packType(t.elem) + ", Elems: " + elems + "}")
+	case kindOptional:
+		g.w("if " + expr + " == nil {")
+		g.w(dst + " = pack.None(" + g.packType(t.elem) + ")")
+		g.w("} else {")
+		elem := g.tmp("elem")
+		g.w("var " + elem + " pack.Value")
+		g.writeEncode(t.elem, "(*"+expr+")", elem, s)
+		g.w(dst + " = pack.Some(" + elem + ")")
+		g.w("}")
+	case kindTuple:
+		elems, i := g.tmp("elems"), g.tmp("i")
+		g.w(elems + " := make(pack.Tuple, len(" + expr + "))")
+		g.w("for " + i + " := range " + expr + " {")
+		g.writeEncode(t.elem, expr+"["+i+"]", elems+"["+i+"]", s)
+		g.w("}")
+		g.w(dst + " = " + elems)
+	case kindMap:
+		entries, k, v := g.tmp("entries"), g.tmp("k"), g.tmp("v")
+		key, value, m := g.tmp("key"), g.tmp("value"), g.tmp("m")
+		g.w(entries + " := make([]pack.MapEntry, 0, len(" + expr + "))")
+		g.w("for " + k + ", " + v + " := range " + expr + " {")
+		g.w("var " + key + ", " + value + " pack.Value")
+		g.writeEncode(t.key, k, key, s)
+		g.writeEncode(t.elem, v, value, s)
+		g.w(entries + " = append(" + entries + ", pack.NewMapEntry(" + key + ", " + value + "))")
+		g.w("}")
+		g.w(m + " := pack.EmptyMap(" + g.packType(t.key) + ", " + g.packType(t.elem) + ")")
+		g.w("if len(" + entries + ") > 0 {")
+		g.errUsed = true
+		g.w("if " + m + ", err = pack.NewMap(" + entries + "...); err != nil {")
+		g.w(g.fail(s, "err"))
+		g.w("}")
+		g.w("}")
+		g.w(dst + " = " + m)
+	}
+}
+
+// writeDecode writes statements that decode the pack value src into expr.
+func (g *generator) writeDecode(t *goType, src, expr string, s scope) {
+	// assert writes a type assertion of src, and returns the name of the
+	// variable that stores the result.
+	assert := func(packType string) string {
+		y, ok := g.tmp("y"), g.tmp("ok")
+		g.w(y + ", " + ok + " := " + src + ".(pack." + packType + ")")
+		g.w("if !" + ok + " {")
+		g.w(g.failf(s, "unexpected value of type %T", src))
+		g.w("}")
+		return y
+	}
+	s.kind = packKind(t)
+	switch t.kind {
+	case kindBool:
+		g.w(expr + " = " + t.expr + "(" + assert("Bool") + ")")
+	case kindUint:
+		g.w(expr + " = " + t.expr + "(" + assert(fmt.Sprintf("U%d", t.bits)) + ")")
+	case kindInt:
+		g.w(expr + " = " + t.expr + "(" + assert(fmt.Sprintf("I%d", t.bits)) + ")")
+	case kindString:
+		g.w(expr + " = " + t.expr + "(" + assert("String") + ")")
+	case kindBytes:
+		g.w(expr + " = " + t.expr + "(" + assert("Bytes") + ")")
+	case kindByteArray:
+		switch t.n {
+		case 32:
+			g.w(expr + " = " + t.expr + "(" + assert("Bytes32") + ")")
+		case 65:
+			g.w(expr + " = " + t.expr + "(" + assert("Bytes65") + ")")
+		default:
+			y := assert("BytesN")
+			g.w("if len(" + y + ") != " + strconv.Itoa(t.n) + " {")
+			g.w(g.failf(s, fmt.Sprintf("expected len=%d, got len=%%v", t.n), "len("+y+")"))
+			g.w("}")
+			g.w("copy(" + expr + "[:], " + y + ")")
+		}
+	case kindValue:
+		g.w(expr + " = " + assert(t.name))
+	case kindStruct:
+		g.w("if err := " + expr + ".PackDecode(" + src + "); err != nil {")
+		g.w(g.fail(s, "err"))
+		g.w("}")
+	case kindList:
+		y, i := assert("List"), g.tmp("i")
+		g.w(expr + " = make(" + t.expr + ", len(" + y + ".Elems))")
+		g.w("for " + i + " := range " + y + ".Elems {")
+		g.writeDecode(t.elem, y+".Elems["+i+"]", expr+"["+i+"]", g.indexScope(s, i))
+		g.w("}")
+	case kindOptional:
+		y := assert("Optional")
+		g.w("if " + y + ".Value == nil {")
+		g.w(expr + " = nil")
+		g.w("} else {")
+		g.w(expr + " = new(" + t.elem.expr + ")")
+		g.writeDecode(t.elem, y+".Value", "(*"+expr+")", s)
+		g.w("}")
+	case kindTuple:
+		y, i := assert("Tuple"), g.tmp("i")
+		g.w("if len(" + y + ") != " + strconv.Itoa(t.n) + " {")
+		g.w(g.failf(s, fmt.Sprintf("expected len=%d, got len=%%v", t.n), "len("+y+")"))
+		g.w("}")
+		g.w("for " + i + " := range " + expr + " {")
+		g.writeDecode(t.elem, y+"["+i+"]", expr+"["+i+"]", g.indexScope(s, i))
+		g.w("}")
+	case kindMap:
+		y, i, e := assert("Map"), g.tmp("i"), g.tmp("e")
+		k, v := g.tmp("k"), g.tmp("v")
+		g.w(expr + " = make(" + t.expr + ", len(" + y + ".Entries))")
+		g.w("for " + i + ", " + e + " := range " + y + ".Entries {")
+		g.w("var " + k + " " + t.key.expr)
+		g.writeDecode(t.key, e+".Key", k, g.indexScope(s, i).at(strconv.Quote(".key")))
+		g.w("var " + v + " " + t.elem.expr)
+		g.writeDecode(t.elem, e+".Value", v, g.indexScope(s, i).at(strconv.Quote(".value")))
+		g.w(expr + "[" + k + "] = " + v)
+		g.w("}")
+	}
+}
+
+// fail returns a statement that returns the error expression err.
+func (g *generator) fail(s scope, err string) string {
+	if err == "err" {
+		g.errUsed = true
+	}
+	if len(s.path) > 0 {
+		return "return " + s.rets + g.wrap(s, err)
+	}
+	if s.prefix == "" {
+		return "return " + s.rets + err
+	}
+	g.imports["fmt"] = true
+	return "return " + s.rets + "fmt.Errorf(" + strconv.Quote(escapeFormat(s.prefix)+"%v") + ", " + err + ")"
+}
+
+// failf returns a statement that returns a new error.
+func (g *generator) failf(s scope, format string, args ...string) string {
+	g.imports["fmt"] = true
+	call := "fmt.Errorf(" + strconv.Quote(escapeFormat(s.prefix)+format)
+	for _, arg := range args {
+		call += ", " + arg
+	}
+	call += ")"
+	if len(s.path) > 0 {
+		return "return " + s.rets + g.wrap(s, call)
+	}
+	return "return " + s.rets + call
+}
+
+// wrap returns an expression that wraps the error expression err in a
+// pack.DecodeError, using the path, kind, and offset of the scope.
+func (g *generator) wrap(s scope, err string) string {
+	if s.offset != "-1" {
+		g.origUsed = true
+	}
+	return "pack.WrapDecodeError(" + err + ", " + pathExpr(s.path) + ", " + s.kind + ", " + s.offset + ")"
+}
+
+// indexScope returns the scope of the element, at the given index, of the
+// list or tuple in a scope. The index must be a Go expression of type int.
+func (g *generator) indexScope(s scope, i string) scope {
+	if len(s.path) == 0 {
+		return s
+	}
+	g.imports["strconv"] = true
+	return s.at(strconv.Quote("["), "strconv.Itoa("+i+")", strconv.Quote("]"))
+}
+
+// pathExpr returns the Go expression that joins the parts of a path. Adjacent
+// string literals are joined when the expression is written, so that paths
+// that are known in advance are written as one string literal.
+func pathExpr(parts []string) string {
+	exprs := []string{}
+	lit, hasLit := "", false
+	for _, part := range parts {
+		if s, err := strconv.Unquote(part); err == nil {
+			lit, hasLit = lit+s, true
+			continue
+		}
+		if hasLit {
+			exprs = append(exprs, strconv.Quote(lit))
+			lit, hasLit = "", false
+		}
+		exprs = append(exprs, part)
+	}
+	if hasLit {
+		exprs = append(exprs, strconv.Quote(lit))
+	}
+	return strings.Join(exprs, " + ")
+}
+
+// fieldPath returns the path of a struct field in the same way as
+// pack.DecodeError (e.g. ".price"). Names that are not identifiers are quoted
+// (e.g. `.["unit price"]`).
+func fieldPath(name string) string {
+	if name == "" || '0' <= name[0] && name[0] <= '9' {
+		return ".[" + strconv.Quote(name) + "]"
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !(c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			return ".[" + strconv.Quote(name) + "]"
+		}
+	}
+	return "." + name
+}
+
+// packKind returns the Go expression for the pack kind of a Go type.
+func packKind(t *goType) string {
+	switch t.kind {
+	case kindBool:
+		return "pack.KindBool"
+	case kindUint:
+		return fmt.Sprintf("pack.KindU%d", t.bits)
+	case kindInt:
+		return fmt.Sprintf("pack.KindI%d", t.bits)
+	case kindString:
+		return "pack.KindString"
+	case kindBytes:
+		return "pack.KindBytes"
+	case kindByteArray:
+		switch t.n {
+		case 32:
+			return "pack.KindBytes32"
+		case 65:
+			return "pack.KindBytes65"
+		}
+		return "pack.KindBytesN"
+	case kindValue:
+		return "pack.Kind" + t.name
+	case kindStruct:
+		return "pack.KindStruct"
+	case kindList:
+		return "pack.KindList"
+	case kindOptional:
+		return "pack.KindOptional"
+	case kindTuple:
+		return "pack.KindTuple"
+	case kindMap:
+		return "pack.KindMap"
+	}
+	panic(fmt.Sprintf("unexpected kind %v", t.kind))
+}
+
+// tmp returns the name of a new temporary variable.
+func (g *generator) tmp(name string) string {
+	g.tmps++
+	return name + strconv.Itoa(g.tmps)
+}
+
+// w writes a line of source. Indentation is fixed when the source is
+// formatted.
+func (g *generator) w(line string) {
+	g.out.WriteString(line)
+	g.out.WriteString("\n")
+}
+
+// convert returns expr converted from the Go type t to the basic Go type, if
+// they are not the same.
+func convert(basic string, t *goType, expr string) string {
+	if sameType(basic, t) {
+		return expr
+	}
+	return basic + "(" + expr + ")"
+}
+
+// sameType returns true if the Go type t is the basic Go type.
+func sameType(basic string, t *goType) bool {
+	return t.expr == basic || (basic == "uint8" && t.expr == "byte") || (basic == "int32" && t.expr == "rune")
+}
+
+func escapeFormat(s string) string {
+	return strings.Replace(s, "%", "%%", -1)
+}
+
+func exprString(expr ast.Expr) string {
+	buf := bytes.Buffer{}
+	if err := format.Node(&buf, token.NewFileSet(), expr); err != nil {
+		return fmt.Sprintf("%T", expr)
+	}
+	return buf.String()
+}
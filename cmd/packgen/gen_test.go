@@ -0,0 +1,108 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// generateSource generates the methods for the named types in a single source
+// file.
+func generateSource(src string, typeNames ...string) ([]byte, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "src.go", src, 0)
+	Expect(err).ToNot(HaveOccurred())
+	return generate(file.Name.Name, []*ast.File{file}, typeNames)
+}
+
+var _ = Describe("Packgen", func() {
+
+	Context("when generating the example package", func() {
+		It("should match the generated file", func() {
+			dir := filepath.Join("internal", "example")
+			pkgName, files, err := parsePackage(dir, "order_pack.go")
+			Expect(err).ToNot(HaveOccurred())
+			src, err := generate(pkgName, files, []string{"Order", "Fill", "Account"})
+			Expect(err).ToNot(HaveOccurred())
+
+			expected, err := ioutil.ReadFile(filepath.Join(dir, "order_pack.go"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(src)).To(Equal(string(expected)), "run go generate ./cmd/packgen/internal/example")
+		})
+	})
+
+	Context("when generating structs with an aliased pack import", func() {
+		It("should recognise pack value types", func() {
+			src, err := generateSource(`package p
+import p "github.com/renproject/pack"
+type T struct {
+	X p.U256 `+"`json:\"X\"`"+`
+}`, "T")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(src)).To(ContainSubstring(`"X", pack.TypeU256()`))
+		})
+	})
+
+	Context("when generating structs with unsupported fields", func() {
+		It("should return an error", func() {
+			for _, src := range []string{
+				"type T struct { X float64 `json:\"x\"` }",
+				"type T struct { X interface{} `json:\"x\"` }",
+				"type T struct { X chan int `json:\"x\"` }",
+				"type T struct { X struct{ Y int } `json:\"x\"` }",
+				"type T struct { X time.Time `json:\"x\"` }",
+				"type T struct { X pack.Struct `json:\"x\"` }",
+				"type T struct { X U `json:\"x\"` }; type U struct{}",
+				"type T struct { X U `json:\"x\"` }; type U float32",
+				"type T struct { X U `json:\"x\"` }; type U pack.U256",
+				"type T struct { X U `json:\"x\"` }; type U uint64; func (U) PackEncode() (pack.Value, error) { return nil, nil }",
+				"type T struct { X U `json:\"x\"` }; type U []U",
+				"type T struct { X [N]int `json:\"x\"` }; const N = 2",
+				"type T struct { X []B `json:\"x\"` }; type B byte",
+				"type T struct { U }; type U struct{}",
+				"type T struct { X int `pack:\"x,u64\"` }",
+				"type T struct { X U `json:\"x,tuple\"` }; type U struct{}",
+				"type T struct { X, Y int `json:\"x\"` }",
+				"type T int",
+			} {
+				_, err := generateSource("package p\nimport \"github.com/renproject/pack\"\nimport \"time\"\n"+src, "T")
+				Expect(err).To(HaveOccurred(), src)
+			}
+		})
+
+		It("should return an error for recursive structs", func() {
+			_, err := generateSource(`package p
+type T struct { X []U `+"`json:\"x\"`"+` }
+type U struct { Y *T `+"`json:\"y\"`"+` }`, "T", "U")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("recursive"))
+		})
+
+		It("should return an error for missing types", func() {
+			_, err := generateSource("package p", "T")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when generating structs with ignored fields", func() {
+		It("should ignore unexported fields, untagged fields, and fields tagged with -", func() {
+			src, err := generateSource(`package p
+type T struct {
+	x float64
+	Y float64 `+"`json:\"-\"`"+`
+	Z uint16 `+"`json:\"z,omitempty\"`"+`
+	W float64
+	V float64 `+"`json:\",omitempty\"`"+`
+}`, "T")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(src)).To(ContainSubstring(`"z", pack.TypeU16()`))
+			Expect(string(src)).ToNot(ContainSubstring(`"Y"`))
+			Expect(string(src)).ToNot(ContainSubstring(`"W"`))
+			Expect(string(src)).ToNot(ContainSubstring(`"V"`))
+		})
+	})
+})
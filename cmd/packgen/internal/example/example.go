@@ -0,0 +1,64 @@
+// Package example contains structs with methods generated by packgen. It is
+// used to test that the generated methods are equivalent to pack.Encode and
+// pack.Decode.
+package example
+
+import (
+	"github.com/renproject/pack"
+)
+
+//go:generate go run github.com/renproject/pack/cmd/packgen -type Order,Fill,Account
+
+// Side of an order.
+type Side uint8
+
+// Memo is a short note attached to an order.
+type Memo string
+
+// Hash is a 32-byte hash.
+type Hash [32]byte
+
+// Order has fields of most of the types that are supported by packgen.
+type Order struct {
+	ID        Hash              `json:"id"`
+	Side      Side              `json:"side"`
+	Price     pack.U256         `json:"price"`
+	Amount    uint64            `json:"amount"`
+	Nonce     uint              `json:"nonce"`
+	Expiry    int64             `json:"expiry"`
+	Offset    int               `json:"offset"`
+	Delta     int8              `json:"delta"`
+	Active    bool              `json:"active"`
+	Memo      Memo              `json:"memo"`
+	Data      []byte            `json:"data"`
+	Signature [65]byte          `json:"signature"`
+	Tag       [4]byte           `json:"tag"`
+	Point     [2]int16          `json:"point"`
+	Fills     []Fill            `json:"fills"`
+	Parent    *Fill             `json:"parent"`
+	Fees      map[string]uint32 `json:"fees"`
+	Limits    map[uint16][]Fill `json:"limits"`
+	Owner     Account           `json:"owner"`
+	Signers   []*Account        `pack:"signers"`
+	Untagged  pack.String
+	Ignored   string `json:"-"`
+	unexported string
+}
+
+// Fill is a fixed-size struct.
+type Fill struct {
+	Price  uint64 `json:"price"`
+	Amount uint32 `json:"amount"`
+	Final  bool   `json:"final"`
+}
+
+// Account is declared after it is used.
+type Account struct {
+	Name    string         `json:"name"`
+	Balance pack.U128      `json:"balance"`
+	Nonces  [3]uint64      `json:"nonces"`
+	Keys    map[Hash]bool  `json:"keys"`
+	Roles   []string       `json:"roles"`
+	Address pack.Bytes32   `json:"address"`
+	Meta    *map[Memo]Side `json:"meta"`
+}
@@ -0,0 +1,13 @@
+package example_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestExample(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Example Suite")
+}
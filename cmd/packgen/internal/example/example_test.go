@@ -0,0 +1,354 @@
+package example_test
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/pack/cmd/packgen/internal/example"
+	"github.com/renproject/surge"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// The reflect types have the same fields as the generated types, but none of
+// the generated methods, so they are encoded and decoded by reflection.
+type (
+	orderReflect   example.Order
+	fillReflect    example.Fill
+	accountReflect example.Account
+)
+
+// generated is implemented by all generated types.
+type generated interface {
+	pack.Value
+	pack.PackEncoder
+}
+
+// expectEquivalent expects the generated methods of x to be equivalent to
+// encoding the reflect value y with pack.Encode.
+func expectEquivalent(x generated, y interface{}) {
+	v, err := pack.Encode(y)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(x.Type()).To(Equal(v.Type()))
+
+	encoded, err := x.PackEncode()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(encoded).To(Equal(v))
+
+	Expect(x.SizeHint()).To(Equal(v.SizeHint()))
+	data, err := surge.ToBinary(x)
+	Expect(err).ToNot(HaveOccurred())
+	expected, err := surge.ToBinary(v)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(data).To(Equal(expected))
+
+	jsonData, err := x.MarshalJSON()
+	Expect(err).ToNot(HaveOccurred())
+	expectedJSON, err := v.MarshalJSON()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(jsonData).To(MatchJSON(expectedJSON))
+}
+
+// expectSameDecodeError expects the generated error to be a DecodeError with the
+// same path, offset, and kind as the expected DecodeError.
+func expectSameDecodeError(err, expected error) {
+	decodeErr, expectedDecodeErr := new(pack.DecodeError), new(pack.DecodeError)
+	ExpectWithOffset(1, errors.As(err, &decodeErr)).To(BeTrue())
+	ExpectWithOffset(1, errors.As(expected, &expectedDecodeErr)).To(BeTrue())
+	ExpectWithOffset(1, decodeErr.Path).To(Equal(expectedDecodeErr.Path))
+	ExpectWithOffset(1, decodeErr.Offset).To(Equal(expectedDecodeErr.Offset))
+	ExpectWithOffset(1, decodeErr.Kind).To(Equal(expectedDecodeErr.Kind))
+}
+
+// expectRoundTrip expects the value that x points to, to equal itself after being marshaled and then
+// unmarshaled, to binary and to JSON, and after being encoded and then
+// decoded. The new value is compared by its binary representation, because nil
+// and empty slices are not distinguished.
+func expectRoundTrip(x generated, newX func() generated) {
+	data, err := surge.ToBinary(x)
+	Expect(err).ToNot(HaveOccurred())
+
+	y := newX()
+	Expect(surge.FromBinary(y, data)).To(Succeed())
+	Expect(surge.ToBinary(y)).To(Equal(data))
+
+	jsonData, err := x.MarshalJSON()
+	Expect(err).ToNot(HaveOccurred())
+	y = newX()
+	Expect(y.(interface{ UnmarshalJSON([]byte) error }).UnmarshalJSON(jsonData)).To(Succeed())
+	Expect(surge.ToBinary(y)).To(Equal(data))
+
+	v, err := pack.Encode(reflect.ValueOf(x).Elem().Interface())
+	Expect(err).ToNot(HaveOccurred())
+	y = newX()
+	Expect(pack.Decode(y, v)).To(Succeed())
+	Expect(surge.ToBinary(y)).To(Equal(data))
+}
+
+var _ = Describe("Generated methods", func() {
+
+	Context("when comparing generated methods to reflection", func() {
+		It("should produce the same types and values", func() {
+			f := func(order example.Order, fill example.Fill, account example.Account) bool {
+				expectEquivalent(order, orderReflect(order))
+				expectEquivalent(fill, fillReflect(fill))
+				expectEquivalent(account, accountReflect(account))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+
+		It("should produce the same types and values for zero values", func() {
+			expectEquivalent(example.Order{}, orderReflect{})
+			expectEquivalent(example.Fill{}, fillReflect{})
+			expectEquivalent(example.Account{}, accountReflect{})
+		})
+
+		It("should unmarshal values that were marshaled by reflection", func() {
+			f := func(order example.Order) bool {
+				v, err := pack.Encode(orderReflect(order))
+				Expect(err).ToNot(HaveOccurred())
+				data, err := surge.ToBinary(v)
+				Expect(err).ToNot(HaveOccurred())
+
+				var y example.Order
+				Expect(surge.FromBinary(&y, data)).To(Succeed())
+				var z orderReflect
+				Expect(pack.Decode(&z, v)).To(Succeed())
+				expectEquivalent(y, z)
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when marshaling and then unmarshaling", func() {
+		It("should equal itself", func() {
+			f := func(order example.Order, fill example.Fill, account example.Account) bool {
+				expectRoundTrip(&order, func() generated { return &example.Order{} })
+				expectRoundTrip(&fill, func() generated { return &example.Fill{} })
+				expectRoundTrip(&account, func() generated { return &example.Account{} })
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when unmarshaling from a buffer that is too small", func() {
+		It("should return an error", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			order := example.Order{}.Generate(r, 10).Interface().(example.Order)
+			data, err := surge.ToBinary(order)
+			Expect(err).ToNot(HaveOccurred())
+			for i := range data {
+				var y example.Order
+				Expect(surge.FromBinary(&y, data[:i])).ToNot(Succeed())
+			}
+		})
+
+		It("should return the same decode error as unmarshaling the pack value", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			order := example.Order{}.Generate(r, 10).Interface().(example.Order)
+			data, err := surge.ToBinary(order)
+			Expect(err).ToNot(HaveOccurred())
+			for i := range data {
+				var y example.Order
+				_, _, err := y.Unmarshal(data[:i], len(data))
+				_, _, _, expected := order.Type().UnmarshalValue(data[:i], len(data))
+				expectSameDecodeError(err, expected)
+			}
+		})
+	})
+
+	Context("when unmarshaling a value that exceeds the decode limits", func() {
+		It("should return the same decode error as unmarshaling the pack value", func() {
+			defer func(opts pack.DecodeOptions) { pack.DefaultDecodeOptions = opts }(pack.DefaultDecodeOptions)
+
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			order := example.Order{}.Generate(r, 10).Interface().(example.Order)
+			order.Fills = []example.Fill{{Price: 1}, {Price: 2}, {Price: 3}}
+			data, err := surge.ToBinary(order)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, opts := range []pack.DecodeOptions{
+				{MaxDepth: 1, MaxListLen: 64, MaxStructFields: 64, MaxNameLen: 64, MaxBytes: surge.MaxBytes},
+				{MaxDepth: 64, MaxListLen: 64, MaxStructFields: 64, MaxNameLen: 64, MaxBytes: 64},
+			} {
+				pack.DefaultDecodeOptions = opts
+				var y example.Order
+				_, _, err := y.Unmarshal(data, len(data))
+				_, _, _, expected := order.Type().UnmarshalValue(data, len(data))
+				Expect(expected).To(HaveOccurred())
+				expectSameDecodeError(err, expected)
+			}
+		})
+
+		It("should return an error when the memory budget is exceeded", func() {
+			defer func(opts pack.DecodeOptions) { pack.DefaultDecodeOptions = opts }(pack.DefaultDecodeOptions)
+
+			order := example.Order{Fills: make([]example.Fill, 100)}
+			data, err := surge.ToBinary(order)
+			Expect(err).ToNot(HaveOccurred())
+			pack.DefaultDecodeOptions.MaxBytes = len(data)
+
+			var y example.Order
+			_, _, err = y.Unmarshal(data, len(data))
+			Expect(errors.Is(err, surge.ErrLengthOverflow)).To(BeTrue())
+			decodeErr := new(pack.DecodeError)
+			Expect(errors.As(err, &decodeErr)).To(BeTrue())
+			Expect(decodeErr.Path).To(Equal(".fills"))
+		})
+	})
+
+	Context("when unmarshaling a map that is not in canonical order", func() {
+		It("should return an error", func() {
+			fill := example.Fill{Price: 1, Amount: 2, Final: true}
+			data, err := surge.ToBinary(fill)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(HaveLen(13))
+
+			account := example.Account{Keys: map[example.Hash]bool{{1}: true, {2}: false}}
+			data, err = surge.ToBinary(account)
+			Expect(err).ToNot(HaveOccurred())
+
+			// Swap the two keys in the binary representation.
+			offset := surge.SizeHintString(account.Name) + 16 + 24 + 4
+			swapped := append([]byte{}, data...)
+			copy(swapped[offset:], data[offset+33:offset+66])
+			copy(swapped[offset+33:], data[offset:offset+33])
+
+			var y example.Account
+			_, _, err = y.Unmarshal(swapped, len(swapped))
+			_, _, _, expected := example.Account{}.Type().UnmarshalValue(swapped, len(swapped))
+			Expect(expected).To(HaveOccurred())
+			expectSameDecodeError(err, expected)
+
+			decodeErr := new(pack.DecodeError)
+			Expect(errors.As(err, &decodeErr)).To(BeTrue())
+			Expect(decodeErr.Path).To(Equal(".keys[1].key"))
+			Expect(decodeErr.Offset).To(Equal(offset + 33))
+		})
+	})
+
+	Context("when decoding a value of the wrong type", func() {
+		It("should return an error", func() {
+			var order example.Order
+			Expect(order.PackDecode(pack.NewU64(1))).ToNot(Succeed())
+			Expect(order.PackDecode(pack.NewStruct("id", pack.NewU64(1)))).ToNot(Succeed())
+
+			var fill example.Fill
+			err := fill.PackDecode(pack.NewStruct(
+				"price", pack.NewU64(1),
+				"amount", pack.NewU64(2),
+				"final", pack.NewBool(true),
+			))
+			decodeErr := new(pack.DecodeError)
+			Expect(errors.As(err, &decodeErr)).To(BeTrue())
+			Expect(decodeErr.Path).To(Equal(".amount"))
+			Expect(decodeErr.Offset).To(Equal(-1))
+			Expect(decodeErr.Kind).To(Equal(pack.KindU32))
+
+			// Errors from nested structs have paths that are relative to
+			// the outer struct.
+			v, err := pack.Encode(example.Order{Fills: []example.Fill{{}, {}}})
+			Expect(err).ToNot(HaveOccurred())
+			v.(pack.Struct)[14].Value.(pack.List).Elems[1].(pack.Struct)[2].Value = pack.NewU8(1)
+			err = order.PackDecode(v)
+			Expect(errors.As(err, &decodeErr)).To(BeTrue())
+			Expect(decodeErr.Path).To(Equal(".fills[1].final"))
+			Expect(fill.PackDecode(pack.NewStruct(
+				"price", pack.NewU64(1),
+				"quantity", pack.NewU32(2),
+				"final", pack.NewBool(true),
+			))).ToNot(Succeed())
+			Expect(fill.PackDecode(pack.NewStruct(
+				"price", pack.NewU64(1),
+				"amount", pack.NewU32(2),
+				"final", pack.NewBool(true),
+			))).To(Succeed())
+			Expect(fill).To(Equal(example.Fill{Price: 1, Amount: 2, Final: true}))
+		})
+
+		It("should decode the generated type itself", func() {
+			fill := example.Fill{Price: 1, Amount: 2, Final: true}
+			var y example.Fill
+			Expect(y.PackDecode(fill)).To(Succeed())
+			Expect(y).To(Equal(fill))
+		})
+	})
+
+	Context("when generating random values", func() {
+		It("should generate values of the generated type", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			v := example.Order{}.Generate(r, 10)
+			Expect(v.Type()).To(Equal(reflect.TypeOf(example.Order{})))
+		})
+	})
+})
+
+func benchmarkOrder() example.Order {
+	r := rand.New(rand.NewSource(0))
+	return example.Order{}.Generate(r, 10).Interface().(example.Order)
+}
+
+func BenchmarkMarshalGenerated(b *testing.B) {
+	order := benchmarkOrder()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := surge.ToBinary(order); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalReflect(b *testing.B) {
+	order := orderReflect(benchmarkOrder())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v, err := pack.Encode(order)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := surge.ToBinary(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalGenerated(b *testing.B) {
+	data, err := surge.ToBinary(benchmarkOrder())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var order example.Order
+		if err := surge.FromBinary(&order, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalReflect(b *testing.B) {
+	data, err := surge.ToBinary(benchmarkOrder())
+	if err != nil {
+		b.Fatal(err)
+	}
+	t := example.Order{}.Type()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v, _, _, err := t.UnmarshalValue(data, len(data))
+		if err != nil {
+			b.Fatal(err)
+		}
+		var order orderReflect
+		if err := pack.Decode(&order, v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,1561 @@
+// Code generated by packgen. DO NOT EDIT.
+
+package example
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/surge"
+)
+
+// packTypeOrder is the pack type of Order.
+var packTypeOrder = pack.StructType(
+	"id", pack.TypeBytes32(),
+	"side", pack.TypeU8(),
+	"price", pack.TypeU256(),
+	"amount", pack.TypeU64(),
+	"nonce", pack.TypeU64(),
+	"expiry", pack.TypeI64(),
+	"offset", pack.TypeI64(),
+	"delta", pack.TypeI8(),
+	"active", pack.TypeBool(),
+	"memo", pack.TypeString(),
+	"data", pack.TypeBytes(),
+	"signature", pack.TypeBytes65(),
+	"tag", pack.TypeBytesN(4),
+	"point", pack.TupleType(pack.TypeI16(), pack.TypeI16()),
+	"fills", pack.ListType(packTypeFill),
+	"parent", pack.OptionalType(packTypeFill),
+	"fees", pack.MapType(pack.TypeString(), pack.TypeU32()),
+	"limits", pack.MapType(pack.TypeU16(), pack.ListType(packTypeFill)),
+	"owner", packTypeAccount,
+	"signers", pack.ListType(pack.OptionalType(packTypeAccount)),
+)
+
+// Type returns the pack type of Order.
+func (Order) Type() pack.Type {
+	return packTypeOrder
+}
+
+// SizeHint returns the number of bytes required to represent Order in
+// binary.
+func (x Order) SizeHint() int {
+	size := 172
+	size += surge.SizeHintString(string(x.Memo))
+	size += surge.SizeHintBytes(x.Data)
+	size += 4 + len(x.Fills)*13
+	size += 1
+	if x.Parent != nil {
+		size += 13
+	}
+	size += 4
+	size += len(x.Fees) * 4
+	for k1 := range x.Fees {
+		size += surge.SizeHintString(k1)
+	}
+	size += 4
+	size += len(x.Limits) * 2
+	for _, v2 := range x.Limits {
+		size += 4 + len(v2)*13
+	}
+	size += x.Owner.SizeHint()
+	size += 4
+	for _, e3 := range x.Signers {
+		size += 1
+		if e3 != nil {
+			size += (*e3).SizeHint()
+		}
+	}
+	return size
+}
+
+// Marshal Order to binary.
+func (x Order) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	var err error
+	if len(buf) < 32 || rem < 32 {
+		return buf, rem, surge.ErrUnexpectedEndOfBuffer
+	}
+	copy(buf, x.ID[:])
+	buf, rem = buf[32:], rem-32
+	if buf, rem, err = surge.MarshalU8(uint8(x.Side), buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if buf, rem, err = x.Price.Marshal(buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if buf, rem, err = surge.MarshalU64(x.Amount, buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if buf, rem, err = surge.MarshalU64(uint64(x.Nonce), buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if buf, rem, err = surge.MarshalI64(x.Expiry, buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if buf, rem, err = surge.MarshalI64(int64(x.Offset), buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if buf, rem, err = surge.MarshalI8(x.Delta, buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if buf, rem, err = surge.MarshalBool(x.Active, buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if buf, rem, err = surge.MarshalString(string(x.Memo), buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if buf, rem, err = surge.MarshalBytes(x.Data, buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if len(buf) < 65 || rem < 65 {
+		return buf, rem, surge.ErrUnexpectedEndOfBuffer
+	}
+	copy(buf, x.Signature[:])
+	buf, rem = buf[65:], rem-65
+	if len(buf) < 4 || rem < 4 {
+		return buf, rem, surge.ErrUnexpectedEndOfBuffer
+	}
+	copy(buf, x.Tag[:])
+	buf, rem = buf[4:], rem-4
+	for i1 := range x.Point {
+		if buf, rem, err = surge.MarshalI16(x.Point[i1], buf, rem); err != nil {
+			return buf, rem, err
+		}
+	}
+	if buf, rem, err = surge.MarshalLen(uint32(len(x.Fills)), buf, rem); err != nil {
+		return buf, rem, err
+	}
+	for _, e2 := range x.Fills {
+		if buf, rem, err = e2.Marshal(buf, rem); err != nil {
+			return buf, rem, err
+		}
+	}
+	if buf, rem, err = surge.MarshalBool(x.Parent != nil, buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if x.Parent != nil {
+		if buf, rem, err = (*x.Parent).Marshal(buf, rem); err != nil {
+			return buf, rem, err
+		}
+	}
+	type entry3 struct {
+		key   []byte
+		value uint32
+	}
+	entries4 := make([]entry3, 0, len(x.Fees))
+	for k5, v6 := range x.Fees {
+		size7 := 0
+		size7 += surge.SizeHintString(k5)
+		keyBuf8 := make([]byte, size7)
+		buf9, rem10 := keyBuf8, size7
+		if buf9, rem10, err = surge.MarshalString(k5, buf9, rem10); err != nil {
+			return buf, rem, err
+		}
+		entries4 = append(entries4, entry3{key: keyBuf8, value: v6})
+	}
+	sort.Slice(entries4, func(i, j int) bool {
+		return bytes.Compare(entries4[i].key, entries4[j].key) < 0
+	})
+	if buf, rem, err = surge.MarshalLen(uint32(len(entries4)), buf, rem); err != nil {
+		return buf, rem, err
+	}
+	for _, e11 := range entries4 {
+		if len(buf) < len(e11.key) || rem < len(e11.key) {
+			return buf, rem, surge.ErrUnexpectedEndOfBuffer
+		}
+		copy(buf, e11.key)
+		buf, rem = buf[len(e11.key):], rem-len(e11.key)
+		if buf, rem, err = surge.MarshalU32(e11.value, buf, rem); err != nil {
+			return buf, rem, err
+		}
+	}
+	type entry12 struct {
+		key   []byte
+		value []Fill
+	}
+	entries13 := make([]entry12, 0, len(x.Limits))
+	for k14, v15 := range x.Limits {
+		size16 := 2
+		keyBuf17 := make([]byte, size16)
+		buf18, rem19 := keyBuf17, size16
+		if buf18, rem19, err = surge.MarshalU16(k14, buf18, rem19); err != nil {
+			return buf, rem, err
+		}
+		entries13 = append(entries13, entry12{key: keyBuf17, value: v15})
+	}
+	sort.Slice(entries13, func(i, j int) bool {
+		return bytes.Compare(entries13[i].key, entries13[j].key) < 0
+	})
+	if buf, rem, err = surge.MarshalLen(uint32(len(entries13)), buf, rem); err != nil {
+		return buf, rem, err
+	}
+	for _, e20 := range entries13 {
+		if len(buf) < len(e20.key) || rem < len(e20.key) {
+			return buf, rem, surge.ErrUnexpectedEndOfBuffer
+		}
+		copy(buf, e20.key)
+		buf, rem = buf[len(e20.key):], rem-len(e20.key)
+		if buf, rem, err = surge.MarshalLen(uint32(len(e20.value)), buf, rem); err != nil {
+			return buf, rem, err
+		}
+		for _, e21 := range e20.value {
+			if buf, rem, err = e21.Marshal(buf, rem); err != nil {
+				return buf, rem, err
+			}
+		}
+	}
+	if buf, rem, err = x.Owner.Marshal(buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if buf, rem, err = surge.MarshalLen(uint32(len(x.Signers)), buf, rem); err != nil {
+		return buf, rem, err
+	}
+	for _, e22 := range x.Signers {
+		if buf, rem, err = surge.MarshalBool(e22 != nil, buf, rem); err != nil {
+			return buf, rem, err
+		}
+		if e22 != nil {
+			if buf, rem, err = (*e22).Marshal(buf, rem); err != nil {
+				return buf, rem, err
+			}
+		}
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal Order from binary, using pack.DefaultDecodeOptions to
+// limit the resources that can be consumed. Errors are returned as a
+// *pack.DecodeError.
+func (x *Order) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	state := pack.DefaultDecodeOptions.NewDecodeState()
+	limited := state.CapRem(rem)
+	rest, limitedRem, err := x.unmarshalWithState(buf, limited, state)
+	return rest, rem - (limited - limitedRem), err
+}
+
+// unmarshalWithState unmarshals Order from binary, enforcing the limits
+// of the decode state across all levels of nesting.
+func (x *Order) unmarshalWithState(buf []byte, rem int, state *pack.DecodeState) ([]byte, int, error) {
+	orig := buf
+	var err error
+	if err = state.Enter(); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".", pack.KindStruct, len(orig)-len(buf))
+	}
+	defer state.Leave()
+	if err = state.AllocStruct(20); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".", pack.KindStruct, len(orig)-len(buf))
+	}
+	if len(buf) < 32 || rem < 32 {
+		return buf, rem, pack.WrapDecodeError(surge.ErrUnexpectedEndOfBuffer, ".id", pack.KindBytes32, len(orig)-len(buf))
+	}
+	copy(x.ID[:], buf[:32])
+	buf, rem = buf[32:], rem-32
+	var x1 uint8
+	if buf, rem, err = surge.UnmarshalU8(&x1, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".side", pack.KindU8, len(orig)-len(buf))
+	}
+	x.Side = Side(x1)
+	if buf, rem, err = x.Price.Unmarshal(buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".price", pack.KindU256, len(orig)-len(buf))
+	}
+	if buf, rem, err = surge.UnmarshalU64(&x.Amount, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".amount", pack.KindU64, len(orig)-len(buf))
+	}
+	var x2 uint64
+	if buf, rem, err = surge.UnmarshalU64(&x2, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".nonce", pack.KindU64, len(orig)-len(buf))
+	}
+	x.Nonce = uint(x2)
+	if buf, rem, err = surge.UnmarshalI64(&x.Expiry, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".expiry", pack.KindI64, len(orig)-len(buf))
+	}
+	var x3 int64
+	if buf, rem, err = surge.UnmarshalI64(&x3, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".offset", pack.KindI64, len(orig)-len(buf))
+	}
+	x.Offset = int(x3)
+	if buf, rem, err = surge.UnmarshalI8(&x.Delta, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".delta", pack.KindI8, len(orig)-len(buf))
+	}
+	if buf, rem, err = surge.UnmarshalBool(&x.Active, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".active", pack.KindBool, len(orig)-len(buf))
+	}
+	var x4 string
+	if buf, rem, err = surge.UnmarshalString(&x4, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".memo", pack.KindString, len(orig)-len(buf))
+	}
+	x.Memo = Memo(x4)
+	if buf, rem, err = surge.UnmarshalBytes(&x.Data, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".data", pack.KindBytes, len(orig)-len(buf))
+	}
+	if len(buf) < 65 || rem < 65 {
+		return buf, rem, pack.WrapDecodeError(surge.ErrUnexpectedEndOfBuffer, ".signature", pack.KindBytes65, len(orig)-len(buf))
+	}
+	copy(x.Signature[:], buf[:65])
+	buf, rem = buf[65:], rem-65
+	if len(buf) < 4 || rem < 4 {
+		return buf, rem, pack.WrapDecodeError(surge.ErrUnexpectedEndOfBuffer, ".tag", pack.KindBytesN, len(orig)-len(buf))
+	}
+	copy(x.Tag[:], buf[:4])
+	buf, rem = buf[4:], rem-4
+	if err = state.Enter(); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".point", pack.KindTuple, len(orig)-len(buf))
+	}
+	if err = state.AllocList(2); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".point", pack.KindTuple, len(orig)-len(buf))
+	}
+	for i5 := range x.Point {
+		if buf, rem, err = surge.UnmarshalI16(&x.Point[i5], buf, rem); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".point["+strconv.Itoa(i5)+"]", pack.KindI16, len(orig)-len(buf))
+		}
+	}
+	state.Leave()
+	if err = state.Enter(); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".fills", pack.KindList, len(orig)-len(buf))
+	}
+	var n6 uint32
+	if buf, rem, err = surge.UnmarshalU32(&n6, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".fills", pack.KindList, len(orig)-len(buf))
+	}
+	if err = state.CheckListLen(n6); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".fills", pack.KindList, len(orig)-len(buf))
+	}
+	if uint64(n6) > uint64(len(buf)) || uint64(n6) > uint64(rem) {
+		return buf, rem, pack.WrapDecodeError(surge.ErrUnexpectedEndOfBuffer, ".fills", pack.KindList, len(orig)-len(buf))
+	}
+	if err = state.AllocList(int(n6)); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".fills", pack.KindList, len(orig)-len(buf))
+	}
+	x.Fills = make([]Fill, n6)
+	for i7 := range x.Fills {
+		if buf, rem, err = x.Fills[i7].unmarshalWithState(buf, rem, state); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".fills["+strconv.Itoa(i7)+"]", pack.KindStruct, len(orig)-len(buf))
+		}
+	}
+	state.Leave()
+	if err = state.Enter(); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".parent", pack.KindOptional, len(orig)-len(buf))
+	}
+	var some8 bool
+	if buf, rem, err = surge.UnmarshalBool(&some8, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".parent", pack.KindOptional, len(orig)-len(buf))
+	}
+	if some8 {
+		x.Parent = new(Fill)
+		if buf, rem, err = (*x.Parent).unmarshalWithState(buf, rem, state); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".parent", pack.KindStruct, len(orig)-len(buf))
+		}
+	} else {
+		x.Parent = nil
+	}
+	state.Leave()
+	if err = state.Enter(); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".fees", pack.KindMap, len(orig)-len(buf))
+	}
+	var n9 uint32
+	if buf, rem, err = surge.UnmarshalU32(&n9, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".fees", pack.KindMap, len(orig)-len(buf))
+	}
+	if err = state.CheckListLen(n9); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".fees", pack.KindMap, len(orig)-len(buf))
+	}
+	if uint64(n9) > uint64(len(buf)) || uint64(n9) > uint64(rem) {
+		return buf, rem, pack.WrapDecodeError(surge.ErrUnexpectedEndOfBuffer, ".fees", pack.KindMap, len(orig)-len(buf))
+	}
+	if err = state.AllocMap(int(n9)); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".fees", pack.KindMap, len(orig)-len(buf))
+	}
+	x.Fees = make(map[string]uint32, n9)
+	var prev10 []byte
+	for i11 := uint32(0); i11 < n9; i11++ {
+		keyBuf12, keyRem13 := buf, rem
+		var k15 string
+		if buf, rem, err = surge.UnmarshalString(&k15, buf, rem); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".fees["+strconv.Itoa(int(i11))+"].key", pack.KindString, len(orig)-len(buf))
+		}
+		key14 := keyBuf12[:len(keyBuf12)-len(buf)]
+		if i11 > 0 && bytes.Compare(prev10, key14) >= 0 {
+			return keyBuf12, keyRem13, pack.WrapDecodeError(fmt.Errorf("non-canonical order"), ".fees["+strconv.Itoa(int(i11))+"].key", pack.KindMap, len(orig)-len(keyBuf12))
+		}
+		prev10 = key14
+		var v16 uint32
+		if buf, rem, err = surge.UnmarshalU32(&v16, buf, rem); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".fees["+strconv.Itoa(int(i11))+"].value", pack.KindU32, len(orig)-len(buf))
+		}
+		x.Fees[k15] = v16
+	}
+	state.Leave()
+	if err = state.Enter(); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".limits", pack.KindMap, len(orig)-len(buf))
+	}
+	var n17 uint32
+	if buf, rem, err = surge.UnmarshalU32(&n17, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".limits", pack.KindMap, len(orig)-len(buf))
+	}
+	if err = state.CheckListLen(n17); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".limits", pack.KindMap, len(orig)-len(buf))
+	}
+	if uint64(n17) > uint64(len(buf)) || uint64(n17) > uint64(rem) {
+		return buf, rem, pack.WrapDecodeError(surge.ErrUnexpectedEndOfBuffer, ".limits", pack.KindMap, len(orig)-len(buf))
+	}
+	if err = state.AllocMap(int(n17)); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".limits", pack.KindMap, len(orig)-len(buf))
+	}
+	x.Limits = make(map[uint16][]Fill, n17)
+	var prev18 []byte
+	for i19 := uint32(0); i19 < n17; i19++ {
+		keyBuf20, keyRem21 := buf, rem
+		var k23 uint16
+		if buf, rem, err = surge.UnmarshalU16(&k23, buf, rem); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".limits["+strconv.Itoa(int(i19))+"].key", pack.KindU16, len(orig)-len(buf))
+		}
+		key22 := keyBuf20[:len(keyBuf20)-len(buf)]
+		if i19 > 0 && bytes.Compare(prev18, key22) >= 0 {
+			return keyBuf20, keyRem21, pack.WrapDecodeError(fmt.Errorf("non-canonical order"), ".limits["+strconv.Itoa(int(i19))+"].key", pack.KindMap, len(orig)-len(keyBuf20))
+		}
+		prev18 = key22
+		var v24 []Fill
+		if err = state.Enter(); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".limits["+strconv.Itoa(int(i19))+"].value", pack.KindList, len(orig)-len(buf))
+		}
+		var n25 uint32
+		if buf, rem, err = surge.UnmarshalU32(&n25, buf, rem); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".limits["+strconv.Itoa(int(i19))+"].value", pack.KindList, len(orig)-len(buf))
+		}
+		if err = state.CheckListLen(n25); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".limits["+strconv.Itoa(int(i19))+"].value", pack.KindList, len(orig)-len(buf))
+		}
+		if uint64(n25) > uint64(len(buf)) || uint64(n25) > uint64(rem) {
+			return buf, rem, pack.WrapDecodeError(surge.ErrUnexpectedEndOfBuffer, ".limits["+strconv.Itoa(int(i19))+"].value", pack.KindList, len(orig)-len(buf))
+		}
+		if err = state.AllocList(int(n25)); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".limits["+strconv.Itoa(int(i19))+"].value", pack.KindList, len(orig)-len(buf))
+		}
+		v24 = make([]Fill, n25)
+		for i26 := range v24 {
+			if buf, rem, err = v24[i26].unmarshalWithState(buf, rem, state); err != nil {
+				return buf, rem, pack.WrapDecodeError(err, ".limits["+strconv.Itoa(int(i19))+"].value["+strconv.Itoa(i26)+"]", pack.KindStruct, len(orig)-len(buf))
+			}
+		}
+		state.Leave()
+		x.Limits[k23] = v24
+	}
+	state.Leave()
+	if buf, rem, err = x.Owner.unmarshalWithState(buf, rem, state); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".owner", pack.KindStruct, len(orig)-len(buf))
+	}
+	if err = state.Enter(); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".signers", pack.KindList, len(orig)-len(buf))
+	}
+	var n27 uint32
+	if buf, rem, err = surge.UnmarshalU32(&n27, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".signers", pack.KindList, len(orig)-len(buf))
+	}
+	if err = state.CheckListLen(n27); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".signers", pack.KindList, len(orig)-len(buf))
+	}
+	if uint64(n27) > uint64(len(buf)) || uint64(n27) > uint64(rem) {
+		return buf, rem, pack.WrapDecodeError(surge.ErrUnexpectedEndOfBuffer, ".signers", pack.KindList, len(orig)-len(buf))
+	}
+	if err = state.AllocList(int(n27)); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".signers", pack.KindList, len(orig)-len(buf))
+	}
+	x.Signers = make([]*Account, n27)
+	for i28 := range x.Signers {
+		if err = state.Enter(); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".signers["+strconv.Itoa(i28)+"]", pack.KindOptional, len(orig)-len(buf))
+		}
+		var some29 bool
+		if buf, rem, err = surge.UnmarshalBool(&some29, buf, rem); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".signers["+strconv.Itoa(i28)+"]", pack.KindOptional, len(orig)-len(buf))
+		}
+		if some29 {
+			x.Signers[i28] = new(Account)
+			if buf, rem, err = (*x.Signers[i28]).unmarshalWithState(buf, rem, state); err != nil {
+				return buf, rem, pack.WrapDecodeError(err, ".signers["+strconv.Itoa(i28)+"]", pack.KindStruct, len(orig)-len(buf))
+			}
+		} else {
+			x.Signers[i28] = nil
+		}
+		state.Leave()
+	}
+	state.Leave()
+	return buf, rem, nil
+}
+
+// MarshalJSON marshals Order to JSON, in the same way as its pack
+// value.
+func (x Order) MarshalJSON() ([]byte, error) {
+	v, err := x.PackEncode()
+	if err != nil {
+		return nil, err
+	}
+	return v.MarshalJSON()
+}
+
+// UnmarshalJSON unmarshals Order from JSON, in the same way as its
+// pack value.
+func (x *Order) UnmarshalJSON(data []byte) error {
+	v, err := packTypeOrder.UnmarshalValueJSON(data)
+	if err != nil {
+		return err
+	}
+	return x.PackDecode(v)
+}
+
+// PackEncode encodes Order as a pack struct.
+func (x Order) PackEncode() (pack.Value, error) {
+	var err error
+	v := pack.Struct{
+		{Name: "id"},
+		{Name: "side"},
+		{Name: "price"},
+		{Name: "amount"},
+		{Name: "nonce"},
+		{Name: "expiry"},
+		{Name: "offset"},
+		{Name: "delta"},
+		{Name: "active"},
+		{Name: "memo"},
+		{Name: "data"},
+		{Name: "signature"},
+		{Name: "tag"},
+		{Name: "point"},
+		{Name: "fills"},
+		{Name: "parent"},
+		{Name: "fees"},
+		{Name: "limits"},
+		{Name: "owner"},
+		{Name: "signers"},
+	}
+	v[0].Value = pack.NewBytes32([32]byte(x.ID))
+	v[1].Value = pack.NewU8(uint8(x.Side))
+	v[2].Value = x.Price
+	v[3].Value = pack.NewU64(x.Amount)
+	v[4].Value = pack.NewU64(uint64(x.Nonce))
+	v[5].Value = pack.NewI64(x.Expiry)
+	v[6].Value = pack.NewI64(int64(x.Offset))
+	v[7].Value = pack.NewI8(x.Delta)
+	v[8].Value = pack.NewBool(x.Active)
+	v[9].Value = pack.NewString(string(x.Memo))
+	v[10].Value = pack.NewBytes(x.Data)
+	v[11].Value = pack.NewBytes65(x.Signature)
+	v[12].Value = pack.NewBytesN(x.Tag[:])
+	elems1 := make(pack.Tuple, len(x.Point))
+	for i2 := range x.Point {
+		elems1[i2] = pack.NewI16(x.Point[i2])
+	}
+	v[13].Value = elems1
+	elems3 := make([]pack.Value, len(x.Fills))
+	for i4 := range x.Fills {
+		if elems3[i4], err = x.Fills[i4].PackEncode(); err != nil {
+			return nil, fmt.Errorf("encoding \"Fills\": %v", err)
+		}
+	}
+	v[14].Value = pack.List{T: packTypeFill, Elems: elems3}
+	if x.Parent == nil {
+		v[15].Value = pack.None(packTypeFill)
+	} else {
+		var elem5 pack.Value
+		if elem5, err = (*x.Parent).PackEncode(); err != nil {
+			return nil, fmt.Errorf("encoding \"Parent\": %v", err)
+		}
+		v[15].Value = pack.Some(elem5)
+	}
+	entries6 := make([]pack.MapEntry, 0, len(x.Fees))
+	for k7, v8 := range x.Fees {
+		var key9, value10 pack.Value
+		key9 = pack.NewString(k7)
+		value10 = pack.NewU32(v8)
+		entries6 = append(entries6, pack.NewMapEntry(key9, value10))
+	}
+	m11 := pack.EmptyMap(pack.TypeString(), pack.TypeU32())
+	if len(entries6) > 0 {
+		if m11, err = pack.NewMap(entries6...); err != nil {
+			return nil, fmt.Errorf("encoding \"Fees\": %v", err)
+		}
+	}
+	v[16].Value = m11
+	entries12 := make([]pack.MapEntry, 0, len(x.Limits))
+	for k13, v14 := range x.Limits {
+		var key15, value16 pack.Value
+		key15 = pack.NewU16(k13)
+		elems18 := make([]pack.Value, len(v14))
+		for i19 := range v14 {
+			if elems18[i19], err = v14[i19].PackEncode(); err != nil {
+				return nil, fmt.Errorf("encoding \"Limits\": %v", err)
+			}
+		}
+		value16 = pack.List{T: packTypeFill, Elems: elems18}
+		entries12 = append(entries12, pack.NewMapEntry(key15, value16))
+	}
+	m17 := pack.EmptyMap(pack.TypeU16(), pack.ListType(packTypeFill))
+	if len(entries12) > 0 {
+		if m17, err = pack.NewMap(entries12...); err != nil {
+			return nil, fmt.Errorf("encoding \"Limits\": %v", err)
+		}
+	}
+	v[17].Value = m17
+	if v[18].Value, err = x.Owner.PackEncode(); err != nil {
+		return nil, fmt.Errorf("encoding \"Owner\": %v", err)
+	}
+	elems20 := make([]pack.Value, len(x.Signers))
+	for i21 := range x.Signers {
+		if x.Signers[i21] == nil {
+			elems20[i21] = pack.None(packTypeAccount)
+		} else {
+			var elem22 pack.Value
+			if elem22, err = (*x.Signers[i21]).PackEncode(); err != nil {
+				return nil, fmt.Errorf("encoding \"Signers\": %v", err)
+			}
+			elems20[i21] = pack.Some(elem22)
+		}
+	}
+	v[19].Value = pack.List{T: pack.OptionalType(packTypeAccount), Elems: elems20}
+	return v, nil
+}
+
+// PackDecode decodes Order from a pack struct. Errors are returned as
+// a *pack.DecodeError.
+func (x *Order) PackDecode(v pack.Value) error {
+	var s pack.Struct
+	switch v := v.(type) {
+	case Order:
+		*x = v
+		return nil
+	case pack.Struct:
+		s = v
+	case pack.Typed:
+		s = pack.Struct(v)
+	default:
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", v), ".", pack.KindStruct, -1)
+	}
+	if len(s) != 20 {
+		return pack.WrapDecodeError(fmt.Errorf("expected fields=%v, got fields=%v", 20, len(s)), ".", pack.KindStruct, -1)
+	}
+	if s[0].Name != "id" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "id", s[0].Name), ".", pack.KindStruct, -1)
+	}
+	y1, ok2 := s[0].Value.(pack.Bytes32)
+	if !ok2 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[0].Value), ".id", pack.KindBytes32, -1)
+	}
+	x.ID = Hash(y1)
+	if s[1].Name != "side" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "side", s[1].Name), ".", pack.KindStruct, -1)
+	}
+	y3, ok4 := s[1].Value.(pack.U8)
+	if !ok4 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[1].Value), ".side", pack.KindU8, -1)
+	}
+	x.Side = Side(y3)
+	if s[2].Name != "price" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "price", s[2].Name), ".", pack.KindStruct, -1)
+	}
+	y5, ok6 := s[2].Value.(pack.U256)
+	if !ok6 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[2].Value), ".price", pack.KindU256, -1)
+	}
+	x.Price = y5
+	if s[3].Name != "amount" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "amount", s[3].Name), ".", pack.KindStruct, -1)
+	}
+	y7, ok8 := s[3].Value.(pack.U64)
+	if !ok8 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[3].Value), ".amount", pack.KindU64, -1)
+	}
+	x.Amount = uint64(y7)
+	if s[4].Name != "nonce" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "nonce", s[4].Name), ".", pack.KindStruct, -1)
+	}
+	y9, ok10 := s[4].Value.(pack.U64)
+	if !ok10 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[4].Value), ".nonce", pack.KindU64, -1)
+	}
+	x.Nonce = uint(y9)
+	if s[5].Name != "expiry" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "expiry", s[5].Name), ".", pack.KindStruct, -1)
+	}
+	y11, ok12 := s[5].Value.(pack.I64)
+	if !ok12 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[5].Value), ".expiry", pack.KindI64, -1)
+	}
+	x.Expiry = int64(y11)
+	if s[6].Name != "offset" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "offset", s[6].Name), ".", pack.KindStruct, -1)
+	}
+	y13, ok14 := s[6].Value.(pack.I64)
+	if !ok14 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[6].Value), ".offset", pack.KindI64, -1)
+	}
+	x.Offset = int(y13)
+	if s[7].Name != "delta" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "delta", s[7].Name), ".", pack.KindStruct, -1)
+	}
+	y15, ok16 := s[7].Value.(pack.I8)
+	if !ok16 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[7].Value), ".delta", pack.KindI8, -1)
+	}
+	x.Delta = int8(y15)
+	if s[8].Name != "active" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "active", s[8].Name), ".", pack.KindStruct, -1)
+	}
+	y17, ok18 := s[8].Value.(pack.Bool)
+	if !ok18 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[8].Value), ".active", pack.KindBool, -1)
+	}
+	x.Active = bool(y17)
+	if s[9].Name != "memo" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "memo", s[9].Name), ".", pack.KindStruct, -1)
+	}
+	y19, ok20 := s[9].Value.(pack.String)
+	if !ok20 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[9].Value), ".memo", pack.KindString, -1)
+	}
+	x.Memo = Memo(y19)
+	if s[10].Name != "data" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "data", s[10].Name), ".", pack.KindStruct, -1)
+	}
+	y21, ok22 := s[10].Value.(pack.Bytes)
+	if !ok22 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[10].Value), ".data", pack.KindBytes, -1)
+	}
+	x.Data = []byte(y21)
+	if s[11].Name != "signature" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "signature", s[11].Name), ".", pack.KindStruct, -1)
+	}
+	y23, ok24 := s[11].Value.(pack.Bytes65)
+	if !ok24 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[11].Value), ".signature", pack.KindBytes65, -1)
+	}
+	x.Signature = [65]byte(y23)
+	if s[12].Name != "tag" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "tag", s[12].Name), ".", pack.KindStruct, -1)
+	}
+	y25, ok26 := s[12].Value.(pack.BytesN)
+	if !ok26 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[12].Value), ".tag", pack.KindBytesN, -1)
+	}
+	if len(y25) != 4 {
+		return pack.WrapDecodeError(fmt.Errorf("expected len=4, got len=%v", len(y25)), ".tag", pack.KindBytesN, -1)
+	}
+	copy(x.Tag[:], y25)
+	if s[13].Name != "point" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "point", s[13].Name), ".", pack.KindStruct, -1)
+	}
+	y27, ok28 := s[13].Value.(pack.Tuple)
+	if !ok28 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[13].Value), ".point", pack.KindTuple, -1)
+	}
+	if len(y27) != 2 {
+		return pack.WrapDecodeError(fmt.Errorf("expected len=2, got len=%v", len(y27)), ".point", pack.KindTuple, -1)
+	}
+	for i29 := range x.Point {
+		y30, ok31 := y27[i29].(pack.I16)
+		if !ok31 {
+			return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", y27[i29]), ".point["+strconv.Itoa(i29)+"]", pack.KindI16, -1)
+		}
+		x.Point[i29] = int16(y30)
+	}
+	if s[14].Name != "fills" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "fills", s[14].Name), ".", pack.KindStruct, -1)
+	}
+	y32, ok33 := s[14].Value.(pack.List)
+	if !ok33 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[14].Value), ".fills", pack.KindList, -1)
+	}
+	x.Fills = make([]Fill, len(y32.Elems))
+	for i34 := range y32.Elems {
+		if err := x.Fills[i34].PackDecode(y32.Elems[i34]); err != nil {
+			return pack.WrapDecodeError(err, ".fills["+strconv.Itoa(i34)+"]", pack.KindStruct, -1)
+		}
+	}
+	if s[15].Name != "parent" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "parent", s[15].Name), ".", pack.KindStruct, -1)
+	}
+	y35, ok36 := s[15].Value.(pack.Optional)
+	if !ok36 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[15].Value), ".parent", pack.KindOptional, -1)
+	}
+	if y35.Value == nil {
+		x.Parent = nil
+	} else {
+		x.Parent = new(Fill)
+		if err := (*x.Parent).PackDecode(y35.Value); err != nil {
+			return pack.WrapDecodeError(err, ".parent", pack.KindStruct, -1)
+		}
+	}
+	if s[16].Name != "fees" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "fees", s[16].Name), ".", pack.KindStruct, -1)
+	}
+	y37, ok38 := s[16].Value.(pack.Map)
+	if !ok38 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[16].Value), ".fees", pack.KindMap, -1)
+	}
+	x.Fees = make(map[string]uint32, len(y37.Entries))
+	for i39, e40 := range y37.Entries {
+		var k41 string
+		y43, ok44 := e40.Key.(pack.String)
+		if !ok44 {
+			return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", e40.Key), ".fees["+strconv.Itoa(i39)+"].key", pack.KindString, -1)
+		}
+		k41 = string(y43)
+		var v42 uint32
+		y45, ok46 := e40.Value.(pack.U32)
+		if !ok46 {
+			return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", e40.Value), ".fees["+strconv.Itoa(i39)+"].value", pack.KindU32, -1)
+		}
+		v42 = uint32(y45)
+		x.Fees[k41] = v42
+	}
+	if s[17].Name != "limits" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "limits", s[17].Name), ".", pack.KindStruct, -1)
+	}
+	y47, ok48 := s[17].Value.(pack.Map)
+	if !ok48 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[17].Value), ".limits", pack.KindMap, -1)
+	}
+	x.Limits = make(map[uint16][]Fill, len(y47.Entries))
+	for i49, e50 := range y47.Entries {
+		var k51 uint16
+		y53, ok54 := e50.Key.(pack.U16)
+		if !ok54 {
+			return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", e50.Key), ".limits["+strconv.Itoa(i49)+"].key", pack.KindU16, -1)
+		}
+		k51 = uint16(y53)
+		var v52 []Fill
+		y55, ok56 := e50.Value.(pack.List)
+		if !ok56 {
+			return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", e50.Value), ".limits["+strconv.Itoa(i49)+"].value", pack.KindList, -1)
+		}
+		v52 = make([]Fill, len(y55.Elems))
+		for i57 := range y55.Elems {
+			if err := v52[i57].PackDecode(y55.Elems[i57]); err != nil {
+				return pack.WrapDecodeError(err, ".limits["+strconv.Itoa(i49)+"].value["+strconv.Itoa(i57)+"]", pack.KindStruct, -1)
+			}
+		}
+		x.Limits[k51] = v52
+	}
+	if s[18].Name != "owner" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "owner", s[18].Name), ".", pack.KindStruct, -1)
+	}
+	if err := x.Owner.PackDecode(s[18].Value); err != nil {
+		return pack.WrapDecodeError(err, ".owner", pack.KindStruct, -1)
+	}
+	if s[19].Name != "signers" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "signers", s[19].Name), ".", pack.KindStruct, -1)
+	}
+	y58, ok59 := s[19].Value.(pack.List)
+	if !ok59 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[19].Value), ".signers", pack.KindList, -1)
+	}
+	x.Signers = make([]*Account, len(y58.Elems))
+	for i60 := range y58.Elems {
+		y61, ok62 := y58.Elems[i60].(pack.Optional)
+		if !ok62 {
+			return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", y58.Elems[i60]), ".signers["+strconv.Itoa(i60)+"]", pack.KindOptional, -1)
+		}
+		if y61.Value == nil {
+			x.Signers[i60] = nil
+		} else {
+			x.Signers[i60] = new(Account)
+			if err := (*x.Signers[i60]).PackDecode(y61.Value); err != nil {
+				return pack.WrapDecodeError(err, ".signers["+strconv.Itoa(i60)+"]", pack.KindStruct, -1)
+			}
+		}
+	}
+	return nil
+}
+
+// Generate a random Order. This method is implemented for use in quick
+// tests. See https://golang.org/pkg/testing/quick/#Generator for more
+// information.
+func (Order) Generate(r *rand.Rand, size int) reflect.Value {
+	x := Order{}
+	if err := x.PackDecode(pack.GenerateFromType(r, size, packTypeOrder)); err != nil {
+		panic(err)
+	}
+	return reflect.ValueOf(x)
+}
+
+// packTypeFill is the pack type of Fill.
+var packTypeFill = pack.StructType(
+	"price", pack.TypeU64(),
+	"amount", pack.TypeU32(),
+	"final", pack.TypeBool(),
+)
+
+// Type returns the pack type of Fill.
+func (Fill) Type() pack.Type {
+	return packTypeFill
+}
+
+// SizeHint returns the number of bytes required to represent Fill in
+// binary.
+func (x Fill) SizeHint() int {
+	size := 13
+	return size
+}
+
+// Marshal Fill to binary.
+func (x Fill) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	var err error
+	if buf, rem, err = surge.MarshalU64(x.Price, buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if buf, rem, err = surge.MarshalU32(x.Amount, buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if buf, rem, err = surge.MarshalBool(x.Final, buf, rem); err != nil {
+		return buf, rem, err
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal Fill from binary, using pack.DefaultDecodeOptions to
+// limit the resources that can be consumed. Errors are returned as a
+// *pack.DecodeError.
+func (x *Fill) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	state := pack.DefaultDecodeOptions.NewDecodeState()
+	limited := state.CapRem(rem)
+	rest, limitedRem, err := x.unmarshalWithState(buf, limited, state)
+	return rest, rem - (limited - limitedRem), err
+}
+
+// unmarshalWithState unmarshals Fill from binary, enforcing the limits
+// of the decode state across all levels of nesting.
+func (x *Fill) unmarshalWithState(buf []byte, rem int, state *pack.DecodeState) ([]byte, int, error) {
+	orig := buf
+	var err error
+	if err = state.Enter(); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".", pack.KindStruct, len(orig)-len(buf))
+	}
+	defer state.Leave()
+	if err = state.AllocStruct(3); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".", pack.KindStruct, len(orig)-len(buf))
+	}
+	if buf, rem, err = surge.UnmarshalU64(&x.Price, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".price", pack.KindU64, len(orig)-len(buf))
+	}
+	if buf, rem, err = surge.UnmarshalU32(&x.Amount, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".amount", pack.KindU32, len(orig)-len(buf))
+	}
+	if buf, rem, err = surge.UnmarshalBool(&x.Final, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".final", pack.KindBool, len(orig)-len(buf))
+	}
+	return buf, rem, nil
+}
+
+// MarshalJSON marshals Fill to JSON, in the same way as its pack
+// value.
+func (x Fill) MarshalJSON() ([]byte, error) {
+	v, err := x.PackEncode()
+	if err != nil {
+		return nil, err
+	}
+	return v.MarshalJSON()
+}
+
+// UnmarshalJSON unmarshals Fill from JSON, in the same way as its
+// pack value.
+func (x *Fill) UnmarshalJSON(data []byte) error {
+	v, err := packTypeFill.UnmarshalValueJSON(data)
+	if err != nil {
+		return err
+	}
+	return x.PackDecode(v)
+}
+
+// PackEncode encodes Fill as a pack struct.
+func (x Fill) PackEncode() (pack.Value, error) {
+	v := pack.Struct{
+		{Name: "price"},
+		{Name: "amount"},
+		{Name: "final"},
+	}
+	v[0].Value = pack.NewU64(x.Price)
+	v[1].Value = pack.NewU32(x.Amount)
+	v[2].Value = pack.NewBool(x.Final)
+	return v, nil
+}
+
+// PackDecode decodes Fill from a pack struct. Errors are returned as
+// a *pack.DecodeError.
+func (x *Fill) PackDecode(v pack.Value) error {
+	var s pack.Struct
+	switch v := v.(type) {
+	case Fill:
+		*x = v
+		return nil
+	case pack.Struct:
+		s = v
+	case pack.Typed:
+		s = pack.Struct(v)
+	default:
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", v), ".", pack.KindStruct, -1)
+	}
+	if len(s) != 3 {
+		return pack.WrapDecodeError(fmt.Errorf("expected fields=%v, got fields=%v", 3, len(s)), ".", pack.KindStruct, -1)
+	}
+	if s[0].Name != "price" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "price", s[0].Name), ".", pack.KindStruct, -1)
+	}
+	y1, ok2 := s[0].Value.(pack.U64)
+	if !ok2 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[0].Value), ".price", pack.KindU64, -1)
+	}
+	x.Price = uint64(y1)
+	if s[1].Name != "amount" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "amount", s[1].Name), ".", pack.KindStruct, -1)
+	}
+	y3, ok4 := s[1].Value.(pack.U32)
+	if !ok4 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[1].Value), ".amount", pack.KindU32, -1)
+	}
+	x.Amount = uint32(y3)
+	if s[2].Name != "final" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "final", s[2].Name), ".", pack.KindStruct, -1)
+	}
+	y5, ok6 := s[2].Value.(pack.Bool)
+	if !ok6 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[2].Value), ".final", pack.KindBool, -1)
+	}
+	x.Final = bool(y5)
+	return nil
+}
+
+// Generate a random Fill. This method is implemented for use in quick
+// tests. See https://golang.org/pkg/testing/quick/#Generator for more
+// information.
+func (Fill) Generate(r *rand.Rand, size int) reflect.Value {
+	x := Fill{}
+	if err := x.PackDecode(pack.GenerateFromType(r, size, packTypeFill)); err != nil {
+		panic(err)
+	}
+	return reflect.ValueOf(x)
+}
+
+// packTypeAccount is the pack type of Account.
+var packTypeAccount = pack.StructType(
+	"name", pack.TypeString(),
+	"balance", pack.TypeU128(),
+	"nonces", pack.TupleType(pack.TypeU64(), pack.TypeU64(), pack.TypeU64()),
+	"keys", pack.MapType(pack.TypeBytes32(), pack.TypeBool()),
+	"roles", pack.ListType(pack.TypeString()),
+	"address", pack.TypeBytes32(),
+	"meta", pack.OptionalType(pack.MapType(pack.TypeString(), pack.TypeU8())),
+)
+
+// Type returns the pack type of Account.
+func (Account) Type() pack.Type {
+	return packTypeAccount
+}
+
+// SizeHint returns the number of bytes required to represent Account in
+// binary.
+func (x Account) SizeHint() int {
+	size := 72
+	size += surge.SizeHintString(x.Name)
+	size += 4
+	size += len(x.Keys) * 33
+	size += 4
+	for _, e1 := range x.Roles {
+		size += surge.SizeHintString(e1)
+	}
+	size += 1
+	if x.Meta != nil {
+		size += 4
+		size += len((*x.Meta)) * 1
+		for k2 := range *x.Meta {
+			size += surge.SizeHintString(string(k2))
+		}
+	}
+	return size
+}
+
+// Marshal Account to binary.
+func (x Account) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	var err error
+	if buf, rem, err = surge.MarshalString(x.Name, buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if buf, rem, err = x.Balance.Marshal(buf, rem); err != nil {
+		return buf, rem, err
+	}
+	for i1 := range x.Nonces {
+		if buf, rem, err = surge.MarshalU64(x.Nonces[i1], buf, rem); err != nil {
+			return buf, rem, err
+		}
+	}
+	type entry2 struct {
+		key   []byte
+		value bool
+	}
+	entries3 := make([]entry2, 0, len(x.Keys))
+	for k4, v5 := range x.Keys {
+		size6 := 32
+		keyBuf7 := make([]byte, size6)
+		buf8, rem9 := keyBuf7, size6
+		if len(buf8) < 32 || rem9 < 32 {
+			return buf, rem, surge.ErrUnexpectedEndOfBuffer
+		}
+		copy(buf8, k4[:])
+		buf8, rem9 = buf8[32:], rem9-32
+		entries3 = append(entries3, entry2{key: keyBuf7, value: v5})
+	}
+	sort.Slice(entries3, func(i, j int) bool {
+		return bytes.Compare(entries3[i].key, entries3[j].key) < 0
+	})
+	if buf, rem, err = surge.MarshalLen(uint32(len(entries3)), buf, rem); err != nil {
+		return buf, rem, err
+	}
+	for _, e10 := range entries3 {
+		if len(buf) < len(e10.key) || rem < len(e10.key) {
+			return buf, rem, surge.ErrUnexpectedEndOfBuffer
+		}
+		copy(buf, e10.key)
+		buf, rem = buf[len(e10.key):], rem-len(e10.key)
+		if buf, rem, err = surge.MarshalBool(e10.value, buf, rem); err != nil {
+			return buf, rem, err
+		}
+	}
+	if buf, rem, err = surge.MarshalLen(uint32(len(x.Roles)), buf, rem); err != nil {
+		return buf, rem, err
+	}
+	for _, e11 := range x.Roles {
+		if buf, rem, err = surge.MarshalString(e11, buf, rem); err != nil {
+			return buf, rem, err
+		}
+	}
+	if buf, rem, err = x.Address.Marshal(buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if buf, rem, err = surge.MarshalBool(x.Meta != nil, buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if x.Meta != nil {
+		type entry12 struct {
+			key   []byte
+			value Side
+		}
+		entries13 := make([]entry12, 0, len((*x.Meta)))
+		for k14, v15 := range *x.Meta {
+			size16 := 0
+			size16 += surge.SizeHintString(string(k14))
+			keyBuf17 := make([]byte, size16)
+			buf18, rem19 := keyBuf17, size16
+			if buf18, rem19, err = surge.MarshalString(string(k14), buf18, rem19); err != nil {
+				return buf, rem, err
+			}
+			entries13 = append(entries13, entry12{key: keyBuf17, value: v15})
+		}
+		sort.Slice(entries13, func(i, j int) bool {
+			return bytes.Compare(entries13[i].key, entries13[j].key) < 0
+		})
+		if buf, rem, err = surge.MarshalLen(uint32(len(entries13)), buf, rem); err != nil {
+			return buf, rem, err
+		}
+		for _, e20 := range entries13 {
+			if len(buf) < len(e20.key) || rem < len(e20.key) {
+				return buf, rem, surge.ErrUnexpectedEndOfBuffer
+			}
+			copy(buf, e20.key)
+			buf, rem = buf[len(e20.key):], rem-len(e20.key)
+			if buf, rem, err = surge.MarshalU8(uint8(e20.value), buf, rem); err != nil {
+				return buf, rem, err
+			}
+		}
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal Account from binary, using pack.DefaultDecodeOptions to
+// limit the resources that can be consumed. Errors are returned as a
+// *pack.DecodeError.
+func (x *Account) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	state := pack.DefaultDecodeOptions.NewDecodeState()
+	limited := state.CapRem(rem)
+	rest, limitedRem, err := x.unmarshalWithState(buf, limited, state)
+	return rest, rem - (limited - limitedRem), err
+}
+
+// unmarshalWithState unmarshals Account from binary, enforcing the limits
+// of the decode state across all levels of nesting.
+func (x *Account) unmarshalWithState(buf []byte, rem int, state *pack.DecodeState) ([]byte, int, error) {
+	orig := buf
+	var err error
+	if err = state.Enter(); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".", pack.KindStruct, len(orig)-len(buf))
+	}
+	defer state.Leave()
+	if err = state.AllocStruct(7); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".", pack.KindStruct, len(orig)-len(buf))
+	}
+	if buf, rem, err = surge.UnmarshalString(&x.Name, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".name", pack.KindString, len(orig)-len(buf))
+	}
+	if buf, rem, err = x.Balance.Unmarshal(buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".balance", pack.KindU128, len(orig)-len(buf))
+	}
+	if err = state.Enter(); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".nonces", pack.KindTuple, len(orig)-len(buf))
+	}
+	if err = state.AllocList(3); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".nonces", pack.KindTuple, len(orig)-len(buf))
+	}
+	for i1 := range x.Nonces {
+		if buf, rem, err = surge.UnmarshalU64(&x.Nonces[i1], buf, rem); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".nonces["+strconv.Itoa(i1)+"]", pack.KindU64, len(orig)-len(buf))
+		}
+	}
+	state.Leave()
+	if err = state.Enter(); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".keys", pack.KindMap, len(orig)-len(buf))
+	}
+	var n2 uint32
+	if buf, rem, err = surge.UnmarshalU32(&n2, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".keys", pack.KindMap, len(orig)-len(buf))
+	}
+	if err = state.CheckListLen(n2); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".keys", pack.KindMap, len(orig)-len(buf))
+	}
+	if uint64(n2) > uint64(len(buf)) || uint64(n2) > uint64(rem) {
+		return buf, rem, pack.WrapDecodeError(surge.ErrUnexpectedEndOfBuffer, ".keys", pack.KindMap, len(orig)-len(buf))
+	}
+	if err = state.AllocMap(int(n2)); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".keys", pack.KindMap, len(orig)-len(buf))
+	}
+	x.Keys = make(map[Hash]bool, n2)
+	var prev3 []byte
+	for i4 := uint32(0); i4 < n2; i4++ {
+		keyBuf5, keyRem6 := buf, rem
+		var k8 Hash
+		if len(buf) < 32 || rem < 32 {
+			return buf, rem, pack.WrapDecodeError(surge.ErrUnexpectedEndOfBuffer, ".keys["+strconv.Itoa(int(i4))+"].key", pack.KindBytes32, len(orig)-len(buf))
+		}
+		copy(k8[:], buf[:32])
+		buf, rem = buf[32:], rem-32
+		key7 := keyBuf5[:len(keyBuf5)-len(buf)]
+		if i4 > 0 && bytes.Compare(prev3, key7) >= 0 {
+			return keyBuf5, keyRem6, pack.WrapDecodeError(fmt.Errorf("non-canonical order"), ".keys["+strconv.Itoa(int(i4))+"].key", pack.KindMap, len(orig)-len(keyBuf5))
+		}
+		prev3 = key7
+		var v9 bool
+		if buf, rem, err = surge.UnmarshalBool(&v9, buf, rem); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".keys["+strconv.Itoa(int(i4))+"].value", pack.KindBool, len(orig)-len(buf))
+		}
+		x.Keys[k8] = v9
+	}
+	state.Leave()
+	if err = state.Enter(); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".roles", pack.KindList, len(orig)-len(buf))
+	}
+	var n10 uint32
+	if buf, rem, err = surge.UnmarshalU32(&n10, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".roles", pack.KindList, len(orig)-len(buf))
+	}
+	if err = state.CheckListLen(n10); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".roles", pack.KindList, len(orig)-len(buf))
+	}
+	if uint64(n10) > uint64(len(buf)) || uint64(n10) > uint64(rem) {
+		return buf, rem, pack.WrapDecodeError(surge.ErrUnexpectedEndOfBuffer, ".roles", pack.KindList, len(orig)-len(buf))
+	}
+	if err = state.AllocList(int(n10)); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".roles", pack.KindList, len(orig)-len(buf))
+	}
+	x.Roles = make([]string, n10)
+	for i11 := range x.Roles {
+		if buf, rem, err = surge.UnmarshalString(&x.Roles[i11], buf, rem); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".roles["+strconv.Itoa(i11)+"]", pack.KindString, len(orig)-len(buf))
+		}
+	}
+	state.Leave()
+	if buf, rem, err = x.Address.Unmarshal(buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".address", pack.KindBytes32, len(orig)-len(buf))
+	}
+	if err = state.Enter(); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".meta", pack.KindOptional, len(orig)-len(buf))
+	}
+	var some12 bool
+	if buf, rem, err = surge.UnmarshalBool(&some12, buf, rem); err != nil {
+		return buf, rem, pack.WrapDecodeError(err, ".meta", pack.KindOptional, len(orig)-len(buf))
+	}
+	if some12 {
+		x.Meta = new(map[Memo]Side)
+		if err = state.Enter(); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".meta", pack.KindMap, len(orig)-len(buf))
+		}
+		var n13 uint32
+		if buf, rem, err = surge.UnmarshalU32(&n13, buf, rem); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".meta", pack.KindMap, len(orig)-len(buf))
+		}
+		if err = state.CheckListLen(n13); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".meta", pack.KindMap, len(orig)-len(buf))
+		}
+		if uint64(n13) > uint64(len(buf)) || uint64(n13) > uint64(rem) {
+			return buf, rem, pack.WrapDecodeError(surge.ErrUnexpectedEndOfBuffer, ".meta", pack.KindMap, len(orig)-len(buf))
+		}
+		if err = state.AllocMap(int(n13)); err != nil {
+			return buf, rem, pack.WrapDecodeError(err, ".meta", pack.KindMap, len(orig)-len(buf))
+		}
+		(*x.Meta) = make(map[Memo]Side, n13)
+		var prev14 []byte
+		for i15 := uint32(0); i15 < n13; i15++ {
+			keyBuf16, keyRem17 := buf, rem
+			var k19 Memo
+			var x21 string
+			if buf, rem, err = surge.UnmarshalString(&x21, buf, rem); err != nil {
+				return buf, rem, pack.WrapDecodeError(err, ".meta["+strconv.Itoa(int(i15))+"].key", pack.KindString, len(orig)-len(buf))
+			}
+			k19 = Memo(x21)
+			key18 := keyBuf16[:len(keyBuf16)-len(buf)]
+			if i15 > 0 && bytes.Compare(prev14, key18) >= 0 {
+				return keyBuf16, keyRem17, pack.WrapDecodeError(fmt.Errorf("non-canonical order"), ".meta["+strconv.Itoa(int(i15))+"].key", pack.KindMap, len(orig)-len(keyBuf16))
+			}
+			prev14 = key18
+			var v20 Side
+			var x22 uint8
+			if buf, rem, err = surge.UnmarshalU8(&x22, buf, rem); err != nil {
+				return buf, rem, pack.WrapDecodeError(err, ".meta["+strconv.Itoa(int(i15))+"].value", pack.KindU8, len(orig)-len(buf))
+			}
+			v20 = Side(x22)
+			(*x.Meta)[k19] = v20
+		}
+		state.Leave()
+	} else {
+		x.Meta = nil
+	}
+	state.Leave()
+	return buf, rem, nil
+}
+
+// MarshalJSON marshals Account to JSON, in the same way as its pack
+// value.
+func (x Account) MarshalJSON() ([]byte, error) {
+	v, err := x.PackEncode()
+	if err != nil {
+		return nil, err
+	}
+	return v.MarshalJSON()
+}
+
+// UnmarshalJSON unmarshals Account from JSON, in the same way as its
+// pack value.
+func (x *Account) UnmarshalJSON(data []byte) error {
+	v, err := packTypeAccount.UnmarshalValueJSON(data)
+	if err != nil {
+		return err
+	}
+	return x.PackDecode(v)
+}
+
+// PackEncode encodes Account as a pack struct.
+func (x Account) PackEncode() (pack.Value, error) {
+	var err error
+	v := pack.Struct{
+		{Name: "name"},
+		{Name: "balance"},
+		{Name: "nonces"},
+		{Name: "keys"},
+		{Name: "roles"},
+		{Name: "address"},
+		{Name: "meta"},
+	}
+	v[0].Value = pack.NewString(x.Name)
+	v[1].Value = x.Balance
+	elems1 := make(pack.Tuple, len(x.Nonces))
+	for i2 := range x.Nonces {
+		elems1[i2] = pack.NewU64(x.Nonces[i2])
+	}
+	v[2].Value = elems1
+	entries3 := make([]pack.MapEntry, 0, len(x.Keys))
+	for k4, v5 := range x.Keys {
+		var key6, value7 pack.Value
+		key6 = pack.NewBytes32([32]byte(k4))
+		value7 = pack.NewBool(v5)
+		entries3 = append(entries3, pack.NewMapEntry(key6, value7))
+	}
+	m8 := pack.EmptyMap(pack.TypeBytes32(), pack.TypeBool())
+	if len(entries3) > 0 {
+		if m8, err = pack.NewMap(entries3...); err != nil {
+			return nil, fmt.Errorf("encoding \"Keys\": %v", err)
+		}
+	}
+	v[3].Value = m8
+	elems9 := make([]pack.Value, len(x.Roles))
+	for i10 := range x.Roles {
+		elems9[i10] = pack.NewString(x.Roles[i10])
+	}
+	v[4].Value = pack.List{T: pack.TypeString(), Elems: elems9}
+	v[5].Value = x.Address
+	if x.Meta == nil {
+		v[6].Value = pack.None(pack.MapType(pack.TypeString(), pack.TypeU8()))
+	} else {
+		var elem11 pack.Value
+		entries12 := make([]pack.MapEntry, 0, len((*x.Meta)))
+		for k13, v14 := range *x.Meta {
+			var key15, value16 pack.Value
+			key15 = pack.NewString(string(k13))
+			value16 = pack.NewU8(uint8(v14))
+			entries12 = append(entries12, pack.NewMapEntry(key15, value16))
+		}
+		m17 := pack.EmptyMap(pack.TypeString(), pack.TypeU8())
+		if len(entries12) > 0 {
+			if m17, err = pack.NewMap(entries12...); err != nil {
+				return nil, fmt.Errorf("encoding \"Meta\": %v", err)
+			}
+		}
+		elem11 = m17
+		v[6].Value = pack.Some(elem11)
+	}
+	return v, nil
+}
+
+// PackDecode decodes Account from a pack struct. Errors are returned as
+// a *pack.DecodeError.
+func (x *Account) PackDecode(v pack.Value) error {
+	var s pack.Struct
+	switch v := v.(type) {
+	case Account:
+		*x = v
+		return nil
+	case pack.Struct:
+		s = v
+	case pack.Typed:
+		s = pack.Struct(v)
+	default:
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", v), ".", pack.KindStruct, -1)
+	}
+	if len(s) != 7 {
+		return pack.WrapDecodeError(fmt.Errorf("expected fields=%v, got fields=%v", 7, len(s)), ".", pack.KindStruct, -1)
+	}
+	if s[0].Name != "name" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "name", s[0].Name), ".", pack.KindStruct, -1)
+	}
+	y1, ok2 := s[0].Value.(pack.String)
+	if !ok2 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[0].Value), ".name", pack.KindString, -1)
+	}
+	x.Name = string(y1)
+	if s[1].Name != "balance" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "balance", s[1].Name), ".", pack.KindStruct, -1)
+	}
+	y3, ok4 := s[1].Value.(pack.U128)
+	if !ok4 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[1].Value), ".balance", pack.KindU128, -1)
+	}
+	x.Balance = y3
+	if s[2].Name != "nonces" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "nonces", s[2].Name), ".", pack.KindStruct, -1)
+	}
+	y5, ok6 := s[2].Value.(pack.Tuple)
+	if !ok6 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[2].Value), ".nonces", pack.KindTuple, -1)
+	}
+	if len(y5) != 3 {
+		return pack.WrapDecodeError(fmt.Errorf("expected len=3, got len=%v", len(y5)), ".nonces", pack.KindTuple, -1)
+	}
+	for i7 := range x.Nonces {
+		y8, ok9 := y5[i7].(pack.U64)
+		if !ok9 {
+			return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", y5[i7]), ".nonces["+strconv.Itoa(i7)+"]", pack.KindU64, -1)
+		}
+		x.Nonces[i7] = uint64(y8)
+	}
+	if s[3].Name != "keys" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "keys", s[3].Name), ".", pack.KindStruct, -1)
+	}
+	y10, ok11 := s[3].Value.(pack.Map)
+	if !ok11 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[3].Value), ".keys", pack.KindMap, -1)
+	}
+	x.Keys = make(map[Hash]bool, len(y10.Entries))
+	for i12, e13 := range y10.Entries {
+		var k14 Hash
+		y16, ok17 := e13.Key.(pack.Bytes32)
+		if !ok17 {
+			return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", e13.Key), ".keys["+strconv.Itoa(i12)+"].key", pack.KindBytes32, -1)
+		}
+		k14 = Hash(y16)
+		var v15 bool
+		y18, ok19 := e13.Value.(pack.Bool)
+		if !ok19 {
+			return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", e13.Value), ".keys["+strconv.Itoa(i12)+"].value", pack.KindBool, -1)
+		}
+		v15 = bool(y18)
+		x.Keys[k14] = v15
+	}
+	if s[4].Name != "roles" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "roles", s[4].Name), ".", pack.KindStruct, -1)
+	}
+	y20, ok21 := s[4].Value.(pack.List)
+	if !ok21 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[4].Value), ".roles", pack.KindList, -1)
+	}
+	x.Roles = make([]string, len(y20.Elems))
+	for i22 := range y20.Elems {
+		y23, ok24 := y20.Elems[i22].(pack.String)
+		if !ok24 {
+			return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", y20.Elems[i22]), ".roles["+strconv.Itoa(i22)+"]", pack.KindString, -1)
+		}
+		x.Roles[i22] = string(y23)
+	}
+	if s[5].Name != "address" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "address", s[5].Name), ".", pack.KindStruct, -1)
+	}
+	y25, ok26 := s[5].Value.(pack.Bytes32)
+	if !ok26 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[5].Value), ".address", pack.KindBytes32, -1)
+	}
+	x.Address = y25
+	if s[6].Name != "meta" {
+		return pack.WrapDecodeError(fmt.Errorf("expected field %q, got field %q", "meta", s[6].Name), ".", pack.KindStruct, -1)
+	}
+	y27, ok28 := s[6].Value.(pack.Optional)
+	if !ok28 {
+		return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", s[6].Value), ".meta", pack.KindOptional, -1)
+	}
+	if y27.Value == nil {
+		x.Meta = nil
+	} else {
+		x.Meta = new(map[Memo]Side)
+		y29, ok30 := y27.Value.(pack.Map)
+		if !ok30 {
+			return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", y27.Value), ".meta", pack.KindMap, -1)
+		}
+		(*x.Meta) = make(map[Memo]Side, len(y29.Entries))
+		for i31, e32 := range y29.Entries {
+			var k33 Memo
+			y35, ok36 := e32.Key.(pack.String)
+			if !ok36 {
+				return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", e32.Key), ".meta["+strconv.Itoa(i31)+"].key", pack.KindString, -1)
+			}
+			k33 = Memo(y35)
+			var v34 Side
+			y37, ok38 := e32.Value.(pack.U8)
+			if !ok38 {
+				return pack.WrapDecodeError(fmt.Errorf("unexpected value of type %T", e32.Value), ".meta["+strconv.Itoa(i31)+"].value", pack.KindU8, -1)
+			}
+			v34 = Side(y37)
+			(*x.Meta)[k33] = v34
+		}
+	}
+	return nil
+}
+
+// Generate a random Account. This method is implemented for use in quick
+// tests. See https://golang.org/pkg/testing/quick/#Generator for more
+// information.
+func (Account) Generate(r *rand.Rand, size int) reflect.Value {
+	x := Account{}
+	if err := x.PackDecode(pack.GenerateFromType(r, size, packTypeAccount)); err != nil {
+		panic(err)
+	}
+	return reflect.ValueOf(x)
+}
@@ -0,0 +1,106 @@
+// Command packgen generates methods that allow Go structs to be marshaled and
+// unmarshaled without reflection. For each struct type, it generates the Type,
+// SizeHint, Marshal, Unmarshal, MarshalJSON, UnmarshalJSON, and Generate
+// methods (so that the struct implements pack.Value), and the PackEncode and
+// PackDecode methods (so that pack.Encode and pack.Decode use the generated
+// methods instead of reflection). The generated methods produce exactly the
+// same binary and JSON representations as pack.Encode.
+//
+// It is designed to be used with go generate:
+//
+//  //go:generate packgen -type Order,Fill
+//
+// Field names are taken from pack and json tags in the same way as pack.Encode,
+// and the following field types are supported:
+//
+//  bool, string, []byte
+//  int, int8, int16, int32, int64 (int is encoded as an i64)
+//  uint, uint8, uint16, uint32, uint64 (uint is encoded as a u64)
+//  [32]byte, [65]byte, [N]byte (encoded as bytes32, bytes65, and bytesN)
+//  pack.Bool, pack.U8, ..., pack.U256, pack.I8, ..., pack.I256
+//  pack.String, pack.Bytes, pack.Bytes32, pack.Bytes65
+//  []T, *T, [N]T, map[K]V (encoded as lists, optionals, tuples, and maps)
+//  other structs in the same package that are also generated
+//
+// Types defined in the same package that have one of these types as their
+// underlying type are also supported. Tag options (e.g. "u64" or "inline"),
+// embedded fields, and recursive types are not supported.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of struct type names (required)")
+	output := flag.String("output", "", "output file name (default <dir>/<type>_pack.go)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: packgen -type T1,T2 [-output file] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *typeNames == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+	names := strings.Split(*typeNames, ",")
+	if *output == "" {
+		*output = filepath.Join(dir, strings.ToLower(names[0])+"_pack.go")
+	}
+	if err := run(dir, names, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "packgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the methods for the named types in the package in the
+// directory, and writes them to the output file.
+func run(dir string, typeNames []string, output string) error {
+	pkgName, files, err := parsePackage(dir, filepath.Base(output))
+	if err != nil {
+		return err
+	}
+	src, err := generate(pkgName, files, typeNames)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(output, src, 0644)
+}
+
+// parsePackage parses the Go files in a directory, ignoring tests and the file
+// with the given name (which is expected to be the previous output of
+// packgen). It returns the name of the package, and its files.
+func parsePackage(dir, ignore string) (string, []*ast.File, error) {
+	fset := token.NewFileSet()
+	filter := func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && info.Name() != ignore
+	}
+	pkgs, err := parser.ParseDir(fset, dir, filter, parser.ParseComments)
+	if err != nil {
+		return "", nil, fmt.Errorf("parsing %v: %v", dir, err)
+	}
+	if len(pkgs) != 1 {
+		return "", nil, fmt.Errorf("expected 1 package in %v, got %v", dir, len(pkgs))
+	}
+	for name, pkg := range pkgs {
+		files := make([]*ast.File, 0, len(pkg.Files))
+		for _, file := range pkg.Files {
+			files = append(files, file)
+		}
+		return name, files, nil
+	}
+	panic("unreachable")
+}
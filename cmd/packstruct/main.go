@@ -0,0 +1,111 @@
+// Command packstruct prints Go struct definitions for a pack type, so that
+// values of the type can be decoded into Go structs using pack.Decode.
+//
+// The type is read from a file, or from standard input, in one of the
+// following formats:
+//
+//  json:   a Typed value, or a type, marshaled to JSON
+//  binary: a Typed value, or a type, marshaled to binary
+//  schema: a type schema (e.g. "struct { amount: u256, to: bytes32 }")
+//
+// For example:
+//
+//  packstruct -name Order -package orders order.json > order.go
+//
+// See pack.GoStructs for how pack types are mapped to Go types.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/renproject/pack"
+)
+
+func main() {
+	name := flag.String("name", "", "name of the Go type (required)")
+	pkg := flag.String("package", "main", "name of the Go package")
+	format := flag.String("format", "json", "format of the input (json, binary, or schema)")
+	output := flag.String("output", "", "output file name (default standard output)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: packstruct -name T [-package p] [-format json|binary|schema] [-output file] [file]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *name == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var input []byte
+	var err error
+	if flag.NArg() == 1 {
+		input, err = ioutil.ReadFile(flag.Arg(0))
+	} else {
+		input, err = ioutil.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "packstruct: %v\n", err)
+		os.Exit(1)
+	}
+	src, err := run(input, *format, *pkg, *name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "packstruct: %v\n", err)
+		os.Exit(1)
+	}
+	if *output == "" {
+		fmt.Print(src)
+		return
+	}
+	if err := ioutil.WriteFile(*output, []byte(src), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "packstruct: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run reads a type from the input, in the given format, and returns the Go
+// struct definitions for it.
+func run(input []byte, format, pkg, name string) (string, error) {
+	t, err := readType(input, format)
+	if err != nil {
+		return "", err
+	}
+	return pack.GoStructs(t, pkg, name)
+}
+
+// readType reads a type from the input. When the input is a Typed value, the
+// type of the value is returned.
+func readType(input []byte, format string) (pack.Type, error) {
+	switch format {
+	case "json":
+		// Typed values are marshaled as an object with a "t" field, and types
+		// are never marshaled as an object with this field, so the two cannot
+		// be confused.
+		fields := map[string]json.RawMessage{}
+		if err := json.Unmarshal(input, &fields); err == nil {
+			if typeData, ok := fields["t"]; ok {
+				input = typeData
+			}
+		}
+		t, err := pack.DefaultDecodeOptions.UnmarshalTypeJSON(input)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshaling type: %v", err)
+		}
+		return t, nil
+	case "binary":
+		// Typed values are marshaled as their type, followed by their value,
+		// so the type can be read in the same way as a type on its own.
+		var t pack.Type
+		if _, _, err := pack.DefaultDecodeOptions.UnmarshalType(&t, input, len(input)); err != nil {
+			return nil, fmt.Errorf("unmarshaling type: %v", err)
+		}
+		return t, nil
+	case "schema":
+		return pack.ParseType(string(input))
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/surge"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Packstruct", func() {
+
+	typed := pack.NewTyped(
+		"amount", pack.NewU64(1),
+		"to", pack.Bytes32{},
+	)
+	expected, err := pack.GoStructs(typed.Type(), "p", "T")
+	if err != nil {
+		panic(err)
+	}
+
+	Context("when reading a typed value", func() {
+		It("should return the structs for its type", func() {
+			data, err := json.Marshal(typed)
+			Expect(err).ToNot(HaveOccurred())
+			src, err := run(data, "json", "p", "T")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(src).To(Equal(expected))
+
+			data, err = surge.ToBinary(typed)
+			Expect(err).ToNot(HaveOccurred())
+			src, err = run(data, "binary", "p", "T")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(src).To(Equal(expected))
+		})
+	})
+
+	Context("when reading a type", func() {
+		It("should return the structs for the type", func() {
+			data, err := json.Marshal(typed)
+			Expect(err).ToNot(HaveOccurred())
+			fields := map[string]json.RawMessage{}
+			Expect(json.Unmarshal(data, &fields)).To(Succeed())
+			src, err := run(fields["t"], "json", "p", "T")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(src).To(Equal(expected))
+
+			src, err = run([]byte(pack.FormatType(typed.Type())), "schema", "p", "T")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(src).To(Equal(expected))
+		})
+	})
+
+	Context("when reading invalid input", func() {
+		It("should return an error", func() {
+			_, err := run([]byte("{"), "json", "p", "T")
+			Expect(err).To(HaveOccurred())
+			_, err = run([]byte{0xFF}, "binary", "p", "T")
+			Expect(err).To(HaveOccurred())
+			_, err = run([]byte("struct {"), "schema", "p", "T")
+			Expect(err).To(HaveOccurred())
+			_, err = run([]byte("u8"), "xml", "p", "T")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,144 @@
+package pack
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// A PackEncoder is a Go type that can encode itself into a value. Encode uses
+// the PackEncode method, instead of reflection, for Go types that implement
+// this interface (or whose pointers implement this interface). The method must
+// return a value of the same type every time it is called, including when it
+// is called on the zero value of the Go type (this is used to infer the type of
+// empty lists and nil pointers).
+type PackEncoder interface {
+	PackEncode() (Value, error)
+}
+
+// A PackDecoder is a Go type that can decode itself from a value. Decode uses
+// the PackDecode method, instead of reflection, for pointers to Go types that
+// implement this interface.
+type PackDecoder interface {
+	PackDecode(Value) error
+}
+
+// codecRegistration stores the functions that are used to encode/decode a Go
+// type that has been registered with RegisterCodec.
+type codecRegistration struct {
+	encode func(interface{}) (Value, error)
+	decode func(interface{}, Value) error
+}
+
+var (
+	codecsMu = new(sync.RWMutex)
+	codecs   = map[reflect.Type]*codecRegistration{}
+)
+
+// RegisterCodec registers functions that are used to encode/decode a Go type,
+// instead of reflection. It is useful for Go types that are defined by other
+// packages, and so cannot implement PackEncoder and PackDecoder. The first
+// argument must be an instance of the Go type. The encode function is called
+// with an instance of the Go type, and the decode function is called with a
+// pointer to an instance of the Go type. Registered functions are used before
+// the PackEncode and PackDecode methods. The function will panic if x is nil,
+// or if either function is nil.
+//
+// It should be called during initialisation, before values are encoded or
+// decoded. It is safe to call concurrently with Encode and Decode, but the
+// types of zero values are cached as they are encoded, and a zero type that is
+// being computed while the Go type is registered can be cached without using
+// the registered functions.
+//
+//  RegisterCodec(uuid.UUID{},
+//      func(x interface{}) (Value, error) {
+//          id := x.(uuid.UUID)
+//          return NewBytes(id[:]), nil
+//      },
+//      func(x interface{}, v Value) error {
+//          id, err := uuid.FromBytes(v.(Bytes))
+//          *x.(*uuid.UUID) = id
+//          return err
+//      },
+//  )
+//
+func RegisterCodec(x interface{}, encode func(interface{}) (Value, error), decode func(interface{}, Value) error) {
+	typeOf := reflect.TypeOf(x)
+	if typeOf == nil {
+		panic(fmt.Errorf("expected instance of type, got %v", x))
+	}
+	if encode == nil || decode == nil {
+		panic(fmt.Errorf("expected encode and decode functions for %v", typeOf))
+	}
+
+	codecsMu.Lock()
+	codecs[typeOf] = &codecRegistration{
+		encode: encode,
+		decode: decode,
+	}
+	codecsMu.Unlock()
+
+	// The zero types of Go types that hold the registered Go type have
+	// changed.
+	resetZeroTypes()
+}
+
+// lookupCodec returns the registration of a Go type that has been registered
+// with RegisterCodec. If the Go type has not been registered, then false is
+// returned.
+func lookupCodec(typeOf reflect.Type) (*codecRegistration, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[typeOf]
+	return codec, ok
+}
+
+// packEncoderOf returns the PackEncoder implemented by a reflected Go value. If
+// only a pointer to the Go value implements PackEncoder, then a pointer to a
+// copy of the Go value is returned. Pointers are ignored, because they are
+// encoded as optionals of the values to which they point.
+func packEncoderOf(valueOf reflect.Value) (PackEncoder, bool) {
+	if !valueOf.IsValid() || valueOf.Kind() == reflect.Ptr {
+		return nil, false
+	}
+	if encoder, ok := valueOf.Interface().(PackEncoder); ok {
+		return encoder, true
+	}
+	if !reflect.PtrTo(valueOf.Type()).Implements(packEncoderType) {
+		return nil, false
+	}
+	ptr := reflect.New(valueOf.Type())
+	ptr.Elem().Set(valueOf)
+	return ptr.Interface().(PackEncoder), true
+}
+
+// encodeCustom encodes a Go interface using a registered codec, or its
+// PackEncode method. If neither are available, then false is returned.
+func encodeCustom(v interface{}) (Value, bool, error) {
+	if codec, ok := lookupCodec(reflect.TypeOf(v)); ok {
+		value, err := codec.encode(v)
+		return value, true, err
+	}
+	if encoder, ok := packEncoderOf(reflect.ValueOf(v)); ok {
+		value, err := encoder.PackEncode()
+		return value, true, err
+	}
+	return nil, false, nil
+}
+
+// decodeCustom decodes a value into a pointer to a Go interface using a
+// registered codec, or its PackDecode method. If neither are available, then
+// false is returned.
+func decodeCustom(interf interface{}, v Value) (bool, error) {
+	if typeOf := reflect.TypeOf(interf); typeOf != nil && typeOf.Kind() == reflect.Ptr {
+		if codec, ok := lookupCodec(typeOf.Elem()); ok {
+			return true, codec.decode(interf, v)
+		}
+	}
+	if decoder, ok := interf.(PackDecoder); ok {
+		return true, decoder.PackDecode(v)
+	}
+	return false, nil
+}
+
+var packEncoderType = reflect.TypeOf((*PackEncoder)(nil)).Elem()
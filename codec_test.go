@@ -0,0 +1,229 @@
+package pack_test
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/renproject/pack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// address implements PackEncoder and PackDecoder, using value receivers where
+// possible. It has an unexported field, so it cannot be encoded by reflection.
+type address struct {
+	raw [32]byte
+}
+
+func (addr address) PackEncode() (pack.Value, error) {
+	return pack.NewBytes32(addr.raw), nil
+}
+
+func (addr *address) PackDecode(v pack.Value) error {
+	raw, ok := v.(pack.Bytes32)
+	if !ok {
+		return fmt.Errorf("unexpected value of type %T", v)
+	}
+	addr.raw = raw
+	return nil
+}
+
+// amount implements PackEncoder and PackDecoder, using pointer receivers.
+type amount struct {
+	inner *big.Int
+}
+
+func (amt *amount) PackEncode() (pack.Value, error) {
+	if amt.inner == nil {
+		return pack.NewU256FromUint64(0), nil
+	}
+	if amt.inner.Sign() < 0 {
+		return nil, fmt.Errorf("negative amount")
+	}
+	return pack.NewU256FromInt(amt.inner), nil
+}
+
+func (amt *amount) PackDecode(v pack.Value) error {
+	u256, ok := v.(pack.U256)
+	if !ok {
+		return fmt.Errorf("unexpected value of type %T", v)
+	}
+	amt.inner = u256.Int()
+	return nil
+}
+
+// celsius does not implement PackEncoder or PackDecoder, and it cannot be
+// encoded by reflection, so it is registered with RegisterCodec.
+type celsius float64
+
+// override implements PackEncoder and PackDecoder, but it is also registered
+// with RegisterCodec.
+type override uint64
+
+func (override) PackEncode() (pack.Value, error) {
+	return nil, fmt.Errorf("not registered")
+}
+
+func (*override) PackDecode(pack.Value) error {
+	return fmt.Errorf("not registered")
+}
+
+func init() {
+	pack.RegisterCodec(celsius(0),
+		func(x interface{}) (pack.Value, error) {
+			return pack.NewI64(int64(math.Round(float64(x.(celsius)) * 1000))), nil
+		},
+		func(x interface{}, v pack.Value) error {
+			i64, ok := v.(pack.I64)
+			if !ok {
+				return fmt.Errorf("unexpected value of type %T", v)
+			}
+			*x.(*celsius) = celsius(float64(i64.Int64()) / 1000)
+			return nil
+		},
+	)
+	pack.RegisterCodec(override(0),
+		func(x interface{}) (pack.Value, error) {
+			return pack.NewU64(uint64(x.(override))), nil
+		},
+		func(x interface{}, v pack.Value) error {
+			*x.(*override) = override(v.(pack.U64).Uint64())
+			return nil
+		},
+	)
+}
+
+var _ = Describe("Codecs", func() {
+
+	type Transfer struct {
+		From    address            `json:"from"`
+		To      *address           `json:"to"`
+		Amount  amount             `json:"amount"`
+		Fees    []amount           `json:"fees"`
+		Temps   map[string]celsius `json:"temps"`
+		Temp    celsius            `json:"temp"`
+		Refunds []*amount          `json:"refunds"`
+	}
+
+	Context("when encoding a type that implements PackEncoder", func() {
+		It("should use the PackEncode method", func() {
+			addr := address{raw: [32]byte{1}}
+			v, err := pack.Encode(addr)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewBytes32([32]byte{1})))
+
+			v, err = pack.Encode(amount{inner: big.NewInt(42)})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewU256FromUint64(42)))
+		})
+
+		It("should encode pointers as optionals", func() {
+			v, err := pack.Encode(&address{raw: [32]byte{1}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.Some(pack.NewBytes32([32]byte{1}))))
+
+			v, err = pack.Encode((*amount)(nil))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.None(pack.TypeU256())))
+		})
+
+		It("should return the error from the PackEncode method", func() {
+			_, err := pack.Encode(amount{inner: big.NewInt(-1)})
+			Expect(err).To(HaveOccurred())
+
+			_, err = pack.Encode(Transfer{Fees: []amount{{inner: big.NewInt(-1)}}})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when decoding a type that implements PackDecoder", func() {
+		It("should use the PackDecode method", func() {
+			var addr address
+			Expect(pack.Decode(&addr, pack.NewBytes32([32]byte{1}))).To(Succeed())
+			Expect(addr).To(Equal(address{raw: [32]byte{1}}))
+
+			var amt amount
+			Expect(pack.Decode(&amt, pack.NewU256FromUint64(42))).To(Succeed())
+			Expect(amt.inner.Uint64()).To(Equal(uint64(42)))
+		})
+
+		It("should return the error from the PackDecode method", func() {
+			var addr address
+			Expect(pack.Decode(&addr, pack.NewU64(1))).ToNot(Succeed())
+		})
+	})
+
+	Context("when encoding a type that has a registered codec", func() {
+		It("should use the registered functions", func() {
+			v, err := pack.Encode(celsius(21.5))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewI64(21500)))
+
+			var temp celsius
+			Expect(pack.Decode(&temp, v)).To(Succeed())
+			Expect(temp).To(Equal(celsius(21.5)))
+		})
+
+		It("should use the registered functions before the methods", func() {
+			v, err := pack.Encode(override(42))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewU64(42)))
+
+			var x override
+			Expect(pack.Decode(&x, v)).To(Succeed())
+			Expect(x).To(Equal(override(42)))
+		})
+
+		It("should panic when registering invalid codecs", func() {
+			Expect(func() { pack.RegisterCodec(nil, nil, nil) }).To(Panic())
+			Expect(func() { pack.RegisterCodec(celsius(0), nil, nil) }).To(Panic())
+		})
+	})
+
+	Context("when encoding and then decoding structs with custom types", func() {
+		It("should equal itself", func() {
+			to := address{raw: [32]byte{2}}
+			x := Transfer{
+				From:    address{raw: [32]byte{1}},
+				To:      &to,
+				Amount:  amount{inner: big.NewInt(100)},
+				Fees:    []amount{{inner: big.NewInt(1)}, {inner: big.NewInt(2)}},
+				Temps:   map[string]celsius{"min": -1.5, "max": 30.25},
+				Temp:    12.125,
+				Refunds: []*amount{{inner: big.NewInt(3)}, nil},
+			}
+			v, err := pack.Encode(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.Type()).To(Equal(pack.StructType(
+				"from", pack.TypeBytes32(),
+				"to", pack.OptionalType(pack.TypeBytes32()),
+				"amount", pack.TypeU256(),
+				"fees", pack.ListType(pack.TypeU256()),
+				"temps", pack.MapType(pack.TypeString(), pack.TypeI64()),
+				"temp", pack.TypeI64(),
+				"refunds", pack.ListType(pack.OptionalType(pack.TypeU256())),
+			)))
+
+			var y Transfer
+			Expect(pack.Decode(&y, v)).To(Succeed())
+			Expect(y.From).To(Equal(x.From))
+			Expect(*y.To).To(Equal(*x.To))
+			Expect(y.Amount.inner.Cmp(x.Amount.inner)).To(Equal(0))
+			Expect(y.Fees).To(HaveLen(2))
+			Expect(y.Fees[1].inner.Cmp(x.Fees[1].inner)).To(Equal(0))
+			Expect(y.Temps).To(Equal(x.Temps))
+			Expect(y.Temp).To(Equal(x.Temp))
+			Expect(y.Refunds[0].inner.Cmp(x.Refunds[0].inner)).To(Equal(0))
+			Expect(y.Refunds[1]).To(BeNil())
+		})
+
+		It("should infer the type of empty values", func() {
+			v, err := pack.Encode(Transfer{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.(pack.Struct).Get("to")).To(Equal(pack.None(pack.TypeBytes32())))
+			Expect(v.(pack.Struct).Get("fees")).To(Equal(pack.EmptyList(pack.TypeU256())))
+		})
+	})
+})
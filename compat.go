@@ -0,0 +1,296 @@
+package pack
+
+import (
+	"fmt"
+)
+
+// A ChangeKind identifies the way in which a type has changed between the type
+// that was used to write a value (the writer type) and the type that is used to
+// read it (the reader type).
+type ChangeKind uint8
+
+const (
+	// ChangeTypeChanged is the kind of change where the reader type cannot
+	// represent values of the writer type (e.g. a string was changed to a
+	// u64). It is always breaking.
+	ChangeTypeChanged = ChangeKind(1)
+	// ChangeIntWidened is the kind of change where an integer was changed to
+	// an integer that can represent all of its values (e.g. a u32 was changed
+	// to a u64, or a u8 was changed to an i16). It is never breaking.
+	ChangeIntWidened = ChangeKind(2)
+	// ChangeMadeOptional is the kind of change where a type was changed to an
+	// optional of that type. It is never breaking.
+	ChangeMadeOptional = ChangeKind(3)
+	// ChangeFieldAdded is the kind of change where a field was added to a
+	// struct. It is breaking, unless the type of the field is optional.
+	ChangeFieldAdded = ChangeKind(4)
+	// ChangeFieldRemoved is the kind of change where a field was removed from
+	// a struct. It is never breaking.
+	ChangeFieldRemoved = ChangeKind(5)
+	// ChangeFieldsReordered is the kind of change where the fields of a
+	// struct, that are present in both types, were reordered. It is never
+	// breaking.
+	ChangeFieldsReordered = ChangeKind(6)
+	// ChangeVariantAdded is the kind of change where a variant was added to a
+	// union. It is never breaking.
+	ChangeVariantAdded = ChangeKind(7)
+	// ChangeVariantRemoved is the kind of change where a variant was removed
+	// from a union. It is always breaking.
+	ChangeVariantRemoved = ChangeKind(8)
+)
+
+// String returns a human-readable representation of the change kind.
+func (kind ChangeKind) String() string {
+	switch kind {
+	case ChangeTypeChanged:
+		return "type changed"
+	case ChangeIntWidened:
+		return "integer widened"
+	case ChangeMadeOptional:
+		return "made optional"
+	case ChangeFieldAdded:
+		return "field added"
+	case ChangeFieldRemoved:
+		return "field removed"
+	case ChangeFieldsReordered:
+		return "fields reordered"
+	case ChangeVariantAdded:
+		return "variant added"
+	case ChangeVariantRemoved:
+		return "variant removed"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(kind))
+	}
+}
+
+// A Change describes one difference between a writer type and a reader type.
+type Change struct {
+	// Path to the changed type, relative to the root type, in the same style
+	// as the paths of types in a DecodeError (e.g. ".fills[].price"). The root
+	// type is ".". Struct fields, and union variants, are identified by name,
+	// tuple elements by "[i]", the elements of lists by "[]", and the keys and
+	// values of maps by "[].key" and "[].value". Optionals do not add to the
+	// path, so the type held by an optional has the same path as the
+	// optional.
+	Path string
+	// Kind of change.
+	Kind ChangeKind
+	// Writer is the type at the path in the writer type, or nil if there is no
+	// such type (e.g. when a field was added).
+	Writer Type
+	// Reader is the type at the path in the reader type, or nil if there is no
+	// such type (e.g. when a field was removed).
+	Reader Type
+	// Breaking is true when values of the writer type cannot be read as values
+	// of the reader type because of this change.
+	Breaking bool
+}
+
+// String returns a human-readable representation of the change.
+func (change Change) String() string {
+	breaking := ""
+	if change.Breaking {
+		breaking = " (breaking)"
+	}
+	return fmt.Sprintf("%v: %v%v", change.Path, change.Kind, breaking)
+}
+
+// Compare the type that was used to write values (the writer type) with the
+// type that will be used to read them (the reader type), and return all of the
+// changes between them. Values of the writer type can be safely read as values
+// of the reader type (see Project) if, and only if, none of the changes are
+// breaking. Adding a field that is not optional is considered breaking, even
+// though Project will fill it with its default value, because default values
+// cannot be distinguished from values that were actually written.
+//
+// Struct fields, and union variants, are matched by name. This means that
+// struct fields can be reordered, removed, or added (if they are optional),
+// and union variants can be reordered or added, without breaking
+// compatibility. Integers can also be widened (unsigned integers can be read
+// as larger unsigned integers, or larger signed integers, and signed integers
+// can be read as larger signed integers), and types can be made optional.
+func Compare(writer, reader Type) []Change {
+	changes := []Change{}
+	compare(&changes, ".", writer, reader)
+	return changes
+}
+
+// Compatible returns true when values of the writer type can be read as values
+// of the reader type. Otherwise, it returns false. See Compare for more
+// information.
+func Compatible(writer, reader Type) bool {
+	for _, change := range Compare(writer, reader) {
+		if change.Breaking {
+			return false
+		}
+	}
+	return true
+}
+
+// BreakingChanges returns the changes between the writer type and the reader
+// type that prevent values of the writer type from being read as values of the
+// reader type. See Compare for more information.
+func BreakingChanges(writer, reader Type) []Change {
+	breaking := []Change{}
+	for _, change := range Compare(writer, reader) {
+		if change.Breaking {
+			breaking = append(breaking, change)
+		}
+	}
+	return breaking
+}
+
+func compare(changes *[]Change, path string, writer, reader Type) {
+	if writer.Equals(reader) {
+		return
+	}
+	changed := func(kind ChangeKind, breaking bool) {
+		*changes = append(*changes, Change{Path: path, Kind: kind, Writer: writer, Reader: reader, Breaking: breaking})
+	}
+
+	// Integers can be widened, and types can be made optional, so these cases
+	// are checked before the kinds of the types are compared.
+	if writerBits, writerSigned, ok := intBits(writer.Kind()); ok {
+		if readerBits, readerSigned, ok := intBits(reader.Kind()); ok {
+			if canWidenInt(writerBits, writerSigned, readerBits, readerSigned) {
+				changed(ChangeIntWidened, false)
+			} else {
+				changed(ChangeTypeChanged, true)
+			}
+			return
+		}
+	}
+	if reader, ok := reader.(typeOptional); ok && writer.Kind() != KindOptional {
+		changed(ChangeMadeOptional, false)
+		compare(changes, path, writer, reader.Type)
+		return
+	}
+	if writer.Kind() != reader.Kind() {
+		changed(ChangeTypeChanged, true)
+		return
+	}
+
+	switch writer := writer.(type) {
+	case typeStruct:
+		compareStruct(changes, path, writer, reader.(typeStruct))
+	case typeUnion:
+		compareUnion(changes, path, writer, reader.(typeUnion))
+	case typeList:
+		compare(changes, appendPath(path, ".[]"), writer.Type, reader.(typeList).Type)
+	case typeOptional:
+		compare(changes, path, writer.Type, reader.(typeOptional).Type)
+	case typeMap:
+		compare(changes, appendPath(path, ".[].key"), writer.Key, reader.(typeMap).Key)
+		compare(changes, appendPath(path, ".[].value"), writer.Value, reader.(typeMap).Value)
+	case typeTuple:
+		if len(writer) != len(reader.(typeTuple)) {
+			changed(ChangeTypeChanged, true)
+			return
+		}
+		for i := range writer {
+			compare(changes, appendPath(path, indexPath(i)), writer[i], reader.(typeTuple)[i])
+		}
+	default:
+		// The only non-abstract types that can be unequal, but still have the
+		// same kind, are parameterised byte arrays of different lengths.
+		changed(ChangeTypeChanged, true)
+	}
+}
+
+func compareStruct(changes *[]Change, path string, writer, reader typeStruct) {
+	// Fields in the reader type must either exist in the writer type, or be
+	// optional.
+	common := []string{}
+	for _, readerField := range reader {
+		readerPath := appendPath(path, fieldPath(readerField.Name))
+		writerIndex := writer.indexOf(readerField.Name)
+		if writerIndex < 0 {
+			*changes = append(*changes, Change{
+				Path:     readerPath,
+				Kind:     ChangeFieldAdded,
+				Reader:   readerField.Type,
+				Breaking: readerField.Type.Kind() != KindOptional,
+			})
+			continue
+		}
+		common = append(common, readerField.Name)
+		compare(changes, readerPath, writer[writerIndex].Type, readerField.Type)
+	}
+
+	// Fields in the writer type that do not exist in the reader type are
+	// dropped.
+	i := 0
+	reordered := false
+	for _, writerField := range writer {
+		if reader.indexOf(writerField.Name) < 0 {
+			*changes = append(*changes, Change{
+				Path:   appendPath(path, fieldPath(writerField.Name)),
+				Kind:   ChangeFieldRemoved,
+				Writer: writerField.Type,
+			})
+			continue
+		}
+		if common[i] != writerField.Name {
+			reordered = true
+		}
+		i++
+	}
+	if reordered {
+		*changes = append(*changes, Change{Path: path, Kind: ChangeFieldsReordered, Writer: writer, Reader: reader})
+	}
+}
+
+func compareUnion(changes *[]Change, path string, writer, reader typeUnion) {
+	// Variants in the writer type must exist in the reader type.
+	for _, writerVariant := range writer {
+		variantPath := appendPath(path, fieldPath(writerVariant.Name))
+		readerIndex := reader.indexOf(writerVariant.Name)
+		if readerIndex < 0 {
+			*changes = append(*changes, Change{
+				Path:     variantPath,
+				Kind:     ChangeVariantRemoved,
+				Writer:   writerVariant.Type,
+				Breaking: true,
+			})
+			continue
+		}
+		compare(changes, variantPath, writerVariant.Type, reader[readerIndex].Type)
+	}
+	for _, readerVariant := range reader {
+		if writer.indexOf(readerVariant.Name) < 0 {
+			*changes = append(*changes, Change{
+				Path:   appendPath(path, fieldPath(readerVariant.Name)),
+				Kind:   ChangeVariantAdded,
+				Reader: readerVariant.Type,
+			})
+		}
+	}
+}
+
+// intBits returns the number of bits in integers of the given kind, and
+// whether or not they are signed. If the kind is not an integer kind, it
+// returns false.
+func intBits(kind Kind) (int, bool, bool) {
+	switch {
+	case KindU8 <= kind && kind <= KindU256:
+		return 8 << (kind - KindU8), false, true
+	case KindI8 <= kind && kind <= KindI256:
+		return 8 << (kind - KindI8), true, true
+	default:
+		return 0, false, false
+	}
+}
+
+// canWidenInt returns true when all integers of the first width, and
+// signedness, can be represented by integers of the second width, and
+// signedness.
+func canWidenInt(fromBits int, fromSigned bool, toBits int, toSigned bool) bool {
+	switch {
+	case fromSigned == toSigned:
+		return toBits >= fromBits
+	case toSigned:
+		return toBits > fromBits
+	default:
+		return false
+	}
+}
@@ -0,0 +1,106 @@
+package pack_test
+
+import (
+	"math/rand"
+
+	"github.com/renproject/pack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compatibility", func() {
+
+	numTrials := 100
+
+	mustParseType := func(schema string) pack.Type {
+		t, err := pack.ParseType(schema)
+		Expect(err).ToNot(HaveOccurred())
+		return t
+	}
+
+	Context("when comparing equal types", func() {
+		It("should return no changes", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < numTrials; trial++ {
+				t := pack.GenerateType(r, 10, pack.GenerateMaxDepth)
+				Expect(pack.Compare(t, t)).To(BeEmpty())
+				Expect(pack.Compatible(t, t)).To(BeTrue())
+			}
+		})
+	})
+
+	Context("when evolving a struct", func() {
+		It("should report compatible changes", func() {
+			writer := mustParseType("struct { amount: u32, to: bytes32, memo: string }")
+			reader := mustParseType("struct { to: bytes32, amount: u64, note: optional<string> }")
+			changes := pack.Compare(writer, reader)
+			Expect(changes).To(ConsistOf(
+				pack.Change{Path: ".amount", Kind: pack.ChangeIntWidened, Writer: pack.TypeU32(), Reader: pack.TypeU64()},
+				pack.Change{Path: ".note", Kind: pack.ChangeFieldAdded, Reader: pack.OptionalType(pack.TypeString())},
+				pack.Change{Path: ".memo", Kind: pack.ChangeFieldRemoved, Writer: pack.TypeString()},
+				pack.Change{Path: ".", Kind: pack.ChangeFieldsReordered, Writer: writer, Reader: reader},
+			))
+			Expect(pack.Compatible(writer, reader)).To(BeTrue())
+			Expect(pack.BreakingChanges(writer, reader)).To(BeEmpty())
+		})
+
+		It("should report breaking changes", func() {
+			writer := mustParseType("struct { amount: u64, inner: struct { to: bytes32 } }")
+			reader := mustParseType("struct { amount: u32, inner: struct { to: bytes, from: bytes32 } }")
+			Expect(pack.Compatible(writer, reader)).To(BeFalse())
+			Expect(pack.BreakingChanges(writer, reader)).To(ConsistOf(
+				pack.Change{Path: ".amount", Kind: pack.ChangeTypeChanged, Writer: pack.TypeU64(), Reader: pack.TypeU32(), Breaking: true},
+				pack.Change{Path: ".inner.to", Kind: pack.ChangeTypeChanged, Writer: pack.TypeBytes32(), Reader: pack.TypeBytes(), Breaking: true},
+				pack.Change{Path: ".inner.from", Kind: pack.ChangeFieldAdded, Reader: pack.TypeBytes32(), Breaking: true},
+			))
+		})
+	})
+
+	Context("when widening integers", func() {
+		It("should only allow widening that preserves all values", func() {
+			Expect(pack.Compatible(pack.TypeU8(), pack.TypeU256())).To(BeTrue())
+			Expect(pack.Compatible(pack.TypeU8(), pack.TypeI16())).To(BeTrue())
+			Expect(pack.Compatible(pack.TypeI64(), pack.TypeI128())).To(BeTrue())
+			Expect(pack.Compatible(pack.TypeU64(), pack.TypeU32())).To(BeFalse())
+			Expect(pack.Compatible(pack.TypeU64(), pack.TypeI64())).To(BeFalse())
+			Expect(pack.Compatible(pack.TypeI8(), pack.TypeU64())).To(BeFalse())
+		})
+	})
+
+	Context("when evolving other types", func() {
+		It("should compare their elements", func() {
+			Expect(pack.Compatible(pack.TypeU64(), pack.OptionalType(pack.TypeU64()))).To(BeTrue())
+			Expect(pack.Compatible(pack.OptionalType(pack.TypeU64()), pack.TypeU64())).To(BeFalse())
+			Expect(pack.Compatible(mustParseType("list<u8>"), mustParseType("list<u16>"))).To(BeTrue())
+			Expect(pack.Compatible(mustParseType("map<u8, string>"), mustParseType("map<u16, optional<string>>"))).To(BeTrue())
+			Expect(pack.Compatible(mustParseType("tuple<u8, i8>"), mustParseType("tuple<u16, i16>"))).To(BeTrue())
+			Expect(pack.Compatible(mustParseType("tuple<u8, i8>"), mustParseType("tuple<u8>"))).To(BeFalse())
+			Expect(pack.Compatible(mustParseType("bytesn<20>"), mustParseType("bytesn<32>"))).To(BeFalse())
+			Expect(pack.Compatible(mustParseType("string"), mustParseType("bytes"))).To(BeFalse())
+
+			changes := pack.Compare(mustParseType("list<struct { x: u8 }>"), mustParseType("list<struct { x: u16 }>"))
+			Expect(changes).To(HaveLen(1))
+			Expect(changes[0].Path).To(Equal(".[].x"))
+			Expect(changes[0].String()).To(Equal(".[].x: integer widened"))
+
+			changes = pack.Compare(mustParseType("struct { \"a b\": tuple<u8, map<u8, optional<u8>>> }"), mustParseType("struct { \"a b\": tuple<u8, map<u16, optional<u16>>> }"))
+			Expect(changes).To(ConsistOf(
+				pack.Change{Path: `.["a b"][1][].key`, Kind: pack.ChangeIntWidened, Writer: pack.TypeU8(), Reader: pack.TypeU16()},
+				pack.Change{Path: `.["a b"][1][].value`, Kind: pack.ChangeIntWidened, Writer: pack.TypeU8(), Reader: pack.TypeU16()},
+			))
+		})
+
+		It("should allow variants to be added, but not removed", func() {
+			writer := mustParseType("union { foo: u64, bar: string }")
+			reader := mustParseType("union { bar: string, foo: u64, baz: bool }")
+			Expect(pack.Compare(writer, reader)).To(ConsistOf(
+				pack.Change{Path: ".baz", Kind: pack.ChangeVariantAdded, Reader: pack.TypeBool()},
+			))
+			Expect(pack.Compatible(writer, reader)).To(BeTrue())
+			Expect(pack.BreakingChanges(reader, writer)).To(ConsistOf(
+				pack.Change{Path: ".baz", Kind: pack.ChangeVariantRemoved, Writer: pack.TypeBool(), Breaking: true},
+			))
+		})
+	})
+})
@@ -0,0 +1,258 @@
+package pack
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/renproject/surge"
+)
+
+// Dump returns an annotated hex dump of the binary representation of a value
+// of the type t. Each region of the buffer is printed on its own line, with
+// its offset, its length, its bytes, the path of the value that it represents
+// (in the style of jq, e.g. ".fills[0].price"), the kind of the value, and the
+// decoded value:
+//
+//  00000000  8  00 00 00 00 00 00 00 2a  .amount  u64     42
+//  00000008  4  00 00 00 05              .memo    string  length 5
+//  0000000c  5  68 65 6c 6c 6f           .memo    string  "hello"
+//
+// Lengths, optional presence, and union variants are printed as their own
+// regions. Errors are printed in place of the value of the region in which
+// they occur. Dumping continues past errors that do not affect the size of a
+// region (e.g. maps that are not in canonical order), and otherwise stops, and
+// prints the remaining bytes as a single region.
+func Dump(t Type, buf []byte) string {
+	d := dumper{buf: buf, state: newDecodeState(DefaultDecodeOptions)}
+	if d.dump(t, ".") && d.offset < len(buf) {
+		d.fail(".", t.Kind(), fmt.Errorf("unexpected %v bytes", len(buf)-d.offset))
+	}
+	return d.String()
+}
+
+// A dumpRow is a region of the buffer, and its annotation.
+type dumpRow struct {
+	offset int
+	length int
+	path   string
+	kind   string
+	value  string
+}
+
+// A dumper walks a buffer according to a type, and records the regions of the
+// buffer that represent each value.
+type dumper struct {
+	buf    []byte
+	offset int
+	rows   []dumpRow
+	state  *decodeState
+}
+
+// dump the value of type t at the current offset. It returns false if the
+// value could not be dumped, and dumping must stop.
+func (d *dumper) dump(t Type, path string) bool {
+	switch t := t.(type) {
+	case typeStruct:
+		for _, field := range t {
+			if !d.dump(field.Type, appendPath(path, fieldPath(field.Name))) {
+				return false
+			}
+		}
+		return true
+	case typeTuple:
+		for i, elemType := range t {
+			if !d.dump(elemType, appendPath(path, indexPath(i))) {
+				return false
+			}
+		}
+		return true
+	case typeList:
+		numElems, ok := d.dumpLen(path, KindList, t.Type)
+		if !ok {
+			return false
+		}
+		if isZeroSizeType(t.Type) {
+			return true
+		}
+		for i := uint32(0); i < numElems; i++ {
+			if !d.dump(t.Type, appendPath(path, indexPath(int(i)))) {
+				return false
+			}
+		}
+		return true
+	case typeMap:
+		numEntries, ok := d.dumpLen(path, KindMap, t.Key)
+		if !ok {
+			return false
+		}
+		if isZeroSizeType(typeTuple{t.Key, t.Value}) {
+			return true
+		}
+		var prevKey []byte
+		for i := uint32(0); i < numEntries; i++ {
+			keyPath := appendPath(path, indexPath(int(i))+".key")
+			keyOffset := d.offset
+			if !d.dump(t.Key, keyPath) {
+				return false
+			}
+			key := d.buf[keyOffset:d.offset]
+			if i > 0 && bytes.Compare(prevKey, key) >= 0 {
+				d.rows = append(d.rows, dumpRow{
+					offset: keyOffset,
+					length: len(key),
+					path:   keyPath,
+					kind:   KindMap.String(),
+					value:  fmt.Sprintf("error: %v", errNonCanonicalOrder),
+				})
+			}
+			prevKey = key
+			if !d.dump(t.Value, appendPath(path, indexPath(int(i))+".value")) {
+				return false
+			}
+		}
+		return true
+	case typeOptional:
+		var some bool
+		if _, _, err := surge.UnmarshalBool(&some, d.buf[d.offset:], len(d.buf)-d.offset); err != nil {
+			return d.fail(path, KindOptional, err)
+		}
+		if !some {
+			d.row(1, path, KindOptional, "none")
+			return true
+		}
+		d.row(1, path, KindOptional, "some")
+		return d.dump(t.Type, path)
+	case typeUnion:
+		var index uint8
+		if _, _, err := surge.UnmarshalU8(&index, d.buf[d.offset:], len(d.buf)-d.offset); err != nil {
+			return d.fail(path, KindUnion, err)
+		}
+		if int(index) >= len(t) {
+			return d.fail(path, KindUnion, fmt.Errorf("expected variant<%v, got variant=%v", len(t), index))
+		}
+		d.row(1, path, KindUnion, fmt.Sprintf("variant %q", t[index].Name))
+		return d.dump(t[index].Type, appendPath(path, fieldPath(t[index].Name)))
+	}
+
+	switch t.Kind() {
+	case KindString, KindBytes:
+		n, ok := d.dumpLen(path, t.Kind(), typeU8{})
+		if !ok {
+			return false
+		}
+		data := d.buf[d.offset : d.offset+int(n)]
+		if t.Kind() == KindString {
+			d.row(len(data), path, KindString, strconv.Quote(string(data)))
+		} else {
+			d.row(len(data), path, KindBytes, Bytes(data).String())
+		}
+		return true
+	}
+
+	v, rest, _, err := t.UnmarshalValue(d.buf[d.offset:], len(d.buf)-d.offset)
+	if err != nil {
+		return d.fail(path, t.Kind(), err)
+	}
+	d.row(len(d.buf)-d.offset-len(rest), path, t.Kind(), fmt.Sprintf("%v", v))
+	return true
+}
+
+// dumpLen dumps the length of a list, map, string, or byte slice, and returns
+// the length. The element type is the key type for maps. It returns false if
+// the length is greater than the limits, or the number of remaining bytes.
+func (d *dumper) dumpLen(path string, kind Kind, elemType Type) (uint32, bool) {
+	var n uint32
+	buf, _, err := surge.UnmarshalU32(&n, d.buf[d.offset:], len(d.buf)-d.offset)
+	if err != nil {
+		return 0, d.fail(path, kind, err)
+	}
+	if err = d.state.checkListLen(int64(n)); err == nil {
+		if kind == KindMap {
+			err = checkNumEntries(n, elemType, buf, len(buf))
+		} else {
+			err = checkNumElems(n, elemType, buf, len(buf))
+		}
+	}
+	if err != nil {
+		return 0, d.fail(path, kind, fmt.Errorf("length %v: %v", n, err))
+	}
+	d.row(4, path, kind, fmt.Sprintf("length %v", n))
+	return n, true
+}
+
+// row records a region of n bytes at the current offset, and moves past it.
+func (d *dumper) row(n int, path string, kind Kind, value string) {
+	d.rows = append(d.rows, dumpRow{offset: d.offset, length: n, path: path, kind: kind.String(), value: value})
+	d.offset += n
+}
+
+// fail records the remaining bytes as a region with an error. It always
+// returns false, because dumping cannot continue.
+func (d *dumper) fail(path string, kind Kind, err error) bool {
+	// The region already identifies the value, so only the cause of a
+	// DecodeError is printed.
+	if decodeErr, ok := err.(*DecodeError); ok {
+		err = decodeErr.Cause
+	}
+	d.row(len(d.buf)-d.offset, path, kind, fmt.Sprintf("error: %v", err))
+	return false
+}
+
+// dumpBytesPerLine is the number of bytes that are printed on each line. Rows
+// with more bytes are continued on the following lines.
+const dumpBytesPerLine = 8
+
+// String returns the rows, with columns aligned.
+func (d *dumper) String() string {
+	lengthWidth, pathWidth, kindWidth := 0, 0, 0
+	for _, row := range d.rows {
+		if n := len(strconv.Itoa(row.length)); n > lengthWidth {
+			lengthWidth = n
+		}
+		if len(row.path) > pathWidth {
+			pathWidth = len(row.path)
+		}
+		if len(row.kind) > kindWidth {
+			kindWidth = len(row.kind)
+		}
+	}
+	hexWidth := 3*dumpBytesPerLine - 1
+
+	builder := new(strings.Builder)
+	for _, row := range d.rows {
+		data := d.buf[row.offset : row.offset+row.length]
+		first := data
+		if len(first) > dumpBytesPerLine {
+			first = first[:dumpBytesPerLine]
+		}
+		fmt.Fprintf(builder, "%08x  %*d  %-*s  %-*s  %-*s  %v\n",
+			row.offset,
+			lengthWidth, row.length,
+			hexWidth, dumpHex(first),
+			pathWidth, row.path,
+			kindWidth, row.kind,
+			row.value)
+		for i := dumpBytesPerLine; i < len(data); i += dumpBytesPerLine {
+			line := data[i:]
+			if len(line) > dumpBytesPerLine {
+				line = line[:dumpBytesPerLine]
+			}
+			fmt.Fprintf(builder, "%08x  %*s  %v\n", row.offset+i, lengthWidth, "", dumpHex(line))
+		}
+	}
+	return builder.String()
+}
+
+// dumpHex returns the bytes in hex, separated by spaces.
+func dumpHex(data []byte) string {
+	builder := new(strings.Builder)
+	for i, b := range data {
+		if i > 0 {
+			builder.WriteByte(' ')
+		}
+		fmt.Fprintf(builder, "%02x", b)
+	}
+	return builder.String()
+}
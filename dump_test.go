@@ -0,0 +1,137 @@
+package pack_test
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/surge"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dump", func() {
+
+	numTrials := 100
+
+	unionType := pack.UnionType(
+		pack.NewUnionVariant("a", pack.TypeU8()),
+		pack.NewUnionVariant("b", pack.TypeString()),
+	)
+	union, err := pack.NewUnion(unionType, "b", pack.NewString("hi"))
+	if err != nil {
+		panic(err)
+	}
+	fees, err := pack.NewMap(
+		pack.NewMapEntry(pack.NewU8(1), pack.NewU16(2)),
+		pack.NewMapEntry(pack.NewU8(3), pack.NewU16(4)),
+	)
+	if err != nil {
+		panic(err)
+	}
+	v := pack.NewStruct(
+		"memo", pack.None(pack.TypeString()),
+		"pair", pack.NewTuple(pack.NewU8(7), pack.NewBool(true)),
+		"choice", union,
+		"fees", fees,
+		"not an ident", pack.Some(pack.NewBytesN([]byte{0xab, 0xcd})),
+	)
+	data, err := surge.ToBinary(v)
+	if err != nil {
+		panic(err)
+	}
+
+	Context("when dumping a value", func() {
+		It("should print each region", func() {
+			Expect(pack.Dump(v.Type(), data)).To(Equal(strings.Join([]string{
+				`00000000  1  00                       .memo              optional  none`,
+				`00000001  1  07                       .pair[0]           u8        7`,
+				`00000002  1  01                       .pair[1]           bool      true`,
+				`00000003  1  01                       .choice            union     variant "b"`,
+				`00000004  4  00 00 00 02              .choice.b          string    length 2`,
+				`00000008  2  68 69                    .choice.b          string    "hi"`,
+				`0000000a  4  00 00 00 02              .fees              map       length 2`,
+				`0000000e  1  01                       .fees[0].key       u8        1`,
+				`0000000f  2  00 02                    .fees[0].value     u16       2`,
+				`00000011  1  03                       .fees[1].key       u8        3`,
+				`00000012  2  00 04                    .fees[1].value     u16       4`,
+				`00000014  1  01                       .["not an ident"]  optional  some`,
+				`00000015  2  ab cd                    .["not an ident"]  bytesn    q80`,
+				``,
+			}, "\n")))
+		})
+
+		It("should continue lines with more bytes", func() {
+			data, err := surge.ToBinary(pack.NewU128FromUint64(1))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pack.Dump(pack.TypeU128(), data)).To(Equal(strings.Join([]string{
+				`00000000  16  00 00 00 00 00 00 00 00  .  u128  1`,
+				`00000008      00 00 00 00 00 00 00 01`,
+				``,
+			}, "\n")))
+		})
+	})
+
+	Context("when dumping a value that is not in canonical order", func() {
+		It("should print the error, and continue", func() {
+			nonCanonical := append([]byte{}, data...)
+			nonCanonical[0x0e], nonCanonical[0x11] = nonCanonical[0x11], nonCanonical[0x0e]
+			dump := pack.Dump(v.Type(), nonCanonical)
+			Expect(dump).To(ContainSubstring("00000011  1  01                       .fees[1].key       map       error: non-canonical order\n"))
+			Expect(dump).To(HaveSuffix(`00000015  2  ab cd                    .["not an ident"]  bytesn    q80` + "\n"))
+		})
+	})
+
+	Context("when dumping a value that is truncated", func() {
+		It("should print the remaining bytes with the error", func() {
+			Expect(pack.Dump(v.Type(), data[:0x0b])).To(HaveSuffix(
+				"0000000a  1  00                       .fees      map       error: unexpected end of buffer\n",
+			))
+		})
+	})
+
+	Context("when dumping a value that has extra bytes", func() {
+		It("should print the extra bytes with the error", func() {
+			Expect(pack.Dump(v.Type(), append(data, 0xff))).To(HaveSuffix(
+				"00000017  1  ff                       .                  struct    error: unexpected 1 bytes\n",
+			))
+		})
+	})
+
+	Context("when dumping a union with an invalid variant", func() {
+		It("should print the error", func() {
+			Expect(pack.Dump(unionType, []byte{0x02, 0x00})).To(Equal(
+				"00000000  2  02 00                    .  union  error: expected variant<2, got variant=2\n",
+			))
+		})
+	})
+
+	Context("when dumping a list that is too long", func() {
+		It("should print the error", func() {
+			Expect(pack.Dump(pack.ListType(pack.TypeU64()), []byte{0x00, 0x00, 0x01, 0x00, 0x00})).To(Equal(
+				"00000000  5  00 00 01 00 00           .  list  error: length 256: unexpected end of buffer\n",
+			))
+		})
+	})
+
+	Context("when dumping random values", func() {
+		It("should print every byte without errors", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < numTrials; trial++ {
+				t := pack.GenerateType(r, 10, 4)
+				data, err := surge.ToBinary(pack.GenerateFromType(r, 10, t))
+				Expect(err).ToNot(HaveOccurred())
+				dump := pack.Dump(t, data)
+				Expect(dump).ToNot(ContainSubstring("error:"))
+
+				// Truncated values are printed with an error, unless they
+				// have no bytes.
+				if len(data) > 0 {
+					dump = pack.Dump(t, data[:r.Intn(len(data))])
+					Expect(dump).To(ContainSubstring("error:"))
+				}
+			}
+		})
+	})
+})
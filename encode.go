@@ -3,18 +3,33 @@ package pack
 import (
 	"fmt"
 	"reflect"
-	"strings"
 )
 
 // Encode a Go interface into a Value interface.
 func Encode(v interface{}) (val Value, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			err = fmt.Errorf("recovered: %v", err)
+			err = fmt.Errorf("recovered: %v", r)
 			return
 		}
 	}()
 
+	// Go types that have a registered codec, or that implement PackEncoder,
+	// encode themselves.
+	if val, ok, err := encodeCustom(v); ok {
+		if err != nil {
+			return nil, fmt.Errorf("encoding %T: %v", v, err)
+		}
+		return val, nil
+	}
+
+	// Pointers to values are encoded as optionals, so they must be handled
+	// before checking whether or not the interface is already a value (a
+	// pointer to a value is also a value).
+	if valueOf := reflect.ValueOf(v); valueOf.Kind() == reflect.Ptr && valueOf.Type().Elem().Implements(valueType) {
+		return encodeOptional(valueOf)
+	}
+
 	// If the interface is already a value, then immediately return the
 	// interface without modification.
 	switch v := v.(type) {
@@ -32,6 +47,18 @@ func Encode(v interface{}) (val Value, err error) {
 		return v, nil
 	case U256:
 		return v, nil
+	case I8:
+		return v, nil
+	case I16:
+		return v, nil
+	case I32:
+		return v, nil
+	case I64:
+		return v, nil
+	case I128:
+		return v, nil
+	case I256:
+		return v, nil
 	case String:
 		return v, nil
 	case Bytes:
@@ -40,10 +67,20 @@ func Encode(v interface{}) (val Value, err error) {
 		return v, nil
 	case Bytes65:
 		return v, nil
+	case BytesN:
+		return v, nil
 	case Struct:
 		return v, nil
 	case List:
 		return v, nil
+	case Optional:
+		return v, nil
+	case Map:
+		return v, nil
+	case Union:
+		return v, nil
+	case Tuple:
+		return v, nil
 	case Typed:
 		return Struct(v), nil
 	case Value:
@@ -62,26 +99,35 @@ func Encode(v interface{}) (val Value, err error) {
 		return NewU16(uint16(valueOf.Uint())), nil
 	case reflect.Uint32:
 		return NewU32(uint32(valueOf.Uint())), nil
-	case reflect.Uint64:
+	case reflect.Uint64, reflect.Uint, reflect.Uintptr:
 		return NewU64(valueOf.Uint()), nil
+	case reflect.Int8:
+		return NewI8(int8(valueOf.Int())), nil
+	case reflect.Int16:
+		return NewI16(int16(valueOf.Int())), nil
+	case reflect.Int32:
+		return NewI32(int32(valueOf.Int())), nil
+	case reflect.Int64:
+		return NewI64(int64(valueOf.Int())), nil
+	case reflect.Int:
+		return NewI64(valueOf.Int()), nil
 	case reflect.String:
 		return NewString(valueOf.String()), nil
 	case reflect.Slice:
-		if valueOf.Type().Elem().Kind() == reflect.Uint8 {
+		if isByteType(valueOf.Type().Elem()) {
 			return NewBytes(valueOf.Bytes()), nil
 		}
 		if valueOf.Len() == 0 {
-			elem := reflect.Zero(valueOf.Type().Elem()).Interface()
-			val, err := Encode(elem)
+			t, err := encodeZeroType(valueOf.Type().Elem())
 			if err != nil {
 				return nil, fmt.Errorf("encoding list item: %v", err)
 			}
-			return EmptyList(val.Type()), nil
+			return EmptyList(t), nil
 		}
 		var err error
 		elems := make([]Value, valueOf.Len())
 		for i := 0; i < valueOf.Len(); i++ {
-			elems[i], err = Encode(valueOf.Index(i).Interface())
+			elems[i], err = encodeReflect(valueOf.Index(i))
 			if err != nil {
 				return nil, fmt.Errorf("encoding list item: %v", err)
 			}
@@ -89,55 +135,170 @@ func Encode(v interface{}) (val Value, err error) {
 		return NewList(elems...)
 	case reflect.Array:
 		typeOf := valueOf.Type()
-		if typeOf.Elem().Kind() == reflect.Uint8 {
+		if isByteType(typeOf.Elem()) {
 			if typeOf.Len() == 32 {
 				return valueOf.Convert(reflect.TypeOf(Bytes32{})).Interface().(Bytes32), nil
 			}
 			if typeOf.Len() == 65 {
 				return valueOf.Convert(reflect.TypeOf(Bytes65{})).Interface().(Bytes65), nil
 			}
+			bytesN := make(BytesN, typeOf.Len())
+			reflect.Copy(reflect.ValueOf(bytesN), valueOf)
+			return bytesN, nil
 		}
-		return nil, fmt.Errorf("non-exhaustive pattern: type %T", v)
+		return encodeTuple(valueOf)
 	case reflect.Struct:
-		typeOf := valueOf.Type()
-		n := typeOf.NumField()
-		structFields := make([]StructField, 0, n)
-		for i := 0; i < n; i++ {
-			f := typeOf.Field(i)
-			tags := strings.Split(f.Tag.Get("json"), ",")
-			name := f.Name
-			for _, tag := range tags {
-				if tag == "-" {
-					name = ""
-					break
-				}
-				if tag != "omitempty" {
-					name = tag
-					break
-				}
-			}
-			if name != "" {
-				value, err := Encode(valueOf.Field(i).Interface())
-				if err != nil {
-					return nil, fmt.Errorf("encoding \"%v\": %v", f.Name, err)
-				}
-				structFields = append(structFields, NewStructField(name, value))
+		plan, err := structPlanOf(valueOf.Type())
+		if err != nil {
+			return nil, err
+		}
+		structFields := make([]StructField, len(plan.fields))
+		for i, f := range plan.fields {
+			value, err := f.encode(valueOf.FieldByIndex(f.index))
+			if err != nil {
+				return nil, fmt.Errorf("encoding \"%v\": %v", f.goName, err)
 			}
+			structFields[i] = NewStructField(f.name, value)
 		}
 		return Struct(structFields), nil
+	case reflect.Map:
+		return encodeMap(valueOf)
+	case reflect.Ptr:
+		return encodeOptional(valueOf)
 	default:
 		return nil, fmt.Errorf("non-exhaustive pattern: type %T", v)
 	}
 }
 
+// encodeOptional encodes a pointer into an optional. Nil pointers are encoded
+// as none, and all other pointers are encoded as some value.
+func encodeOptional(valueOf reflect.Value) (Value, error) {
+	if valueOf.IsNil() {
+		t, err := encodeZeroType(valueOf.Type().Elem())
+		if err != nil {
+			return nil, fmt.Errorf("encoding optional: %v", err)
+		}
+		return None(t), nil
+	}
+	val, err := encodeReflect(valueOf.Elem())
+	if err != nil {
+		return nil, fmt.Errorf("encoding optional: %v", err)
+	}
+	return Some(val), nil
+}
+
+// encodeReflect encodes a reflected Go value into a value. Unlike Encode, it
+// is aware of the static type of the Go value, which is needed to encode
+// interfaces that have been registered as unions.
+func encodeReflect(valueOf reflect.Value) (Value, error) {
+	if valueOf.Kind() == reflect.Interface {
+		if union, ok := lookupUnion(valueOf.Type()); ok {
+			return union.encode(valueOf)
+		}
+		if valueOf.IsNil() {
+			return nil, fmt.Errorf("encoding %v: nil interface", valueOf.Type())
+		}
+	}
+	return Encode(valueOf.Interface())
+}
+
+// encodeZero encodes the zero value of a Go type into a value. It does not use
+// the cache, so encodeZeroType should be used instead.
+func encodeZero(typeOf reflect.Type) (Value, error) {
+	if union, ok := lookupUnion(typeOf); ok {
+		return union.zero, nil
+	}
+	return Encode(reflect.Zero(typeOf).Interface())
+}
+
+// u8Type is the Go type of U8 values.
+var u8Type = reflect.TypeOf(U8(0))
+
+// isByteType returns true if slices, and arrays, of the Go type are encoded as
+// bytes. This is true for all Go types of the uint8 kind, except U8, so that
+// slices and arrays of U8 can be encoded as lists and tuples of u8 (like the
+// code generated by packgen).
+func isByteType(typeOf reflect.Type) bool {
+	return typeOf.Kind() == reflect.Uint8 && typeOf != u8Type
+}
+
+// encodeTuple encodes a Go array, or a Go struct, into a tuple. The elements of
+// the tuple are the elements of the array, or the fields of the struct (in the
+// order in which they are declared, and excluding fields that are ignored by
+// their tags).
+func encodeTuple(valueOf reflect.Value) (Value, error) {
+	if valueOf.Kind() == reflect.Array {
+		elems := make(Tuple, valueOf.Len())
+		for i := range elems {
+			elem, err := encodeReflect(valueOf.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("encoding tuple element %v: %v", i, err)
+			}
+			elems[i] = elem
+		}
+		return elems, nil
+	}
+	plan, err := structPlanOf(valueOf.Type())
+	if err != nil {
+		return nil, err
+	}
+	elems := make(Tuple, len(plan.fields))
+	for i, f := range plan.fields {
+		elem, err := f.encode(valueOf.FieldByIndex(f.index))
+		if err != nil {
+			return nil, fmt.Errorf("encoding \"%v\": %v", f.goName, err)
+		}
+		elems[i] = elem
+	}
+	return elems, nil
+}
+
+// encodeMap encodes a Go map into a map. The key and value types of the map
+// are inferred from the zero values of the Go key and value types.
+func encodeMap(valueOf reflect.Value) (Value, error) {
+	typeOf := valueOf.Type()
+	k, err := encodeZeroType(typeOf.Key())
+	if err != nil {
+		return nil, fmt.Errorf("encoding map key: %v", err)
+	}
+	v, err := encodeZeroType(typeOf.Elem())
+	if err != nil {
+		return nil, fmt.Errorf("encoding map value: %v", err)
+	}
+	entries := make([]MapEntry, 0, valueOf.Len())
+	iter := valueOf.MapRange()
+	for iter.Next() {
+		key, err := encodeReflect(iter.Key())
+		if err != nil {
+			return nil, fmt.Errorf("encoding map key: %v", err)
+		}
+		if !key.Type().Equals(k) {
+			return nil, fmt.Errorf("inconsistent map key type: expected %v, got %v", k, key.Type())
+		}
+		value, err := encodeReflect(iter.Value())
+		if err != nil {
+			return nil, fmt.Errorf("encoding map value: %v", err)
+		}
+		if !value.Type().Equals(v) {
+			return nil, fmt.Errorf("inconsistent map value type: expected %v, got %v", v, value.Type())
+		}
+		entries = append(entries, MapEntry{Key: key, Value: value})
+	}
+	sorted, err := sortMapEntries(entries)
+	if err != nil {
+		return nil, err
+	}
+	return Map{K: k, V: v, Entries: sorted}, nil
+}
+
 // Decode a Value interface into a Go interface. The Go interface must be a
 // pointer.
 func Decode(interf interface{}, v Value) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			err = fmt.Errorf("recovered: %v", err)
-			return
+			err = fmt.Errorf("recovered: %v", r)
 		}
+		err = newDecodeError(err, kindOfValue(v))
 	}()
 
 	// If the interface-to-be-decoded-into is a value, then check the type of
@@ -185,6 +346,42 @@ func Decode(interf interface{}, v Value) (err error) {
 			return nil
 		}
 		return fmt.Errorf("unexpected value of type %T", v)
+	case *I8:
+		if v, ok := v.(I8); ok {
+			*interf = v
+			return nil
+		}
+		return fmt.Errorf("unexpected value of type %T", v)
+	case *I16:
+		if v, ok := v.(I16); ok {
+			*interf = v
+			return nil
+		}
+		return fmt.Errorf("unexpected value of type %T", v)
+	case *I32:
+		if v, ok := v.(I32); ok {
+			*interf = v
+			return nil
+		}
+		return fmt.Errorf("unexpected value of type %T", v)
+	case *I64:
+		if v, ok := v.(I64); ok {
+			*interf = v
+			return nil
+		}
+		return fmt.Errorf("unexpected value of type %T", v)
+	case *I128:
+		if v, ok := v.(I128); ok {
+			*interf = v
+			return nil
+		}
+		return fmt.Errorf("unexpected value of type %T", v)
+	case *I256:
+		if v, ok := v.(I256); ok {
+			*interf = v
+			return nil
+		}
+		return fmt.Errorf("unexpected value of type %T", v)
 	case *String:
 		if v, ok := v.(String); ok {
 			*interf = v
@@ -209,6 +406,12 @@ func Decode(interf interface{}, v Value) (err error) {
 			return nil
 		}
 		return fmt.Errorf("unexpected value of type %T", v)
+	case *BytesN:
+		if v, ok := v.(BytesN); ok {
+			*interf = v
+			return nil
+		}
+		return fmt.Errorf("unexpected value of type %T", v)
 	case *Struct:
 		if v, ok := v.(Struct); ok {
 			*interf = v
@@ -221,6 +424,30 @@ func Decode(interf interface{}, v Value) (err error) {
 			return nil
 		}
 		return fmt.Errorf("unexpected value of type %T", v)
+	case *Optional:
+		if v, ok := v.(Optional); ok {
+			*interf = v
+			return nil
+		}
+		return fmt.Errorf("unexpected value of type %T", v)
+	case *Map:
+		if v, ok := v.(Map); ok {
+			*interf = v
+			return nil
+		}
+		return fmt.Errorf("unexpected value of type %T", v)
+	case *Union:
+		if v, ok := v.(Union); ok {
+			*interf = v
+			return nil
+		}
+		return fmt.Errorf("unexpected value of type %T", v)
+	case *Tuple:
+		if v, ok := v.(Tuple); ok {
+			*interf = v
+			return nil
+		}
+		return fmt.Errorf("unexpected value of type %T", v)
 	case *Typed:
 		if v, ok := v.(Typed); ok {
 			*interf = v
@@ -239,6 +466,15 @@ func Decode(interf interface{}, v Value) (err error) {
 		return fmt.Errorf("unexpected value of type %T", v)
 	}
 
+	// Go types that have a registered codec, or that implement PackDecoder,
+	// decode themselves.
+	if ok, err := decodeCustom(interf, v); ok {
+		if err != nil {
+			return fmt.Errorf("decoding %T: %w", interf, err)
+		}
+		return nil
+	}
+
 	// Otherwise, reflect on the kind/type of the interface, and attempt to
 	// convert the value-to-be-decoded into the interface.
 	valueOf := reflect.ValueOf(interf)
@@ -246,6 +482,17 @@ func Decode(interf interface{}, v Value) (err error) {
 		return fmt.Errorf("expected %v, got %v", reflect.Ptr, valueOf.Kind())
 	}
 	elem := valueOf.Elem()
+
+	// Optionals can be decoded into Go types that are not pointers. None is
+	// decoded as the zero value, and some value is decoded as the value.
+	if optional, ok := v.(Optional); ok && elem.Kind() != reflect.Ptr && elem.Kind() != reflect.Interface {
+		if optional.IsNone() {
+			elem.Set(reflect.Zero(elem.Type()))
+			return nil
+		}
+		return Decode(interf, optional.Value)
+	}
+
 	switch elem.Kind() {
 	case reflect.Bool:
 		if v, ok := v.(Bool); ok {
@@ -271,12 +518,42 @@ func Decode(interf interface{}, v Value) (err error) {
 			return nil
 		}
 		return fmt.Errorf("unexpected value of type %T", v)
-	case reflect.Uint64:
+	case reflect.Uint64, reflect.Uint, reflect.Uintptr:
 		if v, ok := v.(U64); ok {
+			if elem.OverflowUint(v.Uint64()) {
+				return fmt.Errorf("overflow: %v does not fit in %v", v, elem.Type())
+			}
 			elem.SetUint(v.Uint64())
 			return nil
 		}
 		return fmt.Errorf("unexpected value of type %T", v)
+	case reflect.Int8:
+		if v, ok := v.(I8); ok {
+			elem.SetInt(int64(v.Int8()))
+			return nil
+		}
+		return fmt.Errorf("unexpected value of type %T", v)
+	case reflect.Int16:
+		if v, ok := v.(I16); ok {
+			elem.SetInt(int64(v.Int16()))
+			return nil
+		}
+		return fmt.Errorf("unexpected value of type %T", v)
+	case reflect.Int32:
+		if v, ok := v.(I32); ok {
+			elem.SetInt(int64(v.Int32()))
+			return nil
+		}
+		return fmt.Errorf("unexpected value of type %T", v)
+	case reflect.Int64, reflect.Int:
+		if v, ok := v.(I64); ok {
+			if elem.OverflowInt(v.Int64()) {
+				return fmt.Errorf("overflow: %v does not fit in %v", v, elem.Type())
+			}
+			elem.SetInt(v.Int64())
+			return nil
+		}
+		return fmt.Errorf("unexpected value of type %T", v)
 	case reflect.String:
 		if v, ok := v.(String); ok {
 			elem.SetString(string(v))
@@ -285,7 +562,7 @@ func Decode(interf interface{}, v Value) (err error) {
 		return fmt.Errorf("unexpected value of type %T", v)
 	case reflect.Slice:
 		typeOf := elem.Type()
-		if typeOf.Elem().Kind() == reflect.Uint8 {
+		if isByteType(typeOf.Elem()) {
 			if v, ok := v.(Bytes); ok {
 				elem.SetBytes([]byte(v))
 				return nil
@@ -295,13 +572,13 @@ func Decode(interf interface{}, v Value) (err error) {
 		elem.Set(reflect.MakeSlice(typeOf, len(v.(List).Elems), len(v.(List).Elems)))
 		for i := 0; i < len(v.(List).Elems); i++ {
 			if err := Decode(elem.Index(i).Addr().Interface(), v.(List).Elems[i]); err != nil {
-				return fmt.Errorf("decoding list item: %v", err)
+				return prefixDecodeError(err, indexPath(i))
 			}
 		}
 		return nil
 	case reflect.Array:
 		typeOf := elem.Type()
-		if typeOf.Elem().Kind() == reflect.Uint8 {
+		if isByteType(typeOf.Elem()) {
 			if typeOf.Len() == 32 {
 				if v, ok := v.(Bytes32); ok {
 					elem.Set(reflect.ValueOf(v).Convert(typeOf))
@@ -316,8 +593,16 @@ func Decode(interf interface{}, v Value) (err error) {
 				}
 				return fmt.Errorf("unexpected value of type %T", v)
 			}
+			if v, ok := v.(BytesN); ok {
+				if len(v) != typeOf.Len() {
+					return fmt.Errorf("expected len=%v, got len=%v", typeOf.Len(), len(v))
+				}
+				reflect.Copy(elem, reflect.ValueOf(v))
+				return nil
+			}
+			return fmt.Errorf("unexpected value of type %T", v)
 		}
-		return fmt.Errorf("non-exhaustive pattern: type %T", v)
+		return decodeTuple(elem, v)
 	case reflect.Struct:
 		var structOrTyped Struct
 		if s, ok := v.(Struct); ok {
@@ -328,34 +613,111 @@ func Decode(interf interface{}, v Value) (err error) {
 			return fmt.Errorf("non-exhaustive pattern: type %T", v)
 		}
 
+		plan, err := structPlanOf(elem.Type())
+		if err != nil {
+			return err
+		}
+		for _, f := range plan.fields {
+			// If the struct value is nil, do not decode it.
+			fieldValue := structOrTyped.Get(f.name)
+			if fieldValue == nil {
+				continue
+			}
+			if err := f.decode(elem.FieldByIndex(f.index), fieldValue); err != nil {
+				return prefixDecodeError(newDecodeError(err, kindOfValue(fieldValue)), fieldPath(f.name))
+			}
+		}
+		return nil
+	case reflect.Map:
+		m, ok := v.(Map)
+		if !ok {
+			return fmt.Errorf("unexpected value of type %T", v)
+		}
 		typeOf := elem.Type()
-		n := typeOf.NumField()
-		for i := 0; i < n; i++ {
-			f := typeOf.Field(i)
-			tags := strings.Split(f.Tag.Get("json"), ",")
-			name := f.Name
-			for _, tag := range tags {
-				if tag == "-" {
-					name = ""
-					break
-				}
-				if tag != "omitempty" {
-					name = tag
-					break
-				}
+		elem.Set(reflect.MakeMapWithSize(typeOf, len(m.Entries)))
+		for i, entry := range m.Entries {
+			key := reflect.New(typeOf.Key())
+			if err := Decode(key.Interface(), entry.Key); err != nil {
+				return prefixDecodeError(err, indexPath(i)+".key")
 			}
-			if name != "" {
-				// If the struct value is nil, do not decode it.
-				if structOrTyped.Get(name) == nil {
-					continue
-				}
-				if err := Decode(elem.Field(i).Addr().Interface(), structOrTyped.Get(name)); err != nil {
-					return fmt.Errorf("decoding \"%v\": %v", f.Name, err)
-				}
+			value := reflect.New(typeOf.Elem())
+			if err := Decode(value.Interface(), entry.Value); err != nil {
+				return prefixDecodeError(err, indexPath(i)+".value")
 			}
+			elem.SetMapIndex(key.Elem(), value.Elem())
 		}
 		return nil
+	case reflect.Ptr:
+		optional, ok := v.(Optional)
+		if !ok {
+			// Values that are not optional are decoded into the value to
+			// which the pointer points.
+			ptr := reflect.New(elem.Type().Elem())
+			if err := Decode(ptr.Interface(), v); err != nil {
+				return err
+			}
+			elem.Set(ptr)
+			return nil
+		}
+		if optional.IsNone() {
+			elem.Set(reflect.Zero(elem.Type()))
+			return nil
+		}
+		ptr := reflect.New(elem.Type().Elem())
+		if err := Decode(ptr.Interface(), optional.Value); err != nil {
+			return err
+		}
+		elem.Set(ptr)
+		return nil
+	case reflect.Interface:
+		if union, ok := lookupUnion(elem.Type()); ok {
+			return union.decode(elem, v)
+		}
+		// Interfaces that are implemented by the value (e.g. Value, and the
+		// empty interface) hold the value itself.
+		if v != nil && reflect.TypeOf(v).Implements(elem.Type()) {
+			elem.Set(reflect.ValueOf(v))
+			return nil
+		}
+		return fmt.Errorf("non-exhaustive pattern: type %T", v)
 	default:
 		return fmt.Errorf("non-exhaustive pattern: type %T", v)
 	}
 }
+
+// decodeTuple decodes a tuple into a Go array, or a Go struct. The elements of
+// the tuple are decoded into the elements of the array, or the fields of the
+// struct (in the order in which they are declared, and excluding fields that
+// are ignored by their tags).
+func decodeTuple(elem reflect.Value, v Value) error {
+	tuple, ok := v.(Tuple)
+	if !ok {
+		return fmt.Errorf("unexpected value of type %T", v)
+	}
+	if elem.Kind() == reflect.Array {
+		if len(tuple) != elem.Len() {
+			return fmt.Errorf("expected len=%v, got len=%v", elem.Len(), len(tuple))
+		}
+		for i := range tuple {
+			if err := Decode(elem.Index(i).Addr().Interface(), tuple[i]); err != nil {
+				return prefixDecodeError(err, indexPath(i))
+			}
+		}
+		return nil
+	}
+	plan, err := structPlanOf(elem.Type())
+	if err != nil {
+		return err
+	}
+	if len(plan.fields) != len(tuple) {
+		return fmt.Errorf("expected len=%v, got len=%v", len(plan.fields), len(tuple))
+	}
+	for i, f := range plan.fields {
+		if err := f.decode(elem.FieldByIndex(f.index), tuple[i]); err != nil {
+			return prefixDecodeError(newDecodeError(err, kindOfValue(tuple[i])), indexPath(i))
+		}
+	}
+	return nil
+}
+
+var valueType = reflect.TypeOf((*Value)(nil)).Elem()
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/rand"
 	"reflect"
+	"sync"
 	"testing/quick"
 	"time"
 
@@ -24,12 +25,23 @@ var _ = Describe("Encoding", func() {
 		reflect.TypeOf(pack.U64(0)),
 		reflect.TypeOf(pack.NewU128([16]byte{})),
 		reflect.TypeOf(pack.NewU256([32]byte{})),
+		reflect.TypeOf(pack.I8(0)),
+		reflect.TypeOf(pack.I16(0)),
+		reflect.TypeOf(pack.I32(0)),
+		reflect.TypeOf(pack.I64(0)),
+		reflect.TypeOf(pack.NewI128([16]byte{})),
+		reflect.TypeOf(pack.NewI256([32]byte{})),
 		reflect.TypeOf(pack.String("")),
 		reflect.TypeOf(pack.Bytes{}),
 		reflect.TypeOf(pack.Bytes32{}),
 		reflect.TypeOf(pack.Bytes65{}),
+		reflect.TypeOf(pack.BytesN{}),
 		reflect.TypeOf(pack.Struct{}),
 		reflect.TypeOf(pack.List{}),
+		reflect.TypeOf(pack.Optional{}),
+		reflect.TypeOf(pack.Map{}),
+		reflect.TypeOf(pack.Union{}),
+		reflect.TypeOf(pack.Tuple{}),
 
 		// Standard types.
 		reflect.TypeOf(false),
@@ -37,16 +49,29 @@ var _ = Describe("Encoding", func() {
 		reflect.TypeOf(uint16(0)),
 		reflect.TypeOf(uint32(0)),
 		reflect.TypeOf(uint64(0)),
+		reflect.TypeOf(int8(0)),
+		reflect.TypeOf(int16(0)),
+		reflect.TypeOf(int32(0)),
+		reflect.TypeOf(int64(0)),
+		reflect.TypeOf(int(0)),
 		reflect.TypeOf(""),
 		reflect.TypeOf([]byte{}),
 		reflect.TypeOf([32]byte{}),
 		reflect.TypeOf([65]byte{}),
+		reflect.TypeOf([20]byte{}),
+		reflect.TypeOf([0]byte{}),
 		reflect.TypeOf(struct{}{}),
 		reflect.TypeOf([]string{}),
 		reflect.TypeOf([]uint64{}),
+		reflect.TypeOf((*uint64)(nil)),
+		reflect.TypeOf(map[string]uint64{}),
+		reflect.TypeOf(map[uint32]string{}),
+		reflect.TypeOf([3]uint64{}),
+		reflect.TypeOf([0]string{}),
 		reflect.TypeOf(struct {
 			X       uint8  `json:"x"`
 			Y       uint16 `json:"y"`
+			Delta   int32  `json:"delta"`
 			Omit    uint32 `json:"z,omitempty"`
 			Dash    uint64 `json:"-"`
 			Unnamed uint64
@@ -55,6 +80,7 @@ var _ = Describe("Encoding", func() {
 			Bar []byte   `json:"bar"`
 			Baz [32]byte `json:"baz"`
 			Boo [65]byte `json:"boo"`
+			Doo [20]byte `json:"doo"`
 
 			Inner struct {
 				InnerX       uint8  `json:"x"`
@@ -66,6 +92,19 @@ var _ = Describe("Encoding", func() {
 
 			ListOfStrings []string `json:"listOfStrings"`
 			ListOfUints   []uint64 `json:"listOfUints"`
+
+			MapOfUints map[string]uint64 `json:"mapOfUints"`
+
+			Pair struct {
+				First  uint64 `json:"first"`
+				Second string `json:"second"`
+			} `json:"pair,tuple"`
+			Triple [3]uint16 `json:"triple"`
+
+			Maybe      *uint64 `json:"maybe"`
+			MaybeInner *struct {
+				X uint8 `json:"x"`
+			} `json:"maybeInner"`
 		}{}),
 
 		// Mixed types.
@@ -171,4 +210,210 @@ var _ = Describe("Encoding", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 	})
+
+	Context("when encoding and decoding concurrently", func() {
+		It("should equal itself", func() {
+			type Concurrent struct {
+				Foo  uint64            `json:"foo"`
+				Bar  []string          `json:"bar"`
+				Baz  map[string]uint32 `json:"baz"`
+				Pair struct {
+					First  uint8  `json:"first"`
+					Second string `json:"second"`
+				} `json:"pair,tuple"`
+			}
+
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func(seed int64) {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					r := rand.New(rand.NewSource(seed))
+					for trial := 0; trial < numTrials; trial++ {
+						x, ok := quick.Value(reflect.TypeOf(Concurrent{}), r)
+						Expect(ok).To(BeTrue())
+
+						v, err := pack.Encode(x.Interface())
+						Expect(err).ToNot(HaveOccurred())
+
+						var y Concurrent
+						Expect(pack.Decode(&y, v)).To(Succeed())
+						Expect(reflect.DeepEqual(x.Interface(), y)).To(BeTrue())
+					}
+				}(GinkgoRandomSeed() + int64(i))
+			}
+			wg.Wait()
+		})
+	})
+
+	Context("when encoding and decoding embedded structs", func() {
+		type Base struct {
+			ID      uint64 `json:"id"`
+			Version uint8  `json:"version"`
+		}
+		type base struct {
+			Owner string `json:"owner"`
+		}
+
+		It("should promote their fields", func() {
+			type Derived struct {
+				Base
+				base
+				Name string `json:"name"`
+			}
+			x := Derived{Base: Base{ID: 1, Version: 2}, Name: "name"}
+			x.Owner = "owner"
+			v, err := pack.Encode(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewStruct(
+				"id", pack.NewU64(1),
+				"version", pack.NewU8(2),
+				"owner", pack.NewString("owner"),
+				"name", pack.NewString("name"),
+			)))
+
+			var y Derived
+			Expect(pack.Decode(&y, v)).To(Succeed())
+			Expect(y).To(Equal(x))
+		})
+
+		It("should shadow promoted fields with less deeply nested fields", func() {
+			type Derived struct {
+				Base
+				Version uint16 `json:"version"`
+			}
+			x := Derived{Base: Base{ID: 1, Version: 2}, Version: 3}
+			v, err := pack.Encode(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewStruct(
+				"id", pack.NewU64(1),
+				"version", pack.NewU16(3),
+			)))
+
+			var y Derived
+			Expect(pack.Decode(&y, v)).To(Succeed())
+			Expect(y).To(Equal(Derived{Base: Base{ID: 1}, Version: 3}))
+		})
+
+		It("should not promote the fields of named, or pointer, embedded structs", func() {
+			type Extra struct {
+				Note string `json:"note"`
+			}
+			type Derived struct {
+				Base `json:"base"`
+				*Extra
+				*base
+			}
+			v, err := pack.Encode(Derived{Base: Base{ID: 1}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.Type()).To(Equal(pack.StructType(
+				"base", pack.StructType("id", pack.TypeU64(), "version", pack.TypeU8()),
+			)))
+		})
+
+		It("should return an error when promoted fields have the same name", func() {
+			type Other struct {
+				ID uint64 `pack:"id"`
+			}
+			type Derived struct {
+				Base
+				Other
+			}
+			_, err := pack.Encode(Derived{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when encoding and decoding structs with unexported fields", func() {
+		It("should ignore them", func() {
+			type Unexported struct {
+				Foo uint64 `json:"foo"`
+				bar uint64
+				baz func()
+			}
+			v, err := pack.Encode(Unexported{Foo: 1, bar: 2})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewStruct("foo", pack.NewU64(1))))
+
+			var y Unexported
+			Expect(pack.Decode(&y, v)).To(Succeed())
+			Expect(y).To(Equal(Unexported{Foo: 1}))
+		})
+	})
+
+	Context("when encoding and decoding interfaces that hold values", func() {
+		type Holder struct {
+			Value pack.Value   `json:"value"`
+			Any   interface{}  `json:"any"`
+			Str   fmt.Stringer `json:"str"`
+		}
+
+		It("should hold the value", func() {
+			x := Holder{
+				Value: pack.NewU64(1),
+				Any:   pack.NewString("any"),
+				Str:   pack.NewBytes32([32]byte{1}),
+			}
+			v, err := pack.Encode(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewStruct(
+				"value", pack.NewU64(1),
+				"any", pack.NewString("any"),
+				"str", pack.NewBytes32([32]byte{1}),
+			)))
+
+			var y Holder
+			Expect(pack.Decode(&y, v)).To(Succeed())
+			Expect(y).To(Equal(x))
+		})
+
+		It("should return an error when the interface is nil", func() {
+			_, err := pack.Encode(Holder{Any: pack.NewU8(0), Str: pack.NewString("")})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when decoding optionals into values that are not pointers", func() {
+		It("should decode none as the zero value", func() {
+			x := uint64(1)
+			Expect(pack.Decode(&x, pack.None(pack.TypeU64()))).To(Succeed())
+			Expect(x).To(Equal(uint64(0)))
+		})
+
+		It("should decode some value as the value", func() {
+			var x uint64
+			Expect(pack.Decode(&x, pack.Some(pack.NewU64(1)))).To(Succeed())
+			Expect(x).To(Equal(uint64(1)))
+		})
+	})
+
+	Context("when decoding values that are not optionals into pointers", func() {
+		It("should decode the value to which the pointer points", func() {
+			var x *uint64
+			Expect(pack.Decode(&x, pack.NewU64(1))).To(Succeed())
+			Expect(*x).To(Equal(uint64(1)))
+		})
+	})
+
+	Context("when encoding and decoding slices and arrays of u8", func() {
+		It("should encode them as lists and tuples, instead of bytes", func() {
+			x := []pack.U8{1, 2}
+			v, err := pack.Encode(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.Type().Equals(pack.ListType(pack.TypeU8()))).To(BeTrue())
+			y := []pack.U8{}
+			Expect(pack.Decode(&y, v)).To(Succeed())
+			Expect(y).To(Equal(x))
+
+			z := [2]pack.U8{3, 4}
+			v, err = pack.Encode(z)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.Type().Equals(pack.TupleType(pack.TypeU8(), pack.TypeU8()))).To(BeTrue())
+			w := [2]pack.U8{}
+			Expect(pack.Decode(&w, v)).To(Succeed())
+			Expect(w).To(Equal(z))
+		})
+	})
 })
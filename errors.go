@@ -0,0 +1,170 @@
+package pack
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A DecodeError is returned when a type, or a value, cannot be unmarshaled from
+// binary or JSON, or when a value cannot be decoded into a Go value (see
+// Decode). It identifies where the error occurred, and wraps the error that
+// caused it, so that errors.Is and errors.As can be used to inspect the cause:
+//
+//  var decodeErr *pack.DecodeError
+//  if errors.As(err, &decodeErr) {
+//      fmt.Printf("path: %v, offset: %v", decodeErr.Path, decodeErr.Offset)
+//  }
+//
+type DecodeError struct {
+	// Path to the value, or type, that could not be unmarshaled, in the style
+	// of jq (e.g. ".fills[0].price"). It is relative to the value, or type,
+	// being unmarshaled, and the root is ".". Struct fields, and union
+	// variants, are identified by name, list and tuple elements by "[i]", and
+	// map entries by "[i].key" and "[i].value". In types, the elements of
+	// lists, and the entries of maps, are identified by "[]".
+	Path string
+	// Offset of the byte at which unmarshaling failed, relative to the start
+	// of the binary buffer (or, when reading from a Decoder, the first byte
+	// read by the call). It is -1 when the offset is not known (e.g. when
+	// unmarshaling from JSON, or decoding).
+	Offset int
+	// Kind of the value, or type, at the path. It is KindNil when the kind is
+	// not known (e.g. when the kind of a type could not be unmarshaled).
+	Kind Kind
+	// Cause of the error.
+	Cause error
+}
+
+// Error implements the error interface.
+func (err *DecodeError) Error() string {
+	builder := new(strings.Builder)
+	fmt.Fprintf(builder, "decoding %v", err.Path)
+	if err.Kind != KindNil {
+		fmt.Fprintf(builder, " (%v)", err.Kind)
+	}
+	if err.Offset >= 0 {
+		fmt.Fprintf(builder, " at offset %v", err.Offset)
+	}
+	fmt.Fprintf(builder, ": %v", err.Cause)
+	return builder.String()
+}
+
+// Unwrap returns the cause of the error.
+func (err *DecodeError) Unwrap() error {
+	return err.Cause
+}
+
+var (
+	// errNotFound is the cause of a DecodeError when a struct field is
+	// missing from JSON.
+	errNotFound = errors.New("not found")
+	// errNonCanonicalOrder is the cause of a DecodeError when the keys of a
+	// map are not in canonical order.
+	errNonCanonicalOrder = errors.New("non-canonical order")
+)
+
+// newDecodeError returns a DecodeError at the root, with the given kind, that
+// is caused by err. If err is already a DecodeError, it is returned unchanged,
+// so that the path and kind of the innermost value are kept. If err is nil,
+// nil is returned.
+func newDecodeError(err error, kind Kind) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*DecodeError); ok {
+		return err
+	}
+	return &DecodeError{Path: ".", Offset: -1, Kind: kind, Cause: err}
+}
+
+// WrapDecodeError returns a DecodeError at the given path, with the given kind
+// and offset, that is caused by err. If err is already a DecodeError, its path
+// is appended to the given path, and its offset is replaced by the given
+// offset (unless it is -1), so that they become relative to an outer value.
+// If err is nil, nil is returned. It is used by the code generated by packgen.
+func WrapDecodeError(err error, path string, kind Kind, offset int) error {
+	if err == nil {
+		return nil
+	}
+	if decodeErr, ok := err.(*DecodeError); ok {
+		decodeErr.Path = appendPath(path, decodeErr.Path)
+		if offset >= 0 {
+			decodeErr.Offset = offset
+		}
+		return decodeErr
+	}
+	return &DecodeError{Path: path, Offset: offset, Kind: kind, Cause: err}
+}
+
+// newDecodeErrorAt returns a DecodeError in the same way as newDecodeError,
+// and sets its offset to the number of bytes consumed from orig, where buf is
+// the remainder of orig after unmarshaling failed.
+func newDecodeErrorAt(err error, kind Kind, orig, buf []byte) error {
+	return withOffset(newDecodeError(err, kind), orig, buf)
+}
+
+// prefixDecodeError prepends a path to the path of a DecodeError, so that the
+// path of the error becomes relative to an outer value. Errors that are not
+// DecodeErrors are returned unchanged.
+func prefixDecodeError(err error, path string) error {
+	if decodeErr, ok := err.(*DecodeError); ok {
+		decodeErr.Path = appendPath(path, decodeErr.Path)
+	}
+	return err
+}
+
+// withOffset sets the offset of a DecodeError to the number of bytes consumed
+// from orig, where buf is the remainder of orig after unmarshaling failed.
+// Errors that are not DecodeErrors are returned unchanged.
+func withOffset(err error, orig, buf []byte) error {
+	if decodeErr, ok := err.(*DecodeError); ok {
+		decodeErr.Offset = len(orig) - len(buf)
+	}
+	return err
+}
+
+// kindOfValue returns the kind of the type of a value, or KindNil if the value,
+// or its type, is nil.
+func kindOfValue(v Value) Kind {
+	if v == nil {
+		return KindNil
+	}
+	t := v.Type()
+	if t == nil {
+		return KindNil
+	}
+	return t.Kind()
+}
+
+// appendPath returns the path of child, which is relative to the value at the
+// path parent, relative to the root. Paths are in the style of jq (e.g.
+// ".fills[0]", or "." for the root).
+func appendPath(parent, child string) string {
+	if parent == "." {
+		return child
+	}
+	if child == "." {
+		return parent
+	}
+	if strings.HasPrefix(child, ".[") {
+		return parent + child[1:]
+	}
+	return parent + child
+}
+
+// fieldPath returns the path of a struct field, or union variant, with the
+// given name. Names that are not identifiers are quoted.
+func fieldPath(name string) string {
+	if isSchemaIdent(name) {
+		return "." + name
+	}
+	return ".[" + strconv.Quote(name) + "]"
+}
+
+// indexPath returns the path of a list, or tuple, element with the given
+// index.
+func indexPath(i int) string {
+	return ".[" + strconv.Itoa(i) + "]"
+}
@@ -0,0 +1,228 @@
+package pack_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/surge"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type decodeErrorFill struct {
+	Price uint8 `json:"price"`
+}
+
+type decodeErrorOrder struct {
+	Fills []decodeErrorFill `json:"fills"`
+}
+
+// mustNewList returns a list of the values, and panics if the values do not
+// all have the same type.
+func mustNewList(vs ...pack.Value) pack.List {
+	list, err := pack.NewList(vs...)
+	if err != nil {
+		panic(err)
+	}
+	return list
+}
+
+// asDecodeError returns the DecodeError in the chain of the error, and fails
+// the test if there is no such error.
+func asDecodeError(err error) *pack.DecodeError {
+	decodeErr := new(pack.DecodeError)
+	ExpectWithOffset(1, errors.As(err, &decodeErr)).To(BeTrue())
+	return decodeErr
+}
+
+var _ = Describe("Decode errors", func() {
+
+	order := pack.NewStruct(
+		"fills", mustNewList(
+			pack.NewStruct("price", pack.NewU256FromUint64(1)),
+			pack.NewStruct("price", pack.NewU256FromUint64(2)),
+		),
+		"memo", pack.NewString("hello"),
+	)
+	orderData, err := surge.ToBinary(order)
+	if err != nil {
+		panic(err)
+	}
+
+	Context("when unmarshaling a value from binary", func() {
+		It("should return the path, offset, and kind of the value", func() {
+			_, _, _, err := order.Type().UnmarshalValue(orderData[:50], 50)
+			decodeErr := asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal(".fills[1].price"))
+			Expect(decodeErr.Offset).To(Equal(36))
+			Expect(decodeErr.Kind).To(Equal(pack.KindU256))
+			Expect(errors.Is(err, surge.ErrUnexpectedEndOfBuffer)).To(BeTrue())
+			Expect(err.Error()).To(Equal("decoding .fills[1].price (u256) at offset 36: unexpected end of buffer"))
+		})
+
+		It("should return the offset relative to the start of a typed value", func() {
+			data, err := surge.ToBinary(pack.Typed(order))
+			Expect(err).ToNot(HaveOccurred())
+			typed := pack.Typed{}
+			_, _, err = typed.Unmarshal(data[:len(data)-1], len(data)-1)
+			decodeErr := asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal(".memo"))
+			Expect(decodeErr.Offset).To(Equal(len(data) - 5))
+			Expect(decodeErr.Kind).To(Equal(pack.KindString))
+		})
+
+		It("should quote names that are not identifiers", func() {
+			v := pack.NewStruct("not an ident", pack.NewTuple(pack.NewU8(1), pack.NewU16(2)))
+			_, _, _, err := v.Type().UnmarshalValue([]byte{1, 0}, 2)
+			decodeErr := asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal(`.["not an ident"][1]`))
+			Expect(decodeErr.Offset).To(Equal(1))
+			Expect(decodeErr.Kind).To(Equal(pack.KindU16))
+		})
+
+		It("should return the key of maps that are not in canonical order", func() {
+			t := pack.MapType(pack.TypeU8(), pack.TypeBool())
+			_, _, _, err := t.UnmarshalValue([]byte{0, 0, 0, 2, 2, 1, 1, 1}, 8)
+			decodeErr := asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal(".[1].key"))
+			Expect(decodeErr.Offset).To(Equal(6))
+			Expect(decodeErr.Kind).To(Equal(pack.KindMap))
+		})
+
+		It("should return errors from the value itself", func() {
+			unionType := pack.UnionType(pack.NewUnionVariant("a", pack.TypeU8()))
+			_, _, _, err = unionType.UnmarshalValue([]byte{1}, 1)
+			decodeErr := asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal("."))
+			Expect(decodeErr.Kind).To(Equal(pack.KindUnion))
+
+			_, _, _, err = pack.TypeU64().UnmarshalValue([]byte{0}, 1)
+			decodeErr = asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal("."))
+			Expect(decodeErr.Offset).To(Equal(0))
+			Expect(decodeErr.Kind).To(Equal(pack.KindU64))
+		})
+	})
+
+	Context("when unmarshaling a value from json", func() {
+		It("should return the path, and kind, of the value", func() {
+			_, err := order.Type().UnmarshalValueJSON([]byte(`{"fills": [{"price": "1"}, {"price": true}], "memo": ""}`))
+			decodeErr := asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal(".fills[1].price"))
+			Expect(decodeErr.Offset).To(Equal(-1))
+			Expect(decodeErr.Kind).To(Equal(pack.KindU256))
+
+			_, err = order.Type().UnmarshalValueJSON([]byte(`{"fills": []}`))
+			decodeErr = asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal(".memo"))
+			Expect(decodeErr.Kind).To(Equal(pack.KindString))
+			Expect(err.Error()).To(Equal("decoding .memo (string): not found"))
+		})
+
+		It("should return the path of values in maps with string keys", func() {
+			t := pack.MapType(pack.TypeString(), pack.TypeU8())
+			_, err := t.UnmarshalValueJSON([]byte(`{"a b": "x"}`))
+			decodeErr := asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal(`.["a b"]`))
+			Expect(decodeErr.Kind).To(Equal(pack.KindU8))
+		})
+
+		It("should return the path from typed values", func() {
+			data, err := json.Marshal(pack.Typed(order))
+			Expect(err).ToNot(HaveOccurred())
+			raw := map[string]json.RawMessage{}
+			Expect(json.Unmarshal(data, &raw)).To(Succeed())
+			raw["v"] = json.RawMessage(`{"fills": [{"price": "x"}], "memo": ""}`)
+			data, err = json.Marshal(raw)
+			Expect(err).ToNot(HaveOccurred())
+
+			typed := pack.Typed{}
+			err = json.Unmarshal(data, &typed)
+			decodeErr := asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal(".fills[0].price"))
+		})
+	})
+
+	Context("when unmarshaling a type", func() {
+		t := pack.StructType("a", pack.ListType(pack.TypeU8()))
+		data, err := surge.ToBinary(pack.NewTyped("a", mustNewList(pack.NewU8(1))))
+		if err != nil {
+			panic(err)
+		}
+		typeData := data[:len(data)-5]
+
+		It("should return the path, and offset, from binary", func() {
+			var unmarshaled pack.Type
+			_, _, err := pack.UnmarshalType(&unmarshaled, typeData[:len(typeData)-1], len(typeData)-1)
+			decodeErr := asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal(".a[]"))
+			Expect(decodeErr.Offset).To(Equal(len(typeData) - 1))
+			Expect(decodeErr.Kind).To(Equal(pack.KindNil))
+			Expect(errors.Is(err, surge.ErrUnexpectedEndOfBuffer)).To(BeTrue())
+
+			rest, _, err := pack.UnmarshalType(&unmarshaled, typeData, len(typeData))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rest).To(BeEmpty())
+			Expect(unmarshaled.Equals(t)).To(BeTrue())
+		})
+
+		It("should return the path from json", func() {
+			_, err := pack.DefaultDecodeOptions.UnmarshalTypeJSON([]byte(`{"struct": [{"a": {"map": {"key": "u8", "value": "bogus"}}}]}`))
+			decodeErr := asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal(".a[].value"))
+			Expect(decodeErr.Offset).To(Equal(-1))
+			Expect(decodeErr.Kind).To(Equal(pack.KindNil))
+
+			_, err = pack.DefaultDecodeOptions.UnmarshalTypeJSON([]byte(`{"struct": [{"a": {"tuple": ["u8", {"bytesn": "x"}]}}]}`))
+			decodeErr = asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal(".a[1]"))
+			Expect(decodeErr.Kind).To(Equal(pack.KindBytesN))
+		})
+	})
+
+	Context("when wrapping an error", func() {
+		It("should return a decode error at the path", func() {
+			err := pack.WrapDecodeError(surge.ErrUnexpectedEndOfBuffer, ".fills[1]", pack.KindStruct, 4)
+			decodeErr := asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal(".fills[1]"))
+			Expect(decodeErr.Offset).To(Equal(4))
+			Expect(decodeErr.Kind).To(Equal(pack.KindStruct))
+			Expect(errors.Is(err, surge.ErrUnexpectedEndOfBuffer)).To(BeTrue())
+
+			Expect(pack.WrapDecodeError(nil, ".fills", pack.KindList, 0)).To(BeNil())
+		})
+
+		It("should prepend the path, and replace the offset, of decode errors", func() {
+			_, _, _, err := pack.TypeU64().UnmarshalValue([]byte{0}, 1)
+			err = pack.WrapDecodeError(err, ".fills[1].price", pack.KindU64, 36)
+			decodeErr := asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal(".fills[1].price"))
+			Expect(decodeErr.Offset).To(Equal(36))
+			Expect(decodeErr.Kind).To(Equal(pack.KindU64))
+
+			err = pack.WrapDecodeError(err, `.["not an ident"]`, pack.KindStruct, -1)
+			decodeErr = asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal(`.["not an ident"].fills[1].price`))
+			Expect(decodeErr.Offset).To(Equal(36))
+		})
+	})
+
+	Context("when decoding a value", func() {
+		It("should return the path, and kind, of the value", func() {
+			v := pack.NewStruct(
+				"fills", mustNewList(
+					pack.NewStruct("price", pack.NewU64(1)),
+				),
+			)
+			x := decodeErrorOrder{}
+			err := pack.Decode(&x, v)
+			decodeErr := asDecodeError(err)
+			Expect(decodeErr.Path).To(Equal(".fills[0].price"))
+			Expect(decodeErr.Offset).To(Equal(-1))
+			Expect(decodeErr.Kind).To(Equal(pack.KindU64))
+			Expect(err.Error()).To(Equal("decoding .fills[0].price (u64): unexpected value of type pack.U64"))
+		})
+	})
+})
@@ -0,0 +1,256 @@
+package pack
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/renproject/surge"
+)
+
+// DefaultMaxFrameSize is the default maximum number of bytes in the payload of
+// a frame read, or written, by a MessageConn.
+const DefaultMaxFrameSize = 4 * 1024 * 1024
+
+const (
+	// frameTyped is the kind of frame that holds a typed value. The type is
+	// written in the frame, so it can be read without any prior knowledge.
+	frameTyped = byte(1)
+	// frameValue is the kind of frame that holds a value and the hash of its
+	// type (see TypeHash). The type must be registered with the reader.
+	frameValue = byte(2)
+
+	// frameHeaderSize is the number of bytes in the header of a frame: the
+	// length of the payload, and the checksum of the payload.
+	frameHeaderSize = 8
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// A MessageConn wraps a network connection, and reads/writes values from/to it
+// as messages. Each message is written as one frame:
+//
+//  length   (u32): the number of bytes in the payload
+//  checksum (u32): the CRC-32C checksum of the payload
+//  payload:
+//    kind   (u8):  1 for typed values, 2 for values with a type hash
+//    type:         the binary type definition (typed values), or the 32 byte
+//                  type hash (values with a type hash)
+//    value:        the binary value
+//
+// Typed values can be read without any prior knowledge. Other values are
+// smaller on the wire, because only the hash of their type is written, but
+// their type must be registered with the reader (see RegisterType). Frames
+// that are larger than the maximum frame size are rejected before they are
+// read, so that peers cannot exhaust the memory of the reader.
+//
+// It is safe to read and write messages concurrently. If reading a message
+// fails, the connection is likely to be in an inconsistent state and should be
+// closed. The network connection is not exposed, so that bytes cannot be read,
+// or written, without being framed.
+type MessageConn struct {
+	conn net.Conn
+
+	rmu          sync.Mutex
+	wmu          sync.Mutex
+	maxFrameSize int
+	decodeOpts   DecodeOptions
+
+	typesMu sync.RWMutex
+	types   map[[32]byte]Type
+}
+
+// NewMessageConn returns a connection that reads/writes messages from/to the
+// given network connection. By default, the maximum frame size is
+// DefaultMaxFrameSize, and messages are unmarshaled using the
+// DefaultDecodeOptions.
+func NewMessageConn(conn net.Conn) *MessageConn {
+	return &MessageConn{
+		conn:         conn,
+		maxFrameSize: DefaultMaxFrameSize,
+		decodeOpts:   DefaultDecodeOptions,
+		types:        map[[32]byte]Type{},
+	}
+}
+
+// SetMaxFrameSize sets the maximum number of bytes in the payload of a frame.
+// Reading, or writing, a larger frame will return an error. It should not be
+// called concurrently with reading or writing messages.
+func (conn *MessageConn) SetMaxFrameSize(maxFrameSize int) {
+	conn.maxFrameSize = maxFrameSize
+}
+
+// SetDecodeOptions sets the limits that are enforced when unmarshaling the
+// values held by frames. It should not be called concurrently with reading
+// messages.
+func (conn *MessageConn) SetDecodeOptions(opts DecodeOptions) {
+	conn.decodeOpts = opts
+}
+
+// RegisterType registers a type so that values of this type can be read when
+// they are written with a type hash (instead of the full type definition).
+func (conn *MessageConn) RegisterType(t Type) {
+	conn.typesMu.Lock()
+	defer conn.typesMu.Unlock()
+
+	conn.types[TypeHash(t)] = t
+}
+
+// WriteMessage writes a value to the connection as one frame. Typed values are
+// written with their full type definition. All other values are written with
+// the hash of their type, and so their type must be registered with the
+// reader.
+func (conn *MessageConn) WriteMessage(v Value) error {
+	var payload []byte
+	var err error
+	if typed, ok := v.(Typed); ok {
+		payload, err = marshalFrame(frameTyped, nil, typed)
+	} else {
+		hash := TypeHash(v.Type())
+		payload, err = marshalFrame(frameValue, hash[:], v)
+	}
+	if err != nil {
+		return fmt.Errorf("marshaling message: %w", err)
+	}
+	if len(payload) > conn.maxFrameSize {
+		return fmt.Errorf("frame too large: expected at most %v bytes, got %v bytes", conn.maxFrameSize, len(payload))
+	}
+
+	frame := make([]byte, frameHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.Checksum(payload, crc32c))
+	copy(frame[frameHeaderSize:], payload)
+
+	conn.wmu.Lock()
+	defer conn.wmu.Unlock()
+
+	if _, err := conn.conn.Write(frame); err != nil {
+		return fmt.Errorf("writing frame: %w", err)
+	}
+	return nil
+}
+
+// ReadMessage reads one frame from the connection, and returns the value that
+// it holds. Typed values are returned as Typed. If the connection is closed
+// before any of the frame is read, io.EOF is returned.
+func (conn *MessageConn) ReadMessage() (Value, error) {
+	conn.rmu.Lock()
+	defer conn.rmu.Unlock()
+
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(conn.conn, header[:]); err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("reading frame header: %w", err)
+	}
+	length := binary.BigEndian.Uint32(header[0:4])
+	checksum := binary.BigEndian.Uint32(header[4:8])
+	if uint64(length) > uint64(conn.maxFrameSize) {
+		return nil, fmt.Errorf("frame too large: expected at most %v bytes, got %v bytes", conn.maxFrameSize, length)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(conn.conn, payload); err != nil {
+		return nil, fmt.Errorf("reading frame payload: %w", err)
+	}
+	if actual := crc32.Checksum(payload, crc32c); actual != checksum {
+		return nil, fmt.Errorf("bad checksum: expected %08x, got %08x", checksum, actual)
+	}
+	return conn.unmarshalFrame(payload)
+}
+
+// Close closes the network connection. Blocked reads, and writes, will return
+// an error.
+func (conn *MessageConn) Close() error {
+	return conn.conn.Close()
+}
+
+// LocalAddr returns the local address of the network connection.
+func (conn *MessageConn) LocalAddr() net.Addr {
+	return conn.conn.LocalAddr()
+}
+
+// RemoteAddr returns the remote address of the network connection.
+func (conn *MessageConn) RemoteAddr() net.Addr {
+	return conn.conn.RemoteAddr()
+}
+
+// SetDeadline sets the read and write deadlines of the network connection (see
+// net.Conn).
+func (conn *MessageConn) SetDeadline(t time.Time) error {
+	return conn.conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline of the network connection (see
+// net.Conn). Reading a message after the deadline will return an error.
+func (conn *MessageConn) SetReadDeadline(t time.Time) error {
+	return conn.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline of the network connection (see
+// net.Conn). Writing a message after the deadline will return an error.
+func (conn *MessageConn) SetWriteDeadline(t time.Time) error {
+	return conn.conn.SetWriteDeadline(t)
+}
+
+func (conn *MessageConn) unmarshalFrame(payload []byte) (Value, error) {
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("unmarshaling frame kind: %w", surge.ErrUnexpectedEndOfBuffer)
+	}
+	kind, buf := payload[0], payload[1:]
+
+	var v Value
+	var err error
+	switch kind {
+	case frameTyped:
+		var t Type
+		if buf, _, err = conn.decodeOpts.UnmarshalType(&t, buf, surge.MaxBytes); err != nil {
+			return nil, fmt.Errorf("unmarshaling type: %w", err)
+		}
+		if t.Kind() != KindStruct {
+			return nil, fmt.Errorf("expected kind \"struct\", got kind \"%v\"", t.Kind())
+		}
+		if v, buf, _, err = conn.decodeOpts.UnmarshalValue(t, buf, surge.MaxBytes); err == nil {
+			v = Typed(v.(Struct))
+		}
+	case frameValue:
+		if len(buf) < 32 {
+			return nil, fmt.Errorf("unmarshaling type hash: %w", surge.ErrUnexpectedEndOfBuffer)
+		}
+		var hash [32]byte
+		copy(hash[:], buf)
+		buf = buf[32:]
+
+		conn.typesMu.RLock()
+		t, ok := conn.types[hash]
+		conn.typesMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown type hash %x", hash)
+		}
+		v, buf, _, err = conn.decodeOpts.UnmarshalValue(t, buf, surge.MaxBytes)
+	default:
+		return nil, fmt.Errorf("unknown frame kind %v", kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling message: %w", err)
+	}
+	if len(buf) != 0 {
+		return nil, fmt.Errorf("unmarshaling message: %v unexpected trailing bytes", len(buf))
+	}
+	return v, nil
+}
+
+func marshalFrame(kind byte, prefix []byte, v surge.Marshaler) ([]byte, error) {
+	payload := make([]byte, 1+len(prefix)+v.SizeHint())
+	payload[0] = kind
+	copy(payload[1:], prefix)
+	if _, _, err := v.Marshal(payload[1+len(prefix):], v.SizeHint()); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
@@ -0,0 +1,230 @@
+package pack_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/surge"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Framing", func() {
+
+	numTrials := 20
+
+	mustMarshal := func(v pack.Value) []byte {
+		data, err := surge.ToBinary(v)
+		Expect(err).ToNot(HaveOccurred())
+		return data
+	}
+
+	pipe := func() (*pack.MessageConn, *pack.MessageConn) {
+		c1, c2 := net.Pipe()
+		return pack.NewMessageConn(c1), pack.NewMessageConn(c2)
+	}
+
+	// writeAsync writes the messages to the connection in the background,
+	// because writes to a pipe block until they are read.
+	writeAsync := func(conn *pack.MessageConn, vs ...pack.Value) <-chan error {
+		errs := make(chan error, 1)
+		go func() {
+			for _, v := range vs {
+				if err := conn.WriteMessage(v); err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}()
+		return errs
+	}
+
+	// writeRawAsync writes a raw frame to the connection in the background.
+	writeRawAsync := func(conn net.Conn, payload []byte, checksum uint32) {
+		go func() {
+			frame := make([]byte, 8+len(payload))
+			binary.BigEndian.PutUint32(frame[0:4], uint32(len(payload)))
+			binary.BigEndian.PutUint32(frame[4:8], checksum)
+			copy(frame[8:], payload)
+			conn.Write(frame)
+		}()
+	}
+
+	Context("when writing and then reading typed messages", func() {
+		It("should return the original messages", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			typeds := make([]pack.Value, numTrials)
+			for i := range typeds {
+				t := pack.GenerateTypeFromKind(r, 10, pack.KindStruct, pack.GenerateMaxDepth)
+				typeds[i] = pack.Typed(pack.GenerateFromType(r, 10, t).(pack.Struct))
+			}
+
+			w, rd := pipe()
+			defer w.Close()
+			defer rd.Close()
+			errs := writeAsync(w, typeds...)
+			for i := range typeds {
+				msg, err := rd.ReadMessage()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(msg).To(BeAssignableToTypeOf(pack.Typed{}))
+				Expect(msg.Type().Equals(typeds[i].Type())).To(BeTrue())
+				Expect(surge.ToBinary(msg)).To(Equal(mustMarshal(typeds[i])))
+			}
+			Expect(<-errs).ToNot(HaveOccurred())
+
+			Expect(w.Close()).To(Succeed())
+			_, err := rd.ReadMessage()
+			Expect(err).To(Equal(io.EOF))
+		})
+	})
+
+	Context("when writing and then reading messages with type hashes", func() {
+		It("should return the original messages if the type is registered", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < numTrials; trial++ {
+				t := pack.GenerateType(r, 10, pack.GenerateMaxDepth)
+				v := pack.GenerateFromType(r, 10, t)
+				if _, ok := v.(pack.Typed); ok {
+					continue
+				}
+
+				w, rd := pipe()
+				rd.RegisterType(t)
+				errs := writeAsync(w, v)
+				msg, err := rd.ReadMessage()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(msg.Type().Equals(t)).To(BeTrue())
+				Expect(surge.ToBinary(msg)).To(Equal(mustMarshal(v)))
+				Expect(<-errs).ToNot(HaveOccurred())
+				w.Close()
+				rd.Close()
+			}
+		})
+
+		It("should return an error if the type is not registered", func() {
+			w, rd := pipe()
+			defer w.Close()
+			defer rd.Close()
+			rd.RegisterType(pack.TypeU64())
+			errs := writeAsync(w, pack.NewU32(42))
+			_, err := rd.ReadMessage()
+			Expect(err).To(HaveOccurred())
+			Expect(<-errs).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("when the read deadline has passed", func() {
+		It("should return an error", func() {
+			w, rd := pipe()
+			defer w.Close()
+			defer rd.Close()
+			Expect(rd.SetReadDeadline(time.Now())).To(Succeed())
+			_, err := rd.ReadMessage()
+			Expect(err).To(HaveOccurred())
+			Expect(rd.LocalAddr()).ToNot(BeNil())
+			Expect(rd.RemoteAddr()).ToNot(BeNil())
+		})
+	})
+
+	Context("when writing messages that are too large", func() {
+		It("should return an error", func() {
+			w, rd := pipe()
+			defer w.Close()
+			defer rd.Close()
+			w.SetMaxFrameSize(64)
+			Expect(w.WriteMessage(pack.NewBytes(make([]byte, 100)))).ToNot(Succeed())
+		})
+	})
+
+	Context("when reading frames that are too large", func() {
+		It("should return an error without reading the payload", func() {
+			c1, c2 := net.Pipe()
+			defer c1.Close()
+			defer c2.Close()
+			rd := pack.NewMessageConn(c2)
+			rd.SetMaxFrameSize(64)
+			go func() {
+				var header [8]byte
+				binary.BigEndian.PutUint32(header[0:4], 1<<31)
+				c1.Write(header[:])
+			}()
+			_, err := rd.ReadMessage()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when reading truncated frames", func() {
+		It("should return an error that wraps io.ErrUnexpectedEOF", func() {
+			for _, frame := range [][]byte{
+				{0, 0, 0},
+				{0, 0, 0, 16, 0, 0, 0, 0, 1, 2, 3, 4},
+			} {
+				c1, c2 := net.Pipe()
+				rd := pack.NewMessageConn(c2)
+				go func(frame []byte) {
+					c1.Write(frame)
+					c1.Close()
+				}(frame)
+				_, err := rd.ReadMessage()
+				Expect(errors.Is(err, io.ErrUnexpectedEOF)).To(BeTrue())
+				c2.Close()
+			}
+		})
+	})
+
+	Context("when reading frames with a bad checksum", func() {
+		It("should return an error", func() {
+			c1, c2 := net.Pipe()
+			defer c1.Close()
+			defer c2.Close()
+			rd := pack.NewMessageConn(c2)
+
+			payload := []byte{2}
+			hash := pack.TypeHash(pack.TypeU8())
+			payload = append(payload, hash[:]...)
+			payload = append(payload, 42)
+			rd.RegisterType(pack.TypeU8())
+
+			writeRawAsync(c1, payload, crc32.Checksum(payload, crc32.MakeTable(crc32.Castagnoli))+1)
+			_, err := rd.ReadMessage()
+			Expect(err).To(HaveOccurred())
+
+			writeRawAsync(c1, payload, crc32.Checksum(payload, crc32.MakeTable(crc32.Castagnoli)))
+			msg, err := rd.ReadMessage()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(msg).To(Equal(pack.NewU8(42)))
+		})
+	})
+
+	Context("when reading malformed frames", func() {
+		It("should return an error", func() {
+			c1, c2 := net.Pipe()
+			defer c1.Close()
+			defer c2.Close()
+			rd := pack.NewMessageConn(c2)
+			rd.RegisterType(pack.TypeU8())
+			hash := pack.TypeHash(pack.TypeU8())
+			table := crc32.MakeTable(crc32.Castagnoli)
+
+			for _, payload := range [][]byte{
+				{},
+				{3},
+				{2, 1, 2, 3},
+				append(append([]byte{2}, hash[:]...), 42, 43),
+				append([]byte{1}, 99),
+			} {
+				writeRawAsync(c1, payload, crc32.Checksum(payload, table))
+				_, err := rd.ReadMessage()
+				Expect(err).To(HaveOccurred())
+			}
+		})
+	})
+})
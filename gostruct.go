@@ -0,0 +1,377 @@
+package pack
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GoStructs returns the source of a Go file, in the named package, that
+// declares a Go type with the given name for values of the type t. Values can
+// be decoded into the Go type using Decode, and encoded back into identical
+// values using Encode. Struct types are declared as Go structs, with json tags
+// that name their fields (or pack tags, for names that cannot be used in json
+// tags), and nested struct types are named by appending the name of their
+// field (e.g. the "fills" field of Order is declared as OrderFills). Other
+// types are declared as follows:
+//
+//  bool, u8-u64, i8-i64, string:        bool, uint8-uint64, int8-int64, string
+//  u128, u256, i128, i256:               pack.U128, pack.U256, pack.I128, pack.I256
+//  bytes, bytes32, bytes65, bytesn<N>:   []byte, [32]byte, [65]byte, [N]byte
+//  list<T>, optional<T>, map<K, V>:      []T, *T, map[K]V
+//  tuple<T, T>:                          [2]T
+//  list<u8>, tuple<u8, u8>:              []pack.U8, [2]pack.U8
+//  tuple<T, U>:                          a Go struct with a tuple tag
+//  union { ... }:                        pack.Union
+//
+// Maps with keys that cannot be compared in Go (or cannot be compared by their
+// value, like big integers) are declared as pack.Map, and tuples with elements
+// of different types that are not struct fields are declared as pack.Tuple.
+// The types of empty values of these pack types cannot be inferred by Encode.
+// An error is returned if a name cannot be used in a tag.
+func GoStructs(t Type, pkg, name string) (string, error) {
+	if !isGoIdent(pkg) {
+		return "", fmt.Errorf("invalid package name %q", pkg)
+	}
+	if !isGoIdent(name) {
+		return "", fmt.Errorf("invalid type name %q", name)
+	}
+	g := goStructsGen{names: map[string]bool{name: true}}
+	if err := g.declare(t, name); err != nil {
+		return "", err
+	}
+
+	builder := new(strings.Builder)
+	fmt.Fprintf(builder, "package %v\n", pkg)
+	if g.usesPack {
+		builder.WriteString("\nimport \"github.com/renproject/pack\"\n")
+	}
+	for _, decl := range g.decls {
+		builder.WriteString("\n")
+		builder.WriteString(decl)
+	}
+	return builder.String(), nil
+}
+
+// goStructsGen holds the state used by GoStructs.
+type goStructsGen struct {
+	// decls are the Go type declarations, in order.
+	decls []string
+	// names are the Go type names that have been used.
+	names map[string]bool
+	// usesPack is true when the declarations refer to the pack package.
+	usesPack bool
+}
+
+// declare a Go type with the given name for the type t. The name must already
+// be reserved.
+func (g *goStructsGen) declare(t Type, name string) error {
+	switch t := t.(type) {
+	case typeStruct:
+		return g.declareStruct(name, t, false)
+	case typeTuple:
+		if _, ok := homogeneous(t); !ok {
+			// Top-level tuples are not struct fields, so they cannot be
+			// tagged as tuples.
+			g.usesPack = true
+			g.decls = append(g.decls, fmt.Sprintf("type %v = pack.Tuple\n", name))
+			return nil
+		}
+	}
+	// Reserve the position of the declaration, so that it is declared before
+	// the structs of its elements.
+	i := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	expr, _, err := g.typeExpr(t, name+"Elem", false)
+	if err != nil {
+		return err
+	}
+	op := " "
+	if strings.HasPrefix(expr, "pack.") {
+		// Defined types do not have the methods of pack values, so pack
+		// values are aliased.
+		op = " = "
+	}
+	g.decls[i] = "type " + name + op + expr + "\n"
+	return nil
+}
+
+// declareStruct declares a Go struct for the fields of a struct type, or for
+// the elements of a tuple type.
+func (g *goStructsGen) declareStruct(name string, fields []typeStructField, isTuple bool) error {
+	// Reserve the position of the declaration, so that outer structs are
+	// declared before the structs of their fields.
+	i := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	goNames := map[string]bool{}
+	lines := make([][3]string, len(fields))
+	for j, field := range fields {
+		goName := goFieldName(field.Name)
+		if isTuple {
+			goName = fmt.Sprintf("Elem%d", j)
+		}
+		for k := 2; goNames[goName]; k++ {
+			goName = fmt.Sprintf("%v%d", goFieldName(field.Name), k)
+		}
+		goNames[goName] = true
+
+		expr, tuple, err := g.typeExpr(field.Type, name+goName, true)
+		if err != nil {
+			return fmt.Errorf("field %q: %v", field.Name, err)
+		}
+		tag, err := goTag(field.Name, goName, isTuple, tuple)
+		if err != nil {
+			return fmt.Errorf("field %q: %v", field.Name, err)
+		}
+		lines[j] = [3]string{goName, expr, tag}
+	}
+
+	// Align the columns in the same way as gofmt. Names are aligned across
+	// all fields, and types are aligned across consecutive fields with tags.
+	nameWidth := 0
+	for _, line := range lines {
+		if n := utf8.RuneCountInString(line[0]); n > nameWidth {
+			nameWidth = n
+		}
+	}
+	typeWidths := make([]int, len(lines))
+	for j := 0; j < len(lines); {
+		k, width := j, 0
+		for ; k < len(lines) && lines[k][2] != ""; k++ {
+			if n := utf8.RuneCountInString(lines[k][1]); n > width {
+				width = n
+			}
+		}
+		for ; j < k; j++ {
+			typeWidths[j] = width
+		}
+		j++
+	}
+	builder := new(strings.Builder)
+	if len(lines) == 0 {
+		fmt.Fprintf(builder, "type %v struct{}\n", name)
+	} else {
+		fmt.Fprintf(builder, "type %v struct {\n", name)
+		for j, line := range lines {
+			builder.WriteString("\t" + line[0] + strings.Repeat(" ", nameWidth-utf8.RuneCountInString(line[0])+1) + line[1])
+			if line[2] != "" {
+				builder.WriteString(strings.Repeat(" ", typeWidths[j]-utf8.RuneCountInString(line[1])+1) + line[2])
+			}
+			builder.WriteString("\n")
+		}
+		builder.WriteString("}\n")
+	}
+	g.decls[i] = builder.String()
+	return nil
+}
+
+// typeExpr returns the Go type expression for the type t. Structs are declared
+// with the given name (or the name with a numeric suffix, if it is already
+// used). When isField is true, tuples with elements of different types are
+// declared as structs, and typeExpr returns true to indicate that the field
+// must be tagged as a tuple.
+func (g *goStructsGen) typeExpr(t Type, name string, isField bool) (string, bool, error) {
+	switch t := t.(type) {
+	case typeStruct:
+		name = g.reserve(name)
+		return name, false, g.declareStruct(name, t, false)
+	case typeList:
+		elem, err := g.elemExpr(t.Type, name)
+		return "[]" + elem, false, err
+	case typeOptional:
+		elem, _, err := g.typeExpr(t.Type, name, false)
+		return "*" + elem, false, err
+	case typeMap:
+		if !isGoComparable(t.Key) {
+			g.usesPack = true
+			return "pack.Map", false, nil
+		}
+		key, _, err := g.typeExpr(t.Key, name+"Key", false)
+		if err != nil {
+			return "", false, err
+		}
+		value, _, err := g.typeExpr(t.Value, name, false)
+		return "map[" + key + "]" + value, false, err
+	case typeTuple:
+		if elemType, ok := homogeneous(t); ok {
+			elem, err := g.elemExpr(elemType, name)
+			return fmt.Sprintf("[%d]%v", len(t), elem), false, err
+		}
+		if !isField {
+			g.usesPack = true
+			return "pack.Tuple", false, nil
+		}
+		fields := make([]typeStructField, len(t))
+		for i, elemType := range t {
+			fields[i] = typeStructField{Type: elemType}
+		}
+		name = g.reserve(name)
+		return name, true, g.declareStruct(name, fields, true)
+	case typeBytesN:
+		return fmt.Sprintf("[%d]byte", t.N), false, nil
+	}
+
+	switch t.Kind() {
+	case KindBool:
+		return "bool", false, nil
+	case KindU8, KindU16, KindU32, KindU64:
+		return "uint" + strings.TrimPrefix(t.Kind().String(), "u"), false, nil
+	case KindI8, KindI16, KindI32, KindI64:
+		return "int" + strings.TrimPrefix(t.Kind().String(), "i"), false, nil
+	case KindU128, KindU256, KindI128, KindI256, KindUnion:
+		g.usesPack = true
+		return "pack." + strings.Title(t.Kind().String()), false, nil
+	case KindString:
+		return "string", false, nil
+	case KindBytes:
+		return "[]byte", false, nil
+	case KindBytes32:
+		return "[32]byte", false, nil
+	case KindBytes65:
+		return "[65]byte", false, nil
+	}
+	return "", false, fmt.Errorf("unsupported type %v", t)
+}
+
+// elemExpr returns the Go type expression for the elements of lists, and
+// tuples, of the type t. Elements of kind u8 are declared as pack.U8, because
+// slices and arrays of uint8 are encoded as bytes.
+func (g *goStructsGen) elemExpr(t Type, name string) (string, error) {
+	if t.Kind() == KindU8 {
+		g.usesPack = true
+		return "pack.U8", nil
+	}
+	elem, _, err := g.typeExpr(t, name, false)
+	return elem, err
+}
+
+// reserve returns the name, or the name with a numeric suffix if the name is
+// already used, and marks it as used.
+func (g *goStructsGen) reserve(name string) string {
+	reserved := name
+	for i := 2; g.names[reserved]; i++ {
+		reserved = fmt.Sprintf("%v%d", name, i)
+	}
+	g.names[reserved] = true
+	return reserved
+}
+
+// homogeneous returns the type of the elements of a tuple, and true, if there
+// is at least one element and all elements are of the same type. Otherwise,
+// it returns false.
+func homogeneous(t typeTuple) (Type, bool) {
+	if len(t) == 0 {
+		return nil, false
+	}
+	for _, elemType := range t[1:] {
+		if !elemType.Equals(t[0]) {
+			return nil, false
+		}
+	}
+	return t[0], true
+}
+
+// isGoComparable returns true if the Go type declared for the type t can be
+// used as a Go map key, and Go values are equal when their pack values are
+// equal.
+func isGoComparable(t Type) bool {
+	switch t := t.(type) {
+	case typeStruct:
+		for _, field := range t {
+			if !isGoComparable(field.Type) {
+				return false
+			}
+		}
+		return true
+	case typeTuple:
+		elemType, ok := homogeneous(t)
+		return ok && isGoComparable(elemType)
+	}
+	switch t.Kind() {
+	case KindBool, KindU8, KindU16, KindU32, KindU64, KindI8, KindI16, KindI32, KindI64,
+		KindString, KindBytes32, KindBytes65, KindBytesN:
+		return true
+	}
+	return false
+}
+
+// goTag returns the tag for a Go struct field that has the given pack name.
+// Elements of tuples are not named, but fields that are not named by their tags
+// are not encoded, so elements are named after their Go struct field. The names
+// "-" and "omitempty" have other meanings in json tags, so they are used in
+// pack tags instead.
+func goTag(name, goName string, isTuple, tuple bool) (string, error) {
+	options := ""
+	if tuple {
+		options = ",tuple"
+	}
+	if isTuple {
+		name = strings.ToLower(goName)
+	}
+	if name == "" || strings.ContainsAny(name, ",`") {
+		return "", fmt.Errorf("cannot use name %q in a tag", name)
+	}
+	key := "json"
+	if name == "-" || name == "omitempty" {
+		key = "pack"
+	}
+	if name == "-" && !tuple {
+		// A name of "-" means that the field is ignored, unless it is
+		// followed by a comma.
+		options = ","
+	}
+	quoted := strconv.Quote(name + options)
+	return "`" + key + ":" + quoted + "`", nil
+}
+
+// goFieldName returns an exported Go identifier for a pack name. Characters
+// that cannot be used in an identifier separate words, and the first letter of
+// each word is capitalised (e.g. "to_addr" becomes "ToAddr").
+func goFieldName(name string) string {
+	builder := new(strings.Builder)
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		builder.WriteRune(r)
+	}
+	goName := builder.String()
+	if goName == "" {
+		return "Field"
+	}
+	if first := []rune(goName)[0]; !unicode.IsUpper(first) {
+		// Identifiers that start with a digit, or a letter that has no upper
+		// case, cannot be exported without a prefix.
+		return "X" + goName
+	}
+	return goName
+}
+
+// isGoIdent returns true if the name is a Go identifier that is not a keyword.
+func isGoIdent(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	switch name {
+	case "break", "case", "chan", "const", "continue", "default", "defer", "else",
+		"fallthrough", "for", "func", "go", "goto", "if", "import", "interface",
+		"map", "package", "range", "return", "select", "struct", "switch", "type", "var":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,357 @@
+package pack_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/renproject/pack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const goStructsSchema = `struct {
+	id: bytes32,
+	amount: u256,
+	to_addr: bytesn<20>,
+	memo: optional<string>,
+	fills: list<struct { price: u64, qty: u32 }>,
+	pair: tuple<u64, string>,
+	point: tuple<i16, i16>,
+	fees: map<string, u64>,
+	keyed: map<struct { a: u8 }, struct { b: i8 }>,
+	"-": bool,
+	empty: struct {},
+	nested: tuple<tuple<u8, bool>, string>,
+	octets: list<u8>,
+	quad: tuple<u8, u8, u8, u8>,
+}`
+
+const goStructsSource = `package orders
+
+import "github.com/renproject/pack"
+
+type Order struct {
+	Id     [32]byte                     ` + "`json:\"id\"`" + `
+	Amount pack.U256                    ` + "`json:\"amount\"`" + `
+	ToAddr [20]byte                     ` + "`json:\"to_addr\"`" + `
+	Memo   *string                      ` + "`json:\"memo\"`" + `
+	Fills  []OrderFills                 ` + "`json:\"fills\"`" + `
+	Pair   OrderPair                    ` + "`json:\"pair,tuple\"`" + `
+	Point  [2]int16                     ` + "`json:\"point\"`" + `
+	Fees   map[string]uint64            ` + "`json:\"fees\"`" + `
+	Keyed  map[OrderKeyedKey]OrderKeyed ` + "`json:\"keyed\"`" + `
+	Field  bool                         ` + "`pack:\"-,\"`" + `
+	Empty  OrderEmpty                   ` + "`json:\"empty\"`" + `
+	Nested OrderNested                  ` + "`json:\"nested,tuple\"`" + `
+	Octets []pack.U8                    ` + "`json:\"octets\"`" + `
+	Quad   [4]pack.U8                   ` + "`json:\"quad\"`" + `
+}
+
+type OrderFills struct {
+	Price uint64 ` + "`json:\"price\"`" + `
+	Qty   uint32 ` + "`json:\"qty\"`" + `
+}
+
+type OrderPair struct {
+	Elem0 uint64 ` + "`json:\"elem0\"`" + `
+	Elem1 string ` + "`json:\"elem1\"`" + `
+}
+
+type OrderKeyedKey struct {
+	A uint8 ` + "`json:\"a\"`" + `
+}
+
+type OrderKeyed struct {
+	B int8 ` + "`json:\"b\"`" + `
+}
+
+type OrderEmpty struct{}
+
+type OrderNested struct {
+	Elem0 OrderNestedElem0 ` + "`json:\"elem0,tuple\"`" + `
+	Elem1 string           ` + "`json:\"elem1\"`" + `
+}
+
+type OrderNestedElem0 struct {
+	Elem0 uint8 ` + "`json:\"elem0\"`" + `
+	Elem1 bool  ` + "`json:\"elem1\"`" + `
+}
+`
+
+// The declarations below are the same as goStructsSource, so that values can
+// be decoded into them.
+
+type Order struct {
+	Id     [32]byte                     `json:"id"`
+	Amount pack.U256                    `json:"amount"`
+	ToAddr [20]byte                     `json:"to_addr"`
+	Memo   *string                      `json:"memo"`
+	Fills  []OrderFills                 `json:"fills"`
+	Pair   OrderPair                    `json:"pair,tuple"`
+	Point  [2]int16                     `json:"point"`
+	Fees   map[string]uint64            `json:"fees"`
+	Keyed  map[OrderKeyedKey]OrderKeyed `json:"keyed"`
+	Field  bool                         `pack:"-,"`
+	Empty  OrderEmpty                   `json:"empty"`
+	Nested OrderNested                  `json:"nested,tuple"`
+	Octets []pack.U8                    `json:"octets"`
+	Quad   [4]pack.U8                   `json:"quad"`
+}
+
+type OrderFills struct {
+	Price uint64 `json:"price"`
+	Qty   uint32 `json:"qty"`
+}
+
+type OrderPair struct {
+	Elem0 uint64 `json:"elem0"`
+	Elem1 string `json:"elem1"`
+}
+
+type OrderKeyedKey struct {
+	A uint8 `json:"a"`
+}
+
+type OrderKeyed struct {
+	B int8 `json:"b"`
+}
+
+type OrderEmpty struct{}
+
+type OrderNested struct {
+	Elem0 OrderNestedElem0 `json:"elem0,tuple"`
+	Elem1 string           `json:"elem1"`
+}
+
+type OrderNestedElem0 struct {
+	Elem0 uint8 `json:"elem0"`
+	Elem1 bool  `json:"elem1"`
+}
+
+// goStructsSupported returns true if values of the type can be decoded into the
+// Go type declared by GoStructs, and encoded back into identical values. This is
+// not true for types that are declared using pack.Map, pack.Tuple, or
+// pack.Union, because the types of their empty values cannot be inferred.
+func goStructsSupported(t pack.Type) bool {
+	switch t.Kind() {
+	case pack.KindStruct:
+		for _, name := range pack.FieldNames(t) {
+			if !goStructsSupported(pack.FieldType(t, name)) {
+				return false
+			}
+		}
+		return true
+	case pack.KindList, pack.KindOptional:
+		return goStructsSupported(pack.ElemType(t))
+	case pack.KindTuple:
+		elems := pack.ElemTypes(t)
+		for _, elem := range elems {
+			if !elem.Equals(elems[0]) || !goStructsSupported(elem) {
+				return false
+			}
+		}
+		return len(elems) > 0
+	case pack.KindMap, pack.KindUnion:
+		return false
+	default:
+		return true
+	}
+}
+
+// goStructsMain is the source of a program that decodes values into the Go
+// types declared by GoStructs, encodes them back, and exits with an error if
+// they are not identical.
+const goStructsMain = `package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/surge"
+%v)
+
+func main() {
+	roundTrips := []func(pack.Value) (pack.Value, error){%v}
+	schemas := []string{%v}
+	values := []string{%v}
+	for i, roundTrip := range roundTrips {
+		t, err := pack.ParseType(schemas[i])
+		if err != nil {
+			panic(err)
+		}
+		v, err := t.UnmarshalValueJSON([]byte(values[i]))
+		if err != nil {
+			panic(err)
+		}
+		w, err := roundTrip(v)
+		if err != nil {
+			fmt.Printf("%%v: %%v\n", schemas[i], err)
+			os.Exit(1)
+		}
+		vData, _ := surge.ToBinary(v)
+		wData, _ := surge.ToBinary(w)
+		if !w.Type().Equals(t) || !bytes.Equal(vData, wData) {
+			fmt.Printf("%%v: expected %%v, got %%v\n", schemas[i], v, w)
+			os.Exit(1)
+		}
+	}
+}
+`
+
+var _ = Describe("Go structs", func() {
+
+	numTrials := 100
+
+	Context("when generating Go structs for a type", func() {
+		It("should return formatted source", func() {
+			t, err := pack.ParseType(goStructsSchema)
+			Expect(err).ToNot(HaveOccurred())
+			src, err := pack.GoStructs(t, "orders", "Order")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(src).To(Equal(goStructsSource))
+
+			formatted, err := format.Source([]byte(src))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(formatted)).To(Equal(src))
+		})
+
+		It("should decode and encode values of the type", func() {
+			t, err := pack.ParseType(goStructsSchema)
+			Expect(err).ToNot(HaveOccurred())
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < numTrials; trial++ {
+				v := pack.GenerateFromType(r, 10, t)
+				order := Order{}
+				Expect(pack.Decode(&order, v)).To(Succeed())
+				w, err := pack.Encode(order)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(w.Type().Equals(t)).To(BeTrue())
+				Expect(w).To(Equal(v))
+			}
+		})
+	})
+
+	Context("when generating Go structs for random types", func() {
+		It("should decode and encode values of the types", func() {
+			if _, err := exec.LookPath("go"); err != nil {
+				Skip("go is not installed")
+			}
+			// The packages are written inside the module, so that they can
+			// import it.
+			dir, err := ioutil.TempDir(".", "gostructs")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			imports, roundTrips, schemas, values := "", "", "", ""
+			for i := 0; i < numTrials; i++ {
+				t := pack.GenerateTypeFromKind(r, 10, pack.KindStruct, pack.GenerateMaxDepth)
+				if !goStructsSupported(t) {
+					continue
+				}
+				pkg := fmt.Sprintf("p%d", i)
+				src, err := pack.GoStructs(t, pkg, "T")
+				if err != nil {
+					// Random names cannot always be used in tags.
+					continue
+				}
+				Expect(os.Mkdir(filepath.Join(dir, pkg), 0755)).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(dir, pkg, "t.go"), []byte(src), 0644)).To(Succeed())
+
+				data, err := json.Marshal(pack.GenerateFromType(r, 10, t))
+				Expect(err).ToNot(HaveOccurred())
+				imports += fmt.Sprintf("\t%q\n", "github.com/renproject/pack/"+filepath.ToSlash(filepath.Join(dir, pkg)))
+				roundTrips += fmt.Sprintf("func(v pack.Value) (pack.Value, error) { x := %v.T{}; if err := pack.Decode(&x, v); err != nil { return nil, err }; return pack.Encode(x) }, ", pkg)
+				schemas += strconv.Quote(pack.FormatType(t)) + ", "
+				values += strconv.Quote(string(data)) + ", "
+			}
+			main := fmt.Sprintf(goStructsMain, imports, roundTrips, schemas, values)
+			Expect(ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0644)).To(Succeed())
+
+			out, err := exec.Command("go", "run", "./"+filepath.ToSlash(dir)).CombinedOutput()
+			Expect(err).ToNot(HaveOccurred(), strings.TrimSpace(string(out)))
+		})
+	})
+
+	Context("when generating Go structs for types that are not structs", func() {
+		It("should declare the type", func() {
+			for schema, decl := range map[string]string{
+				"u64":                     "type T uint64\n",
+				"list<bytesn<4>>":         "type T [][4]byte\n",
+				"tuple<bool, bool>":       "type T [2]bool\n",
+				"map<bytes, string>":      "type T = pack.Map\n",
+				"tuple<bool, string>":     "type T = pack.Tuple\n",
+				"union { a: u8, b: i8 }":  "type T = pack.Union\n",
+				"optional<struct {}>":     "type T *TElem\n\ntype TElem struct{}\n",
+				"list<tuple<u8, string>>": "type T []pack.Tuple\n",
+				"list<u8>":                "type T []pack.U8\n",
+				"tuple<u8, u8>":           "type T [2]pack.U8\n",
+			} {
+				t, err := pack.ParseType(schema)
+				Expect(err).ToNot(HaveOccurred())
+				src, err := pack.GoStructs(t, "p", "T")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(src).To(HaveSuffix("\n" + decl))
+
+				formatted, err := format.Source([]byte(src))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(formatted)).To(Equal(src))
+			}
+		})
+	})
+
+	Context("when field names collide", func() {
+		It("should rename the fields", func() {
+			t := pack.StructType(
+				"a_b", pack.TypeU8(),
+				"aB", pack.TypeU8(),
+				"", pack.TypeU8(),
+			)
+			_, err := pack.GoStructs(t, "p", "T")
+			Expect(err).To(HaveOccurred())
+
+			t = pack.StructType(
+				"a_b", pack.TypeU8(),
+				"aB", pack.TypeU8(),
+				"0x", pack.TypeU8(),
+				"Ünïcödé", pack.TypeU8(),
+			)
+			src, err := pack.GoStructs(t, "p", "T")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(src).To(Equal("package p\n\ntype T struct {\n" +
+				"\tAB      uint8 `json:\"a_b\"`\n" +
+				"\tAB2     uint8 `json:\"aB\"`\n" +
+				"\tX0x     uint8 `json:\"0x\"`\n" +
+				"\tÜnïcödé uint8 `json:\"Ünïcödé\"`\n" +
+				"}\n"))
+
+			formatted, err := format.Source([]byte(src))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(formatted)).To(Equal(src))
+		})
+	})
+
+	Context("when a name cannot be used", func() {
+		It("should return an error", func() {
+			_, err := pack.GoStructs(pack.TypeU8(), "package", "T")
+			Expect(err).To(HaveOccurred())
+			_, err = pack.GoStructs(pack.TypeU8(), "p", "1T")
+			Expect(err).To(HaveOccurred())
+			_, err = pack.GoStructs(pack.StructType("a,b", pack.TypeU8()), "p", "T")
+			Expect(err).To(HaveOccurred())
+			_, err = pack.GoStructs(pack.StructType("a`b", pack.TypeU8()), "p", "T")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
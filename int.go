@@ -982,6 +982,1031 @@ func (u256 U256) String() string {
 	return u256.inner.Text(10)
 }
 
+// I8 represents a 8-bit signed integer.
+type I8 int8
+
+// NewI8 returns an int8 wrapped as an I8.
+func NewI8(x int8) I8 {
+	return I8(x)
+}
+
+// Type returns the type of this value.
+func (I8) Type() Type {
+	return typeI8{}
+}
+
+// Int8 returns the inner int8.
+func (i8 I8) Int8() int8 {
+	return int8(i8)
+}
+
+// Add one I8 to another and return the result.
+func (i8 I8) Add(other I8) I8 {
+	ret := i8 + other
+	if other > 0 && ret < i8 {
+		panic("overflow")
+	}
+	if other < 0 && ret > i8 {
+		panic("underflow")
+	}
+	return ret
+}
+
+// Sub one I8 from another and return the result.
+func (i8 I8) Sub(other I8) I8 {
+	ret := i8 - other
+	if other < 0 && ret < i8 {
+		panic("overflow")
+	}
+	if other > 0 && ret > i8 {
+		panic("underflow")
+	}
+	return ret
+}
+
+// Mul one I8 by another and return the result.
+func (i8 I8) Mul(other I8) I8 {
+	if i8 == 0 || other == 0 {
+		return 0
+	}
+	ret := i8 * other
+	if ret/other != i8 || (i8 == -1 && other == MinI8) || (other == -1 && i8 == MinI8) {
+		if (i8 < 0) == (other < 0) {
+			panic("overflow")
+		}
+		panic("underflow")
+	}
+	return ret
+}
+
+// Div one I8 by another and return the result. Division truncates towards
+// zero. It will panic if the divisor is zero.
+func (i8 I8) Div(other I8) I8 {
+	if i8 == MinI8 && other == -1 {
+		panic("overflow")
+	}
+	return i8 / other
+}
+
+// Neg returns the negation of the I8.
+func (i8 I8) Neg() I8 {
+	if i8 == MinI8 {
+		panic("overflow")
+	}
+	return -i8
+}
+
+// AddAssign will add one I8 to another and assign the result to the
+// left-hand side.
+func (i8 *I8) AddAssign(other I8) {
+	*i8 = i8.Add(other)
+}
+
+// SubAssign will sub one I8 from another and assign the result to the
+// left-hand side.
+func (i8 *I8) SubAssign(other I8) {
+	*i8 = i8.Sub(other)
+}
+
+// Equal compares one I8 to another. If they are equal, then it returns true.
+// Otherwise, it returns false.
+func (i8 I8) Equal(other I8) bool {
+	return i8 == other
+}
+
+// SizeHint returns the number of bytes required to represent an I8 in binary.
+func (i8 I8) SizeHint() int {
+	return 1
+}
+
+// Marshal the I8 to binary. It is marshaled using its big-endian two's
+// complement representation.
+func (i8 I8) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	return surge.MarshalI8(int8(i8), buf, rem)
+}
+
+// Unmarshal the I8 from binary.
+func (i8 *I8) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	return surge.UnmarshalI8((*int8)(i8), buf, rem)
+}
+
+// MarshalJSON implements the JSON marshaler interface. I8s are marshaled as
+// decimal strings (for consistency with larger integer types).
+func (i8 I8) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i8.String())
+}
+
+// UnmarshalJSON implements the JSON unmarshaler interface. I8s are
+// unmarshaled as decimal strings (for consistency with larger integer types).
+func (i8 *I8) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	x, err := strconv.ParseInt(str, 10, 8)
+	if err != nil {
+		return err
+	}
+	*i8 = I8(x)
+	return nil
+}
+
+func (i8 I8) String() string {
+	return fmt.Sprintf("%v", int8(i8))
+}
+
+// I16 represents a 16-bit signed integer.
+type I16 int16
+
+// NewI16 returns an int16 wrapped as an I16.
+func NewI16(x int16) I16 {
+	return I16(x)
+}
+
+// NewI16FromI8 returns a int16 wrapped as an I16.
+func NewI16FromI8(x I8) I16 {
+	return I16(int16(x.Int8()))
+}
+
+// Type returns the type of this value.
+func (I16) Type() Type {
+	return typeI16{}
+}
+
+// Int16 returns the inner int16.
+func (i16 I16) Int16() int16 {
+	return int16(i16)
+}
+
+// Add one I16 to another and return the result.
+func (i16 I16) Add(other I16) I16 {
+	ret := i16 + other
+	if other > 0 && ret < i16 {
+		panic("overflow")
+	}
+	if other < 0 && ret > i16 {
+		panic("underflow")
+	}
+	return ret
+}
+
+// Sub one I16 from another and return the result.
+func (i16 I16) Sub(other I16) I16 {
+	ret := i16 - other
+	if other < 0 && ret < i16 {
+		panic("overflow")
+	}
+	if other > 0 && ret > i16 {
+		panic("underflow")
+	}
+	return ret
+}
+
+// Mul one I16 by another and return the result.
+func (i16 I16) Mul(other I16) I16 {
+	if i16 == 0 || other == 0 {
+		return 0
+	}
+	ret := i16 * other
+	if ret/other != i16 || (i16 == -1 && other == MinI16) || (other == -1 && i16 == MinI16) {
+		if (i16 < 0) == (other < 0) {
+			panic("overflow")
+		}
+		panic("underflow")
+	}
+	return ret
+}
+
+// Div one I16 by another and return the result. Division truncates towards
+// zero. It will panic if the divisor is zero.
+func (i16 I16) Div(other I16) I16 {
+	if i16 == MinI16 && other == -1 {
+		panic("overflow")
+	}
+	return i16 / other
+}
+
+// Neg returns the negation of the I16.
+func (i16 I16) Neg() I16 {
+	if i16 == MinI16 {
+		panic("overflow")
+	}
+	return -i16
+}
+
+// AddAssign will add one I16 to another and assign the result to the
+// left-hand side.
+func (i16 *I16) AddAssign(other I16) {
+	*i16 = i16.Add(other)
+}
+
+// SubAssign will sub one I16 from another and assign the result to the
+// left-hand side.
+func (i16 *I16) SubAssign(other I16) {
+	*i16 = i16.Sub(other)
+}
+
+// Equal compares one I16 to another. If they are equal, then it returns true.
+// Otherwise, it returns false.
+func (i16 I16) Equal(other I16) bool {
+	return i16 == other
+}
+
+// SizeHint returns the number of bytes required to represent an I16 in binary.
+func (i16 I16) SizeHint() int {
+	return 2
+}
+
+// Marshal the I16 to binary. It is marshaled using its big-endian two's
+// complement representation.
+func (i16 I16) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	return surge.MarshalI16(int16(i16), buf, rem)
+}
+
+// Unmarshal the I16 from binary.
+func (i16 *I16) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	return surge.UnmarshalI16((*int16)(i16), buf, rem)
+}
+
+// MarshalJSON implements the JSON marshaler interface. I16s are marshaled as
+// decimal strings (for consistency with larger integer types).
+func (i16 I16) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i16.String())
+}
+
+// UnmarshalJSON implements the JSON unmarshaler interface. I16s are
+// unmarshaled as decimal strings (for consistency with larger integer types).
+func (i16 *I16) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	x, err := strconv.ParseInt(str, 10, 16)
+	if err != nil {
+		return err
+	}
+	*i16 = I16(x)
+	return nil
+}
+
+func (i16 I16) String() string {
+	return fmt.Sprintf("%v", int16(i16))
+}
+
+// I32 represents a 32-bit signed integer.
+type I32 int32
+
+// NewI32 returns an int32 wrapped as an I32.
+func NewI32(x int32) I32 {
+	return I32(x)
+}
+
+// NewI32FromI8 returns a int32 wrapped as an I32.
+func NewI32FromI8(x I8) I32 {
+	return I32(int32(x.Int8()))
+}
+
+// NewI32FromI16 returns a int32 wrapped as an I32.
+func NewI32FromI16(x I16) I32 {
+	return I32(int32(x.Int16()))
+}
+
+// Type returns the type of this value.
+func (I32) Type() Type {
+	return typeI32{}
+}
+
+// Int32 returns the inner int32.
+func (i32 I32) Int32() int32 {
+	return int32(i32)
+}
+
+// Add one I32 to another and return the result.
+func (i32 I32) Add(other I32) I32 {
+	ret := i32 + other
+	if other > 0 && ret < i32 {
+		panic("overflow")
+	}
+	if other < 0 && ret > i32 {
+		panic("underflow")
+	}
+	return ret
+}
+
+// Sub one I32 from another and return the result.
+func (i32 I32) Sub(other I32) I32 {
+	ret := i32 - other
+	if other < 0 && ret < i32 {
+		panic("overflow")
+	}
+	if other > 0 && ret > i32 {
+		panic("underflow")
+	}
+	return ret
+}
+
+// Mul one I32 by another and return the result.
+func (i32 I32) Mul(other I32) I32 {
+	if i32 == 0 || other == 0 {
+		return 0
+	}
+	ret := i32 * other
+	if ret/other != i32 || (i32 == -1 && other == MinI32) || (other == -1 && i32 == MinI32) {
+		if (i32 < 0) == (other < 0) {
+			panic("overflow")
+		}
+		panic("underflow")
+	}
+	return ret
+}
+
+// Div one I32 by another and return the result. Division truncates towards
+// zero. It will panic if the divisor is zero.
+func (i32 I32) Div(other I32) I32 {
+	if i32 == MinI32 && other == -1 {
+		panic("overflow")
+	}
+	return i32 / other
+}
+
+// Neg returns the negation of the I32.
+func (i32 I32) Neg() I32 {
+	if i32 == MinI32 {
+		panic("overflow")
+	}
+	return -i32
+}
+
+// AddAssign will add one I32 to another and assign the result to the
+// left-hand side.
+func (i32 *I32) AddAssign(other I32) {
+	*i32 = i32.Add(other)
+}
+
+// SubAssign will sub one I32 from another and assign the result to the
+// left-hand side.
+func (i32 *I32) SubAssign(other I32) {
+	*i32 = i32.Sub(other)
+}
+
+// Equal compares one I32 to another. If they are equal, then it returns true.
+// Otherwise, it returns false.
+func (i32 I32) Equal(other I32) bool {
+	return i32 == other
+}
+
+// SizeHint returns the number of bytes required to represent an I32 in binary.
+func (i32 I32) SizeHint() int {
+	return 4
+}
+
+// Marshal the I32 to binary. It is marshaled using its big-endian two's
+// complement representation.
+func (i32 I32) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	return surge.MarshalI32(int32(i32), buf, rem)
+}
+
+// Unmarshal the I32 from binary.
+func (i32 *I32) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	return surge.UnmarshalI32((*int32)(i32), buf, rem)
+}
+
+// MarshalJSON implements the JSON marshaler interface. I32s are marshaled as
+// decimal strings (for consistency with larger integer types).
+func (i32 I32) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i32.String())
+}
+
+// UnmarshalJSON implements the JSON unmarshaler interface. I32s are
+// unmarshaled as decimal strings (for consistency with larger integer types).
+func (i32 *I32) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	x, err := strconv.ParseInt(str, 10, 32)
+	if err != nil {
+		return err
+	}
+	*i32 = I32(x)
+	return nil
+}
+
+func (i32 I32) String() string {
+	return fmt.Sprintf("%v", int32(i32))
+}
+
+// I64 represents a 64-bit signed integer.
+type I64 int64
+
+// NewI64 returns an int64 wrapped as an I64.
+func NewI64(x int64) I64 {
+	return I64(x)
+}
+
+// NewI64FromI8 returns a int64 wrapped as an I64.
+func NewI64FromI8(x I8) I64 {
+	return I64(int64(x.Int8()))
+}
+
+// NewI64FromI16 returns a int64 wrapped as an I64.
+func NewI64FromI16(x I16) I64 {
+	return I64(int64(x.Int16()))
+}
+
+// NewI64FromI32 returns a int64 wrapped as an I64.
+func NewI64FromI32(x I32) I64 {
+	return I64(int64(x.Int32()))
+}
+
+// Type returns the type of this value.
+func (I64) Type() Type {
+	return typeI64{}
+}
+
+// Int64 returns the inner int64.
+func (i64 I64) Int64() int64 {
+	return int64(i64)
+}
+
+// Add one I64 to another and return the result.
+func (i64 I64) Add(other I64) I64 {
+	ret := i64 + other
+	if other > 0 && ret < i64 {
+		panic("overflow")
+	}
+	if other < 0 && ret > i64 {
+		panic("underflow")
+	}
+	return ret
+}
+
+// Sub one I64 from another and return the result.
+func (i64 I64) Sub(other I64) I64 {
+	ret := i64 - other
+	if other < 0 && ret < i64 {
+		panic("overflow")
+	}
+	if other > 0 && ret > i64 {
+		panic("underflow")
+	}
+	return ret
+}
+
+// Mul one I64 by another and return the result.
+func (i64 I64) Mul(other I64) I64 {
+	if i64 == 0 || other == 0 {
+		return 0
+	}
+	ret := i64 * other
+	if ret/other != i64 || (i64 == -1 && other == MinI64) || (other == -1 && i64 == MinI64) {
+		if (i64 < 0) == (other < 0) {
+			panic("overflow")
+		}
+		panic("underflow")
+	}
+	return ret
+}
+
+// Div one I64 by another and return the result. Division truncates towards
+// zero. It will panic if the divisor is zero.
+func (i64 I64) Div(other I64) I64 {
+	if i64 == MinI64 && other == -1 {
+		panic("overflow")
+	}
+	return i64 / other
+}
+
+// Neg returns the negation of the I64.
+func (i64 I64) Neg() I64 {
+	if i64 == MinI64 {
+		panic("overflow")
+	}
+	return -i64
+}
+
+// AddAssign will add one I64 to another and assign the result to the
+// left-hand side.
+func (i64 *I64) AddAssign(other I64) {
+	*i64 = i64.Add(other)
+}
+
+// SubAssign will sub one I64 from another and assign the result to the
+// left-hand side.
+func (i64 *I64) SubAssign(other I64) {
+	*i64 = i64.Sub(other)
+}
+
+// Equal compares one I64 to another. If they are equal, then it returns true.
+// Otherwise, it returns false.
+func (i64 I64) Equal(other I64) bool {
+	return i64 == other
+}
+
+// SizeHint returns the number of bytes required to represent an I64 in binary.
+func (i64 I64) SizeHint() int {
+	return 8
+}
+
+// Marshal the I64 to binary. It is marshaled using its big-endian two's
+// complement representation.
+func (i64 I64) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	return surge.MarshalI64(int64(i64), buf, rem)
+}
+
+// Unmarshal the I64 from binary.
+func (i64 *I64) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	return surge.UnmarshalI64((*int64)(i64), buf, rem)
+}
+
+// MarshalJSON implements the JSON marshaler interface. I64s are marshaled as
+// decimal strings (for consistency with larger integer types).
+func (i64 I64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i64.String())
+}
+
+// UnmarshalJSON implements the JSON unmarshaler interface. I64s are
+// unmarshaled as decimal strings (for consistency with larger integer types).
+func (i64 *I64) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	x, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return err
+	}
+	*i64 = I64(x)
+	return nil
+}
+
+func (i64 I64) String() string {
+	return fmt.Sprintf("%v", int64(i64))
+}
+
+// I128 represents a 128-bit signed integer.
+type I128 struct {
+	inner *big.Int
+}
+
+// NewI128 returns a 16-byte big-endian two's complement integer wrapped as
+// an I128.
+func NewI128(x [16]byte) I128 {
+	return I128{inner: fromTwosComplement(x[:])}
+}
+
+// NewI128FromI8 returns an I8 wrapped as an I128.
+func NewI128FromI8(x I8) I128 {
+	return I128{inner: big.NewInt(int64(x.Int8()))}
+}
+
+// NewI128FromI16 returns an I16 wrapped as an I128.
+func NewI128FromI16(x I16) I128 {
+	return I128{inner: big.NewInt(int64(x.Int16()))}
+}
+
+// NewI128FromI32 returns an I32 wrapped as an I128.
+func NewI128FromI32(x I32) I128 {
+	return I128{inner: big.NewInt(int64(x.Int32()))}
+}
+
+// NewI128FromI64 returns an I64 wrapped as an I128.
+func NewI128FromI64(x I64) I128 {
+	return I128{inner: big.NewInt(int64(x.Int64()))}
+}
+
+// NewI128FromInt8 returns an int8 wrapped as an I128.
+func NewI128FromInt8(x int8) I128 {
+	return I128{inner: big.NewInt(int64(x))}
+}
+
+// NewI128FromInt16 returns an int16 wrapped as an I128.
+func NewI128FromInt16(x int16) I128 {
+	return I128{inner: big.NewInt(int64(x))}
+}
+
+// NewI128FromInt32 returns an int32 wrapped as an I128.
+func NewI128FromInt32(x int32) I128 {
+	return I128{inner: big.NewInt(int64(x))}
+}
+
+// NewI128FromInt64 returns an int64 wrapped as an I128.
+func NewI128FromInt64(x int64) I128 {
+	return I128{inner: big.NewInt(int64(x))}
+}
+
+// NewI128FromInt returns a big integer wrapped as an I128. It will panic if
+// the big integer cannot be represented using 128 bits.
+func NewI128FromInt(x *big.Int) I128 {
+	if x.Cmp(MinI128.inner) < 0 {
+		panic("underflow")
+	}
+	if x.Cmp(MaxI128.inner) > 0 {
+		panic("overflow")
+	}
+	return I128{inner: new(big.Int).Set(x)}
+}
+
+// Type returns the type of this value.
+func (I128) Type() Type {
+	return typeI128{}
+}
+
+// Int returns a copy of the inner big integer.
+func (i128 I128) Int() *big.Int {
+	if i128.inner == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(i128.inner)
+}
+
+// Bytes16 returns the 16-byte big-endian two's complement representation of
+// the I128.
+func (i128 I128) Bytes16() [16]byte {
+	ret := [16]byte{}
+	toTwosComplement(i128.Int(), ret[:])
+	return ret
+}
+
+// Bytes returns the 16-byte big-endian two's complement representation of the
+// I128 as a dynamic byte slice.
+func (i128 I128) Bytes() []byte {
+	bytes := i128.Bytes16()
+	return bytes[:]
+}
+
+// Add one I128 to another and return the result.
+func (i128 I128) Add(other I128) I128 {
+	return i128.checked(new(big.Int).Add(i128.Int(), other.Int()))
+}
+
+// Sub one I128 from another and return the result.
+func (i128 I128) Sub(other I128) I128 {
+	return i128.checked(new(big.Int).Sub(i128.Int(), other.Int()))
+}
+
+// Mul one I128 by another and return the result.
+func (i128 I128) Mul(other I128) I128 {
+	return i128.checked(new(big.Int).Mul(i128.Int(), other.Int()))
+}
+
+// Div one I128 by another and return the result. Division truncates towards
+// zero. It will panic if the divisor is zero.
+func (i128 I128) Div(other I128) I128 {
+	if other.Int().Sign() == 0 {
+		panic("division by zero")
+	}
+	return i128.checked(new(big.Int).Quo(i128.Int(), other.Int()))
+}
+
+// Neg returns the negation of the I128.
+func (i128 I128) Neg() I128 {
+	return i128.checked(new(big.Int).Neg(i128.Int()))
+}
+
+// AddAssign will add one I128 to another and assign the result to the
+// left-hand side.
+func (i128 *I128) AddAssign(other I128) {
+	*i128 = i128.Add(other)
+}
+
+// SubAssign will sub one I128 from another and assign the result to the
+// left-hand side.
+func (i128 *I128) SubAssign(other I128) {
+	*i128 = i128.Sub(other)
+}
+
+// Equal compares one I128 to another. If they are equal, then it returns true.
+// Otherwise, it returns false.
+func (i128 I128) Equal(other I128) bool {
+	return i128.Int().Cmp(other.Int()) == 0
+}
+
+// LessThan returns true when the I128 is strictly less than the other I128.
+func (i128 I128) LessThan(other I128) bool {
+	return i128.Int().Cmp(other.Int()) < 0
+}
+
+// LessThanEqual returns true when the I128 is less than, or equal to, the
+// other I128.
+func (i128 I128) LessThanEqual(other I128) bool {
+	return i128.Int().Cmp(other.Int()) <= 0
+}
+
+// GreaterThan returns true when the I128 is strictly greater than the other
+// I128.
+func (i128 I128) GreaterThan(other I128) bool {
+	return i128.Int().Cmp(other.Int()) > 0
+}
+
+// GreaterThanEqual returns true when the I128 is greater than, or equal to, the
+// other I128.
+func (i128 I128) GreaterThanEqual(other I128) bool {
+	return i128.Int().Cmp(other.Int()) >= 0
+}
+
+// SizeHint returns the number of bytes required to represent an I128 in
+// binary.
+func (i128 I128) SizeHint() int {
+	return 16
+}
+
+// Marshal the I128 to binary. It is marshaled using its big-endian two's
+// complement representation.
+func (i128 I128) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	if len(buf) < 16 || rem < 16 {
+		return buf, rem, surge.ErrUnexpectedEndOfBuffer
+	}
+	toTwosComplement(i128.Int(), buf[:16])
+	return buf[16:], rem - 16, nil
+}
+
+// Unmarshal the I128 from binary.
+func (i128 *I128) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	if len(buf) < 16 || rem < 16 {
+		return buf, rem, surge.ErrUnexpectedEndOfBuffer
+	}
+	i128.inner = fromTwosComplement(buf[:16])
+	return buf[16:], rem - 16, nil
+}
+
+// MarshalJSON implements the JSON marshaler interface. I128s are marshaled as
+// decimal strings.
+func (i128 I128) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i128.String())
+}
+
+// UnmarshalJSON implements the JSON unmarshaler interface. I128s are
+// unmarshaled from decimal strings.
+func (i128 *I128) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	x, ok := new(big.Int).SetString(str, 10)
+	if !ok {
+		return fmt.Errorf("malformed: %v", str)
+	}
+	if x.Cmp(MinI128.inner) < 0 {
+		return fmt.Errorf("underflow: %v", str)
+	}
+	if x.Cmp(MaxI128.inner) > 0 {
+		return fmt.Errorf("overflow: %v", str)
+	}
+	i128.inner = x
+	return nil
+}
+
+func (i128 I128) String() string {
+	return i128.Int().Text(10)
+}
+
+// checked returns the big integer wrapped as an I128. It will panic if the
+// big integer cannot be represented using 128 bits.
+func (I128) checked(x *big.Int) I128 {
+	if x.Cmp(MinI128.inner) < 0 {
+		panic("underflow")
+	}
+	if x.Cmp(MaxI128.inner) > 0 {
+		panic("overflow")
+	}
+	return I128{inner: x}
+}
+
+// I256 represents a 256-bit signed integer.
+type I256 struct {
+	inner *big.Int
+}
+
+// NewI256 returns a 32-byte big-endian two's complement integer wrapped as
+// an I256.
+func NewI256(x [32]byte) I256 {
+	return I256{inner: fromTwosComplement(x[:])}
+}
+
+// NewI256FromI8 returns an I8 wrapped as an I256.
+func NewI256FromI8(x I8) I256 {
+	return I256{inner: big.NewInt(int64(x.Int8()))}
+}
+
+// NewI256FromI16 returns an I16 wrapped as an I256.
+func NewI256FromI16(x I16) I256 {
+	return I256{inner: big.NewInt(int64(x.Int16()))}
+}
+
+// NewI256FromI32 returns an I32 wrapped as an I256.
+func NewI256FromI32(x I32) I256 {
+	return I256{inner: big.NewInt(int64(x.Int32()))}
+}
+
+// NewI256FromI64 returns an I64 wrapped as an I256.
+func NewI256FromI64(x I64) I256 {
+	return I256{inner: big.NewInt(int64(x.Int64()))}
+}
+
+// NewI256FromI128 returns an I128 wrapped as an I256.
+func NewI256FromI128(x I128) I256 {
+	return I256{inner: x.Int()}
+}
+
+// NewI256FromInt8 returns an int8 wrapped as an I256.
+func NewI256FromInt8(x int8) I256 {
+	return I256{inner: big.NewInt(int64(x))}
+}
+
+// NewI256FromInt16 returns an int16 wrapped as an I256.
+func NewI256FromInt16(x int16) I256 {
+	return I256{inner: big.NewInt(int64(x))}
+}
+
+// NewI256FromInt32 returns an int32 wrapped as an I256.
+func NewI256FromInt32(x int32) I256 {
+	return I256{inner: big.NewInt(int64(x))}
+}
+
+// NewI256FromInt64 returns an int64 wrapped as an I256.
+func NewI256FromInt64(x int64) I256 {
+	return I256{inner: big.NewInt(int64(x))}
+}
+
+// NewI256FromInt returns a big integer wrapped as an I256. It will panic if
+// the big integer cannot be represented using 256 bits.
+func NewI256FromInt(x *big.Int) I256 {
+	if x.Cmp(MinI256.inner) < 0 {
+		panic("underflow")
+	}
+	if x.Cmp(MaxI256.inner) > 0 {
+		panic("overflow")
+	}
+	return I256{inner: new(big.Int).Set(x)}
+}
+
+// Type returns the type of this value.
+func (I256) Type() Type {
+	return typeI256{}
+}
+
+// Int returns a copy of the inner big integer.
+func (i256 I256) Int() *big.Int {
+	if i256.inner == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(i256.inner)
+}
+
+// Bytes32 returns the 32-byte big-endian two's complement representation of
+// the I256.
+func (i256 I256) Bytes32() [32]byte {
+	ret := [32]byte{}
+	toTwosComplement(i256.Int(), ret[:])
+	return ret
+}
+
+// Bytes returns the 32-byte big-endian two's complement representation of the
+// I256 as a dynamic byte slice.
+func (i256 I256) Bytes() []byte {
+	bytes := i256.Bytes32()
+	return bytes[:]
+}
+
+// Add one I256 to another and return the result.
+func (i256 I256) Add(other I256) I256 {
+	return i256.checked(new(big.Int).Add(i256.Int(), other.Int()))
+}
+
+// Sub one I256 from another and return the result.
+func (i256 I256) Sub(other I256) I256 {
+	return i256.checked(new(big.Int).Sub(i256.Int(), other.Int()))
+}
+
+// Mul one I256 by another and return the result.
+func (i256 I256) Mul(other I256) I256 {
+	return i256.checked(new(big.Int).Mul(i256.Int(), other.Int()))
+}
+
+// Div one I256 by another and return the result. Division truncates towards
+// zero. It will panic if the divisor is zero.
+func (i256 I256) Div(other I256) I256 {
+	if other.Int().Sign() == 0 {
+		panic("division by zero")
+	}
+	return i256.checked(new(big.Int).Quo(i256.Int(), other.Int()))
+}
+
+// Neg returns the negation of the I256.
+func (i256 I256) Neg() I256 {
+	return i256.checked(new(big.Int).Neg(i256.Int()))
+}
+
+// AddAssign will add one I256 to another and assign the result to the
+// left-hand side.
+func (i256 *I256) AddAssign(other I256) {
+	*i256 = i256.Add(other)
+}
+
+// SubAssign will sub one I256 from another and assign the result to the
+// left-hand side.
+func (i256 *I256) SubAssign(other I256) {
+	*i256 = i256.Sub(other)
+}
+
+// Equal compares one I256 to another. If they are equal, then it returns true.
+// Otherwise, it returns false.
+func (i256 I256) Equal(other I256) bool {
+	return i256.Int().Cmp(other.Int()) == 0
+}
+
+// LessThan returns true when the I256 is strictly less than the other I256.
+func (i256 I256) LessThan(other I256) bool {
+	return i256.Int().Cmp(other.Int()) < 0
+}
+
+// LessThanEqual returns true when the I256 is less than, or equal to, the
+// other I256.
+func (i256 I256) LessThanEqual(other I256) bool {
+	return i256.Int().Cmp(other.Int()) <= 0
+}
+
+// GreaterThan returns true when the I256 is strictly greater than the other
+// I256.
+func (i256 I256) GreaterThan(other I256) bool {
+	return i256.Int().Cmp(other.Int()) > 0
+}
+
+// GreaterThanEqual returns true when the I256 is greater than, or equal to, the
+// other I256.
+func (i256 I256) GreaterThanEqual(other I256) bool {
+	return i256.Int().Cmp(other.Int()) >= 0
+}
+
+// SizeHint returns the number of bytes required to represent an I256 in
+// binary.
+func (i256 I256) SizeHint() int {
+	return 32
+}
+
+// Marshal the I256 to binary. It is marshaled using its big-endian two's
+// complement representation.
+func (i256 I256) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	if len(buf) < 32 || rem < 32 {
+		return buf, rem, surge.ErrUnexpectedEndOfBuffer
+	}
+	toTwosComplement(i256.Int(), buf[:32])
+	return buf[32:], rem - 32, nil
+}
+
+// Unmarshal the I256 from binary.
+func (i256 *I256) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	if len(buf) < 32 || rem < 32 {
+		return buf, rem, surge.ErrUnexpectedEndOfBuffer
+	}
+	i256.inner = fromTwosComplement(buf[:32])
+	return buf[32:], rem - 32, nil
+}
+
+// MarshalJSON implements the JSON marshaler interface. I256s are marshaled as
+// decimal strings.
+func (i256 I256) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i256.String())
+}
+
+// UnmarshalJSON implements the JSON unmarshaler interface. I256s are
+// unmarshaled from decimal strings.
+func (i256 *I256) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	x, ok := new(big.Int).SetString(str, 10)
+	if !ok {
+		return fmt.Errorf("malformed: %v", str)
+	}
+	if x.Cmp(MinI256.inner) < 0 {
+		return fmt.Errorf("underflow: %v", str)
+	}
+	if x.Cmp(MaxI256.inner) > 0 {
+		return fmt.Errorf("overflow: %v", str)
+	}
+	i256.inner = x
+	return nil
+}
+
+func (i256 I256) String() string {
+	return i256.Int().Text(10)
+}
+
+// checked returns the big integer wrapped as an I256. It will panic if the
+// big integer cannot be represented using 256 bits.
+func (I256) checked(x *big.Int) I256 {
+	if x.Cmp(MinI256.inner) < 0 {
+		panic("underflow")
+	}
+	if x.Cmp(MaxI256.inner) > 0 {
+		panic("overflow")
+	}
+	return I256{inner: x}
+}
+
 // Generate a random int. This method is implemented for use in quick tests.
 // See https://golang.org/pkg/testing/quick/#Generator for more information.
 func (u8 U8) Generate(r *rand.Rand, size int) reflect.Value {
@@ -1024,6 +2049,48 @@ func (u256 U256) Generate(r *rand.Rand, size int) reflect.Value {
 	return reflect.ValueOf(NewU256(v.Interface().([32]byte)))
 }
 
+// Generate a random int. This method is implemented for use in quick tests.
+// See https://golang.org/pkg/testing/quick/#Generator for more information.
+func (i8 I8) Generate(r *rand.Rand, size int) reflect.Value {
+	v, _ := quick.Value(reflect.TypeOf(int8(0)), r)
+	return reflect.ValueOf(NewI8(v.Interface().(int8)))
+}
+
+// Generate a random int. This method is implemented for use in quick tests.
+// See https://golang.org/pkg/testing/quick/#Generator for more information.
+func (i16 I16) Generate(r *rand.Rand, size int) reflect.Value {
+	v, _ := quick.Value(reflect.TypeOf(int16(0)), r)
+	return reflect.ValueOf(NewI16(v.Interface().(int16)))
+}
+
+// Generate a random int. This method is implemented for use in quick tests.
+// See https://golang.org/pkg/testing/quick/#Generator for more information.
+func (i32 I32) Generate(r *rand.Rand, size int) reflect.Value {
+	v, _ := quick.Value(reflect.TypeOf(int32(0)), r)
+	return reflect.ValueOf(NewI32(v.Interface().(int32)))
+}
+
+// Generate a random int. This method is implemented for use in quick tests.
+// See https://golang.org/pkg/testing/quick/#Generator for more information.
+func (i64 I64) Generate(r *rand.Rand, size int) reflect.Value {
+	v, _ := quick.Value(reflect.TypeOf(int64(0)), r)
+	return reflect.ValueOf(NewI64(v.Interface().(int64)))
+}
+
+// Generate a random int. This method is implemented for use in quick tests.
+// See https://golang.org/pkg/testing/quick/#Generator for more information.
+func (i128 I128) Generate(r *rand.Rand, size int) reflect.Value {
+	v, _ := quick.Value(reflect.TypeOf([16]byte{}), r)
+	return reflect.ValueOf(NewI128(v.Interface().([16]byte)))
+}
+
+// Generate a random int. This method is implemented for use in quick tests.
+// See https://golang.org/pkg/testing/quick/#Generator for more information.
+func (i256 I256) Generate(r *rand.Rand, size int) reflect.Value {
+	v, _ := quick.Value(reflect.TypeOf([32]byte{}), r)
+	return reflect.ValueOf(NewI256(v.Interface().([32]byte)))
+}
+
 // paddedTo16 encodes a big integer as a big-endian into a 16-byte array. It
 // will panic if the big integer is more than 16 bytes.
 // Modified from:
@@ -1069,6 +2136,33 @@ func readBits(bigint *big.Int, buf []byte) {
 	}
 }
 
+// toTwosComplement encodes a big integer as a big-endian two's complement
+// integer into the given buffer. Callers must ensure that the big integer can be
+// represented using the number of bytes in the buffer.
+func toTwosComplement(bigint *big.Int, buf []byte) {
+	for i := range buf {
+		buf[i] = 0
+	}
+	if bigint.Sign() >= 0 {
+		readBits(bigint, buf)
+		return
+	}
+	// The two's complement of a negative integer x is 2^n + x, where n is the
+	// number of bits.
+	x := new(big.Int).Lsh(big.NewInt(1), uint(8*len(buf)))
+	readBits(x.Add(x, bigint), buf)
+}
+
+// fromTwosComplement decodes a big integer from a big-endian two's complement
+// integer.
+func fromTwosComplement(buf []byte) *big.Int {
+	x := new(big.Int).SetBytes(buf)
+	if len(buf) > 0 && buf[0]&0x80 != 0 {
+		x.Sub(x, new(big.Int).Lsh(big.NewInt(1), uint(8*len(buf))))
+	}
+	return x
+}
+
 const (
 	// wordBits is the number of bits in a big word.
 	wordBits = 32 << (uint64(^big.Word(0)) >> 63)
@@ -1099,3 +2193,45 @@ var (
 		return U256{inner: x}
 	}()
 )
+
+// Minimum and maximum values for signed integers.
+var (
+	MinI8 = func() I8 {
+		return I8(-128)
+	}()
+	MaxI8 = func() I8 {
+		return I8(127)
+	}()
+	MinI16 = func() I16 {
+		return I16(-32768)
+	}()
+	MaxI16 = func() I16 {
+		return I16(32767)
+	}()
+	MinI32 = func() I32 {
+		return I32(-2147483648)
+	}()
+	MaxI32 = func() I32 {
+		return I32(2147483647)
+	}()
+	MinI64 = func() I64 {
+		return I64(-9223372036854775808)
+	}()
+	MaxI64 = func() I64 {
+		return I64(9223372036854775807)
+	}()
+	MinI128 = func() I128 {
+		return I128{inner: new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 127))}
+	}()
+	MaxI128 = func() I128 {
+		x := new(big.Int).Lsh(big.NewInt(1), 127)
+		return I128{inner: x.Sub(x, big.NewInt(1))}
+	}()
+	MinI256 = func() I256 {
+		return I256{inner: new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 255))}
+	}()
+	MaxI256 = func() I256 {
+		x := new(big.Int).Lsh(big.NewInt(1), 255)
+		return I256{inner: x.Sub(x, big.NewInt(1))}
+	}()
+)
@@ -9,6 +9,7 @@ import (
 
 	"github.com/renproject/pack"
 	"github.com/renproject/pack/packutil"
+	"github.com/renproject/surge"
 	"github.com/renproject/surge/surgeutil"
 
 	. "github.com/onsi/ginkgo"
@@ -26,6 +27,12 @@ var _ = Describe("Ints", func() {
 		reflect.TypeOf(pack.NewU64(uint64(0))),
 		reflect.TypeOf(pack.NewU128([16]byte{})),
 		reflect.TypeOf(pack.NewU256([32]byte{})),
+		reflect.TypeOf(pack.NewI8(int8(0))),
+		reflect.TypeOf(pack.NewI16(int16(0))),
+		reflect.TypeOf(pack.NewI32(int32(0))),
+		reflect.TypeOf(pack.NewI64(int64(0))),
+		reflect.TypeOf(pack.NewI128([16]byte{})),
+		reflect.TypeOf(pack.NewI256([32]byte{})),
 	}
 
 	for _, t := range ts {
@@ -264,4 +271,125 @@ var _ = Describe("Ints", func() {
 			Expect(quick.Check(f, nil)).To(Succeed())
 		})
 	})
+
+	Context("when creating int128 from smaller ints", func() {
+		It("should equal the smaller ints", func() {
+			f := func(x1 int8, x2 int16, x3 int32, x4 int64) bool {
+				Expect(pack.NewI128FromI8(pack.NewI8(x1)).String()).To(Equal(pack.NewI8(x1).String()))
+				Expect(pack.NewI128FromI16(pack.NewI16(x2)).String()).To(Equal(pack.NewI16(x2).String()))
+				Expect(pack.NewI128FromI32(pack.NewI32(x3)).String()).To(Equal(pack.NewI32(x3).String()))
+				Expect(pack.NewI128FromI64(pack.NewI64(x4)).String()).To(Equal(pack.NewI64(x4).String()))
+				Expect(pack.NewI128FromInt64(x4).String()).To(Equal(pack.NewI64(x4).String()))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when creating int256 from smaller ints", func() {
+		It("should equal the smaller ints", func() {
+			f := func(x1 int8, x2 int16, x3 int32, x4 int64, x5 [16]byte) bool {
+				Expect(pack.NewI256FromI8(pack.NewI8(x1)).String()).To(Equal(pack.NewI8(x1).String()))
+				Expect(pack.NewI256FromI16(pack.NewI16(x2)).String()).To(Equal(pack.NewI16(x2).String()))
+				Expect(pack.NewI256FromI32(pack.NewI32(x3)).String()).To(Equal(pack.NewI32(x3).String()))
+				Expect(pack.NewI256FromI64(pack.NewI64(x4)).String()).To(Equal(pack.NewI64(x4).String()))
+				Expect(pack.NewI256FromI128(pack.NewI128(x5)).String()).To(Equal(pack.NewI128(x5).String()))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when creating out of range int128 ints", func() {
+		It("should panic", func() {
+			Expect(func() { pack.NewI128FromInt(new(big.Int).Add(pack.MaxI128.Int(), big.NewInt(1))) }).To(Panic())
+			Expect(func() { pack.NewI128FromInt(new(big.Int).Sub(pack.MinI128.Int(), big.NewInt(1))) }).To(Panic())
+		})
+	})
+
+	Context("when creating out of range int256 ints", func() {
+		It("should panic", func() {
+			Expect(func() { pack.NewI256FromInt(new(big.Int).Add(pack.MaxI256.Int(), big.NewInt(1))) }).To(Panic())
+			Expect(func() { pack.NewI256FromInt(new(big.Int).Sub(pack.MinI256.Int(), big.NewInt(1))) }).To(Panic())
+		})
+	})
+
+	Context("when marshaling negative ints", func() {
+		It("should use two's complement", func() {
+			data, err := surge.ToBinary(pack.NewI8(-1))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte{0xFF}))
+
+			data, err = surge.ToBinary(pack.NewI64(-2))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE}))
+
+			data, err = surge.ToBinary(pack.NewI128FromInt64(-1))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal(bytes.Repeat([]byte{0xFF}, 16)))
+
+			data, err = surge.ToBinary(pack.MinI256)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal(append([]byte{0x80}, make([]byte, 31)...)))
+		})
+	})
+
+	Context("when getting the underlying int128", func() {
+		It("should return the underlying int128", func() {
+			f := func(x int64) bool {
+				Expect(pack.NewI128FromInt64(x).Int().Int64()).To(Equal(x))
+				Expect(pack.NewI128(pack.NewI128FromInt64(x).Bytes16()).Int().Int64()).To(Equal(x))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when getting the underlying int256", func() {
+		It("should return the underlying int256", func() {
+			f := func(x int64) bool {
+				Expect(pack.NewI256FromInt64(x).Int().Int64()).To(Equal(x))
+				Expect(pack.NewI256(pack.NewI256FromInt64(x).Bytes32()).Int().Int64()).To(Equal(x))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when multiplying, dividing, and negating signed ints", func() {
+		It("should match big integer arithmetic", func() {
+			f := func(x, y int32) bool {
+				expected := new(big.Int).Mul(big.NewInt(int64(x)), big.NewInt(int64(y)))
+				Expect(pack.NewI64(int64(x)).Mul(pack.NewI64(int64(y))).String()).To(Equal(expected.String()))
+				Expect(pack.NewI128FromInt32(x).Mul(pack.NewI128FromInt32(y)).String()).To(Equal(expected.String()))
+				Expect(pack.NewI256FromInt32(x).Mul(pack.NewI256FromInt32(y)).String()).To(Equal(expected.String()))
+				if y != 0 {
+					expected = new(big.Int).Quo(big.NewInt(int64(x)), big.NewInt(int64(y)))
+					Expect(pack.NewI32(x).Div(pack.NewI32(y)).String()).To(Equal(expected.String()))
+					Expect(pack.NewI128FromInt32(x).Div(pack.NewI128FromInt32(y)).String()).To(Equal(expected.String()))
+				}
+				expected = new(big.Int).Neg(big.NewInt(int64(x)))
+				Expect(pack.NewI64(int64(x)).Neg().String()).To(Equal(expected.String()))
+				Expect(pack.NewI256FromInt32(x).Neg().String()).To(Equal(expected.String()))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+
+		It("should panic on overflow", func() {
+			Expect(func() { pack.MaxI8.Mul(pack.NewI8(2)) }).To(Panic())
+			Expect(func() { pack.MinI16.Mul(pack.NewI16(-1)) }).To(Panic())
+			Expect(func() { pack.NewI32(-1).Mul(pack.MinI32) }).To(Panic())
+			Expect(func() { pack.MinI64.Div(pack.NewI64(-1)) }).To(Panic())
+			Expect(func() { pack.MinI64.Neg() }).To(Panic())
+			Expect(func() { pack.MaxI128.Mul(pack.NewI128FromInt8(2)) }).To(Panic())
+			Expect(func() { pack.MinI128.Div(pack.NewI128FromInt8(-1)) }).To(Panic())
+			Expect(func() { pack.MinI256.Neg() }).To(Panic())
+		})
+
+		It("should panic when dividing by zero", func() {
+			Expect(func() { pack.NewI8(1).Div(pack.NewI8(0)) }).To(Panic())
+			Expect(func() { pack.NewI256FromInt8(1).Div(pack.I256{}) }).To(Panic())
+		})
+	})
 })
@@ -35,6 +35,19 @@ const (
 	// KindU256 is the kind of all U256 values.
 	KindU256 = Kind(7)
 
+	// KindI8 is the kind of all I8 values.
+	KindI8 = Kind(30)
+	// KindI16 is the kind of all I16 values.
+	KindI16 = Kind(31)
+	// KindI32 is the kind of all I32 values.
+	KindI32 = Kind(32)
+	// KindI64 is the kind of all I64 values.
+	KindI64 = Kind(33)
+	// KindI128 is the kind of all I128 values.
+	KindI128 = Kind(34)
+	// KindI256 is the kind of all I256 values.
+	KindI256 = Kind(35)
+
 	// KindString is the kind of all utf8 strings.
 	KindString = Kind(10)
 	// KindBytes is the kind of all dynamic byte arrays.
@@ -43,6 +56,10 @@ const (
 	KindBytes32 = Kind(12)
 	// KindBytes65 is the kind of all 65-byte arrays.
 	KindBytes65 = Kind(13)
+	// KindBytesN is the kind of all fixed-size byte arrays that are not
+	// 32-byte arrays or 65-byte arrays. It is abstract, because it does not
+	// specify the length of the byte array.
+	KindBytesN = Kind(14)
 
 	// KindStruct is the kind of all struct values. It is abstract, because it does
 	// not specify the fields in the struct.
@@ -50,6 +67,19 @@ const (
 	// KindList is the kind of all list values. It is abstract, because it does
 	// not specify the type of the elements in the list.
 	KindList = Kind(21)
+	// KindOptional is the kind of all optional values. It is abstract, because
+	// it does not specify the type of the value that may, or may not, be
+	// present.
+	KindOptional = Kind(22)
+	// KindMap is the kind of all map values. It is abstract, because it does
+	// not specify the type of the keys, or the type of the values, in the map.
+	KindMap = Kind(23)
+	// KindUnion is the kind of all union values. It is abstract, because it
+	// does not specify the variants of the union.
+	KindUnion = Kind(24)
+	// KindTuple is the kind of all tuple values. It is abstract, because it
+	// does not specify the number of elements in the tuple, or their types.
+	KindTuple = Kind(25)
 )
 
 func (kind Kind) String() string {
@@ -69,6 +99,18 @@ func (kind Kind) String() string {
 		return "u128"
 	case KindU256:
 		return "u256"
+	case KindI8:
+		return "i8"
+	case KindI16:
+		return "i16"
+	case KindI32:
+		return "i32"
+	case KindI64:
+		return "i64"
+	case KindI128:
+		return "i128"
+	case KindI256:
+		return "i256"
 
 	// Bytes
 	case KindString:
@@ -79,12 +121,22 @@ func (kind Kind) String() string {
 		return "bytes32"
 	case KindBytes65:
 		return "bytes65"
+	case KindBytesN:
+		return "bytesn"
 
 	// Abstract
 	case KindStruct:
 		return "struct"
 	case KindList:
 		return "list"
+	case KindOptional:
+		return "optional"
+	case KindMap:
+		return "map"
+	case KindUnion:
+		return "union"
+	case KindTuple:
+		return "tuple"
 	default:
 		return "nil"
 	}
@@ -137,6 +189,24 @@ func (kind *Kind) UnmarshalText(text []byte) error {
 	case KindU256.String():
 		*kind = KindU256
 		return nil
+	case KindI8.String():
+		*kind = KindI8
+		return nil
+	case KindI16.String():
+		*kind = KindI16
+		return nil
+	case KindI32.String():
+		*kind = KindI32
+		return nil
+	case KindI64.String():
+		*kind = KindI64
+		return nil
+	case KindI128.String():
+		*kind = KindI128
+		return nil
+	case KindI256.String():
+		*kind = KindI256
+		return nil
 	case KindString.String():
 		*kind = KindString
 		return nil
@@ -149,50 +219,81 @@ func (kind *Kind) UnmarshalText(text []byte) error {
 	case KindBytes65.String():
 		*kind = KindBytes65
 		return nil
+	case KindBytesN.String():
+		*kind = KindBytesN
+		return nil
 	case KindStruct.String():
 		*kind = KindStruct
 		return nil
 	case KindList.String():
 		*kind = KindList
 		return nil
+	case KindOptional.String():
+		*kind = KindOptional
+		return nil
+	case KindMap.String():
+		*kind = KindMap
+		return nil
+	case KindUnion.String():
+		*kind = KindUnion
+		return nil
+	case KindTuple.String():
+		*kind = KindTuple
+		return nil
 	default:
 		*kind = KindNil
 		return nil
 	}
 }
 
+// IsAbstract returns true when the kind does not, by itself, identify a type
+// (e.g. a struct kind does not specify the fields of the struct). Otherwise, it
+// returns false.
+func (kind Kind) IsAbstract() bool {
+	switch kind {
+	case KindBytesN, KindStruct, KindList, KindOptional, KindMap, KindUnion, KindTuple:
+		return true
+	default:
+		return false
+	}
+}
+
 // Generate a random kind. This method is implemented for use in quick tests.
 // See https://golang.org/pkg/testing/quick/#Generator for more information.
+// Generated kinds will never be KindNil.
 func (kind Kind) Generate(r *rand.Rand, size int) reflect.Value {
-	switch r.Int() % 12 {
-	case 0:
-		return reflect.ValueOf(KindBool)
-	case 1:
-		return reflect.ValueOf(KindU8)
-	case 2:
-		return reflect.ValueOf(KindU16)
-	case 3:
-		return reflect.ValueOf(KindU32)
-	case 4:
-		return reflect.ValueOf(KindU64)
-	case 5:
-		return reflect.ValueOf(KindU128)
-	case 6:
-		return reflect.ValueOf(KindU256)
-	case 7:
-		return reflect.ValueOf(KindString)
-	case 8:
-		return reflect.ValueOf(KindBytes)
-	case 9:
-		return reflect.ValueOf(KindBytes32)
-	case 10:
-		return reflect.ValueOf(KindBytes65)
-	case 11:
-		return reflect.ValueOf(KindStruct)
-	case 12:
-		return reflect.ValueOf(KindList)
-	default:
-		// It is intentional that this case never happens.
-		return reflect.ValueOf(KindNil)
-	}
+	return reflect.ValueOf(kinds[r.Intn(len(kinds))])
+}
+
+// kinds is the list of all valid kinds.
+var kinds = []Kind{
+	// Scalar
+	KindBool,
+	KindU8,
+	KindU16,
+	KindU32,
+	KindU64,
+	KindU128,
+	KindU256,
+	KindI8,
+	KindI16,
+	KindI32,
+	KindI64,
+	KindI128,
+	KindI256,
+
+	// Bytes
+	KindString,
+	KindBytes,
+	KindBytes32,
+	KindBytes65,
+	KindBytesN,
+
+	// Abstract
+	KindStruct,
+	KindList,
+	KindOptional,
+	KindMap,
+	KindUnion,
+	KindTuple,
 }
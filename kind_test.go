@@ -17,7 +17,7 @@ var _ = Describe("Kind", func() {
 
 	randomKind := func() pack.Kind {
 		r := rand.New(rand.NewSource(GinkgoRandomSeed()))
-		switch r.Int() % 13 {
+		switch r.Int() % 24 {
 		// Nil
 		case 0:
 			return pack.KindNil
@@ -48,6 +48,34 @@ var _ = Describe("Kind", func() {
 			return pack.KindStruct
 		case 12:
 			return pack.KindList
+		// Signed
+		case 13:
+			return pack.KindI8
+		case 14:
+			return pack.KindI16
+		case 15:
+			return pack.KindI32
+		case 16:
+			return pack.KindI64
+		case 17:
+			return pack.KindI128
+		case 18:
+			return pack.KindI256
+		// Optional
+		case 19:
+			return pack.KindOptional
+		// Map
+		case 20:
+			return pack.KindMap
+		// Union
+		case 21:
+			return pack.KindUnion
+		// Tuple
+		case 22:
+			return pack.KindTuple
+		// Parameterised bytes
+		case 23:
+			return pack.KindBytesN
 		}
 		panic("unreachable")
 	}
@@ -79,4 +107,18 @@ var _ = Describe("Kind", func() {
 			}
 		})
 	})
+
+	Context("when generating kinds", func() {
+		It("should generate every kind except nil", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			seen := map[pack.Kind]bool{}
+			for trial := 0; trial < 100*numTrials; trial++ {
+				kind := pack.Kind(0).Generate(r, 0).Interface().(pack.Kind)
+				Expect(kind).ToNot(Equal(pack.KindNil))
+				seen[kind] = true
+			}
+			Expect(seen).To(HaveKey(pack.KindList))
+			Expect(seen).To(HaveLen(24))
+		})
+	})
 })
@@ -0,0 +1,287 @@
+package pack
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/renproject/surge"
+)
+
+var (
+	// sizeOfValue is the number of bytes needed to store a value in a slice.
+	// It is used to account for the memory needed to store the elements of
+	// lists and tuples.
+	sizeOfValue = int(reflect.TypeOf((*Value)(nil)).Elem().Size())
+	// sizeOfMapEntry is the number of bytes needed to store an entry of a map.
+	sizeOfMapEntry = int(reflect.TypeOf(MapEntry{}).Size())
+	// sizeOfStructField is the number of bytes needed to store a field of a
+	// struct.
+	sizeOfStructField = int(reflect.TypeOf(StructField{}).Size())
+)
+
+// DecodeOptions limit the resources that can be consumed when unmarshaling
+// types and values from binary, or from JSON. This prevents small, malicious,
+// inputs from exhausting memory (e.g. by declaring very long lists) or the
+// stack (e.g. by declaring very deeply nested types). A limit of zero means
+// that the limit from DefaultDecodeOptions is used.
+type DecodeOptions struct {
+	// MaxDepth is the maximum nesting depth of types and values. Each struct,
+	// list, optional, map, union, and tuple adds one level of nesting.
+	MaxDepth int
+	// MaxListLen is the maximum number of elements in a list, and the maximum
+	// number of entries in a map.
+	MaxListLen int
+	// MaxStructFields is the maximum number of fields in a struct. It is also
+	// the maximum number of variants in a union, and the maximum number of
+	// elements in a tuple.
+	MaxStructFields int
+	// MaxBytes is the maximum number of bytes that can be consumed when
+	// unmarshaling from binary (the remaining memory quota is capped at this
+	// value), and the maximum number of bytes in JSON input. It is also the
+	// maximum number of bytes that can be allocated, in total, to store the
+	// elements of lists, tuples, maps, and structs.
+	MaxBytes int
+	// MaxNameLen is the maximum number of bytes in the name of a struct field,
+	// or union variant.
+	MaxNameLen int
+}
+
+// DefaultDecodeOptions are used by UnmarshalType, the UnmarshalValue and
+// UnmarshalValueJSON methods of all types, and the Unmarshal and UnmarshalJSON
+// methods of all values. They can be changed to change the limits used by
+// these functions, but this should only be done during initialisation. By
+// default, the elements of all lists, maps, tuples, and structs in a value can
+// occupy at most surge.MaxBytes of memory.
+var DefaultDecodeOptions = DecodeOptions{
+	MaxDepth:        64,
+	MaxListLen:      surge.MaxBytes / sizeOfValue,
+	MaxStructFields: 1024,
+	MaxBytes:        surge.MaxBytes,
+	MaxNameLen:      1024,
+}
+
+// UnmarshalType from binary, using these options to limit the resources that
+// can be consumed.
+func (opts DecodeOptions) UnmarshalType(t *Type, buf []byte, rem int) ([]byte, int, error) {
+	state := newDecodeState(opts)
+	limited := state.capRem(rem)
+	rest, limitedRem, err := state.unmarshalType(t, buf, limited)
+	return rest, rem - (limited - limitedRem), withOffset(err, buf, rest)
+}
+
+// UnmarshalValue of the given type from binary, using these options to limit
+// the resources that can be consumed.
+func (opts DecodeOptions) UnmarshalValue(t Type, buf []byte, rem int) (Value, []byte, int, error) {
+	state := newDecodeState(opts)
+	limited := state.capRem(rem)
+	v, rest, limitedRem, err := state.unmarshalValue(t, buf, limited)
+	return v, rest, rem - (limited - limitedRem), withOffset(err, buf, rest)
+}
+
+// UnmarshalTypeJSON unmarshals a type from JSON, using these options to limit
+// the resources that can be consumed.
+func (opts DecodeOptions) UnmarshalTypeJSON(data []byte) (Type, error) {
+	state := newDecodeState(opts)
+	if err := state.checkBytes(len(data)); err != nil {
+		return nil, newDecodeError(err, KindNil)
+	}
+	return state.unmarshalTypeJSON(data)
+}
+
+// UnmarshalValueJSON unmarshals a value of the given type from JSON, using
+// these options to limit the resources that can be consumed.
+func (opts DecodeOptions) UnmarshalValueJSON(t Type, data []byte) (Value, error) {
+	state := newDecodeState(opts)
+	if err := state.checkBytes(len(data)); err != nil {
+		return nil, newDecodeError(err, t.Kind())
+	}
+	return state.unmarshalValueJSON(t, data)
+}
+
+// A DecodeState enforces decode options across all levels of nesting while a
+// value is unmarshaled from binary. It tracks the nesting depth, and charges
+// the memory needed to store the elements of values against the MaxBytes
+// budget, in the same way as the UnmarshalValue methods of types. It is used by
+// the Unmarshal methods generated by packgen, so that they enforce the same
+// limits as unmarshaling pack values.
+type DecodeState struct {
+	state *decodeState
+}
+
+// NewDecodeState returns a DecodeState that enforces these options.
+func (opts DecodeOptions) NewDecodeState() *DecodeState {
+	return &DecodeState{state: newDecodeState(opts)}
+}
+
+// CapRem returns the remaining memory quota, capped at MaxBytes.
+func (s *DecodeState) CapRem(rem int) int {
+	return s.state.capRem(rem)
+}
+
+// Enter a struct, list, optional, map, or tuple. An error is returned if this
+// exceeds the maximum depth. Leave must be called when the value has been
+// unmarshaled.
+func (s *DecodeState) Enter() error {
+	return s.state.enter()
+}
+
+// Leave a value that was entered by calling Enter.
+func (s *DecodeState) Leave() {
+	s.state.leave()
+}
+
+// CheckListLen returns an error if the length of a list, or map, exceeds
+// MaxListLen.
+func (s *DecodeState) CheckListLen(n uint32) error {
+	return s.state.checkListLen(int64(n))
+}
+
+// AllocStruct charges the memory needed to store the fields of a struct.
+// surge.ErrLengthOverflow is returned if the budget is exceeded.
+func (s *DecodeState) AllocStruct(numFields int) error {
+	return s.state.alloc(int64(numFields), sizeOfStructField)
+}
+
+// AllocList charges the memory needed to store the elements of a list, or a
+// tuple. surge.ErrLengthOverflow is returned if the budget is exceeded.
+func (s *DecodeState) AllocList(n int) error {
+	return s.state.alloc(int64(n), sizeOfValue)
+}
+
+// AllocMap charges the memory needed to store the entries of a map.
+// surge.ErrLengthOverflow is returned if the budget is exceeded.
+func (s *DecodeState) AllocMap(n int) error {
+	return s.state.alloc(int64(n), sizeOfMapEntry)
+}
+
+// A nestedType is a type that holds other types. Values of nested types are
+// unmarshaled using a decodeState, so that limits can be enforced across all
+// levels of nesting.
+type nestedType interface {
+	unmarshalValue(buf []byte, rem int, state *decodeState) (Value, []byte, int, error)
+	unmarshalValueJSON(data []byte, state *decodeState) (Value, error)
+}
+
+// A decodeState tracks the nesting depth, and the memory allocated, while
+// unmarshaling, and enforces the limits defined by the decode options.
+type decodeState struct {
+	opts  DecodeOptions
+	depth int
+	// budget is the number of bytes that can still be allocated to store the
+	// elements of values. It is shared by all levels of nesting, so that the
+	// elements of nested values cannot each allocate up to the limit.
+	budget int
+}
+
+func newDecodeState(opts DecodeOptions) *decodeState {
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = DefaultDecodeOptions.MaxDepth
+	}
+	if opts.MaxListLen <= 0 {
+		opts.MaxListLen = DefaultDecodeOptions.MaxListLen
+	}
+	if opts.MaxStructFields <= 0 {
+		opts.MaxStructFields = DefaultDecodeOptions.MaxStructFields
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultDecodeOptions.MaxBytes
+	}
+	if opts.MaxNameLen <= 0 {
+		opts.MaxNameLen = DefaultDecodeOptions.MaxNameLen
+	}
+	return &decodeState{opts: opts, budget: opts.MaxBytes}
+}
+
+// unmarshalValue of the given type from binary. Errors are returned as
+// DecodeErrors, with paths that are relative to the value.
+func (state *decodeState) unmarshalValue(t Type, buf []byte, rem int) (Value, []byte, int, error) {
+	nested, ok := t.(nestedType)
+	if !ok {
+		v, buf, rem, err := t.UnmarshalValue(buf, rem)
+		return v, buf, rem, newDecodeError(err, t.Kind())
+	}
+	if err := state.enter(); err != nil {
+		return nil, buf, rem, newDecodeError(err, t.Kind())
+	}
+	defer state.leave()
+	v, buf, rem, err := nested.unmarshalValue(buf, rem, state)
+	return v, buf, rem, newDecodeError(err, t.Kind())
+}
+
+// unmarshalValueJSON of the given type. Errors are returned as DecodeErrors,
+// with paths that are relative to the value.
+func (state *decodeState) unmarshalValueJSON(t Type, data []byte) (Value, error) {
+	nested, ok := t.(nestedType)
+	if !ok {
+		v, err := t.UnmarshalValueJSON(data)
+		return v, newDecodeError(err, t.Kind())
+	}
+	if err := state.enter(); err != nil {
+		return nil, newDecodeError(err, t.Kind())
+	}
+	defer state.leave()
+	v, err := nested.unmarshalValueJSON(data, state)
+	return v, newDecodeError(err, t.Kind())
+}
+
+func (state *decodeState) enter() error {
+	if state.depth >= state.opts.MaxDepth {
+		return fmt.Errorf("expected depth<=%v, got depth=%v", state.opts.MaxDepth, state.depth+1)
+	}
+	state.depth++
+	return nil
+}
+
+func (state *decodeState) leave() {
+	state.depth--
+}
+
+func (state *decodeState) capRem(rem int) int {
+	if rem > state.opts.MaxBytes {
+		return state.opts.MaxBytes
+	}
+	return rem
+}
+
+func (state *decodeState) checkBytes(n int) error {
+	if n > state.opts.MaxBytes {
+		return fmt.Errorf("expected bytes<=%v, got bytes=%v", state.opts.MaxBytes, n)
+	}
+	return nil
+}
+
+// alloc charges the memory needed to store n elements, of the given size,
+// against the budget. surge.ErrLengthOverflow is returned if the budget is
+// exceeded.
+func (state *decodeState) alloc(n int64, size int) error {
+	if n < 0 || n > int64(state.budget/size) {
+		return surge.ErrLengthOverflow
+	}
+	state.budget -= int(n) * size
+	return nil
+}
+
+func (state *decodeState) checkListLen(n int64) error {
+	if n > int64(state.opts.MaxListLen) {
+		return fmt.Errorf("expected len<=%v, got len=%v", state.opts.MaxListLen, n)
+	}
+	return nil
+}
+
+func (state *decodeState) checkNumFields(n int64) error {
+	if n > int64(state.opts.MaxStructFields) {
+		return fmt.Errorf("expected fields<=%v, got fields=%v", state.opts.MaxStructFields, n)
+	}
+	return nil
+}
+
+func (state *decodeState) checkName(name string) error {
+	return state.checkNameLen(int64(len(name)))
+}
+
+func (state *decodeState) checkNameLen(n int64) error {
+	if n > int64(state.opts.MaxNameLen) {
+		return fmt.Errorf("expected name len<=%v, got name len=%v", state.opts.MaxNameLen, n)
+	}
+	return nil
+}
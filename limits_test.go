@@ -0,0 +1,432 @@
+package pack_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"strings"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/surge"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// checkTypeLimits returns true when the type is within the limits of the
+// decode options. Otherwise, it returns false.
+func checkTypeLimits(t pack.Type, opts pack.DecodeOptions, depth int) bool {
+	switch t.Kind() {
+	case pack.KindStruct, pack.KindUnion:
+		if depth+1 > opts.MaxDepth || len(pack.FieldNames(t)) > opts.MaxStructFields {
+			return false
+		}
+		for _, name := range pack.FieldNames(t) {
+			if len(name) > opts.MaxNameLen || !checkTypeLimits(pack.FieldType(t, name), opts, depth+1) {
+				return false
+			}
+		}
+		return true
+	case pack.KindTuple:
+		if depth+1 > opts.MaxDepth || len(pack.ElemTypes(t)) > opts.MaxStructFields {
+			return false
+		}
+		for _, elemType := range pack.ElemTypes(t) {
+			if !checkTypeLimits(elemType, opts, depth+1) {
+				return false
+			}
+		}
+		return true
+	case pack.KindList, pack.KindOptional:
+		return depth+1 <= opts.MaxDepth && checkTypeLimits(pack.ElemType(t), opts, depth+1)
+	case pack.KindMap:
+		return depth+1 <= opts.MaxDepth &&
+			checkTypeLimits(pack.KeyType(t), opts, depth+1) &&
+			checkTypeLimits(pack.ValueType(t), opts, depth+1)
+	default:
+		return true
+	}
+}
+
+// checkValueLimits returns true when the value is within the limits of the
+// decode options. Otherwise, it returns false.
+func checkValueLimits(v pack.Value, opts pack.DecodeOptions, depth int) bool {
+	elems := []pack.Value{}
+	switch v := v.(type) {
+	case pack.List:
+		if len(v.Elems) > opts.MaxListLen {
+			return false
+		}
+		elems = v.Elems
+	case pack.Map:
+		if len(v.Entries) > opts.MaxListLen {
+			return false
+		}
+		for _, entry := range v.Entries {
+			elems = append(elems, entry.Key, entry.Value)
+		}
+	case pack.Struct:
+		for _, field := range v {
+			elems = append(elems, field.Value)
+		}
+	case pack.Optional:
+		if v.Value != nil {
+			elems = append(elems, v.Value)
+		}
+	case pack.Union:
+		elems = append(elems, v.Value)
+	case pack.Tuple:
+		elems = v
+	default:
+		return true
+	}
+	if depth+1 > opts.MaxDepth {
+		return false
+	}
+	for _, elem := range elems {
+		if !checkValueLimits(elem, opts, depth+1) {
+			return false
+		}
+	}
+	return true
+}
+
+var _ = Describe("Decode limits", func() {
+
+	numTrials := 100
+
+	opts := pack.DecodeOptions{
+		MaxDepth:        3,
+		MaxListLen:      4,
+		MaxStructFields: 3,
+		MaxBytes:        1024,
+		MaxNameLen:      8,
+	}
+
+	marshalType := func(t pack.Type) []byte {
+		data := make([]byte, pack.SizeHintType(t))
+		_, _, err := pack.MarshalType(t, data, len(data))
+		Expect(err).ToNot(HaveOccurred())
+		return data
+	}
+
+	nestedListType := func(depth int) pack.Type {
+		t := pack.TypeU8()
+		for i := 0; i < depth; i++ {
+			t = pack.ListType(t)
+		}
+		return t
+	}
+
+	nestedListValue := func(depth int) pack.Value {
+		var v pack.Value = pack.NewU8(0)
+		for i := 0; i < depth; i++ {
+			v = pack.List{T: v.Type(), Elems: []pack.Value{v}}
+		}
+		return v
+	}
+
+	Context("when unmarshaling deeply nested types", func() {
+		It("should return an error without exhausting the stack", func() {
+			// A list of lists of lists (and so on) is one byte per level.
+			data := append(bytes.Repeat([]byte{byte(pack.KindList)}, 1000000), byte(pack.KindU8))
+			var t pack.Type
+			_, _, err := pack.UnmarshalType(&t, data, len(data))
+			Expect(err).To(HaveOccurred())
+
+			rawJSON := strings.Repeat(`{"list":`, 100000) + `"u8"` + strings.Repeat(`}`, 100000)
+			_, err = pack.DefaultDecodeOptions.UnmarshalTypeJSON([]byte(rawJSON))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should respect the max depth", func() {
+			data := marshalType(nestedListType(3))
+			var t pack.Type
+			_, _, err := opts.UnmarshalType(&t, data, len(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t.Equals(nestedListType(3))).To(BeTrue())
+
+			data = marshalType(nestedListType(4))
+			_, _, err = opts.UnmarshalType(&t, data, len(data))
+			Expect(err).To(HaveOccurred())
+
+			rawJSON, err := json.Marshal(pack.Typed{{Name: "foo", Value: pack.Default(nestedListType(3))}})
+			Expect(err).ToNot(HaveOccurred())
+			raw := map[string]json.RawMessage{}
+			Expect(json.Unmarshal(rawJSON, &raw)).To(Succeed())
+			_, err = opts.UnmarshalTypeJSON(raw["t"])
+			Expect(err).To(HaveOccurred())
+			_, err = pack.DefaultDecodeOptions.UnmarshalTypeJSON(raw["t"])
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("when unmarshaling deeply nested values", func() {
+		It("should respect the max depth", func() {
+			data, err := surge.ToBinary(nestedListValue(4))
+			Expect(err).ToNot(HaveOccurred())
+			_, _, _, err = opts.UnmarshalValue(nestedListType(4), data, len(data))
+			Expect(err).To(HaveOccurred())
+			_, _, _, err = pack.DefaultDecodeOptions.UnmarshalValue(nestedListType(4), data, len(data))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = opts.UnmarshalValueJSON(nestedListType(4), []byte(`[[[["0"]]]]`))
+			Expect(err).To(HaveOccurred())
+			_, err = opts.UnmarshalValueJSON(nestedListType(3), []byte(`[[["0"]]]`))
+			Expect(err).ToNot(HaveOccurred())
+
+			// Types with more levels than the default max depth can be
+			// constructed, but their values cannot be unmarshaled.
+			deep := nestedListValue(pack.DefaultDecodeOptions.MaxDepth + 1)
+			data, err = surge.ToBinary(deep)
+			Expect(err).ToNot(HaveOccurred())
+			_, _, _, err = deep.Type().UnmarshalValue(data, len(data))
+			Expect(err).To(HaveOccurred())
+			rawJSON, err := deep.MarshalJSON()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = deep.Type().UnmarshalValueJSON(rawJSON)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when unmarshaling long lists and maps", func() {
+		It("should respect the max list length", func() {
+			t := pack.ListType(pack.TypeU8())
+			elems := []pack.Value{}
+			for i := 0; i < 5; i++ {
+				elems = append(elems, pack.NewU8(uint8(i)))
+			}
+			data, err := surge.ToBinary(pack.List{T: pack.TypeU8(), Elems: elems[:4]})
+			Expect(err).ToNot(HaveOccurred())
+			_, _, _, err = opts.UnmarshalValue(t, data, len(data))
+			Expect(err).ToNot(HaveOccurred())
+
+			data, err = surge.ToBinary(pack.List{T: pack.TypeU8(), Elems: elems})
+			Expect(err).ToNot(HaveOccurred())
+			_, _, _, err = opts.UnmarshalValue(t, data, len(data))
+			Expect(err).To(HaveOccurred())
+			_, err = opts.UnmarshalValueJSON(t, []byte(`[0, 1, 2, 3, 4]`))
+			Expect(err).To(HaveOccurred())
+
+			m := pack.EmptyMap(pack.TypeU8(), pack.TypeU8())
+			for i := 0; i < 5; i++ {
+				m.Set(pack.NewU8(uint8(i)), pack.NewU8(uint8(i)))
+			}
+			data, err = surge.ToBinary(m)
+			Expect(err).ToNot(HaveOccurred())
+			_, _, _, err = opts.UnmarshalValue(m.Type(), data, len(data))
+			Expect(err).To(HaveOccurred())
+			_, err = opts.UnmarshalValueJSON(m.Type(), []byte(`[[0, 0], [1, 1], [2, 2], [3, 3], [4, 4]]`))
+			Expect(err).To(HaveOccurred())
+			_, err = opts.UnmarshalValueJSON(pack.MapType(pack.TypeString(), pack.TypeU8()), []byte(`{"a": 0, "b": 1, "c": 2, "d": 3, "e": 4}`))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should not allocate lists of empty values from small buffers", func() {
+			t := pack.ListType(pack.StructType())
+			data := []byte{0xff, 0xff, 0xff, 0xff}
+			_, _, _, err := t.UnmarshalValue(data, len(data))
+			Expect(err).To(HaveOccurred())
+
+			data = []byte{0, 0, 0, 4}
+			v, _, _, err := opts.UnmarshalValue(t, data, len(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.(pack.List).Elems).To(HaveLen(4))
+		})
+
+		It("should limit the memory allocated by nested lists of empty values", func() {
+			// Each inner list is 4 bytes, but declares the max list length,
+			// so the memory needed by all of the lists is far larger than the
+			// input.
+			t := pack.ListType(pack.ListType(pack.StructType()))
+			numLists := 20
+			data := make([]byte, 4+4*numLists)
+			binary.BigEndian.PutUint32(data, uint32(numLists))
+			for i := 0; i < numLists; i++ {
+				binary.BigEndian.PutUint32(data[4+4*i:], uint32(pack.DefaultDecodeOptions.MaxListLen))
+			}
+			_, _, _, err := t.UnmarshalValue(data, len(data))
+			Expect(errors.Is(err, surge.ErrLengthOverflow)).To(BeTrue())
+
+			// The budget is shared by the inner lists.
+			budgetOpts := pack.DecodeOptions{MaxBytes: 1024}
+			for i := 0; i < numLists; i++ {
+				binary.BigEndian.PutUint32(data[4+4*i:], 16)
+			}
+			_, _, _, err = budgetOpts.UnmarshalValue(t, data, len(data))
+			Expect(errors.Is(err, surge.ErrLengthOverflow)).To(BeTrue())
+			one := []byte{0, 0, 0, 1, 0, 0, 0, 16}
+			_, _, _, err = budgetOpts.UnmarshalValue(t, one, len(one))
+			Expect(err).ToNot(HaveOccurred())
+
+			dec := pack.NewDecoder(bytes.NewReader(data))
+			dec.SetDecodeOptions(budgetOpts)
+			_, err = dec.Decode(t)
+			Expect(errors.Is(err, surge.ErrLengthOverflow)).To(BeTrue())
+		})
+	})
+
+	Context("when unmarshaling types with many fields", func() {
+		It("should respect the max number of fields", func() {
+			for _, schema := range []string{
+				"struct { a: u8, b: u8, c: u8, d: u8 }",
+				"union { a: u8, b: u8, c: u8, d: u8 }",
+				"tuple<u8, u8, u8, u8>",
+			} {
+				t, err := pack.ParseType(schema)
+				Expect(err).ToNot(HaveOccurred())
+				data := marshalType(t)
+				var decoded pack.Type
+				_, _, err = opts.UnmarshalType(&decoded, data, len(data))
+				Expect(err).To(HaveOccurred())
+				_, _, err = pack.UnmarshalType(&decoded, data, len(data))
+				Expect(err).ToNot(HaveOccurred())
+
+				rawJSON, err := json.Marshal(pack.Typed{{Name: "foo", Value: pack.Default(t)}})
+				Expect(err).ToNot(HaveOccurred())
+				raw := map[string]json.RawMessage{}
+				Expect(json.Unmarshal(rawJSON, &raw)).To(Succeed())
+				_, err = opts.UnmarshalTypeJSON(raw["t"])
+				Expect(err).To(HaveOccurred())
+				_, err = pack.DefaultDecodeOptions.UnmarshalTypeJSON(raw["t"])
+				Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	})
+
+	Context("when unmarshaling types with long names", func() {
+		It("should respect the max name length", func() {
+			t := pack.StructType("abcdefghi", pack.TypeU8())
+			data := marshalType(t)
+			var decoded pack.Type
+			_, _, err := opts.UnmarshalType(&decoded, data, len(data))
+			Expect(err).To(HaveOccurred())
+			_, _, err = pack.UnmarshalType(&decoded, data, len(data))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = opts.UnmarshalTypeJSON([]byte(`{"struct":[{"abcdefghi":"u8"}]}`))
+			Expect(err).To(HaveOccurred())
+			_, err = opts.UnmarshalTypeJSON([]byte(`{"struct":[{"abcdefgh":"u8"}]}`))
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("when unmarshaling large inputs", func() {
+		It("should respect the max bytes", func() {
+			v := pack.NewBytes(make([]byte, 2048))
+			data, err := surge.ToBinary(v)
+			Expect(err).ToNot(HaveOccurred())
+			_, _, _, err = opts.UnmarshalValue(pack.TypeBytes(), data, surge.MaxBytes)
+			Expect(err).To(HaveOccurred())
+			_, rest, rem, err := pack.DefaultDecodeOptions.UnmarshalValue(pack.TypeBytes(), data, surge.MaxBytes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rest).To(BeEmpty())
+			Expect(rem).To(Equal(surge.MaxBytes - len(data)))
+
+			rawJSON, err := v.MarshalJSON()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = opts.UnmarshalValueJSON(pack.TypeBytes(), rawJSON)
+			Expect(err).To(HaveOccurred())
+			_, err = pack.TypeBytes().UnmarshalValueJSON(rawJSON)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("when options are zero", func() {
+		It("should use the default options", func() {
+			deep := nestedListType(pack.DefaultDecodeOptions.MaxDepth)
+			data := marshalType(deep)
+			var t pack.Type
+			_, _, err := pack.DecodeOptions{}.UnmarshalType(&t, data, len(data))
+			Expect(err).ToNot(HaveOccurred())
+
+			data = marshalType(pack.ListType(deep))
+			_, _, err = pack.DecodeOptions{}.UnmarshalType(&t, data, len(data))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when decoding from a stream", func() {
+		It("should respect the options", func() {
+			w := new(bytes.Buffer)
+			enc := pack.NewEncoder(w)
+			Expect(enc.EncodeType(nestedListType(4))).To(Succeed())
+			Expect(enc.EncodeType(pack.StructType("abcdefghi", pack.TypeU8()))).To(Succeed())
+			Expect(enc.Encode(pack.List{T: pack.TypeU8(), Elems: []pack.Value{
+				pack.NewU8(0), pack.NewU8(1), pack.NewU8(2), pack.NewU8(3), pack.NewU8(4),
+			}})).To(Succeed())
+
+			dec := pack.NewDecoder(w)
+			dec.SetDecodeOptions(opts)
+			_, err := dec.DecodeType()
+			Expect(err).To(HaveOccurred())
+			dec = pack.NewDecoder(w)
+			dec.SetDecodeOptions(opts)
+			_, err = dec.DecodeType()
+			Expect(err).To(HaveOccurred())
+			dec = pack.NewDecoder(w)
+			dec.SetDecodeOptions(opts)
+			_, err = dec.Decode(pack.ListType(pack.TypeU8()))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when fuzzing", func() {
+		It("should never return types or values that exceed the limits", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < 100*numTrials; trial++ {
+				t := pack.GenerateType(r, 10, pack.GenerateMaxDepth+2)
+				v := pack.GenerateFromType(r, 10, t)
+
+				// Mutate the binary, and JSON, representations so that the
+				// fuzzed inputs are close to valid inputs.
+				typeData := marshalType(t)
+				valueData, err := surge.ToBinary(v)
+				Expect(err).ToNot(HaveOccurred())
+				for i := 0; i < 1+r.Intn(3); i++ {
+					if len(typeData) > 0 {
+						typeData[r.Intn(len(typeData))] = byte(r.Int())
+					}
+					if len(valueData) > 0 {
+						valueData[r.Intn(len(valueData))] = byte(r.Int())
+					}
+				}
+
+				var decodedType pack.Type
+				if _, _, err := opts.UnmarshalType(&decodedType, typeData, len(typeData)); err == nil {
+					Expect(checkTypeLimits(decodedType, opts, 0)).To(BeTrue())
+				}
+				if decoded, _, _, err := opts.UnmarshalValue(t, valueData, len(valueData)); err == nil {
+					Expect(checkValueLimits(decoded, opts, 0)).To(BeTrue())
+				}
+
+				rawJSON, err := v.MarshalJSON()
+				Expect(err).ToNot(HaveOccurred())
+				if decoded, err := opts.UnmarshalValueJSON(t, rawJSON); err == nil {
+					Expect(checkValueLimits(decoded, opts, 0)).To(BeTrue())
+				}
+			}
+		})
+
+		It("should never panic on random inputs", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < 100*numTrials; trial++ {
+				data := make([]byte, r.Intn(64))
+				r.Read(data)
+
+				var t pack.Type
+				Expect(func() {
+					if _, _, err := opts.UnmarshalType(&t, data, len(data)); err == nil {
+						Expect(checkTypeLimits(t, opts, 0)).To(BeTrue())
+					}
+					if _, _, err := pack.UnmarshalType(&t, data, len(data)); err == nil {
+						Expect(checkTypeLimits(t, pack.DefaultDecodeOptions, 0)).To(BeTrue())
+					}
+					_, _ = opts.UnmarshalTypeJSON(data)
+				}).ToNot(Panic())
+			}
+		})
+	})
+})
@@ -77,25 +77,11 @@ func (v *List) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 		return buf, rem, fmt.Errorf("cannot unmarshal into list with unknown type")
 	}
 
-	var numElems uint32
-	buf, rem, err := surge.UnmarshalLen(&numElems, 0, buf, rem)
+	value, buf, rem, err := v.Type().UnmarshalValue(buf, rem)
 	if err != nil {
 		return buf, rem, err
 	}
-
-	v.Elems = make([]Value, numElems)
-	for i := range v.Elems {
-		v.Elems[i], buf, rem, err = v.T.UnmarshalValue(buf, rem)
-		if err != nil {
-			return buf, rem, err
-		}
-
-		// Ensure all elements are of the same type.
-		if v.Elems[i].Type() != v.T {
-			return buf, rem, fmt.Errorf("unexpected type: expected %v, got %v", v.T, v.Elems[i].Type())
-		}
-	}
-
+	*v = value.(List)
 	return buf, rem, nil
 }
 
@@ -123,15 +109,8 @@ func (v List) String() string {
 
 // Generate a random list. This method is implemented for use in quick tests.
 // See https://golang.org/pkg/testing/quick/#Generator for more information.
-// Generated lists will never contain embedded lists.
+// Generated lists are nested to at most GenerateMaxDepth.
 func (List) Generate(r *rand.Rand, size int) reflect.Value {
-	l := List{
-		T:     Generate(r, size, false, false).Interface().(Value).Type(),
-		Elems: make([]Value, 0, size),
-	}
-	for i := 0; i < size; i++ {
-		v := GenerateFromKind(r, size, l.T.Kind(), false, false).Interface().(Value)
-		l.Elems = append(l.Elems, v)
-	}
-	return reflect.ValueOf(l)
+	t := GenerateTypeFromKind(r, size, KindList, GenerateMaxDepth)
+	return reflect.ValueOf(GenerateFromType(r, size, t))
 }
@@ -174,4 +174,13 @@ var _ = Describe("List", func() {
 			})
 		})
 	})
+
+	Context("when unmarshaling a list with a length that is too large", func() {
+		It("should return an error", func() {
+			for _, t := range []pack.Type{pack.TypeU8(), pack.StructType(), pack.TupleType()} {
+				_, _, _, err := pack.ListType(t).UnmarshalValue([]byte{0xff, 0xff, 0xff, 0xff}, surge.MaxBytes)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+	})
 })
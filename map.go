@@ -0,0 +1,265 @@
+package pack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+
+	"github.com/renproject/surge"
+)
+
+// MapEntry represents a key/value pair within a map. It is not, by itself, a
+// value or a type. It is only meant to be used to build maps.
+type MapEntry struct {
+	Key   Value
+	Value Value
+}
+
+// NewMapEntry returns a map entry with the given key and value.
+func NewMapEntry(key, value Value) MapEntry {
+	return MapEntry{Key: key, Value: value}
+}
+
+// Map represents an associative array from keys to values. All keys must be of
+// the same type, and all values must be of the same type. Entries are kept
+// sorted by the binary representation of their keys, so that equal maps are
+// always marshaled identically.
+type Map struct {
+	K       Type
+	V       Type
+	Entries []MapEntry
+}
+
+// EmptyMap returns a map, with the given key and value types, that has no
+// entries.
+func EmptyMap(k, v Type) Map {
+	return Map{
+		K:       k,
+		V:       v,
+		Entries: []MapEntry{},
+	}
+}
+
+// NewMap returns a map from a slice of variadic entries. The key and value
+// types of the map are inferred from the entries. An error is returned if there
+// are no entries, if the entries do not have consistent types, or if the same
+// key is used more than once.
+func NewMap(entries ...MapEntry) (Map, error) {
+	if len(entries) == 0 {
+		return Map{}, fmt.Errorf("cannot construct map with no entries")
+	}
+
+	k, v := entries[0].Key.Type(), entries[0].Value.Type()
+	for _, entry := range entries {
+		// Verify the map entries have a consistent type.
+		if !entry.Key.Type().Equals(k) {
+			return Map{}, fmt.Errorf("inconsistent map key type: expected %v, got %v", k, entry.Key.Type())
+		}
+		if !entry.Value.Type().Equals(v) {
+			return Map{}, fmt.Errorf("inconsistent map value type: expected %v, got %v", v, entry.Value.Type())
+		}
+	}
+	sorted, err := sortMapEntries(entries)
+	if err != nil {
+		return Map{}, err
+	}
+	return Map{
+		K:       k,
+		V:       v,
+		Entries: sorted,
+	}, nil
+}
+
+// Type returns the map type.
+func (v Map) Type() Type {
+	return typeMap{
+		Key:   v.K,
+		Value: v.V,
+	}
+}
+
+// Len returns the number of entries in the map.
+func (v Map) Len() int {
+	return len(v.Entries)
+}
+
+// Get the value associated with a key. If there is no such value, then nil is
+// returned. This method has O(n) complexity, where N is the number of entries
+// in the map.
+func (v Map) Get(key Value) Value {
+	keyData, err := surge.ToBinary(key)
+	if err != nil {
+		return nil
+	}
+	for _, entry := range v.Entries {
+		entryKeyData, err := surge.ToBinary(entry.Key)
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(keyData, entryKeyData) {
+			return entry.Value
+		}
+	}
+	return nil
+}
+
+// Set the value associated with a key, and return the previous value. If there
+// was no previous value, then the entry is inserted (preserving the order of
+// the entries) and nil is returned. This method has O(n) complexity, where N is
+// the number of entries in the map.
+func (v *Map) Set(key, value Value) Value {
+	keyData, err := surge.ToBinary(key)
+	if err != nil {
+		panic(fmt.Errorf("marshaling map key: %v", err))
+	}
+	for i, entry := range v.Entries {
+		entryKeyData, err := surge.ToBinary(entry.Key)
+		if err != nil {
+			panic(fmt.Errorf("marshaling map key: %v", err))
+		}
+		switch bytes.Compare(keyData, entryKeyData) {
+		case 0:
+			prev := entry.Value
+			v.Entries[i] = MapEntry{Key: key, Value: value}
+			return prev
+		case -1:
+			v.Entries = append(v.Entries, MapEntry{})
+			copy(v.Entries[i+1:], v.Entries[i:])
+			v.Entries[i] = MapEntry{Key: key, Value: value}
+			return nil
+		}
+	}
+	v.Entries = append(v.Entries, MapEntry{Key: key, Value: value})
+	return nil
+}
+
+// SizeHint returns the number of bytes required to represent the map in
+// binary.
+func (v Map) SizeHint() int {
+	total := surge.SizeHintU32
+	for _, entry := range v.Entries {
+		total += entry.Key.SizeHint() + entry.Value.SizeHint()
+	}
+	return total
+}
+
+// Marshal the map into binary. Entries are marshaled in ascending order of the
+// binary representation of their keys.
+func (v Map) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	entries, err := sortMapEntries(v.Entries)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.MarshalLen(uint32(len(entries)), buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	for _, entry := range entries {
+		if buf, rem, err = entry.Key.Marshal(buf, rem); err != nil {
+			return buf, rem, err
+		}
+		if buf, rem, err = entry.Value.Marshal(buf, rem); err != nil {
+			return buf, rem, err
+		}
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal the map from binary.
+func (v *Map) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	if v.K == nil || v.V == nil {
+		return buf, rem, fmt.Errorf("cannot unmarshal into map with unknown type")
+	}
+	value, buf, rem, err := v.Type().UnmarshalValue(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	*v = value.(Map)
+	return buf, rem, nil
+}
+
+// MarshalJSON marshals the map to JSON. Maps with string keys are marshaled as
+// JSON objects. All other maps are marshaled as JSON arrays of [key, value]
+// pairs.
+func (v Map) MarshalJSON() ([]byte, error) {
+	if v.K != nil && v.K.Kind() == KindString {
+		raw := map[string]json.RawMessage{}
+		for _, entry := range v.Entries {
+			key, ok := entry.Key.(String)
+			if !ok {
+				return nil, fmt.Errorf("marshaling map key: expected string, got %T", entry.Key)
+			}
+			rawValue, err := entry.Value.MarshalJSON()
+			if err != nil {
+				return nil, fmt.Errorf("marshaling map value \"%v\": %v", key, err)
+			}
+			raw[string(key)] = json.RawMessage(rawValue)
+		}
+		return json.Marshal(raw)
+	}
+
+	entries, err := sortMapEntries(v.Entries)
+	if err != nil {
+		return nil, err
+	}
+	raw := make([][2]json.RawMessage, 0, len(entries))
+	for _, entry := range entries {
+		rawKey, err := entry.Key.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("marshaling map key: %v", err)
+		}
+		rawValue, err := entry.Value.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("marshaling map value: %v", err)
+		}
+		raw = append(raw, [2]json.RawMessage{rawKey, rawValue})
+	}
+	return json.Marshal(raw)
+}
+
+// String returns the map in its JSON representation.
+func (v Map) String() string {
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+// Generate a random map. This method is implemented for use in quick tests.
+// See https://golang.org/pkg/testing/quick/#Generator for more information.
+// Generated maps are nested to at most GenerateMaxDepth.
+func (Map) Generate(r *rand.Rand, size int) reflect.Value {
+	t := GenerateTypeFromKind(r, size, KindMap, GenerateMaxDepth)
+	return reflect.ValueOf(GenerateFromType(r, size, t))
+}
+
+// sortMapEntries returns a copy of the map entries, sorted in ascending order of
+// the binary representation of their keys. An error is returned if the same key
+// is used more than once.
+func sortMapEntries(entries []MapEntry) ([]MapEntry, error) {
+	keys := make([][]byte, len(entries))
+	indices := make([]int, len(entries))
+	for i, entry := range entries {
+		key, err := surge.ToBinary(entry.Key)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling map key: %v", err)
+		}
+		keys[i] = key
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return bytes.Compare(keys[indices[i]], keys[indices[j]]) < 0
+	})
+	sorted := make([]MapEntry, len(entries))
+	for i, index := range indices {
+		if i > 0 && bytes.Equal(keys[indices[i-1]], keys[index]) {
+			return nil, fmt.Errorf("duplicate map key: %v", entries[index].Key)
+		}
+		sorted[i] = entries[index]
+	}
+	return sorted, nil
+}
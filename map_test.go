@@ -0,0 +1,247 @@
+package pack_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing/quick"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/pack/packutil"
+	"github.com/renproject/surge"
+	"github.com/renproject/surge/surgeutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Map", func() {
+
+	numTrials := 10
+
+	Context("when fuzzing", func() {
+		It("should not panic", func() {
+			for trial := 0; trial < numTrials; trial++ {
+				Expect(func() { surgeutil.Fuzz(reflect.TypeOf(pack.Map{})) }).ToNot(Panic())
+				Expect(func() { packutil.JSONFuzz(reflect.TypeOf(pack.Map{})) }).ToNot(Panic())
+			}
+		})
+	})
+
+	Context("when marshaling", func() {
+		Context("when the buffer is too small", func() {
+			It("should return itself", func() {
+				for trial := 0; trial < numTrials; trial++ {
+					Expect(surgeutil.MarshalBufTooSmall(reflect.TypeOf(pack.Map{}))).To(Succeed())
+				}
+			})
+		})
+
+		Context("when the remaining memory quota is too small", func() {
+			It("should return itself", func() {
+				for trial := 0; trial < numTrials; trial++ {
+					Expect(surgeutil.MarshalRemTooSmall(reflect.TypeOf(pack.Map{}))).To(Succeed())
+				}
+			})
+		})
+	})
+
+	Context("when marshaling and unmarshaling to binary", func() {
+		It("should equal itself", func() {
+			f := func(x pack.Map) bool {
+				data, err := surge.ToBinary(x)
+				Expect(err).ToNot(HaveOccurred())
+				y := pack.Map{
+					K: x.K,
+					V: x.V,
+				}
+				err = surge.FromBinary(&y, data)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(y).To(Equal(x))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when marshaling and unmarshaling to JSON", func() {
+		It("should equal itself", func() {
+			f := func(x pack.Map) bool {
+				data, err := json.Marshal(x)
+				Expect(err).ToNot(HaveOccurred())
+				y, err := x.Type().UnmarshalValueJSON(data)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(y).To(Equal(x))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when getting type information", func() {
+		It("should return the map type", func() {
+			f := func(x pack.Map) bool {
+				Expect(x.Type().Kind()).To(Equal(pack.KindMap))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when entries are inserted in different orders", func() {
+		It("should marshal identically", func() {
+			x, err := pack.NewMap(
+				pack.NewMapEntry(pack.NewU16(2), pack.NewString("b")),
+				pack.NewMapEntry(pack.NewU16(1), pack.NewString("a")),
+				pack.NewMapEntry(pack.NewU16(3), pack.NewString("c")),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			y := pack.EmptyMap(pack.U16(0).Type(), pack.String("").Type())
+			Expect(y.Set(pack.NewU16(3), pack.NewString("c"))).To(BeNil())
+			Expect(y.Set(pack.NewU16(1), pack.NewString("a"))).To(BeNil())
+			Expect(y.Set(pack.NewU16(2), pack.NewString("b"))).To(BeNil())
+			Expect(y).To(Equal(x))
+
+			xData, err := surge.ToBinary(x)
+			Expect(err).ToNot(HaveOccurred())
+			yData, err := surge.ToBinary(y)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(xData).To(Equal(yData))
+			Expect(xData).To(Equal([]byte{
+				0, 0, 0, 3,
+				0, 1, 0, 0, 0, 1, 'a',
+				0, 2, 0, 0, 0, 1, 'b',
+				0, 3, 0, 0, 0, 1, 'c',
+			}))
+		})
+	})
+
+	Context("when setting an existing key", func() {
+		It("should replace the value and return the previous value", func() {
+			x := pack.EmptyMap(pack.String("").Type(), pack.U64(0).Type())
+			Expect(x.Set(pack.NewString("foo"), pack.NewU64(1))).To(BeNil())
+			Expect(x.Set(pack.NewString("foo"), pack.NewU64(2))).To(Equal(pack.NewU64(1)))
+			Expect(x.Len()).To(Equal(1))
+			Expect(x.Get(pack.NewString("foo"))).To(Equal(pack.NewU64(2)))
+			Expect(x.Get(pack.NewString("bar"))).To(BeNil())
+		})
+	})
+
+	Context("when marshaling a map with string keys to JSON", func() {
+		It("should marshal an object", func() {
+			x, err := pack.NewMap(
+				pack.NewMapEntry(pack.NewString("foo"), pack.NewU64(1)),
+				pack.NewMapEntry(pack.NewString("bar"), pack.NewU64(2)),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			data, err := json.Marshal(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(`{"bar":"2","foo":"1"}`))
+		})
+	})
+
+	Context("when marshaling a map with non-string keys to JSON", func() {
+		It("should marshal an array of pairs", func() {
+			x, err := pack.NewMap(
+				pack.NewMapEntry(pack.NewU8(2), pack.NewString("b")),
+				pack.NewMapEntry(pack.NewU8(1), pack.NewString("a")),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			data, err := json.Marshal(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(`[["1","a"],["2","b"]]`))
+		})
+	})
+
+	Context("when constructing a map", func() {
+		Context("when there are no entries", func() {
+			It("should return an error", func() {
+				_, err := pack.NewMap()
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("when the entries have inconsistent types", func() {
+			It("should return an error", func() {
+				_, err := pack.NewMap(
+					pack.NewMapEntry(pack.NewU8(1), pack.NewString("a")),
+					pack.NewMapEntry(pack.NewU16(2), pack.NewString("b")),
+				)
+				Expect(err).To(HaveOccurred())
+				_, err = pack.NewMap(
+					pack.NewMapEntry(pack.NewU8(1), pack.NewString("a")),
+					pack.NewMapEntry(pack.NewU8(2), pack.NewU8(2)),
+				)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("when the same key is used more than once", func() {
+			It("should return an error", func() {
+				_, err := pack.NewMap(
+					pack.NewMapEntry(pack.NewU8(1), pack.NewString("a")),
+					pack.NewMapEntry(pack.NewU8(1), pack.NewString("b")),
+				)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Context("when unmarshaling keys that are not in ascending order", func() {
+		It("should return an error", func() {
+			x := pack.EmptyMap(pack.U8(0).Type(), pack.U8(0).Type())
+			Expect(surge.FromBinary(&x, []byte{0, 0, 0, 2, 2, 0, 1, 0})).ToNot(Succeed())
+			Expect(surge.FromBinary(&x, []byte{0, 0, 0, 2, 1, 0, 1, 0})).ToNot(Succeed())
+			Expect(surge.FromBinary(&x, []byte{0, 0, 0, 2, 1, 0, 2, 0})).To(Succeed())
+		})
+	})
+
+	Context("when unmarshaling a map with zero-size entries", func() {
+		It("should not require more memory than the binary representation", func() {
+			x, err := pack.NewMap(pack.MapEntry{Key: pack.Tuple{}, Value: pack.Struct{}})
+			Expect(err).ToNot(HaveOccurred())
+			data, err := surge.ToBinary(x)
+			Expect(err).ToNot(HaveOccurred())
+			y, _, rem, err := x.Type().UnmarshalValue(data, len(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rem).To(Equal(0))
+			Expect(y).To(Equal(x))
+		})
+	})
+
+	Context("when unmarshaling a map with a length that is too large", func() {
+		It("should return an error", func() {
+			t := pack.MapType(pack.TypeU8(), pack.StructType())
+			_, _, _, err := t.UnmarshalValue([]byte{0xff, 0xff, 0xff, 0xff}, surge.MaxBytes)
+			Expect(err).To(HaveOccurred())
+			_, _, _, err = t.UnmarshalValue([]byte{0, 0, 0, 2, 1, 2}, 5)
+			Expect(err).To(HaveOccurred())
+
+			t = pack.MapType(pack.StructType(), pack.TypeU8())
+			_, _, _, err = t.UnmarshalValue([]byte{0, 0, 0, 2, 1, 2}, surge.MaxBytes)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when unmarshaling a map with an unknown type", func() {
+		It("should return an error", func() {
+			x := pack.Map{}
+			Expect(surge.FromBinary(&x, []byte{0, 0, 0, 0})).ToNot(Succeed())
+		})
+	})
+
+	Context("when encoding and decoding Go maps", func() {
+		It("should equal itself", func() {
+			f := func(x map[string]uint64) bool {
+				v, err := pack.Encode(x)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(v.Type().Kind()).To(Equal(pack.KindMap))
+				Expect(v.(pack.Map).Len()).To(Equal(len(x)))
+				y := map[string]uint64{}
+				Expect(pack.Decode(&y, v)).To(Succeed())
+				Expect(y).To(Equal(x))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,115 @@
+package pack
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+
+	"github.com/renproject/surge"
+)
+
+// Optional represents a value that may, or may not, be present. A present value
+// is known as "some" value, and an absent value is known as "none". In binary,
+// optionals are marshaled with a presence byte followed by the value (if it is
+// present). In JSON, absent values are marshaled as null, and present values
+// are marshaled as normal. This means that, in JSON, an optional that holds an
+// absent optional cannot be distinguished from an absent optional.
+type Optional struct {
+	T     Type
+	Value Value
+}
+
+// Some returns an optional that holds the given value.
+func Some(v Value) Optional {
+	return Optional{
+		T:     v.Type(),
+		Value: v,
+	}
+}
+
+// None returns an optional, of the given type, that does not hold a value.
+func None(t Type) Optional {
+	return Optional{
+		T: t,
+	}
+}
+
+// Type returns the optional type.
+func (v Optional) Type() Type {
+	return typeOptional{
+		Type: v.T,
+	}
+}
+
+// IsSome returns true when the optional holds a value. Otherwise, it returns
+// false.
+func (v Optional) IsSome() bool {
+	return v.Value != nil
+}
+
+// IsNone returns true when the optional does not hold a value. Otherwise, it
+// returns false.
+func (v Optional) IsNone() bool {
+	return v.Value == nil
+}
+
+// SizeHint returns the number of bytes required to represent the optional in
+// binary. This includes the presence byte.
+func (v Optional) SizeHint() int {
+	if v.Value == nil {
+		return surge.SizeHintBool
+	}
+	return surge.SizeHintBool + v.Value.SizeHint()
+}
+
+// Marshal the optional into binary.
+func (v Optional) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.MarshalBool(v.Value != nil, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	if v.Value == nil {
+		return buf, rem, nil
+	}
+	return v.Value.Marshal(buf, rem)
+}
+
+// Unmarshal the optional from binary.
+func (v *Optional) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	if v.T == nil {
+		return buf, rem, fmt.Errorf("cannot unmarshal into optional with unknown type")
+	}
+	value, buf, rem, err := v.Type().UnmarshalValue(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	*v = value.(Optional)
+	return buf, rem, nil
+}
+
+// MarshalJSON marshals the optional to JSON. Absent values are marshaled as
+// null.
+func (v Optional) MarshalJSON() ([]byte, error) {
+	if v.Value == nil {
+		return []byte("null"), nil
+	}
+	return v.Value.MarshalJSON()
+}
+
+// String returns the optional in its JSON representation.
+func (v Optional) String() string {
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+// Generate a random optional. This method is implemented for use in quick
+// tests. See https://golang.org/pkg/testing/quick/#Generator for more
+// information. Generated optionals are nested to at most GenerateMaxDepth, and
+// will never contain optionals.
+func (Optional) Generate(r *rand.Rand, size int) reflect.Value {
+	t := GenerateTypeFromKind(r, size, KindOptional, GenerateMaxDepth)
+	return reflect.ValueOf(GenerateFromType(r, size, t))
+}
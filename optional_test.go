@@ -0,0 +1,164 @@
+package pack_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing/quick"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/pack/packutil"
+	"github.com/renproject/surge"
+	"github.com/renproject/surge/surgeutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Optional", func() {
+
+	numTrials := 10
+
+	Context("when fuzzing", func() {
+		It("should not panic", func() {
+			for trial := 0; trial < numTrials; trial++ {
+				Expect(func() { surgeutil.Fuzz(reflect.TypeOf(pack.Optional{})) }).ToNot(Panic())
+				Expect(func() { packutil.JSONFuzz(reflect.TypeOf(pack.Optional{})) }).ToNot(Panic())
+			}
+		})
+	})
+
+	Context("when marshaling", func() {
+		Context("when the buffer is too small", func() {
+			It("should return itself", func() {
+				for trial := 0; trial < numTrials; trial++ {
+					Expect(surgeutil.MarshalBufTooSmall(reflect.TypeOf(pack.Optional{}))).To(Succeed())
+				}
+			})
+		})
+
+		Context("when the remaining memory quota is too small", func() {
+			It("should return itself", func() {
+				for trial := 0; trial < numTrials; trial++ {
+					Expect(surgeutil.MarshalRemTooSmall(reflect.TypeOf(pack.Optional{}))).To(Succeed())
+				}
+			})
+		})
+	})
+
+	Context("when marshaling and unmarshaling to binary", func() {
+		It("should equal itself", func() {
+			f := func(x pack.Optional) bool {
+				data, err := surge.ToBinary(x)
+				Expect(err).ToNot(HaveOccurred())
+				y := pack.Optional{
+					T: x.T,
+				}
+				err = surge.FromBinary(&y, data)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(y).To(Equal(x))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when marshaling and unmarshaling to JSON", func() {
+		It("should equal itself", func() {
+			f := func(x pack.Optional) bool {
+				data, err := json.Marshal(x)
+				Expect(err).ToNot(HaveOccurred())
+				y, err := x.Type().UnmarshalValueJSON(data)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(y).To(Equal(x))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when marshaling an absent value", func() {
+		It("should marshal a zero presence byte in binary and null in JSON", func() {
+			x := pack.None(pack.U64(0).Type())
+			Expect(x.IsNone()).To(BeTrue())
+			Expect(x.IsSome()).To(BeFalse())
+
+			data, err := surge.ToBinary(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte{0}))
+
+			data, err = json.Marshal(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("null"))
+		})
+	})
+
+	Context("when marshaling a present value", func() {
+		It("should marshal a presence byte followed by the value", func() {
+			x := pack.Some(pack.NewU16(42))
+			Expect(x.IsSome()).To(BeTrue())
+			Expect(x.IsNone()).To(BeFalse())
+
+			data, err := surge.ToBinary(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte{1, 0, 42}))
+
+			data, err = json.Marshal(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(`"42"`))
+		})
+	})
+
+	Context("when unmarshaling an optional with an unknown type", func() {
+		It("should return an error", func() {
+			x := pack.Optional{}
+			Expect(surge.FromBinary(&x, []byte{0})).ToNot(Succeed())
+		})
+	})
+
+	Context("when unmarshaling a struct with a missing optional field from JSON", func() {
+		It("should unmarshal the field as none", func() {
+			x := pack.NewStruct(
+				"foo", pack.NewU64(1),
+				"bar", pack.Some(pack.NewString("baz")),
+			)
+			y, err := x.Type().UnmarshalValueJSON([]byte(`{"foo":"1"}`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(y.(pack.Struct).Get("bar")).To(Equal(pack.None(pack.String("").Type())))
+		})
+	})
+
+	Context("when unmarshaling a struct with a missing required field from JSON", func() {
+		It("should return an error", func() {
+			x := pack.NewStruct(
+				"foo", pack.NewU64(1),
+				"bar", pack.NewString("baz"),
+			)
+			_, err := x.Type().UnmarshalValueJSON([]byte(`{"foo":"1"}`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when encoding and decoding pointers", func() {
+		It("should map nil pointers to none and other pointers to some", func() {
+			type Foo struct {
+				X *uint64            `json:"x"`
+				Y *pack.String       `json:"y"`
+				Z *struct{ A uint8 } `json:"z"`
+			}
+			x := uint64(42)
+			foo := Foo{X: &x}
+			v, err := pack.Encode(foo)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.(pack.Struct).Get("x")).To(Equal(pack.Some(pack.NewU64(42))))
+			Expect(v.(pack.Struct).Get("y")).To(Equal(pack.None(pack.String("").Type())))
+			Expect(v.(pack.Struct).Get("z").(pack.Optional).IsNone()).To(BeTrue())
+
+			bar := Foo{}
+			Expect(pack.Decode(&bar, v)).To(Succeed())
+			Expect(bar.X).ToNot(BeNil())
+			Expect(*bar.X).To(Equal(x))
+			Expect(bar.Y).To(BeNil())
+			Expect(bar.Z).To(BeNil())
+		})
+	})
+})
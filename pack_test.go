@@ -18,11 +18,22 @@ var _ = Describe("Value", func() {
 				func(v pack.Value) {}(new(pack.U64))
 				func(v pack.Value) {}(new(pack.U128))
 				func(v pack.Value) {}(new(pack.U256))
+				func(v pack.Value) {}(new(pack.I8))
+				func(v pack.Value) {}(new(pack.I16))
+				func(v pack.Value) {}(new(pack.I32))
+				func(v pack.Value) {}(new(pack.I64))
+				func(v pack.Value) {}(new(pack.I128))
+				func(v pack.Value) {}(new(pack.I256))
 				func(v pack.Value) {}(new(pack.String))
 				func(v pack.Value) {}(new(pack.Bytes))
 				func(v pack.Value) {}(new(pack.Bytes32))
 				func(v pack.Value) {}(new(pack.Bytes65))
+				func(v pack.Value) {}(new(pack.BytesN))
 				func(v pack.Value) {}(new(pack.Struct))
+				func(v pack.Value) {}(new(pack.Optional))
+				func(v pack.Value) {}(new(pack.Map))
+				func(v pack.Value) {}(new(pack.Union))
+				func(v pack.Value) {}(new(pack.Tuple))
 			}).ToNot(Panic())
 		})
 	})
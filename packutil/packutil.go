@@ -135,6 +135,24 @@ func AddOverflow(t reflect.Type, assign bool) {
 	case reflect.TypeOf(pack.U256{}):
 		x = reflect.ValueOf(pack.NewU256([32]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}))
 		y = reflect.ValueOf(pack.NewU256([32]byte{1}))
+	case reflect.TypeOf(pack.I8(0)):
+		x = reflect.ValueOf(pack.MaxI8)
+		y = reflect.ValueOf(pack.NewI8(int8(1)))
+	case reflect.TypeOf(pack.I16(0)):
+		x = reflect.ValueOf(pack.MaxI16)
+		y = reflect.ValueOf(pack.NewI16(int16(1)))
+	case reflect.TypeOf(pack.I32(0)):
+		x = reflect.ValueOf(pack.MaxI32)
+		y = reflect.ValueOf(pack.NewI32(int32(1)))
+	case reflect.TypeOf(pack.I64(0)):
+		x = reflect.ValueOf(pack.MaxI64)
+		y = reflect.ValueOf(pack.NewI64(int64(1)))
+	case reflect.TypeOf(pack.I128{}):
+		x = reflect.ValueOf(pack.MaxI128)
+		y = reflect.ValueOf(pack.NewI128FromInt8(1))
+	case reflect.TypeOf(pack.I256{}):
+		x = reflect.ValueOf(pack.MaxI256)
+		y = reflect.ValueOf(pack.NewI256FromInt8(1))
 	default:
 		// Do not panic, which should cause the test to fail.
 		return
@@ -174,6 +192,24 @@ func SubUnderflow(t reflect.Type, assign bool) {
 	case reflect.TypeOf(pack.U256{}):
 		x = reflect.ValueOf(pack.NewU256([32]byte{}))
 		y = reflect.ValueOf(pack.NewU256([32]byte{1}))
+	case reflect.TypeOf(pack.I8(0)):
+		x = reflect.ValueOf(pack.MinI8)
+		y = reflect.ValueOf(pack.NewI8(int8(1)))
+	case reflect.TypeOf(pack.I16(0)):
+		x = reflect.ValueOf(pack.MinI16)
+		y = reflect.ValueOf(pack.NewI16(int16(1)))
+	case reflect.TypeOf(pack.I32(0)):
+		x = reflect.ValueOf(pack.MinI32)
+		y = reflect.ValueOf(pack.NewI32(int32(1)))
+	case reflect.TypeOf(pack.I64(0)):
+		x = reflect.ValueOf(pack.MinI64)
+		y = reflect.ValueOf(pack.NewI64(int64(1)))
+	case reflect.TypeOf(pack.I128{}):
+		x = reflect.ValueOf(pack.MinI128)
+		y = reflect.ValueOf(pack.NewI128FromInt8(1))
+	case reflect.TypeOf(pack.I256{}):
+		x = reflect.ValueOf(pack.MinI256)
+		y = reflect.ValueOf(pack.NewI256FromInt8(1))
 	default:
 		// Do not panic, which should cause the test to fail.
 		return
@@ -216,6 +252,24 @@ GENERATE:
 	case reflect.TypeOf(pack.U256{}):
 		x = reflect.ValueOf(pack.NewU256FromInt(big.NewInt(r.Int63())))
 		y = reflect.ValueOf(pack.NewU256FromInt(big.NewInt(r.Int63())))
+	case reflect.TypeOf(pack.I8(0)):
+		x = reflect.ValueOf(pack.NewI8(int8(r.Int()) / 2))
+		y = reflect.ValueOf(pack.NewI8(int8(r.Int()) / 2))
+	case reflect.TypeOf(pack.I16(0)):
+		x = reflect.ValueOf(pack.NewI16(int16(r.Int()) / 2))
+		y = reflect.ValueOf(pack.NewI16(int16(r.Int()) / 2))
+	case reflect.TypeOf(pack.I32(0)):
+		x = reflect.ValueOf(pack.NewI32(int32(r.Int()) / 2))
+		y = reflect.ValueOf(pack.NewI32(int32(r.Int()) / 2))
+	case reflect.TypeOf(pack.I64(0)):
+		x = reflect.ValueOf(pack.NewI64(int64(r.Uint64()) / 2))
+		y = reflect.ValueOf(pack.NewI64(int64(r.Uint64()) / 2))
+	case reflect.TypeOf(pack.I128{}):
+		x = reflect.ValueOf(pack.NewI128FromInt(big.NewInt(int64(r.Uint64()))))
+		y = reflect.ValueOf(pack.NewI128FromInt(big.NewInt(int64(r.Uint64()))))
+	case reflect.TypeOf(pack.I256{}):
+		x = reflect.ValueOf(pack.NewI256FromInt(big.NewInt(int64(r.Uint64()))))
+		y = reflect.ValueOf(pack.NewI256FromInt(big.NewInt(int64(r.Uint64()))))
 	}
 	if x.Interface().(fmt.Stringer).String() == y.Interface().(fmt.Stringer).String() || y.Interface().(fmt.Stringer).String() == "0" {
 		// Comparing strings is the easiest way to check that we have not
@@ -0,0 +1,247 @@
+package pack
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// A structPlan is the compiled plan for encoding/decoding a Go struct. It holds
+// the fields that are not ignored by their tags, in the order in which they are
+// declared (the fields of inline Go structs are expanded in place). Plans are
+// compiled once per Go struct type, and then cached, so that tags are not
+// parsed, and the Go struct type is not walked, every time that a value is
+// encoded or decoded.
+type structPlan struct {
+	fields []fieldPlan
+}
+
+// A fieldPlan is the compiled plan for encoding/decoding one field of a Go
+// struct.
+type fieldPlan struct {
+	// index of the field in the Go struct. There is more than one index when
+	// the field belongs to an inline Go struct (see reflect.Value.FieldByIndex).
+	index []int
+	// goName is the name of the field in the Go struct. It is used in error
+	// messages.
+	goName string
+	// name of the field in the encoded struct.
+	name string
+	// encode the field into a value.
+	encode func(reflect.Value) (Value, error)
+	// decode a value into the field. The field must be addressable.
+	decode func(reflect.Value, Value) error
+}
+
+var (
+	// structPlans caches the plan of every Go struct type that has been
+	// encoded/decoded. It maps reflect.Type to *structPlan.
+	structPlans sync.Map
+	// zeroTypes caches the type of the zero value of every Go type whose zero
+	// value has been encoded. It maps reflect.Type to Type.
+	zeroTypes sync.Map
+)
+
+// structPlanOf returns the plan for a Go struct type. The plan is compiled the
+// first time that it is needed, and then cached. An error is returned if the
+// tags of the Go struct type are invalid.
+func structPlanOf(typeOf reflect.Type) (*structPlan, error) {
+	if plan, ok := structPlans.Load(typeOf); ok {
+		return plan.(*structPlan), nil
+	}
+	plan, err := compileStructPlan(typeOf)
+	if err != nil {
+		return nil, err
+	}
+	cached, _ := structPlans.LoadOrStore(typeOf, plan)
+	return cached.(*structPlan), nil
+}
+
+// compileStructPlan compiles the plan for a Go struct type, without using the
+// cache. The fields of inline Go structs, and embedded Go structs that are not
+// named by their tags, are promoted to be fields of the outer Go struct (like
+// the encoding/json package). Unexported fields are ignored.
+func compileStructPlan(typeOf reflect.Type) (*structPlan, error) {
+	n := typeOf.NumField()
+	fields := make([]fieldPlan, 0, n)
+	for i := 0; i < n; i++ {
+		f := typeOf.Field(i)
+		tag, err := parseFieldTag(f)
+		if err != nil {
+			return nil, fmt.Errorf("parsing tag of \"%v\": %v", f.Name, err)
+		}
+		if tag.ignored {
+			continue
+		}
+
+		promote := tag.inline || (f.Anonymous && !tag.named && f.Type.Kind() == reflect.Struct)
+		if tag.name == "" && !promote {
+			// Fields that are not named by their tags are not encoded.
+			continue
+		}
+		if f.PkgPath != "" && !(f.Anonymous && promote) {
+			// Unexported fields are ignored, but the exported fields of
+			// unexported embedded Go structs are promoted.
+			continue
+		}
+		if !promote {
+			field, err := compileFieldPlan(f, tag)
+			if err != nil {
+				return nil, fmt.Errorf("parsing tag of \"%v\": %v", f.Name, err)
+			}
+			field.index = []int{i}
+			fields = append(fields, field)
+			continue
+		}
+
+		if f.Type.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("parsing tag of \"%v\": expected inline %v, got %v", f.Name, reflect.Struct, f.Type.Kind())
+		}
+		inner, err := structPlanOf(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("compiling \"%v\": %v", f.Name, err)
+		}
+		for _, field := range inner.fields {
+			field.index = append([]int{i}, field.index...)
+			fields = append(fields, field)
+		}
+	}
+	return resolveStructPlan(fields)
+}
+
+// resolveStructPlan resolves fields that have the same name. Promoted fields
+// are shadowed by fields that are less deeply nested (like the fields of
+// embedded Go structs). Unlike the encoding/json package, which ignores fields
+// that have the same name and nesting depth, an error is returned.
+func resolveStructPlan(fields []fieldPlan) (*structPlan, error) {
+	depths := make(map[string]int, len(fields))
+	for _, field := range fields {
+		if depth, ok := depths[field.name]; !ok || len(field.index) < depth {
+			depths[field.name] = len(field.index)
+		}
+	}
+	plan := &structPlan{fields: make([]fieldPlan, 0, len(depths))}
+	names := make(map[string]string, len(depths))
+	for _, field := range fields {
+		if len(field.index) > depths[field.name] {
+			continue
+		}
+		if other, ok := names[field.name]; ok {
+			return nil, fmt.Errorf("duplicate field \"%v\": used by \"%v\" and \"%v\"", field.name, other, field.goName)
+		}
+		names[field.name] = field.goName
+		plan.fields = append(plan.fields, field)
+	}
+	return plan, nil
+}
+
+// compileFieldPlan compiles the plan for one field of a Go struct, using the
+// options from its tag. The index of the plan is not set.
+func compileFieldPlan(f reflect.StructField, tag fieldTag) (fieldPlan, error) {
+	field := fieldPlan{
+		goName: f.Name,
+		name:   tag.name,
+		encode: encodeReflect,
+		decode: decodeReflect,
+	}
+	zeroType := func() (Type, error) {
+		return encodeZeroType(f.Type)
+	}
+	if tag.tuple && f.Type.Kind() == reflect.Struct {
+		field.encode = encodeTuple
+		field.decode = decodeTuple
+		zeroType = func() (Type, error) {
+			zero, err := encodeTuple(reflect.Zero(f.Type))
+			if err != nil {
+				return nil, err
+			}
+			return zero.Type(), nil
+		}
+	}
+	if tag.kind != KindNil {
+		encode, decode, err := kindCodec(f.Type, tag.kind)
+		if err != nil {
+			return fieldPlan{}, err
+		}
+		field.encode = encode
+		field.decode = decode
+		zeroType = func() (Type, error) {
+			return scalarType(tag.kind), nil
+		}
+	}
+	// Pointers are already encoded as optionals, so they are not wrapped again.
+	if tag.optional && !(f.Type.Kind() == reflect.Ptr && f.Type != bigIntPtrType) {
+		field.encode, field.decode = optionalCodec(field.encode, field.decode, zeroType)
+	}
+	return field, nil
+}
+
+// optionalCodec wraps functions that encode/decode a Go value, so that zero
+// values are encoded as none, and all other values are encoded as some value.
+// The zeroType function returns the type of the values that are wrapped.
+func optionalCodec(encode func(reflect.Value) (Value, error), decode func(reflect.Value, Value) error, zeroType func() (Type, error)) (func(reflect.Value) (Value, error), func(reflect.Value, Value) error) {
+	encodeOptional := func(valueOf reflect.Value) (Value, error) {
+		if valueOf.IsZero() {
+			t, err := zeroType()
+			if err != nil {
+				return nil, fmt.Errorf("encoding optional: %v", err)
+			}
+			return None(t), nil
+		}
+		value, err := encode(valueOf)
+		if err != nil {
+			return nil, fmt.Errorf("encoding optional: %v", err)
+		}
+		return Some(value), nil
+	}
+	decodeOptional := func(elem reflect.Value, v Value) error {
+		optional, ok := v.(Optional)
+		if !ok {
+			return fmt.Errorf("unexpected value of type %T", v)
+		}
+		if optional.IsNone() {
+			elem.Set(reflect.Zero(elem.Type()))
+			return nil
+		}
+		if err := decode(elem, optional.Value); err != nil {
+			return newDecodeError(err, kindOfValue(optional.Value))
+		}
+		return nil
+	}
+	return encodeOptional, decodeOptional
+}
+
+// encodeZeroType returns the type of the zero value of a Go type. It is used to
+// infer the type of values when there are no Go values from which to infer it
+// (e.g. empty slices, and nil pointers). The type is computed the first time
+// that it is needed, and then cached.
+func encodeZeroType(typeOf reflect.Type) (Type, error) {
+	if t, ok := zeroTypes.Load(typeOf); ok {
+		return t.(Type), nil
+	}
+	zero, err := encodeZero(typeOf)
+	if err != nil {
+		return nil, err
+	}
+	t, _ := zeroTypes.LoadOrStore(typeOf, zero.Type())
+	return t.(Type), nil
+}
+
+// resetZeroTypes removes all cached zero types. It must be called whenever the
+// zero type of a Go type can change (e.g. when an interface is registered as a
+// union).
+func resetZeroTypes() {
+	zeroTypes.Range(func(k, _ interface{}) bool {
+		zeroTypes.Delete(k)
+		return true
+	})
+}
+
+// decodeReflect decodes a value into a reflected Go value. The Go value must
+// be addressable.
+func decodeReflect(elem reflect.Value, v Value) error {
+	if !elem.CanAddr() {
+		return fmt.Errorf("expected addressable value, got %v", elem.Type())
+	}
+	return Decode(elem.Addr().Interface(), v)
+}
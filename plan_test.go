@@ -0,0 +1,98 @@
+package pack
+
+import (
+	"testing"
+)
+
+type benchmarkMessage struct {
+	Nonce     uint64            `json:"nonce"`
+	From      [32]byte          `json:"from"`
+	To        [32]byte          `json:"to"`
+	Memo      string            `json:"memo"`
+	Payload   []byte            `json:"payload"`
+	Amounts   []uint64          `json:"amounts"`
+	Metadata  map[string]uint32 `json:"metadata"`
+	Signature *[65]byte         `json:"signature"`
+	Ignored   uint64            `json:"-"`
+	Pair      struct {
+		First  uint32 `json:"first"`
+		Second string `json:"second"`
+	} `json:"pair,tuple"`
+}
+
+func newBenchmarkMessage() benchmarkMessage {
+	msg := benchmarkMessage{
+		Nonce:    42,
+		Memo:     "transfer",
+		Payload:  []byte{1, 2, 3, 4},
+		Amounts:  []uint64{1, 2, 3},
+		Metadata: map[string]uint32{"fee": 1, "gas": 2},
+	}
+	msg.Pair.First = 1
+	msg.Pair.Second = "second"
+	return msg
+}
+
+// clearPlans removes all cached plans, so that the next call to Encode or
+// Decode must compile them again.
+func clearPlans() {
+	structPlans.Range(func(k, _ interface{}) bool {
+		structPlans.Delete(k)
+		return true
+	})
+	resetZeroTypes()
+}
+
+func BenchmarkEncodeCached(b *testing.B) {
+	msg := newBenchmarkMessage()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Encode(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeUncached(b *testing.B) {
+	msg := newBenchmarkMessage()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		clearPlans()
+		if _, err := Encode(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeCached(b *testing.B) {
+	v, err := Encode(newBenchmarkMessage())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var msg benchmarkMessage
+		if err := Decode(&msg, v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeUncached(b *testing.B) {
+	v, err := Encode(newBenchmarkMessage())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		clearPlans()
+		var msg benchmarkMessage
+		if err := Decode(&msg, v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package pack
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Default returns the default value of a type. The default value of an
+// optional type is none, the default value of a union type is the default
+// value of its first variant, and the default value of all other types is the
+// zero value (e.g. zero for integers, empty strings, empty lists, empty maps,
+// and structs/tuples where all fields/elements have their default values).
+func Default(t Type) Value {
+	switch t := t.(type) {
+	case typeBool:
+		return NewBool(false)
+	case typeU8:
+		return NewU8(0)
+	case typeU16:
+		return NewU16(0)
+	case typeU32:
+		return NewU32(0)
+	case typeU64:
+		return NewU64(0)
+	case typeU128:
+		return NewU128FromUint64(0)
+	case typeU256:
+		return NewU256FromUint64(0)
+	case typeI8:
+		return NewI8(0)
+	case typeI16:
+		return NewI16(0)
+	case typeI32:
+		return NewI32(0)
+	case typeI64:
+		return NewI64(0)
+	case typeI128:
+		return NewI128FromInt64(0)
+	case typeI256:
+		return NewI256FromInt64(0)
+	case typeString:
+		return NewString("")
+	case typeBytes:
+		return NewBytes([]byte{})
+	case typeBytes32:
+		return NewBytes32([32]byte{})
+	case typeBytes65:
+		return NewBytes65([65]byte{})
+	case typeBytesN:
+		return BytesN(make([]byte, t.N))
+	case typeStruct:
+		v := make(Struct, len(t))
+		for i, field := range t {
+			v[i] = StructField{Name: field.Name, Value: Default(field.Type)}
+		}
+		return v
+	case typeList:
+		return EmptyList(t.Type)
+	case typeOptional:
+		return None(t.Type)
+	case typeMap:
+		return EmptyMap(t.Key, t.Value)
+	case typeUnion:
+		return Union{T: t, Index: 0, Value: Default(t[0].Type)}
+	case typeTuple:
+		v := make(Tuple, len(t))
+		for i, elemType := range t {
+			v[i] = Default(elemType)
+		}
+		return v
+	default:
+		panic("non-exhaustive pattern")
+	}
+}
+
+// Project a value into the target type, so that it can be read by code that
+// expects the target type. This is useful when the value was marshaled using an
+// older (or newer) version of the target type. Struct fields, and union
+// variants, are matched by name. Struct fields that are missing from the value
+// are filled with their default values (see Default), and struct fields that
+// are not in the target type are dropped. Integers are widened, and values are
+// wrapped into optionals, as required. An error is returned if the value cannot
+// be projected (see Compare for the changes that are supported).
+func Project(v Value, target Type) (Value, error) {
+	if v.Type().Equals(target) {
+		return v, nil
+	}
+
+	if fromBits, fromSigned, ok := intBits(v.Type().Kind()); ok {
+		if toBits, toSigned, ok := intBits(target.Kind()); ok {
+			if !canWidenInt(fromBits, fromSigned, toBits, toSigned) {
+				return nil, fmt.Errorf("cannot narrow %v to %v", v.Type().Kind(), target.Kind())
+			}
+			return newIntFromBig(target.Kind(), intToBig(v)), nil
+		}
+	}
+
+	switch target := target.(type) {
+	case typeStruct:
+		v, ok := v.(Struct)
+		if !ok {
+			break
+		}
+		projected := make(Struct, len(target))
+		for i, field := range target {
+			value := v.Get(field.Name)
+			if value == nil {
+				projected[i] = StructField{Name: field.Name, Value: Default(field.Type)}
+				continue
+			}
+			value, err := Project(value, field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("projecting field \"%v\": %v", field.Name, err)
+			}
+			projected[i] = StructField{Name: field.Name, Value: value}
+		}
+		return projected, nil
+
+	case typeList:
+		v, ok := v.(List)
+		if !ok {
+			break
+		}
+		projected := List{T: target.Type, Elems: make([]Value, len(v.Elems))}
+		for i, elem := range v.Elems {
+			elem, err := Project(elem, target.Type)
+			if err != nil {
+				return nil, fmt.Errorf("projecting list element %v: %v", i, err)
+			}
+			projected.Elems[i] = elem
+		}
+		return projected, nil
+
+	case typeOptional:
+		if v, ok := v.(Optional); ok {
+			if v.IsNone() {
+				return None(target.Type), nil
+			}
+			value, err := Project(v.Value, target.Type)
+			if err != nil {
+				return nil, fmt.Errorf("projecting optional value: %v", err)
+			}
+			return Optional{T: target.Type, Value: value}, nil
+		}
+		value, err := Project(v, target.Type)
+		if err != nil {
+			return nil, fmt.Errorf("projecting optional value: %v", err)
+		}
+		return Optional{T: target.Type, Value: value}, nil
+
+	case typeMap:
+		v, ok := v.(Map)
+		if !ok {
+			break
+		}
+		projected := EmptyMap(target.Key, target.Value)
+		for _, entry := range v.Entries {
+			key, err := Project(entry.Key, target.Key)
+			if err != nil {
+				return nil, fmt.Errorf("projecting map key %v: %v", entry.Key, err)
+			}
+			value, err := Project(entry.Value, target.Value)
+			if err != nil {
+				return nil, fmt.Errorf("projecting map value %v: %v", entry.Key, err)
+			}
+			projected.Set(key, value)
+		}
+		return projected, nil
+
+	case typeUnion:
+		v, ok := v.(Union)
+		if !ok {
+			break
+		}
+		index := target.indexOf(v.Variant())
+		if index < 0 {
+			return nil, fmt.Errorf("unexpected variant \"%v\"", v.Variant())
+		}
+		value, err := Project(v.Value, target[index].Type)
+		if err != nil {
+			return nil, fmt.Errorf("projecting variant \"%v\": %v", v.Variant(), err)
+		}
+		return Union{T: target, Index: uint8(index), Value: value}, nil
+
+	case typeTuple:
+		v, ok := v.(Tuple)
+		if !ok {
+			break
+		}
+		if len(v) != len(target) {
+			return nil, fmt.Errorf("expected tuple length %v, got tuple length %v", len(target), len(v))
+		}
+		projected := make(Tuple, len(target))
+		for i, elem := range v {
+			elem, err := Project(elem, target[i])
+			if err != nil {
+				return nil, fmt.Errorf("projecting tuple element %v: %v", i, err)
+			}
+			projected[i] = elem
+		}
+		return projected, nil
+	}
+
+	return nil, fmt.Errorf("cannot project %v to %v", v.Type().Kind(), target.Kind())
+}
+
+// UnmarshalValueAs unmarshals a value of the writer type from binary, and then
+// projects it into the reader type (see Project). This allows values that were
+// marshaled using an older (or newer) type to be read using the current type.
+func UnmarshalValueAs(writer, reader Type, buf []byte, rem int) (Value, []byte, int, error) {
+	v, buf, rem, err := writer.UnmarshalValue(buf, rem)
+	if err != nil {
+		return nil, buf, rem, err
+	}
+	projected, err := Project(v, reader)
+	if err != nil {
+		return nil, buf, rem, fmt.Errorf("projecting value: %v", err)
+	}
+	return projected, buf, rem, nil
+}
+
+// intToBig returns an integer value as a big integer. It panics if the value is
+// not an integer.
+func intToBig(v Value) *big.Int {
+	switch v := v.(type) {
+	case U8:
+		return new(big.Int).SetUint64(uint64(v.Uint8()))
+	case U16:
+		return new(big.Int).SetUint64(uint64(v.Uint16()))
+	case U32:
+		return new(big.Int).SetUint64(uint64(v.Uint32()))
+	case U64:
+		return new(big.Int).SetUint64(v.Uint64())
+	case U128:
+		return v.Int()
+	case U256:
+		return v.Int()
+	case I8:
+		return big.NewInt(int64(v.Int8()))
+	case I16:
+		return big.NewInt(int64(v.Int16()))
+	case I32:
+		return big.NewInt(int64(v.Int32()))
+	case I64:
+		return big.NewInt(v.Int64())
+	case I128:
+		return v.Int()
+	case I256:
+		return v.Int()
+	default:
+		panic("non-exhaustive pattern")
+	}
+}
+
+// newIntFromBig returns a big integer as an integer value of the given kind. It
+// panics if the big integer cannot be represented by the kind.
+func newIntFromBig(kind Kind, x *big.Int) Value {
+	switch kind {
+	case KindU8:
+		return NewU8(uint8(x.Uint64()))
+	case KindU16:
+		return NewU16(uint16(x.Uint64()))
+	case KindU32:
+		return NewU32(uint32(x.Uint64()))
+	case KindU64:
+		return NewU64(x.Uint64())
+	case KindU128:
+		return NewU128FromInt(x)
+	case KindU256:
+		return NewU256FromInt(x)
+	case KindI8:
+		return NewI8(int8(x.Int64()))
+	case KindI16:
+		return NewI16(int16(x.Int64()))
+	case KindI32:
+		return NewI32(int32(x.Int64()))
+	case KindI64:
+		return NewI64(x.Int64())
+	case KindI128:
+		return NewI128FromInt(x)
+	case KindI256:
+		return NewI256FromInt(x)
+	default:
+		panic("non-exhaustive pattern")
+	}
+}
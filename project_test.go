@@ -0,0 +1,140 @@
+package pack_test
+
+import (
+	"math/rand"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/surge"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Projection", func() {
+
+	numTrials := 100
+
+	mustParseType := func(schema string) pack.Type {
+		t, err := pack.ParseType(schema)
+		Expect(err).ToNot(HaveOccurred())
+		return t
+	}
+
+	Context("when getting the default value of a type", func() {
+		It("should return a value of that type", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < numTrials; trial++ {
+				t := pack.GenerateType(r, 10, pack.GenerateMaxDepth)
+				v := pack.Default(t)
+				Expect(v.Type().Equals(t)).To(BeTrue())
+
+				data, err := surge.ToBinary(v)
+				Expect(err).ToNot(HaveOccurred())
+				for _, b := range data {
+					Expect(b).To(Equal(byte(0)))
+				}
+			}
+		})
+	})
+
+	Context("when projecting a value into its own type", func() {
+		It("should return itself", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < numTrials; trial++ {
+				t := pack.GenerateType(r, 10, pack.GenerateMaxDepth)
+				v := pack.GenerateFromType(r, 10, t)
+				projected, err := pack.Project(v, t)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(projected).To(Equal(v))
+			}
+		})
+	})
+
+	Context("when projecting a struct into a newer version of its type", func() {
+		It("should fill missing fields, drop unknown fields, and widen integers", func() {
+			writer := mustParseType("struct { amount: u32, to: bytes32, memo: string, tags: list<i8> }")
+			reader := mustParseType("struct { to: bytes32, amount: u64, note: optional<string>, count: u16, tags: list<i64> }")
+			Expect(pack.Compatible(writer, reader)).To(BeFalse())
+
+			x := pack.NewStruct(
+				"amount", pack.NewU32(42),
+				"to", pack.NewBytes32([32]byte{1}),
+				"memo", pack.NewString("hello"),
+				"tags", pack.List{T: pack.TypeI8(), Elems: []pack.Value{pack.NewI8(-1), pack.NewI8(1)}},
+			)
+			data, err := surge.ToBinary(x)
+			Expect(err).ToNot(HaveOccurred())
+
+			y, rest, _, err := pack.UnmarshalValueAs(writer, reader, data, len(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rest).To(BeEmpty())
+			Expect(y.Type().Equals(reader)).To(BeTrue())
+			Expect(y).To(Equal(pack.NewStruct(
+				"to", pack.NewBytes32([32]byte{1}),
+				"amount", pack.NewU64(42),
+				"note", pack.None(pack.TypeString()),
+				"count", pack.NewU16(0),
+				"tags", pack.List{T: pack.TypeI64(), Elems: []pack.Value{pack.NewI64(-1), pack.NewI64(1)}},
+			)))
+		})
+	})
+
+	Context("when projecting other values", func() {
+		It("should project their elements", func() {
+			v, err := pack.Project(pack.NewU8(255), pack.TypeI256())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewI256FromInt64(255)))
+
+			v, err = pack.Project(pack.NewU64(1), pack.OptionalType(pack.TypeU128()))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.Some(pack.NewU128FromUint64(1))))
+
+			v, err = pack.Project(pack.None(pack.TypeU8()), pack.OptionalType(pack.TypeU16()))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.None(pack.TypeU16())))
+
+			v, err = pack.Project(pack.NewTuple(pack.NewU8(1), pack.NewI8(-1)), mustParseType("tuple<u16, i16>"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewTuple(pack.NewU16(1), pack.NewI16(-1))))
+
+			m, err := pack.NewMap(pack.NewMapEntry(pack.NewU8(2), pack.NewString("b")), pack.NewMapEntry(pack.NewU8(1), pack.NewString("a")))
+			Expect(err).ToNot(HaveOccurred())
+			v, err = pack.Project(m, mustParseType("map<u32, optional<string>>"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.(pack.Map).Get(pack.NewU32(1))).To(Equal(pack.Some(pack.NewString("a"))))
+			Expect(v.(pack.Map).Get(pack.NewU32(2))).To(Equal(pack.Some(pack.NewString("b"))))
+
+			writer := mustParseType("union { foo: u8, bar: string }")
+			reader := mustParseType("union { baz: bool, bar: string, foo: u16 }")
+			u, err := pack.NewUnion(writer, "foo", pack.NewU8(7))
+			Expect(err).ToNot(HaveOccurred())
+			v, err = pack.Project(u, reader)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.(pack.Union).Variant()).To(Equal("foo"))
+			Expect(v.(pack.Union).Value).To(Equal(pack.NewU16(7)))
+			Expect(v.Type().Equals(reader)).To(BeTrue())
+		})
+	})
+
+	Context("when projecting a value into an incompatible type", func() {
+		It("should return an error", func() {
+			_, err := pack.Project(pack.NewU64(1), pack.TypeU32())
+			Expect(err).To(HaveOccurred())
+			_, err = pack.Project(pack.NewI8(-1), pack.TypeU64())
+			Expect(err).To(HaveOccurred())
+			_, err = pack.Project(pack.NewString("foo"), pack.TypeBytes())
+			Expect(err).To(HaveOccurred())
+			_, err = pack.Project(pack.Some(pack.NewU8(1)), pack.TypeU8())
+			Expect(err).To(HaveOccurred())
+			_, err = pack.Project(pack.NewTuple(pack.NewU8(1)), mustParseType("tuple<u8, u8>"))
+			Expect(err).To(HaveOccurred())
+			_, err = pack.Project(pack.NewStruct("foo", pack.NewString("")), mustParseType("struct { foo: u8 }"))
+			Expect(err).To(HaveOccurred())
+
+			u, err := pack.NewUnion(mustParseType("union { foo: u8 }"), "foo", pack.NewU8(7))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = pack.Project(u, mustParseType("union { bar: u8 }"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
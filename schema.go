@@ -0,0 +1,381 @@
+package pack
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseType parses a type from its textual schema. Scalar types are written
+// using the name of their kind (e.g. "u64", "string", or "bytes32"), and all
+// other types are written as follows:
+//
+//  bytesn<20>
+//  list<string>
+//  optional<u64>
+//  map<string, u64>
+//  tuple<u64, string, bool>
+//  struct { amount: u256, to: bytes32, memo: list<string> }
+//  union { foo: u64, bar: string }
+//
+// Field, and variant, names that are not identifiers must be written as Go
+// string literals. Whitespace between tokens is ignored, and trailing commas
+// are allowed. See FormatType for converting a type back into its textual
+// schema.
+func ParseType(schema string) (Type, error) {
+	p := schemaParser{src: schema}
+	t, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.next(); tok.kind != schemaTokenEOF {
+		return nil, fmt.Errorf("unexpected %v at offset %v", tok, tok.offset)
+	}
+	return t, nil
+}
+
+// FormatType returns the canonical textual schema of a type. The result can be
+// parsed using ParseType.
+func FormatType(t Type) string {
+	builder := new(strings.Builder)
+	formatType(builder, t)
+	return builder.String()
+}
+
+func formatType(builder *strings.Builder, t Type) {
+	switch t := t.(type) {
+	case typeBytesN:
+		fmt.Fprintf(builder, "%v<%v>", KindBytesN, t.N)
+	case typeStruct:
+		formatFields(builder, KindStruct, t)
+	case typeUnion:
+		formatFields(builder, KindUnion, t)
+	case typeList:
+		builder.WriteString(KindList.String() + "<")
+		formatType(builder, t.Type)
+		builder.WriteString(">")
+	case typeOptional:
+		builder.WriteString(KindOptional.String() + "<")
+		formatType(builder, t.Type)
+		builder.WriteString(">")
+	case typeMap:
+		builder.WriteString(KindMap.String() + "<")
+		formatType(builder, t.Key)
+		builder.WriteString(", ")
+		formatType(builder, t.Value)
+		builder.WriteString(">")
+	case typeTuple:
+		builder.WriteString(KindTuple.String() + "<")
+		for i, elemType := range t {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			formatType(builder, elemType)
+		}
+		builder.WriteString(">")
+	default:
+		builder.WriteString(t.Kind().String())
+	}
+}
+
+func formatFields(builder *strings.Builder, kind Kind, fields []typeStructField) {
+	if len(fields) == 0 {
+		builder.WriteString(kind.String() + " {}")
+		return
+	}
+	builder.WriteString(kind.String() + " { ")
+	for i, field := range fields {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		if isSchemaIdent(field.Name) {
+			builder.WriteString(field.Name)
+		} else {
+			builder.WriteString(strconv.Quote(field.Name))
+		}
+		builder.WriteString(": ")
+		formatType(builder, field.Type)
+	}
+	builder.WriteString(" }")
+}
+
+func isSchemaIdent(name string) bool {
+	if name == "" || '0' <= name[0] && name[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isSchemaIdentByte(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isSchemaIdentByte(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+type schemaTokenKind uint8
+
+const (
+	schemaTokenEOF = schemaTokenKind(iota)
+	schemaTokenIdent
+	schemaTokenNumber
+	schemaTokenString
+	schemaTokenPunct
+)
+
+type schemaToken struct {
+	kind   schemaTokenKind
+	text   string
+	offset int
+}
+
+func (tok schemaToken) String() string {
+	if tok.kind == schemaTokenEOF {
+		return "end of schema"
+	}
+	return strconv.Quote(tok.text)
+}
+
+type schemaParser struct {
+	src    string
+	offset int
+	peeked *schemaToken
+}
+
+func (p *schemaParser) peek() schemaToken {
+	if p.peeked == nil {
+		tok := p.scan()
+		p.peeked = &tok
+	}
+	return *p.peeked
+}
+
+func (p *schemaParser) next() schemaToken {
+	tok := p.peek()
+	p.peeked = nil
+	return tok
+}
+
+func (p *schemaParser) scan() schemaToken {
+	for p.offset < len(p.src) && strings.ContainsRune(" \t\r\n", rune(p.src[p.offset])) {
+		p.offset++
+	}
+	start := p.offset
+	if start == len(p.src) {
+		return schemaToken{kind: schemaTokenEOF, offset: start}
+	}
+	c := p.src[start]
+	switch {
+	case isSchemaIdentByte(c) && !('0' <= c && c <= '9'):
+		for p.offset < len(p.src) && isSchemaIdentByte(p.src[p.offset]) {
+			p.offset++
+		}
+		return schemaToken{kind: schemaTokenIdent, text: p.src[start:p.offset], offset: start}
+	case '0' <= c && c <= '9':
+		for p.offset < len(p.src) && '0' <= p.src[p.offset] && p.src[p.offset] <= '9' {
+			p.offset++
+		}
+		return schemaToken{kind: schemaTokenNumber, text: p.src[start:p.offset], offset: start}
+	case c == '"':
+		p.offset++
+		for p.offset < len(p.src) && p.src[p.offset] != '"' {
+			if p.src[p.offset] == '\\' {
+				p.offset++
+			}
+			p.offset++
+		}
+		p.offset++
+		if p.offset > len(p.src) {
+			p.offset = len(p.src)
+		}
+		return schemaToken{kind: schemaTokenString, text: p.src[start:p.offset], offset: start}
+	default:
+		p.offset++
+		return schemaToken{kind: schemaTokenPunct, text: p.src[start:p.offset], offset: start}
+	}
+}
+
+func (p *schemaParser) expect(punct string) error {
+	tok := p.next()
+	if tok.kind != schemaTokenPunct || tok.text != punct {
+		return fmt.Errorf("expected %q, got %v at offset %v", punct, tok, tok.offset)
+	}
+	return nil
+}
+
+func (p *schemaParser) parseType() (Type, error) {
+	tok := p.next()
+	if tok.kind != schemaTokenIdent {
+		return nil, fmt.Errorf("expected type, got %v at offset %v", tok, tok.offset)
+	}
+	var kind Kind
+	if err := kind.UnmarshalText([]byte(tok.text)); err != nil {
+		return nil, fmt.Errorf("unexpected type %v at offset %v", tok, tok.offset)
+	}
+	switch kind {
+	case KindBool:
+		return TypeBool(), nil
+	case KindU8:
+		return TypeU8(), nil
+	case KindU16:
+		return TypeU16(), nil
+	case KindU32:
+		return TypeU32(), nil
+	case KindU64:
+		return TypeU64(), nil
+	case KindU128:
+		return TypeU128(), nil
+	case KindU256:
+		return TypeU256(), nil
+	case KindI8:
+		return TypeI8(), nil
+	case KindI16:
+		return TypeI16(), nil
+	case KindI32:
+		return TypeI32(), nil
+	case KindI64:
+		return TypeI64(), nil
+	case KindI128:
+		return TypeI128(), nil
+	case KindI256:
+		return TypeI256(), nil
+	case KindString:
+		return TypeString(), nil
+	case KindBytes:
+		return TypeBytes(), nil
+	case KindBytes32:
+		return TypeBytes32(), nil
+	case KindBytes65:
+		return TypeBytes65(), nil
+	case KindBytesN:
+		return p.parseBytesN()
+	case KindStruct:
+		fields, err := p.parseFields(kind)
+		if err != nil {
+			return nil, err
+		}
+		return typeStruct(fields), nil
+	case KindUnion:
+		fields, err := p.parseFields(kind)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkUnionVariants(fields); err != nil {
+			return nil, fmt.Errorf("bad union at offset %v: %v", tok.offset, err)
+		}
+		return typeUnion(fields), nil
+	case KindList, KindOptional, KindMap, KindTuple:
+		params, err := p.parseParams()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case kind == KindList && len(params) == 1:
+			return ListType(params[0]), nil
+		case kind == KindOptional && len(params) == 1:
+			return OptionalType(params[0]), nil
+		case kind == KindMap && len(params) == 2:
+			return MapType(params[0], params[1]), nil
+		case kind == KindTuple:
+			return TupleType(params...), nil
+		default:
+			return nil, fmt.Errorf("unexpected number of parameters to %v at offset %v: got %v", kind, tok.offset, len(params))
+		}
+	default:
+		return nil, fmt.Errorf("unexpected type %v at offset %v", tok, tok.offset)
+	}
+}
+
+func (p *schemaParser) parseBytesN() (Type, error) {
+	if err := p.expect("<"); err != nil {
+		return nil, err
+	}
+	tok := p.next()
+	if tok.kind != schemaTokenNumber {
+		return nil, fmt.Errorf("expected length, got %v at offset %v", tok, tok.offset)
+	}
+	n, err := strconv.ParseUint(tok.text, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("bad length at offset %v: %v", tok.offset, err)
+	}
+	if err := p.expect(">"); err != nil {
+		return nil, err
+	}
+	return TypeBytesN(uint32(n)), nil
+}
+
+func (p *schemaParser) parseParams() ([]Type, error) {
+	if err := p.expect("<"); err != nil {
+		return nil, err
+	}
+	params := []Type{}
+	for {
+		if tok := p.peek(); tok.kind == schemaTokenPunct && tok.text == ">" {
+			p.next()
+			return params, nil
+		}
+		param, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+		if tok := p.peek(); tok.kind == schemaTokenPunct && tok.text == "," {
+			p.next()
+			continue
+		}
+		if err := p.expect(">"); err != nil {
+			return nil, err
+		}
+		return params, nil
+	}
+}
+
+func (p *schemaParser) parseFields(kind Kind) ([]typeStructField, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	fields := []typeStructField{}
+	for {
+		tok := p.next()
+		if tok.kind == schemaTokenPunct && tok.text == "}" {
+			return fields, nil
+		}
+
+		var name string
+		switch tok.kind {
+		case schemaTokenIdent:
+			name = tok.text
+		case schemaTokenString:
+			var err error
+			if name, err = strconv.Unquote(tok.text); err != nil {
+				return nil, fmt.Errorf("bad name at offset %v: %v", tok.offset, err)
+			}
+		default:
+			return nil, fmt.Errorf("expected name, got %v at offset %v", tok, tok.offset)
+		}
+		for _, field := range fields {
+			if field.Name == name {
+				return nil, fmt.Errorf("duplicate %v field %q at offset %v", kind, name, tok.offset)
+			}
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		fieldType, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, typeStructField{Name: name, Type: fieldType})
+
+		if tok := p.peek(); tok.kind == schemaTokenPunct && tok.text == "," {
+			p.next()
+			continue
+		}
+		if err := p.expect("}"); err != nil {
+			return nil, err
+		}
+		return fields, nil
+	}
+}
@@ -0,0 +1,133 @@
+package pack_test
+
+import (
+	"encoding/json"
+	"math/rand"
+
+	"github.com/renproject/pack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schema", func() {
+
+	numTrials := 100
+
+	Context("when parsing a schema", func() {
+		It("should return the type", func() {
+			t, err := pack.ParseType("struct { amount: u256, to: bytes32, memo: list<string> }")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t.Equals(pack.StructType(
+				"amount", pack.TypeU256(),
+				"to", pack.TypeBytes32(),
+				"memo", pack.ListType(pack.TypeString()),
+			))).To(BeTrue())
+
+			t, err = pack.ParseType(`
+				union {
+					none: tuple<>,
+					pair: tuple<i64, optional<bytesn<20>>>,
+					"not an ident": map<string, bool>,
+					empty: struct {},
+				}
+			`)
+			Expect(err).ToNot(HaveOccurred())
+			expected := pack.UnionType(
+				pack.NewUnionVariant("none", pack.TupleType()),
+				pack.NewUnionVariant("pair", pack.TupleType(pack.TypeI64(), pack.OptionalType(pack.TypeBytesN(20)))),
+				pack.NewUnionVariant("not an ident", pack.MapType(pack.TypeString(), pack.TypeBool())),
+				pack.NewUnionVariant("empty", pack.StructType()),
+			)
+			Expect(t.Equals(expected)).To(BeTrue())
+		})
+	})
+
+	Context("when formatting a type", func() {
+		It("should return the canonical schema", func() {
+			t := pack.StructType(
+				"amount", pack.TypeU256(),
+				"to", pack.TypeBytes32(),
+				"memo", pack.ListType(pack.TypeString()),
+				"0x", pack.MapType(pack.TypeU8(), pack.TupleType(pack.TypeBytesN(4), pack.StructType())),
+			)
+			Expect(pack.FormatType(t)).To(Equal(`struct { amount: u256, to: bytes32, memo: list<string>, "0x": map<u8, tuple<bytesn<4>, struct {}>> }`))
+		})
+	})
+
+	Context("when formatting and then parsing a type", func() {
+		It("should return itself", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < numTrials; trial++ {
+				t := pack.GenerateType(r, 10, pack.GenerateMaxDepth)
+				schema := pack.FormatType(t)
+				parsed, err := pack.ParseType(schema)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(parsed.Equals(t)).To(BeTrue())
+				Expect(pack.FormatType(parsed)).To(Equal(schema))
+
+				// The parsed type must have the same binary, and JSON,
+				// representation as the original type.
+				expectedData := make([]byte, pack.SizeHintType(t))
+				_, _, err = pack.MarshalType(t, expectedData, len(expectedData))
+				Expect(err).ToNot(HaveOccurred())
+				data := make([]byte, pack.SizeHintType(parsed))
+				_, _, err = pack.MarshalType(parsed, data, len(data))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal(expectedData))
+
+				expectedJSON, err := json.Marshal(pack.StructType("t", t))
+				Expect(err).ToNot(HaveOccurred())
+				dataJSON, err := json.Marshal(pack.StructType("t", parsed))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dataJSON).To(MatchJSON(expectedJSON))
+			}
+		})
+	})
+
+	Context("when unmarshaling a type and then formatting it", func() {
+		It("should parse to the same type", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < numTrials; trial++ {
+				t := pack.GenerateType(r, 10, pack.GenerateMaxDepth)
+				data := make([]byte, pack.SizeHintType(t))
+				_, _, err := pack.MarshalType(t, data, len(data))
+				Expect(err).ToNot(HaveOccurred())
+				var unmarshaled pack.Type
+				_, _, err = pack.UnmarshalType(&unmarshaled, data, len(data))
+				Expect(err).ToNot(HaveOccurred())
+
+				parsed, err := pack.ParseType(pack.FormatType(unmarshaled))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(parsed.Equals(t)).To(BeTrue())
+			}
+		})
+	})
+
+	Context("when parsing a bad schema", func() {
+		It("should return an error", func() {
+			for _, schema := range []string{
+				"",
+				"u7",
+				"u64 u64",
+				"list<>",
+				"list<u8, u8>",
+				"optional<u8",
+				"map<string>",
+				"bytesn<>",
+				"bytesn<4294967296>",
+				"struct { foo: u8, foo: u16 }",
+				"struct { foo u8 }",
+				"struct { 1: u8 }",
+				`struct { "foo: u8 }`,
+				"struct { foo: u8",
+				"union {}",
+				"tuple<u8,,>",
+				"{}",
+			} {
+				_, err := pack.ParseType(schema)
+				Expect(err).To(HaveOccurred(), schema)
+			}
+		})
+	})
+})
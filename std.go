@@ -0,0 +1,126 @@
+package pack
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"time"
+)
+
+// Codecs for Go types from the standard library are registered when the
+// package is initialised, so that Encode and Decode support them without any
+// configuration. They can be replaced by calling RegisterCodec again.
+//
+//  *big.Int, big.Int: u256 (an error is returned for negative integers)
+//  time.Time:         u64 nanoseconds since the Unix epoch (the zero time is 0)
+//  net.IP:            bytes (16 bytes, or 0 bytes for nil)
+//
+// The time.Duration type does not need a codec, because it is an int64, and
+// so it is encoded as an i64 number of nanoseconds.
+func init() {
+	RegisterCodec((*big.Int)(nil), encodeBigInt, decodeBigInt)
+	RegisterCodec(big.Int{}, encodeBigInt, decodeBigInt)
+	RegisterCodec(time.Time{}, encodeTime, decodeTime)
+	RegisterCodec(net.IP{}, encodeIP, decodeIP)
+}
+
+// encodeBigInt encodes a big integer as a u256. Nil big integers are encoded as
+// zero.
+func encodeBigInt(x interface{}) (Value, error) {
+	switch x := x.(type) {
+	case *big.Int:
+		if x == nil {
+			return NewU256FromUint64(0), nil
+		}
+		return newIntOfKind(KindU256, x)
+	default:
+		i := x.(big.Int)
+		return newIntOfKind(KindU256, &i)
+	}
+}
+
+// decodeBigInt decodes a u256 into a pointer to a big integer, or a pointer to
+// a pointer to a big integer.
+func decodeBigInt(x interface{}, v Value) error {
+	u256, ok := v.(U256)
+	if !ok {
+		return fmt.Errorf("unexpected value of type %T", v)
+	}
+	switch x := x.(type) {
+	case **big.Int:
+		*x = u256.Int()
+	default:
+		x.(*big.Int).Set(u256.Int())
+	}
+	return nil
+}
+
+// maxTime is the latest time that can be encoded as the number of nanoseconds
+// since the Unix epoch.
+var maxTime = time.Unix(0, math.MaxInt64)
+
+// encodeTime encodes a time as a u64 number of nanoseconds since the Unix
+// epoch. The zero time is encoded as zero. Otherwise, an error is returned for
+// times before the Unix epoch, or after maxTime.
+func encodeTime(x interface{}) (Value, error) {
+	t := x.(time.Time)
+	if t.IsZero() {
+		return NewU64(0), nil
+	}
+	if t.Before(time.Unix(0, 0)) || t.After(maxTime) {
+		return nil, fmt.Errorf("overflow: %v does not fit in %v", t, KindU64)
+	}
+	return NewU64(uint64(t.UnixNano())), nil
+}
+
+// decodeTime decodes a u64 number of nanoseconds since the Unix epoch into a
+// pointer to a time. Zero is decoded as the zero time, and all other times are
+// decoded in UTC.
+func decodeTime(x interface{}, v Value) error {
+	u64, ok := v.(U64)
+	if !ok {
+		return fmt.Errorf("unexpected value of type %T", v)
+	}
+	if u64 == 0 {
+		*x.(*time.Time) = time.Time{}
+		return nil
+	}
+	if u64.Uint64() > math.MaxInt64 {
+		return fmt.Errorf("overflow: %v does not fit in %T", u64, time.Time{})
+	}
+	*x.(*time.Time) = time.Unix(0, int64(u64.Uint64())).UTC()
+	return nil
+}
+
+// encodeIP encodes an IP address as bytes. IPv4 addresses are encoded in their
+// 16 byte form, so that every IP address has one encoding.
+func encodeIP(x interface{}) (Value, error) {
+	ip := x.(net.IP)
+	if len(ip) == 0 {
+		return NewBytes(nil), nil
+	}
+	ip16 := ip.To16()
+	if ip16 == nil {
+		return nil, fmt.Errorf("expected len=%v or len=%v, got len=%v", net.IPv4len, net.IPv6len, len(ip))
+	}
+	return NewBytes(append([]byte{}, ip16...)), nil
+}
+
+// decodeIP decodes bytes into a pointer to an IP address. The bytes must be
+// empty, or an IPv4 or IPv6 address.
+func decodeIP(x interface{}, v Value) error {
+	b, ok := v.(Bytes)
+	if !ok {
+		return fmt.Errorf("unexpected value of type %T", v)
+	}
+	switch len(b) {
+	case 0:
+		*x.(*net.IP) = nil
+	case net.IPv4len, net.IPv6len:
+		*x.(*net.IP) = append(net.IP{}, b...)
+	default:
+		return fmt.Errorf("expected len=%v or len=%v, got len=%v", net.IPv4len, net.IPv6len, len(b))
+	}
+	return nil
+}
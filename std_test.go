@@ -0,0 +1,211 @@
+package pack_test
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"net"
+	"reflect"
+	"testing/quick"
+	"time"
+
+	"github.com/renproject/pack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Standard library types", func() {
+
+	Context("when encoding and decoding big integers", func() {
+		It("should equal itself", func() {
+			f := func(x [32]byte) bool {
+				i := new(big.Int).SetBytes(x[:])
+				v, err := pack.Encode(i)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(v).To(Equal(pack.NewU256FromInt(i)))
+
+				var j *big.Int
+				Expect(pack.Decode(&j, v)).To(Succeed())
+				Expect(j.Cmp(i)).To(Equal(0))
+
+				var k big.Int
+				Expect(pack.Decode(&k, v)).To(Succeed())
+				Expect(k.Cmp(i)).To(Equal(0))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+
+		It("should encode nil as zero", func() {
+			v, err := pack.Encode((*big.Int)(nil))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewU256FromUint64(0)))
+
+			v, err = pack.Encode([]*big.Int{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.EmptyList(pack.TypeU256())))
+		})
+
+		It("should return an error when the integer does not fit", func() {
+			_, err := pack.Encode(big.NewInt(-1))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("does not fit"))
+
+			_, err = pack.Encode(new(big.Int).Lsh(big.NewInt(1), 256))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("does not fit"))
+
+			var i *big.Int
+			Expect(pack.Decode(&i, pack.NewU128FromUint64(1))).ToNot(Succeed())
+		})
+	})
+
+	Context("when encoding and decoding times", func() {
+		It("should equal itself", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < 100; trial++ {
+				t := time.Unix(0, r.Int63())
+				v, err := pack.Encode(t)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(v).To(Equal(pack.NewU64(uint64(t.UnixNano()))))
+
+				var u time.Time
+				Expect(pack.Decode(&u, v)).To(Succeed())
+				Expect(u.Equal(t)).To(BeTrue())
+				Expect(u.Location()).To(Equal(time.UTC))
+			}
+		})
+
+		It("should encode the zero time as zero", func() {
+			v, err := pack.Encode(time.Time{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewU64(0)))
+
+			u := time.Now()
+			Expect(pack.Decode(&u, v)).To(Succeed())
+			Expect(u.IsZero()).To(BeTrue())
+		})
+
+		It("should return an error when the time does not fit", func() {
+			_, err := pack.Encode(time.Unix(-1, 0))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("does not fit"))
+
+			_, err = pack.Encode(time.Unix(0, math.MaxInt64).Add(time.Nanosecond))
+			Expect(err).To(HaveOccurred())
+
+			var u time.Time
+			Expect(pack.Decode(&u, pack.NewU64(math.MaxUint64))).ToNot(Succeed())
+		})
+	})
+
+	Context("when encoding and decoding durations", func() {
+		It("should equal itself", func() {
+			f := func(d time.Duration) bool {
+				v, err := pack.Encode(d)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(v).To(Equal(pack.NewI64(int64(d))))
+
+				var e time.Duration
+				Expect(pack.Decode(&e, v)).To(Succeed())
+				Expect(e).To(Equal(d))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when encoding and decoding IP addresses", func() {
+		It("should equal itself", func() {
+			for _, ip := range []net.IP{
+				net.IPv4(127, 0, 0, 1),
+				net.IP{10, 0, 0, 1},
+				net.ParseIP("2001:db8::1"),
+			} {
+				v, err := pack.Encode(ip)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(v).To(Equal(pack.NewBytes(ip.To16())))
+
+				var jp net.IP
+				Expect(pack.Decode(&jp, v)).To(Succeed())
+				Expect(jp.Equal(ip)).To(BeTrue())
+			}
+		})
+
+		It("should encode nil as no bytes", func() {
+			v, err := pack.Encode(net.IP(nil))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewBytes(nil)))
+
+			jp := net.IPv4(127, 0, 0, 1)
+			Expect(pack.Decode(&jp, v)).To(Succeed())
+			Expect(jp).To(BeNil())
+		})
+
+		It("should return an error when the length is invalid", func() {
+			_, err := pack.Encode(net.IP{1, 2, 3})
+			Expect(err).To(HaveOccurred())
+
+			var ip net.IP
+			Expect(pack.Decode(&ip, pack.NewBytes([]byte{1, 2, 3}))).ToNot(Succeed())
+		})
+	})
+
+	Context("when encoding and decoding uints", func() {
+		It("should equal itself", func() {
+			f := func(x uint, y uintptr) bool {
+				v, err := pack.Encode(x)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(v).To(Equal(pack.NewU64(uint64(x))))
+				var z uint
+				Expect(pack.Decode(&z, v)).To(Succeed())
+				Expect(z).To(Equal(x))
+
+				v, err = pack.Encode(y)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(v).To(Equal(pack.NewU64(uint64(y))))
+				var w uintptr
+				Expect(pack.Decode(&w, v)).To(Succeed())
+				Expect(w).To(Equal(y))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when encoding and decoding structs with standard library types", func() {
+		It("should equal itself", func() {
+			type Order struct {
+				Amount   *big.Int      `json:"amount"`
+				Deadline time.Time     `json:"deadline"`
+				Timeout  time.Duration `json:"timeout"`
+				Peer     net.IP        `json:"peer"`
+				Count    uint          `json:"count"`
+				Fees     []*big.Int    `json:"fees"`
+			}
+			x := Order{
+				Amount:   big.NewInt(1000),
+				Deadline: time.Unix(1600000000, 0).UTC(),
+				Timeout:  time.Minute,
+				Peer:     net.IPv4(192, 168, 0, 1),
+				Count:    3,
+				Fees:     []*big.Int{big.NewInt(1), big.NewInt(2)},
+			}
+			v, err := pack.Encode(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.Type()).To(Equal(pack.StructType(
+				"amount", pack.TypeU256(),
+				"deadline", pack.TypeU64(),
+				"timeout", pack.TypeI64(),
+				"peer", pack.TypeBytes(),
+				"count", pack.TypeU64(),
+				"fees", pack.ListType(pack.TypeU256()),
+			)))
+
+			var y Order
+			Expect(pack.Decode(&y, v)).To(Succeed())
+			Expect(reflect.DeepEqual(x, y)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,707 @@
+package pack
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/renproject/surge"
+)
+
+// An Encoder writes values, types, and typed values to an output stream. The
+// binary representation written by an encoder is identical to the binary
+// representation produced by marshaling, but the encoder never needs to
+// allocate a buffer for the entire value. Instead, values are written
+// incrementally. Large lists can also be written one element at a time (see
+// EncodeLen), and large byte slices can be copied from another stream (see
+// EncodeBytesFrom), so that they never need to be held in memory.
+type Encoder struct {
+	w       *bufio.Writer
+	scratch []byte
+}
+
+// NewEncoder returns an encoder that writes to the given stream.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// Encode a value to the stream. The type of the value is not written.
+func (enc *Encoder) Encode(v Value) error {
+	if err := enc.encode(v); err != nil {
+		return err
+	}
+	return enc.w.Flush()
+}
+
+// EncodeType encodes a type to the stream.
+func (enc *Encoder) EncodeType(t Type) error {
+	buf := make([]byte, SizeHintType(t))
+	if _, _, err := MarshalType(t, buf, len(buf)); err != nil {
+		return fmt.Errorf("marshaling type: %v", err)
+	}
+	if _, err := enc.w.Write(buf); err != nil {
+		return err
+	}
+	return enc.w.Flush()
+}
+
+// EncodeTyped encodes a typed value to the stream. The type is written first,
+// and then the value.
+func (enc *Encoder) EncodeTyped(typed Typed) error {
+	if err := enc.EncodeType(typed.Type()); err != nil {
+		return err
+	}
+	return enc.Encode(Struct(typed))
+}
+
+// EncodeLen encodes a length prefix to the stream. This can be used to stream
+// a list (or a map) one element at a time: first encode the number of elements
+// using EncodeLen, and then encode each element using Encode (for maps, encode
+// the key and then the value, ordered by the binary representation of the
+// keys). The result can be decoded as a normal list (or map).
+func (enc *Encoder) EncodeLen(n uint32) error {
+	if err := enc.writeU32(n); err != nil {
+		return err
+	}
+	return enc.w.Flush()
+}
+
+// EncodeBytesFrom encodes n bytes, read from the given stream, as a byte slice.
+// The bytes are copied from one stream to the other without being held in
+// memory. The result can be decoded as a normal byte slice.
+func (enc *Encoder) EncodeBytesFrom(r io.Reader, n uint32) error {
+	if err := enc.writeU32(n); err != nil {
+		return err
+	}
+	copied, err := io.CopyN(enc.w, r, int64(n))
+	if err != nil {
+		return fmt.Errorf("copying bytes: expected %v bytes, got %v bytes: %v", n, copied, err)
+	}
+	return enc.w.Flush()
+}
+
+func (enc *Encoder) encode(v Value) error {
+	switch v := v.(type) {
+	case String:
+		if err := enc.writeU32(uint32(len(v))); err != nil {
+			return err
+		}
+		_, err := enc.w.WriteString(string(v))
+		return err
+	case Bytes:
+		if err := enc.writeU32(uint32(len(v))); err != nil {
+			return err
+		}
+		_, err := enc.w.Write(v)
+		return err
+	case BytesN:
+		_, err := enc.w.Write(v)
+		return err
+	case Struct:
+		for _, field := range v {
+			if err := enc.encode(field.Value); err != nil {
+				return fmt.Errorf("encoding field \"%v\": %v", field.Name, err)
+			}
+		}
+		return nil
+	case Typed:
+		buf := make([]byte, SizeHintType(v.Type()))
+		if _, _, err := MarshalType(v.Type(), buf, len(buf)); err != nil {
+			return fmt.Errorf("marshaling type: %v", err)
+		}
+		if _, err := enc.w.Write(buf); err != nil {
+			return err
+		}
+		return enc.encode(Struct(v))
+	case List:
+		if err := enc.writeU32(uint32(len(v.Elems))); err != nil {
+			return err
+		}
+		for i, elem := range v.Elems {
+			if err := enc.encode(elem); err != nil {
+				return fmt.Errorf("encoding list element %v: %v", i, err)
+			}
+		}
+		return nil
+	case Optional:
+		if err := enc.w.WriteByte(boolToByte(v.IsSome())); err != nil {
+			return err
+		}
+		if v.IsNone() {
+			return nil
+		}
+		return enc.encode(v.Value)
+	case Map:
+		entries, err := sortMapEntries(v.Entries)
+		if err != nil {
+			return err
+		}
+		if err := enc.writeU32(uint32(len(entries))); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := enc.encode(entry.Key); err != nil {
+				return fmt.Errorf("encoding map key: %v", err)
+			}
+			if err := enc.encode(entry.Value); err != nil {
+				return fmt.Errorf("encoding map value: %v", err)
+			}
+		}
+		return nil
+	case Union:
+		if err := enc.w.WriteByte(v.Index); err != nil {
+			return err
+		}
+		return enc.encode(v.Value)
+	case Tuple:
+		for i, elem := range v {
+			if err := enc.encode(elem); err != nil {
+				return fmt.Errorf("encoding tuple element %v: %v", i, err)
+			}
+		}
+		return nil
+	default:
+		// All other values are small enough to be marshaled in their
+		// entirety.
+		size := v.SizeHint()
+		if cap(enc.scratch) < size {
+			enc.scratch = make([]byte, size)
+		}
+		buf := enc.scratch[:size]
+		if _, _, err := v.Marshal(buf, size); err != nil {
+			return err
+		}
+		_, err := enc.w.Write(buf)
+		return err
+	}
+}
+
+func (enc *Encoder) writeU32(x uint32) error {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], x)
+	_, err := enc.w.Write(buf[:])
+	return err
+}
+
+func boolToByte(x bool) byte {
+	if x {
+		return 1
+	}
+	return 0
+}
+
+// A Decoder reads values, types, and typed values from an input stream. Values
+// are read incrementally, so the entire value never needs to be buffered
+// before it is decoded. The memory allocated when decoding any one value is
+// limited by a memory budget (see SetMemoryBudget), and other limits are
+// enforced in the same way as when unmarshaling (see SetDecodeOptions).
+type Decoder struct {
+	r     *bufio.Reader
+	opts  DecodeOptions
+	state *decodeState
+
+	// n is the total number of bytes read, and start is the number of bytes
+	// that had been read when the current call began.
+	n     int64
+	start int64
+
+	// eof is true when the stream ended during the current call.
+	eof bool
+}
+
+// NewDecoder returns a decoder that reads from the given stream. By default,
+// the decoder uses the DefaultDecodeOptions, and so the memory budget is
+// surge.MaxBytes.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), opts: DefaultDecodeOptions}
+}
+
+// SetMemoryBudget sets the maximum number of bytes that can be allocated when
+// decoding a value, a type, or a typed value. Decoding anything that requires
+// more memory will return surge.ErrLengthOverflow. This is the same as setting
+// the MaxBytes decode option.
+func (dec *Decoder) SetMemoryBudget(budget int) {
+	dec.opts.MaxBytes = budget
+}
+
+// SetDecodeOptions sets the limits that are enforced when decoding a value, a
+// type, or a typed value. The MaxBytes option is used as the memory budget.
+func (dec *Decoder) SetDecodeOptions(opts DecodeOptions) {
+	dec.opts = opts
+}
+
+// Decode a value of the given type from the stream. If the stream ends before
+// any of the value has been read, io.EOF is returned. Otherwise, errors are
+// returned as a *DecodeError, with offsets that are relative to the first byte
+// read by the call. If the stream ends after some, but not all, of the value
+// has been read, the cause of the error is io.ErrUnexpectedEOF.
+func (dec *Decoder) Decode(t Type) (Value, error) {
+	dec.reset()
+	v, err := dec.decode(t)
+	if err != nil {
+		return nil, dec.checkEOF(err)
+	}
+	return v, nil
+}
+
+// DecodeType decodes a type from the stream. Errors are returned in the same
+// way as by Decode.
+func (dec *Decoder) DecodeType() (Type, error) {
+	dec.reset()
+	t, err := dec.decodeType()
+	if err != nil {
+		return nil, dec.checkEOF(err)
+	}
+	return t, nil
+}
+
+// DecodeTyped decodes a typed value from the stream. The type is read first,
+// and then the value. Errors are returned in the same way as by Decode, and
+// their offsets are relative to the start of the type.
+func (dec *Decoder) DecodeTyped() (Typed, error) {
+	dec.reset()
+	t, err := dec.decodeType()
+	if err != nil {
+		return nil, dec.checkEOF(err)
+	}
+	if t.Kind() != KindStruct {
+		return nil, dec.decodeErrorAt(fmt.Errorf("expected kind \"struct\", got kind \"%v\"", t.Kind()), t.Kind(), dec.n)
+	}
+	v, err := dec.decode(t)
+	if err != nil {
+		return nil, dec.checkEOF(err)
+	}
+	return Typed(v.(Struct)), nil
+}
+
+// DecodeLen decodes a length prefix from the stream. This can be used to
+// stream a list (or a map) one element at a time: first decode the number of
+// elements using DecodeLen, and then decode each element using Decode.
+func (dec *Decoder) DecodeLen() (uint32, error) {
+	dec.reset()
+	n, err := dec.readU32()
+	if err != nil {
+		return 0, dec.checkEOF(dec.decodeErrorAt(err, KindNil, dec.n))
+	}
+	return n, nil
+}
+
+// DecodeBytesTo decodes a byte slice from the stream, and copies it to the
+// given stream. The bytes are copied from one stream to the other without
+// being held in memory, so the memory budget does not apply. It returns the
+// number of bytes copied.
+func (dec *Decoder) DecodeBytesTo(w io.Writer) (uint32, error) {
+	dec.reset()
+	n, err := dec.readU32()
+	if err != nil {
+		return 0, dec.checkEOF(dec.decodeErrorAt(err, KindBytes, dec.n))
+	}
+	copied, err := io.CopyN(w, dec.r, int64(n))
+	dec.n += copied
+	if err != nil {
+		return uint32(copied), dec.decodeErrorAt(dec.checkUnexpectedEOF(err), KindBytes, dec.n)
+	}
+	return n, nil
+}
+
+func (dec *Decoder) reset() {
+	dec.state = newDecodeState(dec.opts)
+	dec.start = dec.n
+	dec.eof = false
+}
+
+// checkEOF returns io.EOF if the stream ended before anything was read during
+// the current call. Otherwise, it returns the error.
+func (dec *Decoder) checkEOF(err error) error {
+	if dec.eof && dec.n == dec.start {
+		return io.EOF
+	}
+	return err
+}
+
+// checkUnexpectedEOF converts an error from reading the stream into
+// io.ErrUnexpectedEOF if the stream ended before all of the expected bytes
+// were read.
+func (dec *Decoder) checkUnexpectedEOF(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		dec.eof = true
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// decodeErrorAt returns a DecodeError in the same way as newDecodeError. If
+// the offset of the error is not known, it is set to the offset of the n-th
+// byte read from the stream, relative to the start of the current call.
+func (dec *Decoder) decodeErrorAt(err error, kind Kind, n int64) error {
+	err = newDecodeError(err, kind)
+	if decodeErr, ok := err.(*DecodeError); ok && decodeErr.Offset < 0 {
+		decodeErr.Offset = int(n - dec.start)
+	}
+	return err
+}
+
+func (dec *Decoder) read(n int) ([]byte, error) {
+	if err := dec.state.alloc(int64(n), 1); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	if err := dec.readFull(buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// readWithLen reads n bytes that were prefixed by their length, and returns
+// them with the length prefix, so that they can be unmarshaled in the same way
+// as when unmarshaling from binary.
+func (dec *Decoder) readWithLen(n uint32) ([]byte, error) {
+	if err := dec.state.alloc(4+int64(n), 1); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 4+int(n))
+	binary.BigEndian.PutUint32(buf, n)
+	if err := dec.readFull(buf[4:]); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (dec *Decoder) readFull(buf []byte) error {
+	read, err := io.ReadFull(dec.r, buf)
+	dec.n += int64(read)
+	if err != nil {
+		return dec.checkUnexpectedEOF(err)
+	}
+	return nil
+}
+
+func (dec *Decoder) readByte() (byte, error) {
+	b, err := dec.r.ReadByte()
+	if err != nil {
+		return 0, dec.checkUnexpectedEOF(err)
+	}
+	dec.n++
+	return b, nil
+}
+
+func (dec *Decoder) readU32() (uint32, error) {
+	var buf [4]byte
+	if err := dec.readFull(buf[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(buf[:]), nil
+}
+
+// readValue reads the binary representation of a value that does not hold
+// other values. These values have a length prefix, or a fixed size, so they
+// can be read in their entirety before being unmarshaled.
+func (dec *Decoder) readValue(t Type) ([]byte, error) {
+	switch t := t.(type) {
+	case typeString, typeBytes:
+		n, err := dec.readU32()
+		if err != nil {
+			return nil, err
+		}
+		return dec.readWithLen(n)
+	case typeBytesN:
+		return dec.read(int(t.N))
+	default:
+		return dec.read(Default(t).SizeHint())
+	}
+}
+
+// decode a value of the given type. Values that hold other values are read
+// incrementally. All other values are read in their entirety, and then
+// unmarshaled in the same way as when unmarshaling from binary. Errors are
+// returned as DecodeErrors, with paths that are relative to the value.
+func (dec *Decoder) decode(t Type) (Value, error) {
+	v, err := dec.decodeValue(t)
+	return v, dec.decodeErrorAt(err, t.Kind(), dec.n)
+}
+
+func (dec *Decoder) decodeValue(t Type) (Value, error) {
+	if _, ok := t.(nestedType); !ok {
+		buf, err := dec.readValue(t)
+		if err != nil {
+			return nil, err
+		}
+		v, rest, _, err := dec.state.unmarshalValue(t, buf, len(buf))
+		return v, dec.decodeErrorAt(err, t.Kind(), dec.n-int64(len(rest)))
+	}
+	if err := dec.state.enter(); err != nil {
+		return nil, err
+	}
+	defer dec.state.leave()
+
+	switch t := t.(type) {
+	case typeStruct:
+		if err := dec.state.alloc(int64(len(t)), sizeOfStructField); err != nil {
+			return nil, err
+		}
+		v := make(Struct, 0, len(t))
+		for _, field := range t {
+			value, err := dec.decode(field.Type)
+			if err != nil {
+				return nil, prefixDecodeError(err, fieldPath(field.Name))
+			}
+			v = append(v, StructField{Name: field.Name, Value: value})
+		}
+		return v, nil
+	case typeList:
+		n, err := dec.readU32()
+		if err != nil {
+			return nil, err
+		}
+		if err := dec.state.checkListLen(int64(n)); err != nil {
+			return nil, err
+		}
+		// The elements are charged as they are read, instead of all at
+		// once, because the length cannot be checked against the number of
+		// bytes that remain in the stream.
+		v := List{T: t.Type, Elems: []Value{}}
+		for i := uint32(0); i < n; i++ {
+			if err := dec.state.alloc(1, sizeOfValue); err != nil {
+				return nil, err
+			}
+			elem, err := dec.decode(t.Type)
+			if err != nil {
+				return nil, prefixDecodeError(err, indexPath(int(i)))
+			}
+			v.Elems = append(v.Elems, elem)
+		}
+		return v, nil
+	case typeOptional:
+		present, err := dec.readByte()
+		if err != nil {
+			return nil, err
+		}
+		var some bool
+		if _, _, err := surge.UnmarshalBool(&some, []byte{present}, 1); err != nil {
+			return nil, fmt.Errorf("unmarshaling optional: %w", err)
+		}
+		if !some {
+			return None(t.Type), nil
+		}
+		value, err := dec.decode(t.Type)
+		if err != nil {
+			return nil, err
+		}
+		return Optional{T: t.Type, Value: value}, nil
+	case typeMap:
+		n, err := dec.readU32()
+		if err != nil {
+			return nil, err
+		}
+		if err := dec.state.checkListLen(int64(n)); err != nil {
+			return nil, err
+		}
+		v := EmptyMap(t.Key, t.Value)
+		var prevKey []byte
+		for i := uint32(0); i < n; i++ {
+			if err := dec.state.alloc(1, sizeOfMapEntry); err != nil {
+				return nil, err
+			}
+			keyStart := dec.n
+			key, err := dec.decode(t.Key)
+			if err != nil {
+				return nil, prefixDecodeError(err, indexPath(int(i))+".key")
+			}
+			keyData, err := surge.ToBinary(key)
+			if err != nil {
+				return nil, err
+			}
+			if i > 0 {
+				if err := checkKeyOrder(prevKey, keyData); err != nil {
+					// The error is reported at the start of the key, because
+					// the key is the cause of the error.
+					return nil, prefixDecodeError(dec.decodeErrorAt(err, KindMap, keyStart), indexPath(int(i))+".key")
+				}
+			}
+			prevKey = keyData
+			value, err := dec.decode(t.Value)
+			if err != nil {
+				return nil, prefixDecodeError(err, indexPath(int(i))+".value")
+			}
+			v.Entries = append(v.Entries, MapEntry{Key: key, Value: value})
+		}
+		return v, nil
+	case typeUnion:
+		index, err := dec.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if err := t.checkIndex(index); err != nil {
+			return nil, fmt.Errorf("unmarshaling variant: %w", err)
+		}
+		value, err := dec.decode(t[index].Type)
+		if err != nil {
+			return nil, prefixDecodeError(err, fieldPath(t[index].Name))
+		}
+		return Union{T: t, Index: index, Value: value}, nil
+	case typeTuple:
+		if err := dec.state.alloc(int64(len(t)), sizeOfValue); err != nil {
+			return nil, err
+		}
+		v := make(Tuple, len(t))
+		for i, elemType := range t {
+			elem, err := dec.decode(elemType)
+			if err != nil {
+				return nil, prefixDecodeError(err, indexPath(i))
+			}
+			v[i] = elem
+		}
+		return v, nil
+	default:
+		panic("non-exhaustive pattern")
+	}
+}
+
+// decodeType decodes a type. Types that hold other types are read
+// incrementally. All other types are read in their entirety, and then
+// unmarshaled in the same way as when unmarshaling from binary. Errors are
+// returned as DecodeErrors, with paths that are relative to the type.
+func (dec *Decoder) decodeType() (_ Type, err error) {
+	kind := KindNil
+	defer func() {
+		err = dec.decodeErrorAt(err, kind, dec.n)
+	}()
+	b, err := dec.readByte()
+	if err != nil {
+		return nil, err
+	}
+	kind = Kind(b)
+	switch kind {
+	case KindStruct, KindList, KindOptional, KindMap, KindUnion, KindTuple:
+	case KindBytesN:
+		n, err := dec.readU32()
+		if err != nil {
+			return nil, err
+		}
+		buf := []byte{b, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(buf[1:], n)
+		return dec.unmarshalType(buf)
+	default:
+		return dec.unmarshalType([]byte{b})
+	}
+
+	if err := dec.state.enter(); err != nil {
+		return nil, err
+	}
+	defer dec.state.leave()
+
+	switch kind {
+	case KindStruct:
+		fields, err := dec.decodeTypeFields(kind)
+		if err != nil {
+			return nil, err
+		}
+		return typeStruct(fields), nil
+	case KindUnion:
+		fields, err := dec.decodeTypeFields(kind)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkUnionVariants(fields); err != nil {
+			return nil, err
+		}
+		return typeUnion(fields), nil
+	case KindList:
+		elemType, err := dec.decodeType()
+		if err != nil {
+			return nil, prefixDecodeError(err, ".[]")
+		}
+		return typeList{Type: elemType}, nil
+	case KindOptional:
+		innerType, err := dec.decodeType()
+		if err != nil {
+			return nil, err
+		}
+		return typeOptional{Type: innerType}, nil
+	case KindMap:
+		keyType, err := dec.decodeType()
+		if err != nil {
+			return nil, prefixDecodeError(err, ".[].key")
+		}
+		valueType, err := dec.decodeType()
+		if err != nil {
+			return nil, prefixDecodeError(err, ".[].value")
+		}
+		return typeMap{Key: keyType, Value: valueType}, nil
+	default:
+		n, err := dec.readU32()
+		if err != nil {
+			return nil, err
+		}
+		if err := dec.state.checkNumFields(int64(n)); err != nil {
+			return nil, err
+		}
+		t := typeTuple{}
+		for i := uint32(0); i < n; i++ {
+			if err := dec.state.alloc(1, sizeOfValue); err != nil {
+				return nil, err
+			}
+			elemType, err := dec.decodeType()
+			if err != nil {
+				return nil, prefixDecodeError(err, indexPath(int(i)))
+			}
+			t = append(t, elemType)
+		}
+		return t, nil
+	}
+}
+
+// unmarshalType from the binary representation of a type that does not hold
+// other types. The buffer must be the last bytes read from the stream.
+func (dec *Decoder) unmarshalType(buf []byte) (Type, error) {
+	var t Type
+	if rest, _, err := dec.state.unmarshalType(&t, buf, len(buf)); err != nil {
+		return nil, dec.decodeErrorAt(err, KindNil, dec.n-int64(len(rest)))
+	}
+	return t, nil
+}
+
+// decodeTypeFields decodes the fields of a struct type, or the variants of a
+// union type.
+func (dec *Decoder) decodeTypeFields(kind Kind) ([]typeStructField, error) {
+	n, err := dec.readU32()
+	if err != nil {
+		return nil, err
+	}
+	if kind == KindUnion {
+		if err := checkNumVariants(n); err != nil {
+			return nil, err
+		}
+	}
+	if err := dec.state.checkNumFields(int64(n)); err != nil {
+		return nil, err
+	}
+	fields := []typeStructField{}
+	for i := uint32(0); i < n; i++ {
+		nameLen, err := dec.readU32()
+		if err != nil {
+			return nil, err
+		}
+		if err := dec.state.checkNameLen(int64(nameLen)); err != nil {
+			return nil, err
+		}
+		buf, err := dec.readWithLen(nameLen)
+		if err != nil {
+			return nil, err
+		}
+		var name string
+		if _, _, err := surge.UnmarshalString(&name, buf, len(buf)); err != nil {
+			return nil, err
+		}
+		fieldType, err := dec.decodeType()
+		if err != nil {
+			return nil, prefixDecodeError(err, fieldPath(name))
+		}
+		if err := dec.state.alloc(1, sizeOfStructField); err != nil {
+			return nil, err
+		}
+		fields = append(fields, typeStructField{Name: name, Type: fieldType})
+	}
+	return fields, nil
+}
@@ -0,0 +1,240 @@
+package pack_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/surge"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Streaming", func() {
+
+	numTrials := 100
+
+	Context("when encoding values", func() {
+		It("should produce the same bytes as marshaling", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < numTrials; trial++ {
+				t := pack.GenerateType(r, 10, pack.GenerateMaxDepth)
+				v := pack.GenerateFromType(r, 10, t)
+
+				w := new(bytes.Buffer)
+				Expect(pack.NewEncoder(w).Encode(v)).To(Succeed())
+
+				data, err := surge.ToBinary(v)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(w.Bytes()).To(Equal(data))
+			}
+		})
+	})
+
+	Context("when encoding and then decoding values", func() {
+		It("should return the original values", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < numTrials; trial++ {
+				t := pack.GenerateType(r, 10, pack.GenerateMaxDepth)
+				v := pack.GenerateFromType(r, 10, t)
+
+				w := new(bytes.Buffer)
+				Expect(pack.NewEncoder(w).Encode(v)).To(Succeed())
+
+				dec := pack.NewDecoder(w)
+				decoded, err := dec.Decode(t)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(decoded.Type().Equals(t)).To(BeTrue())
+
+				data, err := surge.ToBinary(v)
+				Expect(err).ToNot(HaveOccurred())
+				decodedData, err := surge.ToBinary(decoded)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(decodedData).To(Equal(data))
+
+				// Values that have no bytes (e.g. empty structs) can always be
+				// decoded, even at the end of the stream.
+				if len(data) > 0 {
+					_, err = dec.Decode(t)
+					Expect(err).To(Equal(io.EOF))
+				}
+			}
+		})
+	})
+
+	Context("when encoding and then decoding types", func() {
+		It("should return the original types", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < numTrials; trial++ {
+				t := pack.GenerateType(r, 10, pack.GenerateMaxDepth)
+
+				w := new(bytes.Buffer)
+				Expect(pack.NewEncoder(w).EncodeType(t)).To(Succeed())
+
+				data := make([]byte, pack.SizeHintType(t))
+				_, _, err := pack.MarshalType(t, data, len(data))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(w.Bytes()).To(Equal(data))
+
+				decoded, err := pack.NewDecoder(w).DecodeType()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(decoded.Equals(t)).To(BeTrue())
+			}
+		})
+	})
+
+	Context("when encoding and then decoding many typed values", func() {
+		It("should return the original typed values, and then EOF", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			typeds := make([]pack.Typed, numTrials)
+			w := new(bytes.Buffer)
+			enc := pack.NewEncoder(w)
+			for i := range typeds {
+				t := pack.GenerateTypeFromKind(r, 10, pack.KindStruct, pack.GenerateMaxDepth)
+				typeds[i] = pack.Typed(pack.GenerateFromType(r, 10, t).(pack.Struct))
+				Expect(enc.EncodeTyped(typeds[i])).To(Succeed())
+			}
+
+			dec := pack.NewDecoder(w)
+			for i := range typeds {
+				typed, err := dec.DecodeTyped()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(typed.Type().Equals(typeds[i].Type())).To(BeTrue())
+				data, err := surge.ToBinary(typeds[i])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(surge.ToBinary(typed)).To(Equal(data))
+			}
+			_, err := dec.DecodeTyped()
+			Expect(err).To(Equal(io.EOF))
+		})
+	})
+
+	Context("when decoding from a truncated stream", func() {
+		It("should return an unexpected EOF error", func() {
+			w := new(bytes.Buffer)
+			Expect(pack.NewEncoder(w).Encode(pack.NewString("hello, world"))).To(Succeed())
+			dec := pack.NewDecoder(bytes.NewReader(w.Bytes()[:w.Len()-1]))
+			_, err := dec.Decode(pack.TypeString())
+			Expect(errors.Is(err, io.ErrUnexpectedEOF)).To(BeTrue())
+
+			dec = pack.NewDecoder(bytes.NewReader([]byte{0, 0}))
+			_, err = dec.Decode(pack.TypeU64())
+			Expect(errors.Is(err, io.ErrUnexpectedEOF)).To(BeTrue())
+
+			// The offset is the number of bytes read before the stream ended.
+			dec = pack.NewDecoder(bytes.NewReader([]byte{0, 0, 0, 1, 7, 0, 0, 0, 3, 'a'}))
+			_, err = dec.Decode(pack.MapType(pack.TypeU8(), pack.TypeString()))
+			decodeErr := new(pack.DecodeError)
+			Expect(errors.As(err, &decodeErr)).To(BeTrue())
+			Expect(decodeErr.Path).To(Equal(".[0].value"))
+			Expect(decodeErr.Offset).To(Equal(10))
+			Expect(decodeErr.Kind).To(Equal(pack.KindString))
+		})
+	})
+
+	Context("when decoding invalid values", func() {
+		It("should return the same decode errors as unmarshaling", func() {
+			t, err := pack.ParseType("struct { a: u8, b: list<optional<u8>>, c: map<u8, string> }")
+			Expect(err).ToNot(HaveOccurred())
+			for _, data := range [][]byte{
+				// The value of the second element of b is missing.
+				{1, 0, 0, 0, 2, 1, 5, 1},
+				// The keys of c are not in canonical order.
+				{1, 0, 0, 0, 0, 0, 0, 0, 2, 2, 0, 0, 0, 0, 1, 0, 0, 0, 0},
+			} {
+				_, _, _, err := pack.DefaultDecodeOptions.UnmarshalValue(t, data, len(data))
+				expected := new(pack.DecodeError)
+				Expect(errors.As(err, &expected)).To(BeTrue())
+
+				_, err = pack.NewDecoder(bytes.NewReader(data)).Decode(t)
+				decodeErr := new(pack.DecodeError)
+				Expect(errors.As(err, &decodeErr)).To(BeTrue())
+				Expect(decodeErr.Path).To(Equal(expected.Path))
+				Expect(decodeErr.Offset).To(Equal(expected.Offset))
+				Expect(decodeErr.Kind).To(Equal(expected.Kind))
+			}
+		})
+	})
+
+	Context("when decoding values that exceed the memory budget", func() {
+		It("should return an error", func() {
+			w := new(bytes.Buffer)
+			enc := pack.NewEncoder(w)
+			Expect(enc.Encode(pack.NewBytes(make([]byte, 100)))).To(Succeed())
+			Expect(enc.Encode(pack.NewBytes(make([]byte, 10)))).To(Succeed())
+
+			dec := pack.NewDecoder(w)
+			dec.SetMemoryBudget(50)
+			_, err := dec.Decode(pack.TypeBytes())
+			Expect(errors.Is(err, surge.ErrLengthOverflow)).To(BeTrue())
+
+			dec = pack.NewDecoder(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff}))
+			_, err = dec.Decode(pack.TypeString())
+			Expect(errors.Is(err, surge.ErrLengthOverflow)).To(BeTrue())
+
+			dec = pack.NewDecoder(bytes.NewReader(make([]byte, 100)))
+			dec.SetMemoryBudget(50)
+			_, err = dec.Decode(pack.TypeBytesN(100))
+			Expect(errors.Is(err, surge.ErrLengthOverflow)).To(BeTrue())
+		})
+	})
+
+	Context("when streaming a list one element at a time", func() {
+		It("should decode as a list", func() {
+			w := new(bytes.Buffer)
+			enc := pack.NewEncoder(w)
+			Expect(enc.EncodeLen(3)).To(Succeed())
+			for i := 0; i < 3; i++ {
+				Expect(enc.Encode(pack.NewU16(uint16(i)))).To(Succeed())
+			}
+			data := append([]byte{}, w.Bytes()...)
+
+			dec := pack.NewDecoder(w)
+			v, err := dec.Decode(pack.ListType(pack.TypeU16()))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.List{T: pack.TypeU16(), Elems: []pack.Value{pack.NewU16(0), pack.NewU16(1), pack.NewU16(2)}}))
+
+			dec = pack.NewDecoder(bytes.NewReader(data))
+			n, err := dec.DecodeLen()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(Equal(uint32(3)))
+			for i := uint32(0); i < n; i++ {
+				v, err := dec.Decode(pack.TypeU16())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(v).To(Equal(pack.NewU16(uint16(i))))
+			}
+		})
+	})
+
+	Context("when streaming bytes", func() {
+		It("should copy the bytes between streams", func() {
+			src := make([]byte, 1<<16)
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			r.Read(src)
+
+			pr, pw := io.Pipe()
+			go func() {
+				defer GinkgoRecover()
+				Expect(pack.NewEncoder(pw).EncodeBytesFrom(bytes.NewReader(src), uint32(len(src)))).To(Succeed())
+				Expect(pw.Close()).To(Succeed())
+			}()
+
+			dst := new(bytes.Buffer)
+			dec := pack.NewDecoder(pr)
+			dec.SetMemoryBudget(1024)
+			n, err := dec.DecodeBytesTo(dst)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(Equal(uint32(len(src))))
+			Expect(dst.Bytes()).To(Equal(src))
+		})
+
+		It("should return an error if the source stream is too short", func() {
+			w := new(bytes.Buffer)
+			err := pack.NewEncoder(w).EncodeBytesFrom(bytes.NewReader([]byte{1, 2, 3}), 4)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
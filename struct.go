@@ -37,15 +37,14 @@ func (x StructField) Marshal(buf []byte, rem int) ([]byte, int, error) {
 
 // Generate a random struct field. This method is implemented for use in quick
 // tests. See https://golang.org/pkg/testing/quick/#Generator for more
-// information. Generated struct fields will never have structs as values.
+// information. Generated struct fields have values that are nested to at most
+// one less than GenerateMaxDepth.
 func (x StructField) Generate(r *rand.Rand, size int) reflect.Value {
 	name, _ := quick.Value(reflect.TypeOf(""), r)
-	for {
-		return reflect.ValueOf(StructField{
-			Name:  name.String(),
-			Value: Generate(r, size, false, false).Interface().(Value),
-		})
-	}
+	return reflect.ValueOf(StructField{
+		Name:  name.String(),
+		Value: GenerateFromType(r, size, GenerateType(r, size, GenerateMaxDepth-1)),
+	})
 }
 
 // Struct represents a structured record.
@@ -152,16 +151,10 @@ func (v Struct) String() string {
 	return string(data)
 }
 
-// Generate a random struct field. This method is implemented for use in quick
-// tests. See https://golang.org/pkg/testing/quick/#Generator for more
-// information. Generated structs will never contain embedded structs.
+// Generate a random struct. This method is implemented for use in quick tests.
+// See https://golang.org/pkg/testing/quick/#Generator for more information.
+// Generated structs are nested to at most GenerateMaxDepth.
 func (Struct) Generate(r *rand.Rand, size int) reflect.Value {
-	s := make(Struct, 0, size)
-	for i := 0; i < size; i++ {
-		structField := StructField{}.Generate(r, size).Interface().(StructField)
-		if s.Get(structField.Name) == nil {
-			s = append(s, structField)
-		}
-	}
-	return reflect.ValueOf(s)
+	t := GenerateTypeFromKind(r, size, KindStruct, GenerateMaxDepth)
+	return reflect.ValueOf(GenerateFromType(r, size, t))
 }
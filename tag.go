@@ -0,0 +1,424 @@
+package pack
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+// fieldTag represents the parsed tag of a Go struct field.
+type fieldTag struct {
+	// name of the field, or the empty string if the field is not named by its
+	// tag (see parseFieldTag).
+	name string
+	// named is true when the name of the field is set by the tag, instead of
+	// defaulting to the name of the Go struct field.
+	named bool
+	// ignored is true when the field is ignored by its tag.
+	ignored bool
+	// tuple is true when the field is a Go struct that must be encoded as a
+	// tuple instead of as a struct.
+	tuple bool
+	// inline is true when the field is a Go struct whose fields must be
+	// encoded as if they were fields of the outer Go struct.
+	inline bool
+	// optional is true when the field must be encoded as an optional. Zero
+	// values are encoded as none, and all other values are encoded as some
+	// value.
+	optional bool
+	// kind that the field must be encoded as, or KindNil if the kind must be
+	// inferred from the Go type of the field.
+	kind Kind
+}
+
+// parseFieldTag parses the tag of a Go struct field. The pack tag is used when
+// it is present, otherwise the json tag is used.
+//
+// In pack tags, the first element of the tag is the name (which defaults to
+// the name of the Go struct field), and the remaining elements are options:
+//
+//  tuple:    encode a Go struct as a tuple
+//  inline:   encode the fields of a Go struct as fields of the outer Go struct
+//  optional: encode zero values as none, and all other values as some value
+//  <kind>:   encode the field as a bool, string, bytes, bytes32, bytes65, or
+//            integer (e.g. u64, u256, i128)
+//
+// Empty options are ignored, so that a field can be named "-" using the tag
+// "-,", and unrecognised options are an error.
+//
+// In json tags, the name is the first element of the tag that is not
+// "omitempty" (or the name of the Go struct field, if there is no such
+// element). Fields that are named "-", or that have no name in their json tag
+// (including fields with no tag), are not encoded, unless they are embedded Go
+// structs (see compileStructPlan). These are the rules that have always been
+// used to name fields, so the encoding of existing Go structs does not change.
+// Only the tuple option is recognised in json tags, and all other options are
+// ignored (they are options for the encoding/json package).
+func parseFieldTag(f reflect.StructField) (fieldTag, error) {
+	raw, isPack := f.Tag.Lookup("pack")
+	if !isPack {
+		return parseJSONFieldTag(f), nil
+	}
+	tags := strings.Split(raw, ",")
+	if tags[0] == "-" && len(tags) == 1 {
+		return fieldTag{ignored: true}, nil
+	}
+	tag := fieldTag{name: tags[0], named: tags[0] != ""}
+	if tag.name == "" {
+		tag.name = f.Name
+	}
+	for _, option := range tags[1:] {
+		switch option {
+		case "":
+		case "tuple":
+			tag.tuple = true
+		case "inline":
+			tag.inline = true
+		case "optional":
+			tag.optional = true
+		default:
+			var kind Kind
+			if err := kind.UnmarshalText([]byte(option)); err != nil || scalarType(kind) == nil {
+				return fieldTag{}, fmt.Errorf("unexpected option %q", option)
+			}
+			if tag.kind != KindNil {
+				return fieldTag{}, fmt.Errorf("unexpected option %q: kind is already %v", option, tag.kind)
+			}
+			tag.kind = kind
+		}
+	}
+	if tag.inline && (tag.tuple || tag.optional || tag.kind != KindNil) {
+		return fieldTag{}, fmt.Errorf("unexpected options: inline fields cannot have other options")
+	}
+	if tag.tuple && tag.kind != KindNil {
+		return fieldTag{}, fmt.Errorf("unexpected options: tuple fields cannot be encoded as %v", tag.kind)
+	}
+	return tag, nil
+}
+
+// parseJSONFieldTag parses the json tag of a Go struct field (see
+// parseFieldTag).
+func parseJSONFieldTag(f reflect.StructField) fieldTag {
+	tags := strings.Split(f.Tag.Get("json"), ",")
+	tag := fieldTag{name: f.Name}
+	for _, name := range tags {
+		if name == "-" {
+			return fieldTag{ignored: true}
+		}
+		if name != "omitempty" {
+			tag.name = name
+			tag.named = name != ""
+			break
+		}
+	}
+	for _, option := range tags[1:] {
+		if option == "tuple" {
+			tag.tuple = true
+		}
+	}
+	return tag
+}
+
+// scalarType returns the type of a kind that can be used in a tag, or nil if
+// the kind cannot be used in a tag.
+func scalarType(kind Kind) Type {
+	switch kind {
+	case KindBool:
+		return TypeBool()
+	case KindU8:
+		return TypeU8()
+	case KindU16:
+		return TypeU16()
+	case KindU32:
+		return TypeU32()
+	case KindU64:
+		return TypeU64()
+	case KindU128:
+		return TypeU128()
+	case KindU256:
+		return TypeU256()
+	case KindI8:
+		return TypeI8()
+	case KindI16:
+		return TypeI16()
+	case KindI32:
+		return TypeI32()
+	case KindI64:
+		return TypeI64()
+	case KindI128:
+		return TypeI128()
+	case KindI256:
+		return TypeI256()
+	case KindString:
+		return TypeString()
+	case KindBytes:
+		return TypeBytes()
+	case KindBytes32:
+		return TypeBytes32()
+	case KindBytes65:
+		return TypeBytes65()
+	default:
+		return nil
+	}
+}
+
+// kindCodec returns functions that encode/decode a Go type as a specific kind.
+// Pointers (other than big integers) are encoded as optionals of the kind. An
+// error is returned if the Go type cannot be encoded as the kind.
+func kindCodec(typeOf reflect.Type, kind Kind) (func(reflect.Value) (Value, error), func(reflect.Value, Value) error, error) {
+	if typeOf.Kind() == reflect.Ptr && typeOf != bigIntPtrType {
+		encode, decode, err := kindCodec(typeOf.Elem(), kind)
+		if err != nil {
+			return nil, nil, err
+		}
+		encodePtr := func(valueOf reflect.Value) (Value, error) {
+			if valueOf.IsNil() {
+				return None(scalarType(kind)), nil
+			}
+			value, err := encode(valueOf.Elem())
+			if err != nil {
+				return nil, fmt.Errorf("encoding optional: %v", err)
+			}
+			return Some(value), nil
+		}
+		decodePtr := func(elem reflect.Value, v Value) error {
+			optional, ok := v.(Optional)
+			if !ok {
+				return fmt.Errorf("unexpected value of type %T", v)
+			}
+			if optional.IsNone() {
+				elem.Set(reflect.Zero(elem.Type()))
+				return nil
+			}
+			ptr := reflect.New(elem.Type().Elem())
+			if err := decode(ptr.Elem(), optional.Value); err != nil {
+				return newDecodeError(err, kindOfValue(optional.Value))
+			}
+			elem.Set(ptr)
+			return nil
+		}
+		return encodePtr, decodePtr, nil
+	}
+
+	switch kind {
+	case KindBool:
+		if typeOf.Kind() != reflect.Bool {
+			break
+		}
+		encode := func(valueOf reflect.Value) (Value, error) {
+			return NewBool(valueOf.Bool()), nil
+		}
+		decode := func(elem reflect.Value, v Value) error {
+			b, ok := v.(Bool)
+			if !ok {
+				return fmt.Errorf("unexpected value of type %T", v)
+			}
+			elem.SetBool(bool(b))
+			return nil
+		}
+		return encode, decode, nil
+
+	case KindU8, KindU16, KindU32, KindU64, KindU128, KindU256,
+		KindI8, KindI16, KindI32, KindI64, KindI128, KindI256:
+		if !isIntType(typeOf) {
+			break
+		}
+		encode := func(valueOf reflect.Value) (Value, error) {
+			return newIntOfKind(kind, reflectToInt(valueOf))
+		}
+		decode := func(elem reflect.Value, v Value) error {
+			if v.Type().Kind() != kind {
+				return fmt.Errorf("unexpected value of type %T", v)
+			}
+			return setReflectInt(elem, intToBig(v))
+		}
+		return encode, decode, nil
+
+	case KindString, KindBytes, KindBytes32, KindBytes65:
+		if !isBytesType(typeOf) {
+			break
+		}
+		encode := func(valueOf reflect.Value) (Value, error) {
+			return newBytesOfKind(kind, reflectToBytes(valueOf))
+		}
+		decode := func(elem reflect.Value, v Value) error {
+			if v.Type().Kind() != kind {
+				return fmt.Errorf("unexpected value of type %T", v)
+			}
+			return setReflectBytes(elem, bytesOfValue(v))
+		}
+		return encode, decode, nil
+	}
+	return nil, nil, fmt.Errorf("cannot encode %v as %v", typeOf, kind)
+}
+
+var (
+	bigIntType    = reflect.TypeOf(big.Int{})
+	bigIntPtrType = reflect.TypeOf((*big.Int)(nil))
+)
+
+// isIntType returns true if the Go type can be encoded as an integer kind.
+func isIntType(typeOf reflect.Type) bool {
+	switch typeOf.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return typeOf == bigIntType || typeOf == bigIntPtrType
+}
+
+// isBytesType returns true if the Go type can be encoded as a string, or bytes
+// kind.
+func isBytesType(typeOf reflect.Type) bool {
+	switch typeOf.Kind() {
+	case reflect.String:
+		return true
+	case reflect.Slice, reflect.Array:
+		return typeOf.Elem().Kind() == reflect.Uint8
+	}
+	return false
+}
+
+// reflectToInt returns the integer held by a reflected Go value. Nil big
+// integers are zero.
+func reflectToInt(valueOf reflect.Value) *big.Int {
+	switch valueOf.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(valueOf.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Int).SetUint64(valueOf.Uint())
+	case reflect.Ptr:
+		if valueOf.IsNil() {
+			return new(big.Int)
+		}
+		return new(big.Int).Set(valueOf.Interface().(*big.Int))
+	default:
+		x := valueOf.Interface().(big.Int)
+		return new(big.Int).Set(&x)
+	}
+}
+
+// setReflectInt sets a reflected Go value to an integer. An error is returned
+// if the integer overflows the Go value.
+func setReflectInt(elem reflect.Value, x *big.Int) error {
+	switch elem.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !x.IsInt64() || elem.OverflowInt(x.Int64()) {
+			return fmt.Errorf("overflow: %v does not fit in %v", x, elem.Type())
+		}
+		elem.SetInt(x.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if !x.IsUint64() || elem.OverflowUint(x.Uint64()) {
+			return fmt.Errorf("overflow: %v does not fit in %v", x, elem.Type())
+		}
+		elem.SetUint(x.Uint64())
+	case reflect.Ptr:
+		elem.Set(reflect.ValueOf(x))
+	default:
+		elem.Addr().Interface().(*big.Int).Set(x)
+	}
+	return nil
+}
+
+// newIntOfKind returns an integer value of a specific kind (see
+// newIntFromBig). An error is returned if the integer overflows the kind.
+func newIntOfKind(kind Kind, x *big.Int) (Value, error) {
+	bits, signed, _ := intBits(kind)
+	if !intFits(x, bits, signed) {
+		return nil, fmt.Errorf("overflow: %v does not fit in %v", x, kind)
+	}
+	return newIntFromBig(kind, x), nil
+}
+
+// intFits returns true if the integer can be represented using the given
+// number of bits.
+func intFits(x *big.Int, bits int, signed bool) bool {
+	if !signed {
+		return x.Sign() >= 0 && x.BitLen() <= bits
+	}
+	if x.Sign() >= 0 {
+		return x.BitLen() <= bits-1
+	}
+	// The most negative integer is -2^(bits-1), so -x-1 must fit in bits-1.
+	return new(big.Int).Not(x).BitLen() <= bits-1
+}
+
+// reflectToBytes returns the bytes held by a reflected Go string, byte slice,
+// or byte array.
+func reflectToBytes(valueOf reflect.Value) []byte {
+	switch valueOf.Kind() {
+	case reflect.String:
+		return []byte(valueOf.String())
+	case reflect.Slice:
+		return append([]byte{}, valueOf.Bytes()...)
+	default:
+		b := make([]byte, valueOf.Len())
+		for i := range b {
+			b[i] = uint8(valueOf.Index(i).Uint())
+		}
+		return b
+	}
+}
+
+// setReflectBytes sets a reflected Go string, byte slice, or byte array to the
+// given bytes. An error is returned if the length of a byte array does not
+// match the number of bytes.
+func setReflectBytes(elem reflect.Value, b []byte) error {
+	switch elem.Kind() {
+	case reflect.String:
+		elem.SetString(string(b))
+	case reflect.Slice:
+		elem.SetBytes(b)
+	default:
+		if len(b) != elem.Len() {
+			return fmt.Errorf("expected len=%v, got len=%v", elem.Len(), len(b))
+		}
+		for i := range b {
+			elem.Index(i).SetUint(uint64(b[i]))
+		}
+	}
+	return nil
+}
+
+// newBytesOfKind returns a string, or bytes, value of a specific kind. No bytes
+// are encoded as zero bytes. Otherwise, an error is returned if the number of
+// bytes does not match the kind.
+func newBytesOfKind(kind Kind, b []byte) (Value, error) {
+	switch kind {
+	case KindString:
+		return NewString(string(b)), nil
+	case KindBytes:
+		return NewBytes(b), nil
+	case KindBytes32:
+		var x Bytes32
+		if len(b) != 0 && len(b) != len(x) {
+			return nil, fmt.Errorf("expected len=%v, got len=%v", len(x), len(b))
+		}
+		copy(x[:], b)
+		return x, nil
+	default:
+		var x Bytes65
+		if len(b) != 0 && len(b) != len(x) {
+			return nil, fmt.Errorf("expected len=%v, got len=%v", len(x), len(b))
+		}
+		copy(x[:], b)
+		return x, nil
+	}
+}
+
+// bytesOfValue returns a copy of the bytes held by a string, or bytes, value.
+func bytesOfValue(v Value) []byte {
+	switch v := v.(type) {
+	case String:
+		return []byte(v)
+	case Bytes:
+		return append([]byte{}, v...)
+	case Bytes32:
+		return append([]byte{}, v[:]...)
+	case Bytes65:
+		return append([]byte{}, v[:]...)
+	default:
+		return nil
+	}
+}
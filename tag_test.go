@@ -0,0 +1,346 @@
+package pack_test
+
+import (
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing/quick"
+
+	"github.com/renproject/pack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tags", func() {
+
+	Context("when a field has a pack tag", func() {
+		It("should use the pack tag instead of the json tag", func() {
+			type Renamed struct {
+				Foo uint64 `json:"foo" pack:"bar"`
+				Baz uint64 `json:"baz"`
+				Ign uint64 `json:"ign" pack:"-"`
+				Def uint64 `json:"def" pack:""`
+			}
+			v, err := pack.Encode(Renamed{Foo: 1, Baz: 2, Ign: 3, Def: 4})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.(pack.Struct).Get("foo")).To(BeNil())
+			Expect(v.(pack.Struct).Get("bar")).To(Equal(pack.NewU64(1)))
+			Expect(v.(pack.Struct).Get("baz")).To(Equal(pack.NewU64(2)))
+			Expect(v.(pack.Struct).Get("ign")).To(BeNil())
+			Expect(v.(pack.Struct).Get("Def")).To(Equal(pack.NewU64(4)))
+
+			var x Renamed
+			Expect(pack.Decode(&x, v)).To(Succeed())
+			Expect(x).To(Equal(Renamed{Foo: 1, Baz: 2, Def: 4}))
+		})
+
+		It("should return an error for unknown options", func() {
+			type Unknown struct {
+				Foo uint64 `pack:"foo,unknown"`
+			}
+			_, err := pack.Encode(Unknown{})
+			Expect(err).To(HaveOccurred())
+			Expect(pack.Decode(&Unknown{}, pack.NewStruct("foo", pack.NewU64(0)))).ToNot(Succeed())
+		})
+
+		It("should ignore pack options in json tags", func() {
+			type JSONOnly struct {
+				Foo uint64 `json:"foo,omitempty,u128,optional"`
+			}
+			v, err := pack.Encode(JSONOnly{Foo: 1})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.(pack.Struct).Get("foo")).To(Equal(pack.NewU64(1)))
+		})
+	})
+
+	Context("when a field has no name in its json tag", func() {
+		It("should not encode the field", func() {
+			type Unnamed struct {
+				Foo  uint64 `json:"foo"`
+				Bar  uint64
+				Baz  uint64 `json:",omitempty"`
+				Dash uint64 `json:"-,"`
+				Omit uint64 `json:"omitempty"`
+			}
+			v, err := pack.Encode(Unnamed{Foo: 1, Bar: 2, Baz: 3, Dash: 4, Omit: 5})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewStruct("foo", pack.NewU64(1), "Omit", pack.NewU64(5))))
+
+			var x Unnamed
+			Expect(pack.Decode(&x, v)).To(Succeed())
+			Expect(x).To(Equal(Unnamed{Foo: 1, Omit: 5}))
+		})
+
+		It("should encode the field when it has a pack tag", func() {
+			type Named struct {
+				Foo  uint64 `pack:""`
+				Bar  uint64 `pack:",u8"`
+				Dash uint64 `pack:"-,"`
+			}
+			v, err := pack.Encode(Named{Foo: 1, Bar: 2, Dash: 3})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewStruct("Foo", pack.NewU64(1), "Bar", pack.NewU8(2), "-", pack.NewU64(3))))
+		})
+	})
+
+	Context("when a field has a kind option", func() {
+		It("should encode the field as that kind", func() {
+			type Forced struct {
+				BigInt    *big.Int `pack:"bigInt,u256"`
+				BigIntVal big.Int  `pack:"bigIntVal,i128"`
+				Uint      uint64   `pack:"uint,u128"`
+				Int       int      `pack:"int,i256"`
+				Byte      uint8    `pack:"byte,u16"`
+				Hash      []byte   `pack:"hash,bytes32"`
+				Sig       []byte   `pack:"sig,bytes65"`
+				Memo      string   `pack:"memo,bytes"`
+				Data      []byte   `pack:"data,string"`
+				Array     [4]byte  `pack:"array,bytes"`
+				Ptr       *uint32  `pack:"ptr,u64"`
+				Flag      bool     `pack:"flag,bool"`
+			}
+			three := uint32(3)
+			x := Forced{
+				BigInt:    big.NewInt(42),
+				BigIntVal: *big.NewInt(-42),
+				Uint:      1,
+				Int:       -1,
+				Byte:      255,
+				Hash:      make([]byte, 32),
+				Sig:       make([]byte, 65),
+				Memo:      "memo",
+				Data:      []byte("data"),
+				Array:     [4]byte{1, 2, 3, 4},
+				Ptr:       &three,
+				Flag:      true,
+			}
+			x.Hash[0] = 1
+			x.Sig[64] = 2
+
+			v, err := pack.Encode(x)
+			Expect(err).ToNot(HaveOccurred())
+			s := v.(pack.Struct)
+			Expect(s.Get("bigInt")).To(Equal(pack.NewU256FromUint64(42)))
+			Expect(s.Get("bigIntVal")).To(Equal(pack.NewI128FromInt64(-42)))
+			Expect(s.Get("uint")).To(Equal(pack.NewU128FromUint64(1)))
+			Expect(s.Get("int")).To(Equal(pack.NewI256FromInt64(-1)))
+			Expect(s.Get("byte")).To(Equal(pack.NewU16(255)))
+			Expect(s.Get("hash").Type()).To(Equal(pack.TypeBytes32()))
+			Expect(s.Get("sig").Type()).To(Equal(pack.TypeBytes65()))
+			Expect(s.Get("memo")).To(Equal(pack.NewBytes([]byte("memo"))))
+			Expect(s.Get("data")).To(Equal(pack.NewString("data")))
+			Expect(s.Get("array")).To(Equal(pack.NewBytes([]byte{1, 2, 3, 4})))
+			Expect(s.Get("ptr")).To(Equal(pack.Some(pack.NewU64(3))))
+			Expect(s.Get("flag")).To(Equal(pack.NewBool(true)))
+
+			var y Forced
+			Expect(pack.Decode(&y, v)).To(Succeed())
+			Expect(y.BigInt.Cmp(x.BigInt)).To(Equal(0))
+			Expect(y.BigIntVal.Cmp(&x.BigIntVal)).To(Equal(0))
+			y.BigInt, x.BigInt = nil, nil
+			y.BigIntVal, x.BigIntVal = big.Int{}, big.Int{}
+			Expect(y).To(Equal(x))
+		})
+
+		It("should encode nil pointers as none", func() {
+			type Forced struct {
+				Ptr *uint32 `pack:"ptr,u64"`
+			}
+			v, err := pack.Encode(Forced{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.(pack.Struct).Get("ptr")).To(Equal(pack.None(pack.TypeU64())))
+
+			y := Forced{Ptr: new(uint32)}
+			Expect(pack.Decode(&y, v)).To(Succeed())
+			Expect(y.Ptr).To(BeNil())
+		})
+
+		It("should return an error when the value overflows the kind", func() {
+			type Overflow struct {
+				Foo uint64 `pack:"foo,u8"`
+			}
+			_, err := pack.Encode(Overflow{Foo: 256})
+			Expect(err).To(HaveOccurred())
+
+			type Underflow struct {
+				Foo int64 `pack:"foo,u64"`
+			}
+			_, err = pack.Encode(Underflow{Foo: -1})
+			Expect(err).To(HaveOccurred())
+
+			type Small struct {
+				Foo uint8 `pack:"foo,u64"`
+			}
+			v := pack.NewStruct("foo", pack.NewU64(256))
+			Expect(pack.Decode(&Small{}, v)).ToNot(Succeed())
+		})
+
+		It("should return an error when the length does not match the kind", func() {
+			type Hash struct {
+				Foo []byte `pack:"foo,bytes32"`
+			}
+			_, err := pack.Encode(Hash{Foo: []byte{1, 2, 3}})
+			Expect(err).To(HaveOccurred())
+
+			type Array struct {
+				Foo [4]byte `pack:"foo,bytes"`
+			}
+			v := pack.NewStruct("foo", pack.NewBytes([]byte{1, 2, 3}))
+			Expect(pack.Decode(&Array{}, v)).ToNot(Succeed())
+		})
+
+		It("should return an error when the Go type cannot be encoded as the kind", func() {
+			type Mismatch struct {
+				Foo string `pack:"foo,u64"`
+			}
+			_, err := pack.Encode(Mismatch{})
+			Expect(err).To(HaveOccurred())
+
+			type Multiple struct {
+				Foo uint64 `pack:"foo,u64,u128"`
+			}
+			_, err = pack.Encode(Multiple{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when a field has the optional option", func() {
+		It("should encode zero values as none", func() {
+			type Maybe struct {
+				Foo uint64  `pack:"foo,optional"`
+				Bar string  `pack:"bar,optional"`
+				Baz *uint64 `pack:"baz,optional"`
+				Big big.Int `pack:"big,u256,optional"`
+			}
+			v, err := pack.Encode(Maybe{})
+			Expect(err).ToNot(HaveOccurred())
+			s := v.(pack.Struct)
+			Expect(s.Get("foo")).To(Equal(pack.None(pack.TypeU64())))
+			Expect(s.Get("bar")).To(Equal(pack.None(pack.TypeString())))
+			Expect(s.Get("baz")).To(Equal(pack.None(pack.TypeU64())))
+			Expect(s.Get("big")).To(Equal(pack.None(pack.TypeU256())))
+
+			y := Maybe{Foo: 1, Bar: "bar"}
+			Expect(pack.Decode(&y, v)).To(Succeed())
+			Expect(y.Foo).To(Equal(uint64(0)))
+			Expect(y.Bar).To(Equal(""))
+		})
+
+		It("should encode other values as some value", func() {
+			type Maybe struct {
+				Foo uint64 `pack:"foo,optional"`
+				Bar string `pack:"bar,optional"`
+			}
+			v, err := pack.Encode(Maybe{Foo: 1, Bar: "bar"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.(pack.Struct).Get("foo")).To(Equal(pack.Some(pack.NewU64(1))))
+			Expect(v.(pack.Struct).Get("bar")).To(Equal(pack.Some(pack.NewString("bar"))))
+
+			var y Maybe
+			Expect(pack.Decode(&y, v)).To(Succeed())
+			Expect(y).To(Equal(Maybe{Foo: 1, Bar: "bar"}))
+		})
+	})
+
+	Context("when a field has the inline option", func() {
+		type Header struct {
+			Version uint8  `pack:"version"`
+			Nonce   uint64 `pack:"nonce"`
+		}
+
+		It("should encode its fields as fields of the outer struct", func() {
+			type Message struct {
+				Header `pack:",inline"`
+				Body   string `pack:"body"`
+			}
+			x := Message{Header: Header{Version: 1, Nonce: 2}, Body: "body"}
+			v, err := pack.Encode(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewStruct(
+				"version", pack.NewU8(1),
+				"nonce", pack.NewU64(2),
+				"body", pack.NewString("body"),
+			)))
+
+			var y Message
+			Expect(pack.Decode(&y, v)).To(Succeed())
+			Expect(y).To(Equal(x))
+		})
+
+		It("should return an error when field names are duplicated", func() {
+			type Trailer struct {
+				Nonce uint64 `pack:"nonce"`
+			}
+			type Message struct {
+				Header  `pack:",inline"`
+				Trailer `pack:",inline"`
+			}
+			_, err := pack.Encode(Message{})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error when the field is not a struct", func() {
+			type Message struct {
+				Foo uint64 `pack:",inline"`
+			}
+			_, err := pack.Encode(Message{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when a field has the tuple option", func() {
+		It("should encode the field as a tuple", func() {
+			type Pair struct {
+				First  uint64   `pack:"first"`
+				Second *big.Int `pack:"second,u128"`
+			}
+			type Outer struct {
+				Pair Pair `pack:"pair,tuple"`
+			}
+			v, err := pack.Encode(Outer{Pair: Pair{First: 1, Second: big.NewInt(2)}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.(pack.Struct).Get("pair")).To(Equal(pack.Tuple{pack.NewU64(1), pack.NewU128FromUint64(2)}))
+
+			var y Outer
+			Expect(pack.Decode(&y, v)).To(Succeed())
+			Expect(y.Pair.First).To(Equal(uint64(1)))
+			Expect(y.Pair.Second.Int64()).To(Equal(int64(2)))
+		})
+	})
+
+	Context("when encoding and then decoding structs with tags", func() {
+		It("should equal itself", func() {
+			type Inner struct {
+				A uint16 `pack:"a,optional"`
+				B string `pack:"b,bytes"`
+			}
+			type Tagged struct {
+				Inner `pack:",inline"`
+				C     uint32   `pack:"c,u256"`
+				D     int8     `pack:"d,i64,optional"`
+				E     [32]byte `pack:"e,bytes32"`
+				F     []uint64 `json:"f"`
+				G     *int16   `pack:"g,i128"`
+			}
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < 100; trial++ {
+				x, ok := quick.Value(reflect.TypeOf(Tagged{}), r)
+				Expect(ok).To(BeTrue())
+
+				v, err := pack.Encode(x.Interface())
+				Expect(err).ToNot(HaveOccurred())
+
+				var y Tagged
+				Expect(pack.Decode(&y, v)).To(Succeed())
+				Expect(y).To(Equal(x.Interface()))
+
+				data, err := v.MarshalJSON()
+				Expect(err).ToNot(HaveOccurred())
+				w, err := v.Type().UnmarshalValueJSON(data)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(w).To(Equal(v))
+			}
+		})
+	})
+})
@@ -0,0 +1,109 @@
+package pack
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+)
+
+// Tuple represents a fixed-length sequence of values. Unlike lists, the
+// elements of a tuple do not need to have the same type. In binary, tuples are
+// marshaled as their elements, one after the other, without a length prefix
+// (the length is part of the tuple type). In JSON, tuples are marshaled as
+// arrays.
+type Tuple []Value
+
+// NewTuple returns a tuple from a slice of variadic elements.
+//
+//  x := NewTuple(
+//      NewU64(42),
+//      NewString("pack is awesome"),
+//      NewBool(true),
+//  )
+//
+func NewTuple(vs ...Value) Tuple {
+	elems := make(Tuple, len(vs))
+	copy(elems, vs)
+	return elems
+}
+
+// Type returns the tuple type. This method has O(n) complexity, where N is the
+// number of elements in the tuple.
+func (v Tuple) Type() Type {
+	t := make(typeTuple, len(v))
+	for i, elem := range v {
+		t[i] = elem.Type()
+	}
+	return t
+}
+
+// Len returns the number of elements in the tuple.
+func (v Tuple) Len() int {
+	return len(v)
+}
+
+// SizeHint returns the number of bytes required to represent the tuple in
+// binary.
+func (v Tuple) SizeHint() int {
+	total := 0
+	for _, elem := range v {
+		total += elem.SizeHint()
+	}
+	return total
+}
+
+// Marshal the tuple into binary.
+func (v Tuple) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	var err error
+	for _, elem := range v {
+		buf, rem, err = elem.Marshal(buf, rem)
+		if err != nil {
+			return buf, rem, err
+		}
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal the tuple from binary. Because the length of the tuple is not
+// marshaled, the tuple must already hold elements of the expected types.
+func (v *Tuple) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	value, buf, rem, err := v.Type().UnmarshalValue(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	*v = value.(Tuple)
+	return buf, rem, nil
+}
+
+// MarshalJSON marshals the tuple to JSON. This is done by marshaling the tuple
+// as if it was a JSON array, where each element in the tuple is an element in
+// the JSON array.
+func (v Tuple) MarshalJSON() ([]byte, error) {
+	raw := make([]json.RawMessage, len(v))
+	for i, elem := range v {
+		rawElem, err := elem.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("marshaling tuple element %v: %v", i, err)
+		}
+		raw[i] = rawElem
+	}
+	return json.Marshal(raw)
+}
+
+// String returns the tuple in its JSON representation.
+func (v Tuple) String() string {
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+// Generate a random tuple. This method is implemented for use in quick tests.
+// See https://golang.org/pkg/testing/quick/#Generator for more information.
+// Generated tuples are nested to at most GenerateMaxDepth.
+func (Tuple) Generate(r *rand.Rand, size int) reflect.Value {
+	t := GenerateTypeFromKind(r, size, KindTuple, GenerateMaxDepth)
+	return reflect.ValueOf(GenerateFromType(r, size, t))
+}
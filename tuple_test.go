@@ -0,0 +1,149 @@
+package pack_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing/quick"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/pack/packutil"
+	"github.com/renproject/surge"
+	"github.com/renproject/surge/surgeutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tuple", func() {
+
+	numTrials := 10
+
+	Context("when fuzzing", func() {
+		It("should not panic", func() {
+			for trial := 0; trial < numTrials; trial++ {
+				Expect(func() { surgeutil.Fuzz(reflect.TypeOf(pack.Tuple{})) }).ToNot(Panic())
+				Expect(func() { packutil.JSONFuzz(reflect.TypeOf(pack.Tuple{})) }).ToNot(Panic())
+			}
+		})
+	})
+
+	Context("when marshaling", func() {
+		Context("when the buffer is too small", func() {
+			It("should return itself", func() {
+				for trial := 0; trial < numTrials; trial++ {
+					Expect(surgeutil.MarshalBufTooSmall(reflect.TypeOf(pack.Tuple{}))).To(Succeed())
+				}
+			})
+		})
+
+		Context("when the remaining memory quota is too small", func() {
+			It("should return itself", func() {
+				for trial := 0; trial < numTrials; trial++ {
+					Expect(surgeutil.MarshalRemTooSmall(reflect.TypeOf(pack.Tuple{}))).To(Succeed())
+				}
+			})
+		})
+	})
+
+	Context("when marshaling and unmarshaling to binary", func() {
+		It("should equal itself", func() {
+			f := func(x pack.Tuple) bool {
+				data, err := surge.ToBinary(x)
+				Expect(err).ToNot(HaveOccurred())
+				y, _, _, err := x.Type().UnmarshalValue(data, len(data))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(y).To(Equal(x))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when marshaling and unmarshaling to JSON", func() {
+		It("should equal itself", func() {
+			f := func(x pack.Tuple) bool {
+				data, err := json.Marshal(x)
+				Expect(err).ToNot(HaveOccurred())
+				y, err := x.Type().UnmarshalValueJSON(data)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(y).To(Equal(x))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when getting type information", func() {
+		It("should return the tuple type", func() {
+			f := func(x pack.Tuple) bool {
+				Expect(x.Type().Kind()).To(Equal(pack.KindTuple))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when marshaling a tuple with mixed types", func() {
+		It("should marshal the elements without a length prefix", func() {
+			x := pack.NewTuple(pack.NewU16(42), pack.NewBool(true), pack.NewString("a"))
+			Expect(x.Len()).To(Equal(3))
+
+			data, err := surge.ToBinary(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte{0, 42, 1, 0, 0, 0, 1, 'a'}))
+
+			data, err = json.Marshal(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(`["42",true,"a"]`))
+		})
+	})
+
+	Context("when unmarshaling a JSON array with the wrong length", func() {
+		It("should return an error", func() {
+			t := pack.NewTuple(pack.NewU16(0), pack.NewBool(false)).Type()
+			_, err := t.UnmarshalValueJSON([]byte(`["42"]`))
+			Expect(err).To(HaveOccurred())
+			_, err = t.UnmarshalValueJSON([]byte(`["42",true,true]`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when encoding and decoding Go arrays", func() {
+		It("should map arrays of values to tuples", func() {
+			x := [2]pack.Value{pack.NewU64(1), pack.NewString("foo")}
+			v, err := pack.Encode(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(pack.NewTuple(pack.NewU64(1), pack.NewString("foo"))))
+
+			y := [2]pack.Value{}
+			Expect(pack.Decode(&y, v)).To(Succeed())
+			Expect(y).To(Equal(x))
+
+			z := [3]pack.Value{}
+			Expect(pack.Decode(&z, v)).ToNot(Succeed())
+		})
+	})
+
+	Context("when encoding and decoding structs tagged as tuples", func() {
+		It("should map the fields to tuple elements in order", func() {
+			type Point struct {
+				X    int64  `json:"x"`
+				Y    int64  `json:"y"`
+				Skip uint64 `json:"-"`
+			}
+			type Line struct {
+				From Point `json:"from,tuple"`
+				To   Point `json:"to"`
+			}
+			x := Line{From: Point{X: 1, Y: -1}, To: Point{X: 2, Y: -2}}
+			v, err := pack.Encode(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.(pack.Struct).Get("from")).To(Equal(pack.NewTuple(pack.NewI64(1), pack.NewI64(-1))))
+			Expect(v.(pack.Struct).Get("to").Type().Kind()).To(Equal(pack.KindStruct))
+
+			y := Line{}
+			Expect(pack.Decode(&y, v)).To(Succeed())
+			Expect(y).To(Equal(x))
+		})
+	})
+})
@@ -1,6 +1,8 @@
 package pack
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"math/rand"
@@ -29,6 +31,244 @@ type Type interface {
 	UnmarshalValueJSON(data []byte) (Value, error)
 }
 
+// TypeBool returns the boolean type.
+func TypeBool() Type {
+	return typeBool{}
+}
+
+// TypeU8 returns the 8-bit unsigned integer type.
+func TypeU8() Type {
+	return typeU8{}
+}
+
+// TypeU16 returns the 16-bit unsigned integer type.
+func TypeU16() Type {
+	return typeU16{}
+}
+
+// TypeU32 returns the 32-bit unsigned integer type.
+func TypeU32() Type {
+	return typeU32{}
+}
+
+// TypeU64 returns the 64-bit unsigned integer type.
+func TypeU64() Type {
+	return typeU64{}
+}
+
+// TypeU128 returns the 128-bit unsigned integer type.
+func TypeU128() Type {
+	return typeU128{}
+}
+
+// TypeU256 returns the 256-bit unsigned integer type.
+func TypeU256() Type {
+	return typeU256{}
+}
+
+// TypeI8 returns the 8-bit signed integer type.
+func TypeI8() Type {
+	return typeI8{}
+}
+
+// TypeI16 returns the 16-bit signed integer type.
+func TypeI16() Type {
+	return typeI16{}
+}
+
+// TypeI32 returns the 32-bit signed integer type.
+func TypeI32() Type {
+	return typeI32{}
+}
+
+// TypeI64 returns the 64-bit signed integer type.
+func TypeI64() Type {
+	return typeI64{}
+}
+
+// TypeI128 returns the 128-bit signed integer type.
+func TypeI128() Type {
+	return typeI128{}
+}
+
+// TypeI256 returns the 256-bit signed integer type.
+func TypeI256() Type {
+	return typeI256{}
+}
+
+// TypeString returns the string type.
+func TypeString() Type {
+	return typeString{}
+}
+
+// TypeBytes returns the variable-length bytes type.
+func TypeBytes() Type {
+	return typeBytes{}
+}
+
+// TypeBytes32 returns the 32-byte array type.
+func TypeBytes32() Type {
+	return typeBytes32{}
+}
+
+// TypeBytes65 returns the 65-byte array type.
+func TypeBytes65() Type {
+	return typeBytes65{}
+}
+
+// TypeBytesN returns the type of byte arrays with exactly n bytes.
+func TypeBytesN(n uint32) Type {
+	return typeBytesN{N: n}
+}
+
+// StructType returns a struct type from a slice of variadic arguments. The
+// arguments are expected to be of the form ("name", type)*, and the same field
+// name must not be used more than once. Otherwise, the function will panic.
+//
+//  t := StructType(
+//      "foo", TypeU64(),
+//      "bar", TypeString(),
+//      "baz", ListType(TypeBool()),
+//  )
+//
+func StructType(vs ...interface{}) Type {
+	if len(vs)%2 != 0 {
+		panic(fmt.Errorf("expected (name, type) pairs, got %v arguments", len(vs)))
+	}
+	t := make(typeStruct, len(vs)/2)
+	for i := range t {
+		name, ok := vs[2*i+0].(string)
+		if !ok {
+			panic(fmt.Errorf("expected name of type string, got %T", vs[2*i+0]))
+		}
+		fieldType, ok := vs[2*i+1].(Type)
+		if !ok {
+			panic(fmt.Errorf("expected field \"%v\" of type Type, got %T", name, vs[2*i+1]))
+		}
+		for _, other := range t[:i] {
+			if other.Name == name {
+				panic(fmt.Errorf("duplicate field \"%v\"", name))
+			}
+		}
+		t[i] = typeStructField{Name: name, Type: fieldType}
+	}
+	return t
+}
+
+// ListType returns the type of lists with elements of the given type.
+func ListType(elem Type) Type {
+	return typeList{Type: elem}
+}
+
+// OptionalType returns the type of optionals that hold values of the given
+// type.
+func OptionalType(t Type) Type {
+	return typeOptional{Type: t}
+}
+
+// MapType returns the type of maps with keys, and values, of the given types.
+func MapType(key, value Type) Type {
+	return typeMap{Key: key, Value: value}
+}
+
+// TupleType returns the type of tuples with elements of the given types, in
+// order.
+func TupleType(elems ...Type) Type {
+	t := make(typeTuple, len(elems))
+	copy(t, elems)
+	return t
+}
+
+// FieldNames returns the names of the fields of a struct type, or the names of
+// the variants of a union type, in order. For all other types, it returns nil.
+func FieldNames(t Type) []string {
+	var fields []typeStructField
+	switch t := t.(type) {
+	case typeStruct:
+		fields = t
+	case typeUnion:
+		fields = t
+	default:
+		return nil
+	}
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.Name
+	}
+	return names
+}
+
+// FieldType returns the type of a field in a struct type, or the type of a
+// variant in a union type, given its name. If there is no such field, or the
+// type is neither a struct type nor a union type, it returns nil.
+func FieldType(t Type, name string) Type {
+	var fields []typeStructField
+	switch t := t.(type) {
+	case typeStruct:
+		fields = t
+	case typeUnion:
+		fields = t
+	}
+	for _, field := range fields {
+		if field.Name == name {
+			return field.Type
+		}
+	}
+	return nil
+}
+
+// ElemType returns the element type of a list type, or the type held by an
+// optional type. For all other types, it returns nil.
+func ElemType(t Type) Type {
+	switch t := t.(type) {
+	case typeList:
+		return t.Type
+	case typeOptional:
+		return t.Type
+	default:
+		return nil
+	}
+}
+
+// ElemTypes returns the element types of a tuple type, in order. For all other
+// types, it returns nil.
+func ElemTypes(t Type) []Type {
+	tt, ok := t.(typeTuple)
+	if !ok {
+		return nil
+	}
+	elems := make([]Type, len(tt))
+	copy(elems, tt)
+	return elems
+}
+
+// KeyType returns the key type of a map type. For all other types, it returns
+// nil.
+func KeyType(t Type) Type {
+	if tm, ok := t.(typeMap); ok {
+		return tm.Key
+	}
+	return nil
+}
+
+// ValueType returns the value type of a map type. For all other types, it
+// returns nil.
+func ValueType(t Type) Type {
+	if tm, ok := t.(typeMap); ok {
+		return tm.Value
+	}
+	return nil
+}
+
+// BytesNLen returns the number of bytes in a parameterised byte array type.
+// For all other types, it returns zero.
+func BytesNLen(t Type) uint32 {
+	if tb, ok := t.(typeBytesN); ok {
+		return tb.N
+	}
+	return 0
+}
+
 type typeBool struct{}
 
 func (typeBool) Kind() Kind {
@@ -40,16 +280,16 @@ func (t typeBool) Equals(other Type) bool {
 	return ok
 }
 
-func (typeBool) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+func (t typeBool) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
 	value := Bool(false)
-	buf, rem, err := value.Unmarshal(buf, rem)
-	return value, buf, rem, err
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
 }
 
-func (typeBool) UnmarshalValueJSON(data []byte) (Value, error) {
+func (t typeBool) UnmarshalValueJSON(data []byte) (Value, error) {
 	value := Bool(false)
 	err := value.UnmarshalJSON(data)
-	return value, err
+	return value, newDecodeError(err, t.Kind())
 }
 
 func (t typeBool) SizeHint() int {
@@ -107,16 +347,16 @@ func (t typeU8) Equals(other Type) bool {
 	return ok
 }
 
-func (typeU8) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+func (t typeU8) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
 	value := U8(0)
-	buf, rem, err := value.Unmarshal(buf, rem)
-	return value, buf, rem, err
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
 }
 
-func (typeU8) UnmarshalValueJSON(data []byte) (Value, error) {
+func (t typeU8) UnmarshalValueJSON(data []byte) (Value, error) {
 	value := U8(0)
 	err := value.UnmarshalJSON(data)
-	return value, err
+	return value, newDecodeError(err, t.Kind())
 }
 
 func (t typeU8) SizeHint() int {
@@ -174,16 +414,16 @@ func (t typeU16) Equals(other Type) bool {
 	return ok
 }
 
-func (typeU16) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+func (t typeU16) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
 	value := U16(0)
-	buf, rem, err := value.Unmarshal(buf, rem)
-	return value, buf, rem, err
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
 }
 
-func (typeU16) UnmarshalValueJSON(data []byte) (Value, error) {
+func (t typeU16) UnmarshalValueJSON(data []byte) (Value, error) {
 	value := U16(0)
 	err := value.UnmarshalJSON(data)
-	return value, err
+	return value, newDecodeError(err, t.Kind())
 }
 
 func (t typeU16) SizeHint() int {
@@ -241,16 +481,16 @@ func (t typeU32) Equals(other Type) bool {
 	return ok
 }
 
-func (typeU32) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+func (t typeU32) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
 	value := U32(0)
-	buf, rem, err := value.Unmarshal(buf, rem)
-	return value, buf, rem, err
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
 }
 
-func (typeU32) UnmarshalValueJSON(data []byte) (Value, error) {
+func (t typeU32) UnmarshalValueJSON(data []byte) (Value, error) {
 	value := U32(0)
 	err := value.UnmarshalJSON(data)
-	return value, err
+	return value, newDecodeError(err, t.Kind())
 }
 
 func (t typeU32) SizeHint() int {
@@ -308,16 +548,16 @@ func (t typeU64) Equals(other Type) bool {
 	return ok
 }
 
-func (typeU64) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+func (t typeU64) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
 	value := U64(0)
-	buf, rem, err := value.Unmarshal(buf, rem)
-	return value, buf, rem, err
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
 }
 
-func (typeU64) UnmarshalValueJSON(data []byte) (Value, error) {
+func (t typeU64) UnmarshalValueJSON(data []byte) (Value, error) {
 	value := U64(0)
 	err := value.UnmarshalJSON(data)
-	return value, err
+	return value, newDecodeError(err, t.Kind())
 }
 
 func (t typeU64) SizeHint() int {
@@ -375,16 +615,16 @@ func (t typeU128) Equals(other Type) bool {
 	return ok
 }
 
-func (typeU128) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+func (t typeU128) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
 	value := U128{}
-	buf, rem, err := value.Unmarshal(buf, rem)
-	return value, buf, rem, err
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
 }
 
-func (typeU128) UnmarshalValueJSON(data []byte) (Value, error) {
+func (t typeU128) UnmarshalValueJSON(data []byte) (Value, error) {
 	value := U128{}
 	err := value.UnmarshalJSON(data)
-	return value, err
+	return value, newDecodeError(err, t.Kind())
 }
 
 func (t typeU128) SizeHint() int {
@@ -442,16 +682,16 @@ func (t typeU256) Equals(other Type) bool {
 	return ok
 }
 
-func (typeU256) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+func (t typeU256) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
 	value := U256{}
-	buf, rem, err := value.Unmarshal(buf, rem)
-	return value, buf, rem, err
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
 }
 
-func (typeU256) UnmarshalValueJSON(data []byte) (Value, error) {
+func (t typeU256) UnmarshalValueJSON(data []byte) (Value, error) {
 	value := U256{}
 	err := value.UnmarshalJSON(data)
-	return value, err
+	return value, newDecodeError(err, t.Kind())
 }
 
 func (t typeU256) SizeHint() int {
@@ -498,38 +738,38 @@ func (t *typeU256) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-type typeString struct{}
+type typeI8 struct{}
 
-func (typeString) Kind() Kind {
-	return KindString
+func (typeI8) Kind() Kind {
+	return KindI8
 }
 
-func (t typeString) Equals(other Type) bool {
-	_, ok := other.(typeString)
+func (t typeI8) Equals(other Type) bool {
+	_, ok := other.(typeI8)
 	return ok
 }
 
-func (typeString) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
-	value := String("")
-	buf, rem, err := value.Unmarshal(buf, rem)
-	return value, buf, rem, err
+func (t typeI8) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	value := I8(0)
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
 }
 
-func (typeString) UnmarshalValueJSON(data []byte) (Value, error) {
-	value := String("")
+func (t typeI8) UnmarshalValueJSON(data []byte) (Value, error) {
+	value := I8(0)
 	err := value.UnmarshalJSON(data)
-	return value, err
+	return value, newDecodeError(err, t.Kind())
 }
 
-func (t typeString) SizeHint() int {
+func (t typeI8) SizeHint() int {
 	return t.Kind().SizeHint()
 }
 
-func (t typeString) Marshal(buf []byte, rem int) ([]byte, int, error) {
+func (t typeI8) Marshal(buf []byte, rem int) ([]byte, int, error) {
 	return t.Kind().Marshal(buf, rem)
 }
 
-func (t *typeString) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+func (t *typeI8) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	var kind Kind
 	var err error
 	buf, rem, err = kind.Unmarshal(buf, rem)
@@ -542,7 +782,7 @@ func (t *typeString) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	return buf, rem, nil
 }
 
-func (t typeString) MarshalJSON() ([]byte, error) {
+func (t typeI8) MarshalJSON() ([]byte, error) {
 	data, err := t.Kind().MarshalText()
 	if err != nil {
 		return nil, err
@@ -550,7 +790,7 @@ func (t typeString) MarshalJSON() ([]byte, error) {
 	return json.Marshal(string(data))
 }
 
-func (t *typeString) UnmarshalJSON(data []byte) error {
+func (t *typeI8) UnmarshalJSON(data []byte) error {
 	var text string
 	if err := json.Unmarshal(data, &text); err != nil {
 		return err
@@ -565,38 +805,38 @@ func (t *typeString) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-type typeBytes struct{}
+type typeI16 struct{}
 
-func (typeBytes) Kind() Kind {
-	return KindBytes
+func (typeI16) Kind() Kind {
+	return KindI16
 }
 
-func (t typeBytes) Equals(other Type) bool {
-	_, ok := other.(typeBytes)
+func (t typeI16) Equals(other Type) bool {
+	_, ok := other.(typeI16)
 	return ok
 }
 
-func (typeBytes) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
-	value := Bytes{}
-	buf, rem, err := value.Unmarshal(buf, rem)
-	return value, buf, rem, err
+func (t typeI16) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	value := I16(0)
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
 }
 
-func (typeBytes) UnmarshalValueJSON(data []byte) (Value, error) {
-	value := Bytes{}
+func (t typeI16) UnmarshalValueJSON(data []byte) (Value, error) {
+	value := I16(0)
 	err := value.UnmarshalJSON(data)
-	return value, err
+	return value, newDecodeError(err, t.Kind())
 }
 
-func (t typeBytes) SizeHint() int {
+func (t typeI16) SizeHint() int {
 	return t.Kind().SizeHint()
 }
 
-func (t typeBytes) Marshal(buf []byte, rem int) ([]byte, int, error) {
+func (t typeI16) Marshal(buf []byte, rem int) ([]byte, int, error) {
 	return t.Kind().Marshal(buf, rem)
 }
 
-func (t *typeBytes) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+func (t *typeI16) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	var kind Kind
 	var err error
 	buf, rem, err = kind.Unmarshal(buf, rem)
@@ -609,7 +849,7 @@ func (t *typeBytes) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	return buf, rem, nil
 }
 
-func (t typeBytes) MarshalJSON() ([]byte, error) {
+func (t typeI16) MarshalJSON() ([]byte, error) {
 	data, err := t.Kind().MarshalText()
 	if err != nil {
 		return nil, err
@@ -617,7 +857,7 @@ func (t typeBytes) MarshalJSON() ([]byte, error) {
 	return json.Marshal(string(data))
 }
 
-func (t *typeBytes) UnmarshalJSON(data []byte) error {
+func (t *typeI16) UnmarshalJSON(data []byte) error {
 	var text string
 	if err := json.Unmarshal(data, &text); err != nil {
 		return err
@@ -632,38 +872,38 @@ func (t *typeBytes) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-type typeBytes32 struct{}
+type typeI32 struct{}
 
-func (typeBytes32) Kind() Kind {
-	return KindBytes32
+func (typeI32) Kind() Kind {
+	return KindI32
 }
 
-func (t typeBytes32) Equals(other Type) bool {
-	_, ok := other.(typeBytes32)
+func (t typeI32) Equals(other Type) bool {
+	_, ok := other.(typeI32)
 	return ok
 }
 
-func (typeBytes32) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
-	value := Bytes32{}
-	buf, rem, err := value.Unmarshal(buf, rem)
-	return value, buf, rem, err
+func (t typeI32) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	value := I32(0)
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
 }
 
-func (typeBytes32) UnmarshalValueJSON(data []byte) (Value, error) {
-	value := Bytes32{}
+func (t typeI32) UnmarshalValueJSON(data []byte) (Value, error) {
+	value := I32(0)
 	err := value.UnmarshalJSON(data)
-	return value, err
+	return value, newDecodeError(err, t.Kind())
 }
 
-func (t typeBytes32) SizeHint() int {
+func (t typeI32) SizeHint() int {
 	return t.Kind().SizeHint()
 }
 
-func (t typeBytes32) Marshal(buf []byte, rem int) ([]byte, int, error) {
+func (t typeI32) Marshal(buf []byte, rem int) ([]byte, int, error) {
 	return t.Kind().Marshal(buf, rem)
 }
 
-func (t *typeBytes32) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+func (t *typeI32) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	var kind Kind
 	var err error
 	buf, rem, err = kind.Unmarshal(buf, rem)
@@ -676,7 +916,7 @@ func (t *typeBytes32) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	return buf, rem, nil
 }
 
-func (t typeBytes32) MarshalJSON() ([]byte, error) {
+func (t typeI32) MarshalJSON() ([]byte, error) {
 	data, err := t.Kind().MarshalText()
 	if err != nil {
 		return nil, err
@@ -684,7 +924,7 @@ func (t typeBytes32) MarshalJSON() ([]byte, error) {
 	return json.Marshal(string(data))
 }
 
-func (t *typeBytes32) UnmarshalJSON(data []byte) error {
+func (t *typeI32) UnmarshalJSON(data []byte) error {
 	var text string
 	if err := json.Unmarshal(data, &text); err != nil {
 		return err
@@ -699,38 +939,38 @@ func (t *typeBytes32) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-type typeBytes65 struct{}
+type typeI64 struct{}
 
-func (typeBytes65) Kind() Kind {
-	return KindBytes65
+func (typeI64) Kind() Kind {
+	return KindI64
 }
 
-func (t typeBytes65) Equals(other Type) bool {
-	_, ok := other.(typeBytes65)
+func (t typeI64) Equals(other Type) bool {
+	_, ok := other.(typeI64)
 	return ok
 }
 
-func (typeBytes65) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
-	value := Bytes65{}
-	buf, rem, err := value.Unmarshal(buf, rem)
-	return value, buf, rem, err
+func (t typeI64) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	value := I64(0)
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
 }
 
-func (typeBytes65) UnmarshalValueJSON(data []byte) (Value, error) {
-	value := Bytes65{}
+func (t typeI64) UnmarshalValueJSON(data []byte) (Value, error) {
+	value := I64(0)
 	err := value.UnmarshalJSON(data)
-	return value, err
+	return value, newDecodeError(err, t.Kind())
 }
 
-func (t typeBytes65) SizeHint() int {
+func (t typeI64) SizeHint() int {
 	return t.Kind().SizeHint()
 }
 
-func (t typeBytes65) Marshal(buf []byte, rem int) ([]byte, int, error) {
+func (t typeI64) Marshal(buf []byte, rem int) ([]byte, int, error) {
 	return t.Kind().Marshal(buf, rem)
 }
 
-func (t *typeBytes65) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+func (t *typeI64) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	var kind Kind
 	var err error
 	buf, rem, err = kind.Unmarshal(buf, rem)
@@ -743,7 +983,7 @@ func (t *typeBytes65) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	return buf, rem, nil
 }
 
-func (t typeBytes65) MarshalJSON() ([]byte, error) {
+func (t typeI64) MarshalJSON() ([]byte, error) {
 	data, err := t.Kind().MarshalText()
 	if err != nil {
 		return nil, err
@@ -751,7 +991,7 @@ func (t typeBytes65) MarshalJSON() ([]byte, error) {
 	return json.Marshal(string(data))
 }
 
-func (t *typeBytes65) UnmarshalJSON(data []byte) error {
+func (t *typeI64) UnmarshalJSON(data []byte) error {
 	var text string
 	if err := json.Unmarshal(data, &text); err != nil {
 		return err
@@ -766,149 +1006,1210 @@ func (t *typeBytes65) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-type typeStructField struct {
-	Name string
-	Type Type
+type typeI128 struct{}
+
+func (typeI128) Kind() Kind {
+	return KindI128
 }
 
-func (field typeStructField) SizeHint() int {
-	return surge.SizeHintString(field.Name) + SizeHintType(field.Type)
+func (t typeI128) Equals(other Type) bool {
+	_, ok := other.(typeI128)
+	return ok
 }
 
-func (field typeStructField) Marshal(buf []byte, rem int) ([]byte, int, error) {
-	var err error
-	if buf, rem, err = surge.MarshalString(field.Name, buf, rem); err != nil {
-		return buf, rem, err
-	}
-	if buf, rem, err = MarshalType(field.Type, buf, rem); err != nil {
-		return buf, rem, err
-	}
-	return buf, rem, nil
+func (t typeI128) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	value := I128{}
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
 }
 
-func (field *typeStructField) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+func (t typeI128) UnmarshalValueJSON(data []byte) (Value, error) {
+	value := I128{}
+	err := value.UnmarshalJSON(data)
+	return value, newDecodeError(err, t.Kind())
+}
+
+func (t typeI128) SizeHint() int {
+	return t.Kind().SizeHint()
+}
+
+func (t typeI128) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	return t.Kind().Marshal(buf, rem)
+}
+
+func (t *typeI128) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	var kind Kind
 	var err error
-	if buf, rem, err = surge.UnmarshalString(&field.Name, buf, rem); err != nil {
+	buf, rem, err = kind.Unmarshal(buf, rem)
+	if err != nil {
 		return buf, rem, err
 	}
-	if buf, rem, err = UnmarshalType(&field.Type, buf, rem); err != nil {
-		return buf, rem, err
+	if kind != t.Kind() {
+		return buf, rem, fmt.Errorf("unexpected kind: expected %v, got %v", t.Kind(), kind)
 	}
-	return buf, rem, err
+	return buf, rem, nil
 }
 
-func (field typeStructField) MarshalJSON() ([]byte, error) {
-	raw, err := marshalTypeJSON(field.Type)
+func (t typeI128) MarshalJSON() ([]byte, error) {
+	data, err := t.Kind().MarshalText()
 	if err != nil {
-		return raw, err
+		return nil, err
 	}
-	return json.Marshal(map[string]json.RawMessage{field.Name: raw})
+	return json.Marshal(string(data))
 }
 
-func (field *typeStructField) UnmarshalJSON(data []byte) error {
-	raw := map[string]json.RawMessage{}
-	if err := json.Unmarshal(data, &raw); err != nil {
+func (t *typeI128) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
 		return err
 	}
-	if len(raw) != 1 {
-		return fmt.Errorf("expected len=1, got len=%v", len(raw))
+	var kind Kind
+	if err := kind.UnmarshalText([]byte(text)); err != nil {
+		return err
 	}
-	for name, data := range raw {
-		field.Name = name
-		innerType, err := unmarshalTypeJSON(data)
-		if err != nil {
-			return err
-		}
-		field.Type = innerType
-		return nil
+	if kind != t.Kind() {
+		return fmt.Errorf("unexpected kind: expected %v, got %v", t.Kind(), kind)
 	}
-	panic("unreachable")
+	return nil
 }
 
-func (typeStructField) Generate(r *rand.Rand, size int) reflect.Value {
-	name, _ := quick.Value(reflect.TypeOf(""), r)
-	for {
-		return reflect.ValueOf(typeStructField{
-			Name: name.String(),
-			Type: typeU8{}, // FIXME: Generate actual type (no struct).
-		})
-	}
+type typeI256 struct{}
+
+func (typeI256) Kind() Kind {
+	return KindI256
 }
 
-type typeStruct []typeStructField
+func (t typeI256) Equals(other Type) bool {
+	_, ok := other.(typeI256)
+	return ok
+}
 
-func (typeStruct) Kind() Kind {
-	return KindStruct
+func (t typeI256) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	value := I256{}
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
 }
 
-func (t typeStruct) Equals(other Type) bool {
-	otherStruct, ok := other.(typeStruct)
-	if !ok {
-		return false
-	}
-	if len(t) != len(otherStruct) {
-		return false
-	}
-	for i := range t {
-		if t[i].Name != otherStruct[i].Name {
-			return false
-		}
-		if !t[i].Type.Equals(otherStruct[i].Type) {
-			return false
-		}
-	}
-	return true
+func (t typeI256) UnmarshalValueJSON(data []byte) (Value, error) {
+	value := I256{}
+	err := value.UnmarshalJSON(data)
+	return value, newDecodeError(err, t.Kind())
 }
 
-func (t typeStruct) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
-	v := Struct{}
-	for _, field := range t {
-		var err error
-		var value Value
-		if value, buf, rem, err = field.Type.UnmarshalValue(buf, rem); err != nil {
-			return nil, buf, rem, fmt.Errorf("unmarshaling value \"%v\": %v", field.Name, err)
-		}
-		v = append(v, StructField{Name: field.Name, Value: value})
+func (t typeI256) SizeHint() int {
+	return t.Kind().SizeHint()
+}
+
+func (t typeI256) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	return t.Kind().Marshal(buf, rem)
+}
+
+func (t *typeI256) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	var kind Kind
+	var err error
+	buf, rem, err = kind.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
 	}
-	return v, buf, rem, nil
+	if kind != t.Kind() {
+		return buf, rem, fmt.Errorf("unexpected kind: expected %v, got %v", t.Kind(), kind)
+	}
+	return buf, rem, nil
 }
 
-func (t typeStruct) UnmarshalValueJSON(data []byte) (Value, error) {
-	raw := map[string]json.RawMessage{}
-	if err := json.Unmarshal(data, &raw); err != nil {
+func (t typeI256) MarshalJSON() ([]byte, error) {
+	data, err := t.Kind().MarshalText()
+	if err != nil {
 		return nil, err
 	}
-	v := Struct{}
-	for _, field := range t {
-		rawValue, ok := raw[field.Name]
+	return json.Marshal(string(data))
+}
+
+func (t *typeI256) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	var kind Kind
+	if err := kind.UnmarshalText([]byte(text)); err != nil {
+		return err
+	}
+	if kind != t.Kind() {
+		return fmt.Errorf("unexpected kind: expected %v, got %v", t.Kind(), kind)
+	}
+	return nil
+}
+
+type typeString struct{}
+
+func (typeString) Kind() Kind {
+	return KindString
+}
+
+func (t typeString) Equals(other Type) bool {
+	_, ok := other.(typeString)
+	return ok
+}
+
+func (t typeString) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	value := String("")
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
+}
+
+func (t typeString) UnmarshalValueJSON(data []byte) (Value, error) {
+	value := String("")
+	err := value.UnmarshalJSON(data)
+	return value, newDecodeError(err, t.Kind())
+}
+
+func (t typeString) SizeHint() int {
+	return t.Kind().SizeHint()
+}
+
+func (t typeString) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	return t.Kind().Marshal(buf, rem)
+}
+
+func (t *typeString) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	var kind Kind
+	var err error
+	buf, rem, err = kind.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	if kind != t.Kind() {
+		return buf, rem, fmt.Errorf("unexpected kind: expected %v, got %v", t.Kind(), kind)
+	}
+	return buf, rem, nil
+}
+
+func (t typeString) MarshalJSON() ([]byte, error) {
+	data, err := t.Kind().MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(data))
+}
+
+func (t *typeString) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	var kind Kind
+	if err := kind.UnmarshalText([]byte(text)); err != nil {
+		return err
+	}
+	if kind != t.Kind() {
+		return fmt.Errorf("unexpected kind: expected %v, got %v", t.Kind(), kind)
+	}
+	return nil
+}
+
+type typeBytes struct{}
+
+func (typeBytes) Kind() Kind {
+	return KindBytes
+}
+
+func (t typeBytes) Equals(other Type) bool {
+	_, ok := other.(typeBytes)
+	return ok
+}
+
+func (t typeBytes) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	value := Bytes{}
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
+}
+
+func (t typeBytes) UnmarshalValueJSON(data []byte) (Value, error) {
+	value := Bytes{}
+	err := value.UnmarshalJSON(data)
+	return value, newDecodeError(err, t.Kind())
+}
+
+func (t typeBytes) SizeHint() int {
+	return t.Kind().SizeHint()
+}
+
+func (t typeBytes) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	return t.Kind().Marshal(buf, rem)
+}
+
+func (t *typeBytes) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	var kind Kind
+	var err error
+	buf, rem, err = kind.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	if kind != t.Kind() {
+		return buf, rem, fmt.Errorf("unexpected kind: expected %v, got %v", t.Kind(), kind)
+	}
+	return buf, rem, nil
+}
+
+func (t typeBytes) MarshalJSON() ([]byte, error) {
+	data, err := t.Kind().MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(data))
+}
+
+func (t *typeBytes) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	var kind Kind
+	if err := kind.UnmarshalText([]byte(text)); err != nil {
+		return err
+	}
+	if kind != t.Kind() {
+		return fmt.Errorf("unexpected kind: expected %v, got %v", t.Kind(), kind)
+	}
+	return nil
+}
+
+type typeBytes32 struct{}
+
+func (typeBytes32) Kind() Kind {
+	return KindBytes32
+}
+
+func (t typeBytes32) Equals(other Type) bool {
+	_, ok := other.(typeBytes32)
+	return ok
+}
+
+func (t typeBytes32) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	value := Bytes32{}
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
+}
+
+func (t typeBytes32) UnmarshalValueJSON(data []byte) (Value, error) {
+	value := Bytes32{}
+	err := value.UnmarshalJSON(data)
+	return value, newDecodeError(err, t.Kind())
+}
+
+func (t typeBytes32) SizeHint() int {
+	return t.Kind().SizeHint()
+}
+
+func (t typeBytes32) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	return t.Kind().Marshal(buf, rem)
+}
+
+func (t *typeBytes32) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	var kind Kind
+	var err error
+	buf, rem, err = kind.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	if kind != t.Kind() {
+		return buf, rem, fmt.Errorf("unexpected kind: expected %v, got %v", t.Kind(), kind)
+	}
+	return buf, rem, nil
+}
+
+func (t typeBytes32) MarshalJSON() ([]byte, error) {
+	data, err := t.Kind().MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(data))
+}
+
+func (t *typeBytes32) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	var kind Kind
+	if err := kind.UnmarshalText([]byte(text)); err != nil {
+		return err
+	}
+	if kind != t.Kind() {
+		return fmt.Errorf("unexpected kind: expected %v, got %v", t.Kind(), kind)
+	}
+	return nil
+}
+
+type typeBytes65 struct{}
+
+func (typeBytes65) Kind() Kind {
+	return KindBytes65
+}
+
+func (t typeBytes65) Equals(other Type) bool {
+	_, ok := other.(typeBytes65)
+	return ok
+}
+
+func (t typeBytes65) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	value := Bytes65{}
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
+}
+
+func (t typeBytes65) UnmarshalValueJSON(data []byte) (Value, error) {
+	value := Bytes65{}
+	err := value.UnmarshalJSON(data)
+	return value, newDecodeError(err, t.Kind())
+}
+
+func (t typeBytes65) SizeHint() int {
+	return t.Kind().SizeHint()
+}
+
+func (t typeBytes65) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	return t.Kind().Marshal(buf, rem)
+}
+
+func (t *typeBytes65) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	var kind Kind
+	var err error
+	buf, rem, err = kind.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	if kind != t.Kind() {
+		return buf, rem, fmt.Errorf("unexpected kind: expected %v, got %v", t.Kind(), kind)
+	}
+	return buf, rem, nil
+}
+
+func (t typeBytes65) MarshalJSON() ([]byte, error) {
+	data, err := t.Kind().MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(data))
+}
+
+func (t *typeBytes65) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	var kind Kind
+	if err := kind.UnmarshalText([]byte(text)); err != nil {
+		return err
+	}
+	if kind != t.Kind() {
+		return fmt.Errorf("unexpected kind: expected %v, got %v", t.Kind(), kind)
+	}
+	return nil
+}
+
+type typeBytesN struct {
+	N uint32
+}
+
+func (typeBytesN) Kind() Kind {
+	return KindBytesN
+}
+
+func (t typeBytesN) Equals(other Type) bool {
+	otherBytesN, ok := other.(typeBytesN)
+	if !ok {
+		return false
+	}
+	return t.N == otherBytesN.N
+}
+
+func (t typeBytesN) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	if len(buf) < int(t.N) || rem < int(t.N) {
+		return nil, buf, rem, newDecodeErrorAt(surge.ErrUnexpectedEndOfBuffer, t.Kind(), buf, buf)
+	}
+	value := make(BytesN, t.N)
+	rest, rem, err := value.Unmarshal(buf, rem)
+	return value, rest, rem, newDecodeErrorAt(err, t.Kind(), buf, rest)
+}
+
+func (t typeBytesN) UnmarshalValueJSON(data []byte) (Value, error) {
+	value := Bytes{}
+	if err := value.UnmarshalJSON(data); err != nil {
+		return nil, newDecodeError(err, t.Kind())
+	}
+	if len(value) != int(t.N) {
+		return nil, newDecodeError(fmt.Errorf("expected len=%v, got len=%v", t.N, len(value)), t.Kind())
+	}
+	return BytesN(value), nil
+}
+
+func (t typeBytesN) SizeHint() int {
+	return surge.SizeHintU32
+}
+
+func (t typeBytesN) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	return surge.MarshalU32(t.N, buf, rem)
+}
+
+func (t *typeBytesN) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	return surge.UnmarshalU32(&t.N, buf, rem)
+}
+
+func (t typeBytesN) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.N)
+}
+
+func (t *typeBytesN) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &t.N)
+}
+
+func (typeBytesN) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(typeBytesN{N: uint32(r.Intn(size + 1))})
+}
+
+type typeStructField struct {
+	Name string
+	Type Type
+}
+
+func (field typeStructField) SizeHint() int {
+	return surge.SizeHintString(field.Name) + SizeHintType(field.Type)
+}
+
+func (field typeStructField) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	var err error
+	if buf, rem, err = surge.MarshalString(field.Name, buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if buf, rem, err = MarshalType(field.Type, buf, rem); err != nil {
+		return buf, rem, err
+	}
+	return buf, rem, nil
+}
+
+func (field *typeStructField) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	return field.unmarshal(buf, rem, newDecodeState(DefaultDecodeOptions))
+}
+
+func (field *typeStructField) unmarshal(buf []byte, rem int, state *decodeState) ([]byte, int, error) {
+	var err error
+	if buf, rem, err = surge.UnmarshalString(&field.Name, buf, rem); err != nil {
+		return buf, rem, err
+	}
+	if err = state.checkName(field.Name); err != nil {
+		return buf, rem, err
+	}
+	if buf, rem, err = state.unmarshalType(&field.Type, buf, rem); err != nil {
+		return buf, rem, prefixDecodeError(err, fieldPath(field.Name))
+	}
+	return buf, rem, err
+}
+
+func (field typeStructField) MarshalJSON() ([]byte, error) {
+	raw, err := marshalTypeJSON(field.Type)
+	if err != nil {
+		return raw, err
+	}
+	return json.Marshal(map[string]json.RawMessage{field.Name: raw})
+}
+
+func (field *typeStructField) UnmarshalJSON(data []byte) error {
+	return field.unmarshalJSON(data, newDecodeState(DefaultDecodeOptions))
+}
+
+func (field *typeStructField) unmarshalJSON(data []byte, state *decodeState) error {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 1 {
+		return fmt.Errorf("expected len=1, got len=%v", len(raw))
+	}
+	for name, data := range raw {
+		if err := state.checkName(name); err != nil {
+			return err
+		}
+		field.Name = name
+		innerType, err := state.unmarshalTypeJSON(data)
+		if err != nil {
+			return prefixDecodeError(err, fieldPath(name))
+		}
+		field.Type = innerType
+		return nil
+	}
+	panic("unreachable")
+}
+
+func (typeStructField) Generate(r *rand.Rand, size int) reflect.Value {
+	name, _ := quick.Value(reflect.TypeOf(""), r)
+	return reflect.ValueOf(typeStructField{
+		Name: name.String(),
+		Type: GenerateType(r, size, GenerateMaxDepth-1),
+	})
+}
+
+type typeStruct []typeStructField
+
+func (typeStruct) Kind() Kind {
+	return KindStruct
+}
+
+func (t typeStruct) Equals(other Type) bool {
+	otherStruct, ok := other.(typeStruct)
+	if !ok {
+		return false
+	}
+	if len(t) != len(otherStruct) {
+		return false
+	}
+	for i := range t {
+		if t[i].Name != otherStruct[i].Name {
+			return false
+		}
+		if !t[i].Type.Equals(otherStruct[i].Type) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t typeStruct) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	return DefaultDecodeOptions.UnmarshalValue(t, buf, rem)
+}
+
+func (t typeStruct) UnmarshalValueJSON(data []byte) (Value, error) {
+	return DefaultDecodeOptions.UnmarshalValueJSON(t, data)
+}
+
+func (t typeStruct) unmarshalValue(buf []byte, rem int, state *decodeState) (Value, []byte, int, error) {
+	if err := state.alloc(int64(len(t)), sizeOfStructField); err != nil {
+		return nil, buf, rem, err
+	}
+	v := make(Struct, 0, len(t))
+	for _, field := range t {
+		var err error
+		var value Value
+		if value, buf, rem, err = state.unmarshalValue(field.Type, buf, rem); err != nil {
+			return nil, buf, rem, prefixDecodeError(err, fieldPath(field.Name))
+		}
+		v = append(v, StructField{Name: field.Name, Value: value})
+	}
+	return v, buf, rem, nil
+}
+
+func (t typeStruct) unmarshalValueJSON(data []byte, state *decodeState) (Value, error) {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if err := state.checkNumFields(int64(len(raw))); err != nil {
+		return nil, err
+	}
+	if err := state.alloc(int64(len(t)), sizeOfStructField); err != nil {
+		return nil, err
+	}
+	v := make(Struct, 0, len(t))
+	for _, field := range t {
+		rawValue, ok := raw[field.Name]
 		if !ok {
-			return nil, fmt.Errorf("unmarshaling value \"%v\": not found", field.Name)
+			// Optional fields are allowed to be absent, in which case they
+			// are unmarshaled as none.
+			if field.Type.Kind() == KindOptional {
+				v = append(v, StructField{Name: field.Name, Value: None(field.Type.(typeOptional).Type)})
+				continue
+			}
+			return nil, prefixDecodeError(newDecodeError(errNotFound, field.Type.Kind()), fieldPath(field.Name))
+		}
+		value, err := state.unmarshalValueJSON(field.Type, rawValue)
+		if err != nil {
+			return nil, prefixDecodeError(err, fieldPath(field.Name))
+		}
+		v = append(v, StructField{Name: field.Name, Value: value})
+	}
+	return v, nil
+}
+
+func (t typeStruct) SizeHint() int {
+	total := 4
+	for _, field := range t {
+		total += field.SizeHint()
+	}
+	return total
+}
+
+func (t typeStruct) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	var err error
+	buf, rem, err = surge.MarshalU32(uint32(len(t)), buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	for _, field := range t {
+		buf, rem, err = field.Marshal(buf, rem)
+		if err != nil {
+			return buf, rem, err
+		}
+	}
+	return buf, rem, nil
+}
+
+func (t *typeStruct) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	return t.unmarshal(buf, rem, newDecodeState(DefaultDecodeOptions))
+}
+
+func (t *typeStruct) unmarshal(buf []byte, rem int, state *decodeState) ([]byte, int, error) {
+	var err error
+	var numFields uint32
+	buf, rem, err = surge.UnmarshalU32(&numFields, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	if err = state.checkNumFields(int64(numFields)); err != nil {
+		return buf, rem, err
+	}
+	*t = typeStruct{}
+	for i := uint32(0); i < numFields; i++ {
+		field := typeStructField{}
+		buf, rem, err = field.unmarshal(buf, rem, state)
+		if err != nil {
+			return buf, rem, err
+		}
+		*t = append(*t, field)
+	}
+	return buf, rem, nil
+}
+
+func (t typeStruct) MarshalJSON() ([]byte, error) {
+	raw := make([]json.RawMessage, len(t))
+	for i, field := range t {
+		rawField, err := field.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("cannot marshal \"%v\": %v", field.Name, err)
+		}
+		raw[i] = rawField
+	}
+	return json.Marshal(raw)
+}
+
+func (t *typeStruct) UnmarshalJSON(data []byte) error {
+	return t.unmarshalJSON(data, newDecodeState(DefaultDecodeOptions))
+}
+
+func (t *typeStruct) unmarshalJSON(data []byte, state *decodeState) error {
+	raw := []json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if err := state.checkNumFields(int64(len(raw))); err != nil {
+		return err
+	}
+	*t = make(typeStruct, len(raw))
+	for i, rawField := range raw {
+		field := typeStructField{}
+		if err := field.unmarshalJSON(rawField, state); err != nil {
+			// Errors in the type of the field already identify the field.
+			if _, ok := err.(*DecodeError); ok {
+				return err
+			}
+			return fmt.Errorf("cannot unmarshal field=%v: %v", i, err)
+		}
+		(*t)[i] = field
+	}
+	return nil
+}
+
+func (typeStruct) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(GenerateTypeFromKind(r, size, KindStruct, GenerateMaxDepth))
+}
+
+// indexOf returns the index of the field with the given name. If there is no
+// such field, then -1 is returned.
+func (t typeStruct) indexOf(name string) int {
+	for i, field := range t {
+		if field.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+type typeList struct {
+	Type Type
+}
+
+func (typeList) Kind() Kind {
+	return KindList
+}
+
+func (t typeList) Equals(other Type) bool {
+	otherList, ok := other.(typeList)
+	if !ok {
+		return false
+	}
+	return t.Type.Equals(otherList.Type)
+}
+
+func (t typeList) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	return DefaultDecodeOptions.UnmarshalValue(t, buf, rem)
+}
+
+func (t typeList) UnmarshalValueJSON(data []byte) (Value, error) {
+	return DefaultDecodeOptions.UnmarshalValueJSON(t, data)
+}
+
+func (t typeList) unmarshalValue(buf []byte, rem int, state *decodeState) (Value, []byte, int, error) {
+	var err error
+	var numElems uint32
+	if buf, rem, err = surge.UnmarshalU32(&numElems, buf, rem); err != nil {
+		return nil, buf, rem, fmt.Errorf("unmarshaling list length: %w", err)
+	}
+	if err = state.checkListLen(int64(numElems)); err != nil {
+		return nil, buf, rem, fmt.Errorf("unmarshaling list length: %w", err)
+	}
+	if err = checkNumElems(numElems, t.Type, buf, rem); err != nil {
+		return nil, buf, rem, fmt.Errorf("unmarshaling list length: %w", err)
+	}
+	if err = state.alloc(int64(numElems), sizeOfValue); err != nil {
+		return nil, buf, rem, fmt.Errorf("unmarshaling list length: %w", err)
+	}
+	v := List{
+		T:     t.Type,
+		Elems: make([]Value, numElems),
+	}
+	for i := range v.Elems {
+		var value Value
+		if value, buf, rem, err = state.unmarshalValue(v.T, buf, rem); err != nil {
+			return nil, buf, rem, prefixDecodeError(err, indexPath(i))
+		}
+		v.Elems[i] = value
+	}
+	return v, buf, rem, nil
+}
+
+func (t typeList) unmarshalValueJSON(data []byte, state *decodeState) (Value, error) {
+	raw := []json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if err := state.checkListLen(int64(len(raw))); err != nil {
+		return nil, fmt.Errorf("unmarshaling list length: %w", err)
+	}
+	if err := state.alloc(int64(len(raw)), sizeOfValue); err != nil {
+		return nil, fmt.Errorf("unmarshaling list length: %w", err)
+	}
+	v := List{
+		T:     t.Type,
+		Elems: make([]Value, len(raw)),
+	}
+	for i := range v.Elems {
+		value, err := state.unmarshalValueJSON(v.T, raw[i])
+		if err != nil {
+			return nil, prefixDecodeError(err, indexPath(i))
+		}
+		v.Elems[i] = value
+	}
+	return v, nil
+}
+
+func (t typeList) SizeHint() int {
+	return SizeHintType(t.Type)
+}
+
+func (t typeList) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	return MarshalType(t.Type, buf, rem)
+}
+
+func (t *typeList) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	return t.unmarshal(buf, rem, newDecodeState(DefaultDecodeOptions))
+}
+
+func (t *typeList) unmarshal(buf []byte, rem int, state *decodeState) ([]byte, int, error) {
+	buf, rem, err := state.unmarshalType(&t.Type, buf, rem)
+	return buf, rem, prefixDecodeError(err, ".[]")
+}
+
+func (t typeList) MarshalJSON() ([]byte, error) {
+	return marshalTypeJSON(t.Type)
+}
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	return t.unmarshalJSON(data, newDecodeState(DefaultDecodeOptions))
+}
+
+func (t *typeList) unmarshalJSON(data []byte, state *decodeState) error {
+	var err error
+	t.Type, err = state.unmarshalTypeJSON(data)
+	return prefixDecodeError(err, ".[]")
+}
+
+func (typeList) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(GenerateTypeFromKind(r, size, KindList, GenerateMaxDepth))
+}
+
+type typeOptional struct {
+	Type Type
+}
+
+func (typeOptional) Kind() Kind {
+	return KindOptional
+}
+
+func (t typeOptional) Equals(other Type) bool {
+	otherOptional, ok := other.(typeOptional)
+	if !ok {
+		return false
+	}
+	return t.Type.Equals(otherOptional.Type)
+}
+
+func (t typeOptional) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	return DefaultDecodeOptions.UnmarshalValue(t, buf, rem)
+}
+
+func (t typeOptional) UnmarshalValueJSON(data []byte) (Value, error) {
+	return DefaultDecodeOptions.UnmarshalValueJSON(t, data)
+}
+
+func (t typeOptional) unmarshalValue(buf []byte, rem int, state *decodeState) (Value, []byte, int, error) {
+	var err error
+	var some bool
+	if buf, rem, err = surge.UnmarshalBool(&some, buf, rem); err != nil {
+		return nil, buf, rem, fmt.Errorf("unmarshaling optional presence: %w", err)
+	}
+	if !some {
+		return None(t.Type), buf, rem, nil
+	}
+	var value Value
+	if value, buf, rem, err = state.unmarshalValue(t.Type, buf, rem); err != nil {
+		return nil, buf, rem, err
+	}
+	return Optional{T: t.Type, Value: value}, buf, rem, nil
+}
+
+func (t typeOptional) unmarshalValueJSON(data []byte, state *decodeState) (Value, error) {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return None(t.Type), nil
+	}
+	value, err := state.unmarshalValueJSON(t.Type, data)
+	if err != nil {
+		return nil, err
+	}
+	return Optional{T: t.Type, Value: value}, nil
+}
+
+func (t typeOptional) SizeHint() int {
+	return SizeHintType(t.Type)
+}
+
+func (t typeOptional) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	return MarshalType(t.Type, buf, rem)
+}
+
+func (t *typeOptional) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	return t.unmarshal(buf, rem, newDecodeState(DefaultDecodeOptions))
+}
+
+func (t *typeOptional) unmarshal(buf []byte, rem int, state *decodeState) ([]byte, int, error) {
+	return state.unmarshalType(&t.Type, buf, rem)
+}
+
+func (t typeOptional) MarshalJSON() ([]byte, error) {
+	return marshalTypeJSON(t.Type)
+}
+
+func (t *typeOptional) UnmarshalJSON(data []byte) error {
+	return t.unmarshalJSON(data, newDecodeState(DefaultDecodeOptions))
+}
+
+func (t *typeOptional) unmarshalJSON(data []byte, state *decodeState) error {
+	var err error
+	t.Type, err = state.unmarshalTypeJSON(data)
+	return err
+}
+
+func (typeOptional) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(GenerateTypeFromKind(r, size, KindOptional, GenerateMaxDepth))
+}
+
+type typeMap struct {
+	Key   Type
+	Value Type
+}
+
+func (typeMap) Kind() Kind {
+	return KindMap
+}
+
+func (t typeMap) Equals(other Type) bool {
+	otherMap, ok := other.(typeMap)
+	if !ok {
+		return false
+	}
+	return t.Key.Equals(otherMap.Key) && t.Value.Equals(otherMap.Value)
+}
+
+func (t typeMap) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	return DefaultDecodeOptions.UnmarshalValue(t, buf, rem)
+}
+
+func (t typeMap) UnmarshalValueJSON(data []byte) (Value, error) {
+	return DefaultDecodeOptions.UnmarshalValueJSON(t, data)
+}
+
+func (t typeMap) unmarshalValue(buf []byte, rem int, state *decodeState) (Value, []byte, int, error) {
+	var err error
+	var numEntries uint32
+	if buf, rem, err = surge.UnmarshalU32(&numEntries, buf, rem); err != nil {
+		return nil, buf, rem, fmt.Errorf("unmarshaling map length: %w", err)
+	}
+	if err = state.checkListLen(int64(numEntries)); err != nil {
+		return nil, buf, rem, fmt.Errorf("unmarshaling map length: %w", err)
+	}
+	if err = checkNumEntries(numEntries, t.Key, buf, rem); err != nil {
+		return nil, buf, rem, fmt.Errorf("unmarshaling map length: %w", err)
+	}
+	if err = state.alloc(int64(numEntries), sizeOfMapEntry); err != nil {
+		return nil, buf, rem, fmt.Errorf("unmarshaling map length: %w", err)
+	}
+	v := Map{
+		K:       t.Key,
+		V:       t.Value,
+		Entries: make([]MapEntry, numEntries),
+	}
+	var prevKey []byte
+	for i := range v.Entries {
+		// Keep track of the binary representation of the key, so that we can
+		// reject maps that are not in canonical order.
+		keyBuf, keyRem := buf, rem
+		var key, value Value
+		if key, buf, rem, err = state.unmarshalValue(t.Key, buf, rem); err != nil {
+			return nil, buf, rem, prefixDecodeError(err, indexPath(i)+".key")
+		}
+		keyData := keyBuf[:len(keyBuf)-len(buf)]
+		if i > 0 {
+			if err = checkKeyOrder(prevKey, keyData); err != nil {
+				// The error is reported at the start of the key, because
+				// the key is the cause of the error.
+				return nil, keyBuf, keyRem, prefixDecodeError(newDecodeError(err, KindMap), indexPath(i)+".key")
+			}
+		}
+		prevKey = keyData
+		if value, buf, rem, err = state.unmarshalValue(t.Value, buf, rem); err != nil {
+			return nil, buf, rem, prefixDecodeError(err, indexPath(i)+".value")
+		}
+		v.Entries[i] = MapEntry{Key: key, Value: value}
+	}
+	return v, buf, rem, nil
+}
+
+func (t typeMap) unmarshalValueJSON(data []byte, state *decodeState) (Value, error) {
+	entries := []MapEntry{}
+	if t.Key.Kind() == KindString {
+		raw := map[string]json.RawMessage{}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		if err := state.checkListLen(int64(len(raw))); err != nil {
+			return nil, fmt.Errorf("unmarshaling map length: %w", err)
+		}
+		if err := state.alloc(int64(len(raw)), sizeOfMapEntry); err != nil {
+			return nil, fmt.Errorf("unmarshaling map length: %w", err)
+		}
+		for key, rawValue := range raw {
+			value, err := state.unmarshalValueJSON(t.Value, rawValue)
+			if err != nil {
+				return nil, prefixDecodeError(err, fieldPath(key))
+			}
+			entries = append(entries, MapEntry{Key: String(key), Value: value})
+		}
+	} else {
+		raw := [][2]json.RawMessage{}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		if err := state.checkListLen(int64(len(raw))); err != nil {
+			return nil, fmt.Errorf("unmarshaling map length: %w", err)
+		}
+		if err := state.alloc(int64(len(raw)), sizeOfMapEntry); err != nil {
+			return nil, fmt.Errorf("unmarshaling map length: %w", err)
+		}
+		for i, rawEntry := range raw {
+			key, err := state.unmarshalValueJSON(t.Key, rawEntry[0])
+			if err != nil {
+				return nil, prefixDecodeError(err, indexPath(i)+".key")
+			}
+			value, err := state.unmarshalValueJSON(t.Value, rawEntry[1])
+			if err != nil {
+				return nil, prefixDecodeError(err, indexPath(i)+".value")
+			}
+			entries = append(entries, MapEntry{Key: key, Value: value})
+		}
+	}
+	sorted, err := sortMapEntries(entries)
+	if err != nil {
+		return nil, err
+	}
+	return Map{K: t.Key, V: t.Value, Entries: sorted}, nil
+}
+
+func (t typeMap) SizeHint() int {
+	return SizeHintType(t.Key) + SizeHintType(t.Value)
+}
+
+func (t typeMap) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	var err error
+	if buf, rem, err = MarshalType(t.Key, buf, rem); err != nil {
+		return buf, rem, err
+	}
+	return MarshalType(t.Value, buf, rem)
+}
+
+func (t *typeMap) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	return t.unmarshal(buf, rem, newDecodeState(DefaultDecodeOptions))
+}
+
+func (t *typeMap) unmarshal(buf []byte, rem int, state *decodeState) ([]byte, int, error) {
+	var err error
+	if buf, rem, err = state.unmarshalType(&t.Key, buf, rem); err != nil {
+		return buf, rem, prefixDecodeError(err, ".[].key")
+	}
+	buf, rem, err = state.unmarshalType(&t.Value, buf, rem)
+	return buf, rem, prefixDecodeError(err, ".[].value")
+}
+
+func (t typeMap) MarshalJSON() ([]byte, error) {
+	rawKey, err := marshalTypeJSON(t.Key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal key: %v", err)
+	}
+	rawValue, err := marshalTypeJSON(t.Value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal value: %v", err)
+	}
+	return json.Marshal(map[string]json.RawMessage{
+		"key":   rawKey,
+		"value": rawValue,
+	})
+}
+
+func (t *typeMap) UnmarshalJSON(data []byte) error {
+	return t.unmarshalJSON(data, newDecodeState(DefaultDecodeOptions))
+}
+
+func (t *typeMap) unmarshalJSON(data []byte, state *decodeState) error {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	rawKey, ok := raw["key"]
+	if !ok {
+		return fmt.Errorf("cannot unmarshal key: not found")
+	}
+	rawValue, ok := raw["value"]
+	if !ok {
+		return fmt.Errorf("cannot unmarshal value: not found")
+	}
+	var err error
+	if t.Key, err = state.unmarshalTypeJSON(rawKey); err != nil {
+		return prefixDecodeError(err, ".[].key")
+	}
+	if t.Value, err = state.unmarshalTypeJSON(rawValue); err != nil {
+		return prefixDecodeError(err, ".[].value")
+	}
+	return nil
+}
+
+func (typeMap) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(GenerateTypeFromKind(r, size, KindMap, GenerateMaxDepth))
+}
+
+type typeUnion []typeStructField
+
+func (typeUnion) Kind() Kind {
+	return KindUnion
+}
+
+func (t typeUnion) Equals(other Type) bool {
+	otherUnion, ok := other.(typeUnion)
+	if !ok {
+		return false
+	}
+	if len(t) != len(otherUnion) {
+		return false
+	}
+	for i := range t {
+		if t[i].Name != otherUnion[i].Name {
+			return false
+		}
+		if !t[i].Type.Equals(otherUnion[i].Type) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t typeUnion) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	return DefaultDecodeOptions.UnmarshalValue(t, buf, rem)
+}
+
+func (t typeUnion) UnmarshalValueJSON(data []byte) (Value, error) {
+	return DefaultDecodeOptions.UnmarshalValueJSON(t, data)
+}
+
+func (t typeUnion) unmarshalValue(buf []byte, rem int, state *decodeState) (Value, []byte, int, error) {
+	var err error
+	var index uint8
+	if buf, rem, err = surge.UnmarshalU8(&index, buf, rem); err != nil {
+		return nil, buf, rem, fmt.Errorf("unmarshaling variant: %w", err)
+	}
+	if err = t.checkIndex(index); err != nil {
+		return nil, buf, rem, fmt.Errorf("unmarshaling variant: %w", err)
+	}
+	var value Value
+	if value, buf, rem, err = state.unmarshalValue(t[index].Type, buf, rem); err != nil {
+		return nil, buf, rem, prefixDecodeError(err, fieldPath(t[index].Name))
+	}
+	return Union{T: t, Index: index, Value: value}, buf, rem, nil
+}
+
+func (t typeUnion) unmarshalValueJSON(data []byte, state *decodeState) (Value, error) {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw) != 1 {
+		return nil, fmt.Errorf("expected 1 variant, got %v variants", len(raw))
+	}
+	for name, rawValue := range raw {
+		i := t.indexOf(name)
+		if i < 0 {
+			return nil, fmt.Errorf("unexpected variant \"%v\"", name)
 		}
-		value, err := field.Type.UnmarshalValueJSON(rawValue)
+		value, err := state.unmarshalValueJSON(t[i].Type, rawValue)
 		if err != nil {
-			return nil, fmt.Errorf("unmarshaling value \"%v\": %v", field.Name, err)
+			return nil, prefixDecodeError(err, fieldPath(name))
 		}
-		v = append(v, StructField{Name: field.Name, Value: value})
+		return Union{T: t, Index: uint8(i), Value: value}, nil
 	}
-	return v, nil
+	panic("unreachable")
 }
 
-func (t typeStruct) SizeHint() int {
+func (t typeUnion) SizeHint() int {
 	total := 4
-	for _, field := range t {
-		total += field.SizeHint()
+	for _, variant := range t {
+		total += variant.SizeHint()
 	}
 	return total
 }
 
-func (t typeStruct) Marshal(buf []byte, rem int) ([]byte, int, error) {
+func (t typeUnion) Marshal(buf []byte, rem int) ([]byte, int, error) {
 	var err error
 	buf, rem, err = surge.MarshalU32(uint32(len(t)), buf, rem)
 	if err != nil {
 		return buf, rem, err
 	}
-	for _, field := range t {
-		buf, rem, err = field.Marshal(buf, rem)
+	for _, variant := range t {
+		buf, rem, err = variant.Marshal(buf, rem)
 		if err != nil {
 			return buf, rem, err
 		}
@@ -916,135 +2217,301 @@ func (t typeStruct) Marshal(buf []byte, rem int) ([]byte, int, error) {
 	return buf, rem, nil
 }
 
-func (t *typeStruct) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+func (t *typeUnion) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	return t.unmarshal(buf, rem, newDecodeState(DefaultDecodeOptions))
+}
+
+func (t *typeUnion) unmarshal(buf []byte, rem int, state *decodeState) ([]byte, int, error) {
 	var err error
-	var numFields uint32
-	buf, rem, err = surge.UnmarshalU32(&numFields, buf, rem)
+	var numVariants uint32
+	buf, rem, err = surge.UnmarshalU32(&numVariants, buf, rem)
 	if err != nil {
 		return buf, rem, err
 	}
-	for i := uint32(0); i < numFields; i++ {
-		field := typeStructField{}
-		buf, rem, err = field.Unmarshal(buf, rem)
+	if err = checkNumVariants(numVariants); err != nil {
+		return buf, rem, err
+	}
+	if err = state.checkNumFields(int64(numVariants)); err != nil {
+		return buf, rem, err
+	}
+	*t = typeUnion{}
+	for i := uint32(0); i < numVariants; i++ {
+		variant := typeStructField{}
+		buf, rem, err = variant.unmarshal(buf, rem, state)
 		if err != nil {
 			return buf, rem, err
 		}
-		*t = append(*t, field)
+		*t = append(*t, variant)
 	}
-	return buf, rem, nil
+	return buf, rem, checkUnionVariants(*t)
 }
 
-func (t typeStruct) MarshalJSON() ([]byte, error) {
-	raw := make([]json.RawMessage, len(t))
-	for i, field := range t {
-		rawField, err := field.MarshalJSON()
-		if err != nil {
-			return nil, fmt.Errorf("cannot marshal \"%v\": %v", field.Name, err)
-		}
-		raw[i] = rawField
-	}
-	return json.Marshal(raw)
+func (t typeUnion) MarshalJSON() ([]byte, error) {
+	return typeStruct(t).MarshalJSON()
 }
 
-func (t *typeStruct) UnmarshalJSON(data []byte) error {
-	raw := []json.RawMessage{}
-	if err := json.Unmarshal(data, &raw); err != nil {
+func (t *typeUnion) UnmarshalJSON(data []byte) error {
+	return t.unmarshalJSON(data, newDecodeState(DefaultDecodeOptions))
+}
+
+func (t *typeUnion) unmarshalJSON(data []byte, state *decodeState) error {
+	ts := typeStruct{}
+	if err := ts.unmarshalJSON(data, state); err != nil {
 		return err
 	}
-	*t = make(typeStruct, len(raw))
-	for i, rawField := range raw {
-		field := typeStructField{}
-		if err := field.UnmarshalJSON(rawField); err != nil {
-			return fmt.Errorf("cannot unmarshal field=%v: %v", i, err)
-		}
-		(*t)[i] = field
+	if err := checkUnionVariants(ts); err != nil {
+		return err
 	}
+	*t = typeUnion(ts)
 	return nil
 }
 
-func (typeStruct) Generate(r *rand.Rand, size int) reflect.Value {
-	typeStructFields := make([]typeStructField, size)
-	for i := 0; i < size; i++ {
-		typeStructFields[i] = typeStructField{}.Generate(r, size).Interface().(typeStructField)
-	}
-	return reflect.ValueOf(typeStruct(typeStructFields))
+func (typeUnion) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(GenerateTypeFromKind(r, size, KindUnion, GenerateMaxDepth))
 }
 
-type typeList struct {
-	Type Type
+// indexOf returns the index of the variant with the given name. If there is no
+// such variant, then -1 is returned.
+func (t typeUnion) indexOf(name string) int {
+	return typeStruct(t).indexOf(name)
 }
 
-func (typeList) Kind() Kind {
-	return KindList
+type typeTuple []Type
+
+func (typeTuple) Kind() Kind {
+	return KindTuple
 }
 
-func (t typeList) Equals(other Type) bool {
-	otherList, ok := other.(typeList)
+func (t typeTuple) Equals(other Type) bool {
+	otherTuple, ok := other.(typeTuple)
 	if !ok {
 		return false
 	}
-	return t.Type.Equals(otherList.Type)
+	if len(t) != len(otherTuple) {
+		return false
+	}
+	for i := range t {
+		if !t[i].Equals(otherTuple[i]) {
+			return false
+		}
+	}
+	return true
 }
 
-func (t typeList) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
-	var err error
-	var numElems uint32
-	if buf, rem, err = surge.UnmarshalU32(&numElems, buf, rem); err != nil {
-		return nil, buf, rem, fmt.Errorf("unmarshaling list length: %v", err)
-	}
-	v := List{
-		T:     t.Type,
-		Elems: make([]Value, numElems),
+func (t typeTuple) UnmarshalValue(buf []byte, rem int) (Value, []byte, int, error) {
+	return DefaultDecodeOptions.UnmarshalValue(t, buf, rem)
+}
+
+func (t typeTuple) UnmarshalValueJSON(data []byte) (Value, error) {
+	return DefaultDecodeOptions.UnmarshalValueJSON(t, data)
+}
+
+func (t typeTuple) unmarshalValue(buf []byte, rem int, state *decodeState) (Value, []byte, int, error) {
+	if err := state.alloc(int64(len(t)), sizeOfValue); err != nil {
+		return nil, buf, rem, err
 	}
-	for i := range v.Elems {
+	v := make(Tuple, len(t))
+	for i, elemType := range t {
+		var err error
 		var value Value
-		if value, buf, rem, err = v.T.UnmarshalValue(buf, rem); err != nil {
-			return nil, buf, rem, fmt.Errorf("unmarshaling list value: %v", err)
+		if value, buf, rem, err = state.unmarshalValue(elemType, buf, rem); err != nil {
+			return nil, buf, rem, prefixDecodeError(err, indexPath(i))
 		}
-		v.Elems[i] = value
+		v[i] = value
 	}
 	return v, buf, rem, nil
 }
 
-func (t typeList) UnmarshalValueJSON(data []byte) (Value, error) {
+func (t typeTuple) unmarshalValueJSON(data []byte, state *decodeState) (Value, error) {
 	raw := []json.RawMessage{}
 	if err := json.Unmarshal(data, &raw); err != nil {
 		return nil, err
 	}
-	v := List{
-		T:     t.Type,
-		Elems: make([]Value, len(raw)),
+	if len(raw) != len(t) {
+		return nil, fmt.Errorf("expected len=%v, got len=%v", len(t), len(raw))
 	}
-	for i := range v.Elems {
-		value, err := v.T.UnmarshalValueJSON(raw[i])
+	if err := state.alloc(int64(len(t)), sizeOfValue); err != nil {
+		return nil, err
+	}
+	v := make(Tuple, len(t))
+	for i, elemType := range t {
+		value, err := state.unmarshalValueJSON(elemType, raw[i])
 		if err != nil {
-			return nil, fmt.Errorf("unmarshaling list value: %v", err)
+			return nil, prefixDecodeError(err, indexPath(i))
 		}
-		v.Elems[i] = value
+		v[i] = value
 	}
 	return v, nil
 }
 
-func (t typeList) SizeHint() int {
-	return t.Type.SizeHint() + 64
+func (t typeTuple) SizeHint() int {
+	total := 4
+	for _, elemType := range t {
+		total += SizeHintType(elemType)
+	}
+	return total
 }
 
-func (t typeList) Marshal(buf []byte, rem int) ([]byte, int, error) {
-	return MarshalType(t.Type, buf, rem)
+func (t typeTuple) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	var err error
+	buf, rem, err = surge.MarshalU32(uint32(len(t)), buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	for _, elemType := range t {
+		buf, rem, err = MarshalType(elemType, buf, rem)
+		if err != nil {
+			return buf, rem, err
+		}
+	}
+	return buf, rem, nil
 }
 
-func (t *typeList) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
-	return UnmarshalType(&t.Type, buf, rem)
+func (t *typeTuple) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	return t.unmarshal(buf, rem, newDecodeState(DefaultDecodeOptions))
 }
 
-func (t typeList) MarshalJSON() ([]byte, error) {
-	return marshalTypeJSON(t.Type)
+func (t *typeTuple) unmarshal(buf []byte, rem int, state *decodeState) ([]byte, int, error) {
+	var err error
+	var numElems uint32
+	buf, rem, err = surge.UnmarshalU32(&numElems, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	if err = state.checkNumFields(int64(numElems)); err != nil {
+		return buf, rem, err
+	}
+	*t = typeTuple{}
+	for i := uint32(0); i < numElems; i++ {
+		var elemType Type
+		buf, rem, err = state.unmarshalType(&elemType, buf, rem)
+		if err != nil {
+			return buf, rem, prefixDecodeError(err, indexPath(int(i)))
+		}
+		*t = append(*t, elemType)
+	}
+	return buf, rem, nil
 }
 
-func (t *typeList) UnmarshalJSON(data []byte) error {
-	var err error
-	t.Type, err = unmarshalTypeJSON(data)
-	return err
+func (t typeTuple) MarshalJSON() ([]byte, error) {
+	raw := make([]json.RawMessage, len(t))
+	for i, elemType := range t {
+		rawElem, err := marshalTypeJSON(elemType)
+		if err != nil {
+			return nil, fmt.Errorf("cannot marshal element=%v: %v", i, err)
+		}
+		raw[i] = rawElem
+	}
+	return json.Marshal(raw)
+}
+
+func (t *typeTuple) UnmarshalJSON(data []byte) error {
+	return t.unmarshalJSON(data, newDecodeState(DefaultDecodeOptions))
+}
+
+func (t *typeTuple) unmarshalJSON(data []byte, state *decodeState) error {
+	raw := []json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if err := state.checkNumFields(int64(len(raw))); err != nil {
+		return err
+	}
+	*t = make(typeTuple, len(raw))
+	for i, rawElem := range raw {
+		elemType, err := state.unmarshalTypeJSON(rawElem)
+		if err != nil {
+			return prefixDecodeError(err, indexPath(i))
+		}
+		(*t)[i] = elemType
+	}
+	return nil
+}
+
+func (typeTuple) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(GenerateTypeFromKind(r, size, KindTuple, GenerateMaxDepth))
+}
+
+// checkIndex returns an error if the index does not select a variant of the
+// union type.
+func (t typeUnion) checkIndex(index uint8) error {
+	if int(index) >= len(t) {
+		return fmt.Errorf("expected variant<%v, got variant=%v", len(t), index)
+	}
+	return nil
+}
+
+// checkNumVariants returns an error if a union type declares more than
+// MaxUnionVariants variants, so that the variants are not unmarshaled.
+func checkNumVariants(numVariants uint32) error {
+	if numVariants > MaxUnionVariants {
+		return fmt.Errorf("expected variants<=%v, got variants=%v", MaxUnionVariants, numVariants)
+	}
+	return nil
+}
+
+// checkKeyOrder returns errNonCanonicalOrder if the binary representation of
+// a map key does not come after the binary representation of the previous key.
+func checkKeyOrder(prevKey, key []byte) error {
+	if bytes.Compare(prevKey, key) >= 0 {
+		return errNonCanonicalOrder
+	}
+	return nil
+}
+
+// checkNumElems returns an error if the buffer, or the remaining memory quota,
+// is too small to hold the given number of elements of the given type. Every
+// element needs at least one byte, unless values of the type have no binary
+// representation (e.g. empty structs). This prevents small, malicious, buffers
+// from causing large allocations.
+func checkNumElems(numElems uint32, elemType Type, buf []byte, rem int) error {
+	if isZeroSizeType(elemType) {
+		return nil
+	}
+	if uint64(numElems) > uint64(len(buf)) || uint64(numElems) > uint64(rem) {
+		return surge.ErrUnexpectedEndOfBuffer
+	}
+	return nil
+}
+
+// checkNumEntries returns an error if the buffer, or the remaining memory
+// quota, is too small to hold the given number of map entries. Every entry
+// needs at least one byte for its key, unless keys of the type have no binary
+// representation. All such keys are equal, so a map in canonical order has at
+// most one entry.
+func checkNumEntries(numEntries uint32, keyType Type, buf []byte, rem int) error {
+	if isZeroSizeType(keyType) {
+		if numEntries > 1 {
+			return fmt.Errorf("expected len<=1, got len=%v", numEntries)
+		}
+		return nil
+	}
+	return checkNumElems(numEntries, keyType, buf, rem)
+}
+
+// isZeroSizeType returns true when values of the type have no binary
+// representation. Otherwise, it returns false.
+func isZeroSizeType(t Type) bool {
+	switch t := t.(type) {
+	case typeStruct:
+		for _, field := range t {
+			if !isZeroSizeType(field.Type) {
+				return false
+			}
+		}
+		return true
+	case typeTuple:
+		for _, elemType := range t {
+			if !isZeroSizeType(elemType) {
+				return false
+			}
+		}
+		return true
+	case typeBytesN:
+		return t.N == 0
+	default:
+		return false
+	}
 }
 
 // SizeHintType returns the number of bytes requires to represent this type in
@@ -1065,6 +2532,18 @@ func SizeHintType(t Type) int {
 		return t.SizeHint()
 	case KindU256:
 		return t.SizeHint()
+	case KindI8:
+		return t.SizeHint()
+	case KindI16:
+		return t.SizeHint()
+	case KindI32:
+		return t.SizeHint()
+	case KindI64:
+		return t.SizeHint()
+	case KindI128:
+		return t.SizeHint()
+	case KindI256:
+		return t.SizeHint()
 	case KindString:
 		return t.SizeHint()
 	case KindBytes:
@@ -1073,7 +2552,7 @@ func SizeHintType(t Type) int {
 		return t.SizeHint()
 	case KindBytes65:
 		return t.SizeHint()
-	case KindStruct, KindList:
+	case KindBytesN, KindStruct, KindList, KindOptional, KindMap, KindUnion, KindTuple:
 		return t.Kind().SizeHint() + t.SizeHint()
 	default:
 		return 0
@@ -1097,6 +2576,18 @@ func MarshalType(t Type, buf []byte, rem int) ([]byte, int, error) {
 		return t.Marshal(buf, rem)
 	case KindU256:
 		return t.Marshal(buf, rem)
+	case KindI8:
+		return t.Marshal(buf, rem)
+	case KindI16:
+		return t.Marshal(buf, rem)
+	case KindI32:
+		return t.Marshal(buf, rem)
+	case KindI64:
+		return t.Marshal(buf, rem)
+	case KindI128:
+		return t.Marshal(buf, rem)
+	case KindI256:
+		return t.Marshal(buf, rem)
 	case KindString:
 		return t.Marshal(buf, rem)
 	case KindBytes:
@@ -1105,7 +2596,7 @@ func MarshalType(t Type, buf []byte, rem int) ([]byte, int, error) {
 		return t.Marshal(buf, rem)
 	case KindBytes65:
 		return t.Marshal(buf, rem)
-	case KindStruct, KindList:
+	case KindBytesN, KindStruct, KindList, KindOptional, KindMap, KindUnion, KindTuple:
 		var err error
 		if buf, rem, err = t.Kind().Marshal(buf, rem); err != nil {
 			return buf, rem, err
@@ -1119,10 +2610,32 @@ func MarshalType(t Type, buf []byte, rem int) ([]byte, int, error) {
 	}
 }
 
-// UnmarshalType from binary.
+// TypeHash returns the SHA256 hash of the binary representation of a type (as
+// produced by MarshalType). The binary representation of a type is canonical,
+// so two types have the same hash if, and only if, they are equal. The hash is
+// guaranteed to be stable across releases, and can be used as a compact
+// identifier for the type.
+func TypeHash(t Type) [32]byte {
+	buf := make([]byte, SizeHintType(t))
+	if _, _, err := MarshalType(t, buf, len(buf)); err != nil {
+		panic(fmt.Errorf("marshaling type: %v", err))
+	}
+	return sha256.Sum256(buf)
+}
+
+// UnmarshalType from binary. The DefaultDecodeOptions are used to limit the
+// resources consumed by unmarshaling (see DecodeOptions).
 func UnmarshalType(t *Type, buf []byte, rem int) ([]byte, int, error) {
-	var err error
-	var kind Kind
+	return DefaultDecodeOptions.UnmarshalType(t, buf, rem)
+}
+
+// unmarshalType from binary. Errors are returned as DecodeErrors, with paths
+// that are relative to the type.
+func (state *decodeState) unmarshalType(t *Type, buf []byte, rem int) (_ []byte, _ int, err error) {
+	kind := KindNil
+	defer func() {
+		err = newDecodeError(err, kind)
+	}()
 	if buf, rem, err = kind.Unmarshal(buf, rem); err != nil {
 		return buf, rem, err
 	}
@@ -1148,6 +2661,24 @@ func UnmarshalType(t *Type, buf []byte, rem int) ([]byte, int, error) {
 	case KindU256:
 		*t = typeU256{}
 		return buf, rem, nil
+	case KindI8:
+		*t = typeI8{}
+		return buf, rem, nil
+	case KindI16:
+		*t = typeI16{}
+		return buf, rem, nil
+	case KindI32:
+		*t = typeI32{}
+		return buf, rem, nil
+	case KindI64:
+		*t = typeI64{}
+		return buf, rem, nil
+	case KindI128:
+		*t = typeI128{}
+		return buf, rem, nil
+	case KindI256:
+		*t = typeI256{}
+		return buf, rem, nil
 	case KindString:
 		*t = typeString{}
 		return buf, rem, nil
@@ -1160,22 +2691,83 @@ func UnmarshalType(t *Type, buf []byte, rem int) ([]byte, int, error) {
 	case KindBytes65:
 		*t = typeBytes65{}
 		return buf, rem, nil
+	case KindBytesN:
+		tb := typeBytesN{}
+		if buf, rem, err = tb.Unmarshal(buf, rem); err != nil {
+			return buf, rem, err
+		}
+		*t = tb
+		return buf, rem, nil
 	case KindStruct:
+		if err = state.enter(); err != nil {
+			return buf, rem, err
+		}
+		defer state.leave()
 		ts := typeStruct{}
-		if buf, rem, err = ts.Unmarshal(buf, rem); err != nil {
+		if buf, rem, err = ts.unmarshal(buf, rem, state); err != nil {
 			return buf, rem, err
 		}
 		*t = ts
 		return buf, rem, nil
 	case KindList:
+		if err = state.enter(); err != nil {
+			return buf, rem, err
+		}
+		defer state.leave()
 		tl := typeList{}
-		if buf, rem, err = tl.Unmarshal(buf, rem); err != nil {
+		if buf, rem, err = tl.unmarshal(buf, rem, state); err != nil {
 			return buf, rem, err
 		}
 		*t = tl
 		return buf, rem, nil
+	case KindOptional:
+		if err = state.enter(); err != nil {
+			return buf, rem, err
+		}
+		defer state.leave()
+		to := typeOptional{}
+		if buf, rem, err = to.unmarshal(buf, rem, state); err != nil {
+			return buf, rem, err
+		}
+		*t = to
+		return buf, rem, nil
+	case KindMap:
+		if err = state.enter(); err != nil {
+			return buf, rem, err
+		}
+		defer state.leave()
+		tm := typeMap{}
+		if buf, rem, err = tm.unmarshal(buf, rem, state); err != nil {
+			return buf, rem, err
+		}
+		*t = tm
+		return buf, rem, nil
+	case KindUnion:
+		if err = state.enter(); err != nil {
+			return buf, rem, err
+		}
+		defer state.leave()
+		tu := typeUnion{}
+		if buf, rem, err = tu.unmarshal(buf, rem, state); err != nil {
+			return buf, rem, err
+		}
+		*t = tu
+		return buf, rem, nil
+	case KindTuple:
+		if err = state.enter(); err != nil {
+			return buf, rem, err
+		}
+		defer state.leave()
+		tt := typeTuple{}
+		if buf, rem, err = tt.unmarshal(buf, rem, state); err != nil {
+			return buf, rem, err
+		}
+		*t = tt
+		return buf, rem, nil
 	default:
-		return buf, rem, fmt.Errorf("unsupported kind %v", kind)
+		err = fmt.Errorf("unsupported kind %v", kind)
+		kind = KindNil
+		return buf, rem, err
 	}
 }
 
@@ -1185,6 +2777,10 @@ func marshalTypeJSON(t Type) ([]byte, error) {
 		return raw, err
 	}
 	switch t.Kind() {
+	case KindBytesN:
+		return json.Marshal(map[string]interface{}{
+			"bytesn": json.RawMessage(raw),
+		})
 	case KindStruct:
 		return json.Marshal(map[string]interface{}{
 			"struct": json.RawMessage(raw),
@@ -1193,17 +2789,39 @@ func marshalTypeJSON(t Type) ([]byte, error) {
 		return json.Marshal(map[string]interface{}{
 			"list": json.RawMessage(raw),
 		})
+	case KindOptional:
+		return json.Marshal(map[string]interface{}{
+			"optional": json.RawMessage(raw),
+		})
+	case KindMap:
+		return json.Marshal(map[string]interface{}{
+			"map": json.RawMessage(raw),
+		})
+	case KindUnion:
+		return json.Marshal(map[string]interface{}{
+			"union": json.RawMessage(raw),
+		})
+	case KindTuple:
+		return json.Marshal(map[string]interface{}{
+			"tuple": json.RawMessage(raw),
+		})
 	default:
 		return raw, nil
 	}
 }
 
-func unmarshalTypeJSON(data []byte) (Type, error) {
+// unmarshalTypeJSON unmarshals a type from JSON. Errors are returned as
+// DecodeErrors, with paths that are relative to the type.
+func (state *decodeState) unmarshalTypeJSON(data []byte) (_ Type, err error) {
+	kind := KindNil
+	defer func() {
+		err = newDecodeError(err, kind)
+	}()
+
 	// First attempt to unmarshal the type directly into a kind. If this
 	// succeeds, then the type is simple, and we can return it based solely on
 	// the kind. Otherwise, we are dealing with an abstract type, and need to
 	// unmarshal differently.
-	kind := KindNil
 	if err := json.Unmarshal(data, &kind); err == nil {
 		switch kind {
 		case KindBool:
@@ -1220,6 +2838,18 @@ func unmarshalTypeJSON(data []byte) (Type, error) {
 			return typeU128{}, nil
 		case KindU256:
 			return typeU256{}, nil
+		case KindI8:
+			return typeI8{}, nil
+		case KindI16:
+			return typeI16{}, nil
+		case KindI32:
+			return typeI32{}, nil
+		case KindI64:
+			return typeI64{}, nil
+		case KindI128:
+			return typeI128{}, nil
+		case KindI256:
+			return typeI256{}, nil
 		case KindString:
 			return typeString{}, nil
 		case KindBytes:
@@ -1241,18 +2871,55 @@ func unmarshalTypeJSON(data []byte) (Type, error) {
 	if len(raw) != 1 {
 		return nil, fmt.Errorf("expected 1 kind, got %v kinds", len(raw))
 	}
-	for kind, data := range raw {
+	for k, data := range raw {
+		kind = k
+		if kind != KindBytesN {
+			if err := state.enter(); err != nil {
+				return nil, err
+			}
+			defer state.leave()
+		}
 		switch kind {
+		case KindBytesN:
+			t := typeBytesN{}
+			if err := json.Unmarshal(data, &t); err != nil {
+				return nil, fmt.Errorf("unmarshaling bytesn: %v", err)
+			}
+			return t, nil
 		case KindStruct:
 			t := typeStruct{}
-			if err := json.Unmarshal(data, &t); err != nil {
-				return nil, fmt.Errorf("unmarshaling struct: %v", err)
+			if err := t.unmarshalJSON(data, state); err != nil {
+				return nil, err
 			}
 			return t, nil
 		case KindList:
 			t := typeList{}
-			if err := json.Unmarshal(data, &t); err != nil {
-				return nil, fmt.Errorf("unmarshaling list: %v", err)
+			if err := t.unmarshalJSON(data, state); err != nil {
+				return nil, err
+			}
+			return t, nil
+		case KindOptional:
+			t := typeOptional{}
+			if err := t.unmarshalJSON(data, state); err != nil {
+				return nil, err
+			}
+			return t, nil
+		case KindMap:
+			t := typeMap{}
+			if err := t.unmarshalJSON(data, state); err != nil {
+				return nil, err
+			}
+			return t, nil
+		case KindUnion:
+			t := typeUnion{}
+			if err := t.unmarshalJSON(data, state); err != nil {
+				return nil, err
+			}
+			return t, nil
+		case KindTuple:
+			t := typeTuple{}
+			if err := t.unmarshalJSON(data, state); err != nil {
+				return nil, err
 			}
 			return t, nil
 		default:
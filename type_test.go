@@ -2,6 +2,7 @@ package pack_test
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"math/rand"
 	"reflect"
@@ -19,6 +20,11 @@ import (
 
 var _ = Describe("Types", func() {
 
+	unionType := pack.UnionType(
+		pack.NewUnionVariant("foo", pack.NewU64(0).Type()),
+		pack.NewUnionVariant("bar", pack.NewString("").Type()),
+	)
+
 	ts := []reflect.Type{
 		reflect.TypeOf(pack.NewBool(false).Type()),
 		reflect.TypeOf(pack.NewU8(0).Type()),
@@ -27,10 +33,21 @@ var _ = Describe("Types", func() {
 		reflect.TypeOf(pack.NewU64(0).Type()),
 		reflect.TypeOf(pack.NewU128([16]byte{}).Type()),
 		reflect.TypeOf(pack.NewU256([32]byte{}).Type()),
+		reflect.TypeOf(pack.NewI8(0).Type()),
+		reflect.TypeOf(pack.NewI16(0).Type()),
+		reflect.TypeOf(pack.NewI32(0).Type()),
+		reflect.TypeOf(pack.NewI64(0).Type()),
+		reflect.TypeOf(pack.NewI128([16]byte{}).Type()),
+		reflect.TypeOf(pack.NewI256([32]byte{}).Type()),
 		reflect.TypeOf(pack.NewString("").Type()),
 		reflect.TypeOf(pack.NewBytes([]byte{}).Type()),
 		reflect.TypeOf(pack.NewBytes32([32]byte{}).Type()),
 		reflect.TypeOf(pack.NewBytes65([65]byte{}).Type()),
+		reflect.TypeOf(pack.NewBytesN(make([]byte, 20)).Type()),
+		reflect.TypeOf(pack.None(pack.NewU64(0).Type()).Type()),
+		reflect.TypeOf(pack.EmptyMap(pack.String("").Type(), pack.NewU64(0).Type()).Type()),
+		reflect.TypeOf(unionType),
+		reflect.TypeOf(pack.NewTuple(pack.NewU64(0), pack.NewString("")).Type()),
 		reflect.TypeOf(pack.NewStruct(
 			"foo", pack.NewU32(0),
 			"bar", pack.NewString(""),
@@ -135,4 +152,153 @@ var _ = Describe("Types", func() {
 			})
 		})
 	}
+	Context("when constructing types directly", func() {
+		It("should equal the types of values", func() {
+			Expect(pack.TypeBool().Equals(pack.NewBool(false).Type())).To(BeTrue())
+			Expect(pack.TypeU8().Equals(pack.NewU8(0).Type())).To(BeTrue())
+			Expect(pack.TypeU16().Equals(pack.NewU16(0).Type())).To(BeTrue())
+			Expect(pack.TypeU32().Equals(pack.NewU32(0).Type())).To(BeTrue())
+			Expect(pack.TypeU64().Equals(pack.NewU64(0).Type())).To(BeTrue())
+			Expect(pack.TypeU128().Equals(pack.NewU128([16]byte{}).Type())).To(BeTrue())
+			Expect(pack.TypeU256().Equals(pack.NewU256([32]byte{}).Type())).To(BeTrue())
+			Expect(pack.TypeI8().Equals(pack.NewI8(0).Type())).To(BeTrue())
+			Expect(pack.TypeI16().Equals(pack.NewI16(0).Type())).To(BeTrue())
+			Expect(pack.TypeI32().Equals(pack.NewI32(0).Type())).To(BeTrue())
+			Expect(pack.TypeI64().Equals(pack.NewI64(0).Type())).To(BeTrue())
+			Expect(pack.TypeI128().Equals(pack.NewI128([16]byte{}).Type())).To(BeTrue())
+			Expect(pack.TypeI256().Equals(pack.NewI256([32]byte{}).Type())).To(BeTrue())
+			Expect(pack.TypeString().Equals(pack.NewString("").Type())).To(BeTrue())
+			Expect(pack.TypeBytes().Equals(pack.NewBytes([]byte{}).Type())).To(BeTrue())
+			Expect(pack.TypeBytes32().Equals(pack.NewBytes32([32]byte{}).Type())).To(BeTrue())
+			Expect(pack.TypeBytes65().Equals(pack.NewBytes65([65]byte{}).Type())).To(BeTrue())
+			Expect(pack.TypeBytesN(20).Equals(pack.NewBytesN(make([]byte, 20)).Type())).To(BeTrue())
+			Expect(pack.ListType(pack.TypeString()).Equals(pack.EmptyList(pack.TypeString()).Type())).To(BeTrue())
+			Expect(pack.OptionalType(pack.TypeU64()).Equals(pack.None(pack.TypeU64()).Type())).To(BeTrue())
+			Expect(pack.MapType(pack.TypeString(), pack.TypeU64()).Equals(pack.EmptyMap(pack.TypeString(), pack.TypeU64()).Type())).To(BeTrue())
+			Expect(pack.TupleType(pack.TypeU64(), pack.TypeString()).Equals(pack.NewTuple(pack.NewU64(0), pack.NewString("")).Type())).To(BeTrue())
+			Expect(pack.StructType(
+				"foo", pack.TypeU32(),
+				"bar", pack.TypeString(),
+			).Equals(pack.NewStruct(
+				"foo", pack.NewU32(0),
+				"bar", pack.NewString(""),
+			).Type())).To(BeTrue())
+		})
+
+		It("should unmarshal values", func() {
+			t := pack.StructType(
+				"amount", pack.TypeU256(),
+				"memo", pack.ListType(pack.TypeString()),
+			)
+			x := pack.NewStruct(
+				"amount", pack.NewU256([32]byte{1}),
+				"memo", pack.EmptyList(pack.TypeString()),
+			)
+			data, err := surge.ToBinary(x)
+			Expect(err).ToNot(HaveOccurred())
+			y, _, _, err := t.UnmarshalValue(data, len(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(y).To(Equal(x))
+		})
+		It("should panic when the arguments are malformed", func() {
+			Expect(func() { pack.StructType("foo") }).To(Panic())
+			Expect(func() { pack.StructType(1, pack.TypeU8()) }).To(Panic())
+			Expect(func() { pack.StructType("foo", nil) }).To(Panic())
+			Expect(func() { pack.StructType("foo", pack.TypeU8(), "foo", pack.TypeU16()) }).To(Panic())
+		})
+	})
+
+	Context("when inspecting types", func() {
+		It("should return the field names and types of structs", func() {
+			t := pack.StructType(
+				"amount", pack.TypeU256(),
+				"to", pack.TypeBytes32(),
+				"memo", pack.ListType(pack.TypeString()),
+			)
+			Expect(pack.FieldNames(t)).To(Equal([]string{"amount", "to", "memo"}))
+			Expect(pack.FieldType(t, "amount")).To(Equal(pack.TypeU256()))
+			Expect(pack.FieldType(t, "to")).To(Equal(pack.TypeBytes32()))
+			Expect(pack.ElemType(pack.FieldType(t, "memo"))).To(Equal(pack.TypeString()))
+			Expect(pack.FieldType(t, "missing")).To(BeNil())
+		})
+
+		It("should return the variant names and types of unions", func() {
+			Expect(pack.FieldNames(unionType)).To(Equal([]string{"foo", "bar"}))
+			Expect(pack.FieldType(unionType, "bar")).To(Equal(pack.TypeString()))
+		})
+
+		It("should return the element types of other types", func() {
+			Expect(pack.ElemType(pack.OptionalType(pack.TypeBool()))).To(Equal(pack.TypeBool()))
+			Expect(pack.ElemTypes(pack.TupleType(pack.TypeU8(), pack.TypeI8()))).To(Equal([]pack.Type{pack.TypeU8(), pack.TypeI8()}))
+			Expect(pack.KeyType(pack.MapType(pack.TypeString(), pack.TypeU64()))).To(Equal(pack.TypeString()))
+			Expect(pack.ValueType(pack.MapType(pack.TypeString(), pack.TypeU64()))).To(Equal(pack.TypeU64()))
+			Expect(pack.BytesNLen(pack.TypeBytesN(20))).To(Equal(uint32(20)))
+		})
+
+		It("should return nothing for types of other kinds", func() {
+			t := pack.TypeU64()
+			Expect(pack.FieldNames(t)).To(BeNil())
+			Expect(pack.FieldType(t, "foo")).To(BeNil())
+			Expect(pack.ElemType(t)).To(BeNil())
+			Expect(pack.ElemTypes(t)).To(BeNil())
+			Expect(pack.KeyType(t)).To(BeNil())
+			Expect(pack.ValueType(t)).To(BeNil())
+			Expect(pack.BytesNLen(t)).To(Equal(uint32(0)))
+		})
+	})
+
+	Context("when hashing types", func() {
+		It("should return the pinned hashes", func() {
+			// These hashes must never change. Changing them will break
+			// compatibility with services that use type hashes to identify
+			// types.
+			vectors := []struct {
+				t    pack.Type
+				hash string
+			}{
+				{pack.TypeBool(), "4bf5122f344554c53bde2ebb8cd2b7e3d1600ad631c385a5d7cce23c7785459a"},
+				{pack.TypeU64(), "e77b9a9ae9e30b0dbdb6f510a264ef9de781501d7b6b92ae89eb059c5ab743db"},
+				{pack.TypeI256(), "334359b90efed75da5f0ada1d5e6b256f4a6bd0aee7eb39c0f90182a021ffc8b"},
+				{pack.TypeString(), "01ba4719c80b6fe911b091a7c05124b64eeece964e09c058ef8f9805daca546b"},
+				{pack.TypeBytes32(), "ef6cbd2161eaea7943ce8693b9824d23d1793ffb1c0fca05b600d3899b44c977"},
+				{pack.TypeBytesN(20), "69408e867520ac1a96684801440822d3bdeff061cbfb0f58809c7e2675d4a82f"},
+				{pack.ListType(pack.TypeString()), "ebf16fe7c400f116de46b932eff38e73588332b84a684a661f6c16e7b69e7cb1"},
+				{pack.OptionalType(pack.TypeU64()), "ce175b867a1e88f9a579985880bc29adf9f8b07e81dec797d95e0b2e5ee77c38"},
+				{pack.MapType(pack.TypeString(), pack.TypeU64()), "0b95fa3945b44d59059b2dfbb1c9fc13afca5179186aa62cdc64cfea79463c9b"},
+				{pack.TupleType(pack.TypeU64(), pack.TypeString()), "d68b5723cebc205a47a45cd8853c3219de682d18eaed953536d759ce4193f28a"},
+				{pack.StructType(), "9e0b4ab3de1c14ba78692fe97ee18784d7f02d83ff0395bd17cc2a2eb4650a2d"},
+				{pack.StructType(
+					"amount", pack.TypeU256(),
+					"to", pack.TypeBytes32(),
+					"memo", pack.ListType(pack.TypeString()),
+				), "b50e3594925f34ffd72aa89e0f89008952b881aeb951d34e0aacf115cf26dbd3"},
+				{unionType, "27f0f05774f15e87ba1ffe22fd73ad74467548cbf5c6d1d995cbcd73a04c5578"},
+			}
+			for _, vector := range vectors {
+				hash := pack.TypeHash(vector.t)
+				Expect(hex.EncodeToString(hash[:])).To(Equal(vector.hash))
+			}
+		})
+
+		It("should return the same hash for equal types", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < numTrials; trial++ {
+				x := pack.GenerateType(r, 10, pack.GenerateMaxDepth)
+				y := pack.GenerateType(r, 10, pack.GenerateMaxDepth)
+				if x.Equals(y) {
+					Expect(pack.TypeHash(x)).To(Equal(pack.TypeHash(y)))
+				} else {
+					Expect(pack.TypeHash(x)).ToNot(Equal(pack.TypeHash(y)))
+				}
+
+				var z pack.Type
+				data := make([]byte, pack.SizeHintType(x))
+				_, _, err := pack.MarshalType(x, data, len(data))
+				Expect(err).ToNot(HaveOccurred())
+				_, _, err = pack.UnmarshalType(&z, data, len(data))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pack.TypeHash(z)).To(Equal(pack.TypeHash(x)))
+			}
+		})
+	})
 })
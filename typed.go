@@ -71,19 +71,19 @@ func (typed *Typed) UnmarshalJSON(data []byte) error {
 	raw := Raw{}
 	err := json.Unmarshal(data, &raw)
 	if err != nil {
-		return fmt.Errorf("unmarshaling raw: %v", err)
+		return newDecodeError(fmt.Errorf("unmarshaling raw: %v", err), KindStruct)
 	}
-	t, err := unmarshalTypeJSON(raw.T)
+	t, err := DefaultDecodeOptions.UnmarshalTypeJSON(raw.T)
 	if err != nil {
-		return fmt.Errorf("unmarshaling \"t\": %v", err)
+		return fmt.Errorf("unmarshaling \"t\": %w", err)
 	}
 	v, err := t.UnmarshalValueJSON(raw.V)
 	if err != nil {
-		return fmt.Errorf("unmarshaling \"v\": %v", err)
+		return fmt.Errorf("unmarshaling \"v\": %w", err)
 	}
 	s, ok := v.(Struct)
 	if !ok {
-		return fmt.Errorf("expected kind \"struct\", got kind \"%v\"", t.Kind())
+		return newDecodeError(fmt.Errorf("expected kind \"struct\", got kind \"%v\"", t.Kind()), t.Kind())
 	}
 	*typed = Typed(s)
 	return nil
@@ -110,20 +110,24 @@ func (typed Typed) Marshal(buf []byte, rem int) ([]byte, int, error) {
 
 // Unmarshal the typed value from binary. The type definition will be
 // unmarshaled first, and then this will be used to unmarshal the actual value
-// into a well-typed struct.
+// into a well-typed struct. The offsets of errors are relative to the start of
+// the type definition.
 func (typed *Typed) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	var err error
 	var t Type
 	var v Value
+	orig := buf
 	if buf, rem, err = UnmarshalType(&t, buf, rem); err != nil {
-		return buf, rem, err
+		return buf, rem, withOffset(err, orig, buf)
 	}
+	valueBuf := buf
 	if v, buf, rem, err = t.UnmarshalValue(buf, rem); err != nil {
-		return buf, rem, err
+		return buf, rem, withOffset(err, orig, buf)
 	}
 	s, ok := v.(Struct)
 	if !ok {
-		return buf, rem, fmt.Errorf("expected kind \"struct\", got kind \"%v\"", t.Kind())
+		err = newDecodeErrorAt(fmt.Errorf("expected kind \"struct\", got kind \"%v\"", t.Kind()), t.Kind(), orig, valueBuf)
+		return buf, rem, err
 	}
 	*typed = Typed(s)
 	return buf, rem, nil
@@ -140,7 +144,7 @@ func (typed Typed) String() string {
 
 // Generate a random well-typed struct. This method is implemented for use in
 // quick tests. See https://golang.org/pkg/testing/quick/#Generator for more
-// information. Generated typed values will never contain embedded structs.
+// information. Generated typed values are nested to at most GenerateMaxDepth.
 func (Typed) Generate(r *rand.Rand, size int) reflect.Value {
 	return reflect.ValueOf(Typed(Struct{}.Generate(r, size).Interface().(Struct)))
 }
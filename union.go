@@ -0,0 +1,313 @@
+package pack
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+
+	"github.com/renproject/surge"
+)
+
+// MaxUnionVariants is the maximum number of variants that a union type can
+// have. This limit exists because the index of the selected variant is
+// marshaled as a single byte.
+const MaxUnionVariants = 256
+
+// UnionVariant represents a named variant within a union type. It is not, by
+// itself, a type. It is only meant to be used to build union types.
+type UnionVariant struct {
+	Name string
+	Type Type
+}
+
+// NewUnionVariant returns a union variant with the given name and type.
+func NewUnionVariant(name string, t Type) UnionVariant {
+	return UnionVariant{Name: name, Type: t}
+}
+
+// UnionType returns a union type from a slice of variadic variants. The
+// function will panic if there are no variants, if there are more than
+// MaxUnionVariants variants, or if the same name is used more than once.
+//
+//  t := UnionType(
+//      NewUnionVariant("foo", TypeU64()),
+//      NewUnionVariant("bar", TypeString()),
+//  )
+//
+func UnionType(variants ...UnionVariant) Type {
+	t := make(typeUnion, 0, len(variants))
+	for _, variant := range variants {
+		t = append(t, typeStructField{Name: variant.Name, Type: variant.Type})
+	}
+	if err := checkUnionVariants(t); err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// checkUnionVariants returns an error if there are no variants, if there are
+// more than MaxUnionVariants variants, or if the same name is used more than
+// once. It is used to check union types that are constructed, and union types
+// that are unmarshaled, in the same way.
+func checkUnionVariants(variants []typeStructField) error {
+	if len(variants) == 0 {
+		return fmt.Errorf("cannot construct union with no variants")
+	}
+	if len(variants) > MaxUnionVariants {
+		return fmt.Errorf("expected variants<=%v, got variants=%v", MaxUnionVariants, len(variants))
+	}
+	for i, variant := range variants {
+		for _, other := range variants[:i] {
+			if other.Name == variant.Name {
+				return fmt.Errorf("duplicate variant \"%v\"", variant.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Union represents a value that is exactly one of a fixed set of named
+// variants. In binary, unions are marshaled as the index of the selected
+// variant (a single byte) followed by the value. In JSON, unions are marshaled
+// as an object with exactly one field, where the name of the field is the name
+// of the selected variant.
+type Union struct {
+	T     Type
+	Index uint8
+	Value Value
+}
+
+// NewUnion returns a union, of the given union type, that holds the given
+// value as the named variant. An error is returned if the type is not a union
+// type, if the variant does not exist, or if the value does not have the type
+// of the variant.
+func NewUnion(t Type, name string, value Value) (Union, error) {
+	tu, ok := t.(typeUnion)
+	if !ok {
+		return Union{}, fmt.Errorf("expected %v, got %v", KindUnion, t.Kind())
+	}
+	i := tu.indexOf(name)
+	if i < 0 {
+		return Union{}, fmt.Errorf("unexpected variant \"%v\"", name)
+	}
+	if !tu[i].Type.Equals(value.Type()) {
+		return Union{}, fmt.Errorf("unexpected type for variant \"%v\": expected %v, got %v", name, tu[i].Type, value.Type())
+	}
+	return Union{T: tu, Index: uint8(i), Value: value}, nil
+}
+
+// Type returns the union type.
+func (v Union) Type() Type {
+	return v.T
+}
+
+// Variant returns the name of the selected variant. If the union type is
+// unknown, or the index is out of range, then the empty string is returned.
+func (v Union) Variant() string {
+	tu, ok := v.T.(typeUnion)
+	if !ok || int(v.Index) >= len(tu) {
+		return ""
+	}
+	return tu[v.Index].Name
+}
+
+// SizeHint returns the number of bytes required to represent the union in
+// binary. This includes the variant index.
+func (v Union) SizeHint() int {
+	return surge.SizeHintU8 + v.Value.SizeHint()
+}
+
+// Marshal the union into binary.
+func (v Union) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.MarshalU8(v.Index, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return v.Value.Marshal(buf, rem)
+}
+
+// Unmarshal the union from binary.
+func (v *Union) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	if v.T == nil || v.T.Kind() != KindUnion {
+		return buf, rem, fmt.Errorf("cannot unmarshal into union with unknown type")
+	}
+	value, buf, rem, err := v.T.UnmarshalValue(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	*v = value.(Union)
+	return buf, rem, nil
+}
+
+// MarshalJSON marshals the union to JSON. This is done by marshaling the union
+// as if it was a JSON object with exactly one field: the selected variant.
+func (v Union) MarshalJSON() ([]byte, error) {
+	if v.T == nil || v.T.Kind() != KindUnion {
+		return nil, fmt.Errorf("cannot marshal union with unknown type")
+	}
+	name := v.Variant()
+	rawValue, err := v.Value.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("marshaling variant \"%v\": %v", name, err)
+	}
+	return json.Marshal(map[string]json.RawMessage{name: rawValue})
+}
+
+// String returns the union in its JSON representation.
+func (v Union) String() string {
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+// Generate a random union. This method is implemented for use in quick tests.
+// See https://golang.org/pkg/testing/quick/#Generator for more information.
+// Generated unions are nested to at most GenerateMaxDepth.
+func (Union) Generate(r *rand.Rand, size int) reflect.Value {
+	t := GenerateTypeFromKind(r, size, KindUnion, GenerateMaxDepth)
+	return reflect.ValueOf(GenerateFromType(r, size, t))
+}
+
+// unionRegistration stores the union type of a Go interface that has been
+// registered as a union, and the Go types of its variants.
+type unionRegistration struct {
+	t        typeUnion
+	zero     Value
+	variants []reflect.Type
+}
+
+var (
+	unionsMu = new(sync.RWMutex)
+	unions   = map[reflect.Type]*unionRegistration{}
+)
+
+// RegisterUnion registers a Go interface as a union, so that struct fields,
+// list elements, and map values of that interface type can be encoded and
+// decoded. The first argument must be a nil pointer to the interface. The
+// remaining arguments are expected to be of the form ("name", value)*, where
+// each value is an instance of a variant that implements the interface. The
+// order of the variants defines their indices, so it must not change once
+// values have been marshaled. The function will panic if the arguments are not
+// of this form, if a variant does not implement the interface, or if the
+// variants cannot be used to construct a union type (see UnionType).
+//
+//  RegisterUnion((*Shape)(nil),
+//      "circle", Circle{},
+//      "square", Square{},
+//  )
+//
+func RegisterUnion(iface interface{}, vs ...interface{}) {
+	ifaceType := reflect.TypeOf(iface)
+	if ifaceType == nil || ifaceType.Kind() != reflect.Ptr || ifaceType.Elem().Kind() != reflect.Interface {
+		panic(fmt.Errorf("expected pointer to interface, got %T", iface))
+	}
+	ifaceType = ifaceType.Elem()
+	if len(vs)%2 != 0 {
+		panic(fmt.Errorf("expected (name, variant) pairs, got %v arguments", len(vs)))
+	}
+
+	variants := make([]UnionVariant, len(vs)/2)
+	variantTypes := make([]reflect.Type, len(vs)/2)
+	zeros := make([]Value, len(vs)/2)
+	for i := range variants {
+		name, ok := vs[2*i+0].(string)
+		if !ok {
+			panic(fmt.Errorf("expected name of type string, got %T", vs[2*i+0]))
+		}
+		variantType := reflect.TypeOf(vs[2*i+1])
+		if variantType == nil || !variantType.Implements(ifaceType) {
+			panic(fmt.Errorf("variant \"%v\" of type %T does not implement %v", name, vs[2*i+1], ifaceType))
+		}
+		// Pointer variants are encoded as the value to which they point.
+		zeroType := variantType
+		if zeroType.Kind() == reflect.Ptr {
+			zeroType = zeroType.Elem()
+		}
+		zero, err := Encode(reflect.Zero(zeroType).Interface())
+		if err != nil {
+			panic(fmt.Errorf("encoding variant \"%v\": %v", name, err))
+		}
+		variants[i] = NewUnionVariant(name, zero.Type())
+		variantTypes[i] = variantType
+		zeros[i] = zero
+	}
+	t := UnionType(variants...)
+
+	unionsMu.Lock()
+	unions[ifaceType] = &unionRegistration{
+		t:        t.(typeUnion),
+		zero:     Union{T: t, Index: 0, Value: zeros[0]},
+		variants: variantTypes,
+	}
+	unionsMu.Unlock()
+
+	// The zero types of Go types that hold the interface have changed.
+	resetZeroTypes()
+}
+
+// lookupUnion returns the registration of a Go interface that has been
+// registered as a union. If the Go type has not been registered, then false is
+// returned.
+func lookupUnion(typeOf reflect.Type) (*unionRegistration, bool) {
+	unionsMu.RLock()
+	defer unionsMu.RUnlock()
+	union, ok := unions[typeOf]
+	return union, ok
+}
+
+// encode a Go interface into a union.
+func (union *unionRegistration) encode(valueOf reflect.Value) (Value, error) {
+	if valueOf.Kind() == reflect.Interface {
+		if valueOf.IsNil() {
+			return nil, fmt.Errorf("encoding union: nil interface")
+		}
+		valueOf = valueOf.Elem()
+	}
+	for i, variantType := range union.variants {
+		if variantType != valueOf.Type() {
+			continue
+		}
+		if valueOf.Kind() == reflect.Ptr {
+			if valueOf.IsNil() {
+				return nil, fmt.Errorf("encoding variant \"%v\": nil pointer", union.t[i].Name)
+			}
+			valueOf = valueOf.Elem()
+		}
+		value, err := Encode(valueOf.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("encoding variant \"%v\": %v", union.t[i].Name, err)
+		}
+		return Union{T: union.t, Index: uint8(i), Value: value}, nil
+	}
+	return nil, fmt.Errorf("encoding union: unexpected variant of type %v", valueOf.Type())
+}
+
+// decode a union into a Go interface.
+func (union *unionRegistration) decode(elem reflect.Value, v Value) error {
+	u, ok := v.(Union)
+	if !ok {
+		return fmt.Errorf("unexpected value of type %T", v)
+	}
+	if !union.t.Equals(u.T) {
+		return fmt.Errorf("unexpected union type: expected %v, got %v", union.t, u.T)
+	}
+	variantType := union.variants[u.Index]
+	if variantType.Kind() == reflect.Ptr {
+		ptr := reflect.New(variantType.Elem())
+		if err := Decode(ptr.Interface(), u.Value); err != nil {
+			return prefixDecodeError(err, fieldPath(u.Variant()))
+		}
+		elem.Set(ptr)
+		return nil
+	}
+	ptr := reflect.New(variantType)
+	if err := Decode(ptr.Interface(), u.Value); err != nil {
+		return prefixDecodeError(err, fieldPath(u.Variant()))
+	}
+	elem.Set(ptr.Elem())
+	return nil
+}
@@ -0,0 +1,263 @@
+package pack_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing/quick"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/pack/packutil"
+	"github.com/renproject/surge"
+	"github.com/renproject/surge/surgeutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type Shape interface {
+	Area() uint64
+}
+
+type Circle struct {
+	Radius uint64 `json:"radius"`
+}
+
+func (circle Circle) Area() uint64 {
+	return 3 * circle.Radius * circle.Radius
+}
+
+type Rect struct {
+	Width  uint64 `json:"width"`
+	Height uint64 `json:"height"`
+}
+
+func (rect *Rect) Area() uint64 {
+	return rect.Width * rect.Height
+}
+
+func init() {
+	pack.RegisterUnion((*Shape)(nil),
+		"circle", Circle{},
+		"rect", &Rect{},
+	)
+}
+
+var _ = Describe("Union", func() {
+
+	numTrials := 10
+
+	Context("when fuzzing", func() {
+		It("should not panic", func() {
+			for trial := 0; trial < numTrials; trial++ {
+				Expect(func() { surgeutil.Fuzz(reflect.TypeOf(pack.Union{})) }).ToNot(Panic())
+				Expect(func() { packutil.JSONFuzz(reflect.TypeOf(pack.Union{})) }).ToNot(Panic())
+			}
+		})
+	})
+
+	Context("when marshaling", func() {
+		Context("when the buffer is too small", func() {
+			It("should return itself", func() {
+				for trial := 0; trial < numTrials; trial++ {
+					Expect(surgeutil.MarshalBufTooSmall(reflect.TypeOf(pack.Union{}))).To(Succeed())
+				}
+			})
+		})
+
+		Context("when the remaining memory quota is too small", func() {
+			It("should return itself", func() {
+				for trial := 0; trial < numTrials; trial++ {
+					Expect(surgeutil.MarshalRemTooSmall(reflect.TypeOf(pack.Union{}))).To(Succeed())
+				}
+			})
+		})
+	})
+
+	Context("when marshaling and unmarshaling to binary", func() {
+		It("should equal itself", func() {
+			f := func(x pack.Union) bool {
+				data, err := surge.ToBinary(x)
+				Expect(err).ToNot(HaveOccurred())
+				y := pack.Union{
+					T: x.T,
+				}
+				err = surge.FromBinary(&y, data)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(y).To(Equal(x))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when marshaling and unmarshaling to JSON", func() {
+		It("should equal itself", func() {
+			f := func(x pack.Union) bool {
+				data, err := json.Marshal(x)
+				Expect(err).ToNot(HaveOccurred())
+				y, err := x.Type().UnmarshalValueJSON(data)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(y).To(Equal(x))
+				return true
+			}
+			Expect(quick.Check(f, nil)).To(Succeed())
+		})
+	})
+
+	Context("when marshaling a union", func() {
+		It("should marshal the variant index followed by the value", func() {
+			t := pack.UnionType(
+				pack.NewUnionVariant("foo", pack.NewU64(0).Type()),
+				pack.NewUnionVariant("bar", pack.NewU16(0).Type()),
+			)
+			x, err := pack.NewUnion(t, "bar", pack.NewU16(42))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(x.Index).To(Equal(uint8(1)))
+			Expect(x.Variant()).To(Equal("bar"))
+
+			data, err := surge.ToBinary(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte{1, 0, 42}))
+
+			data, err = json.Marshal(x)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(`{"bar":"42"}`))
+		})
+	})
+
+	Context("when constructing a union", func() {
+		t := pack.UnionType(
+			pack.NewUnionVariant("foo", pack.NewU64(0).Type()),
+		)
+
+		Context("when the variant does not exist", func() {
+			It("should return an error", func() {
+				_, err := pack.NewUnion(t, "bar", pack.NewU64(0))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("when the value does not have the type of the variant", func() {
+			It("should return an error", func() {
+				_, err := pack.NewUnion(t, "foo", pack.NewU32(0))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("when the type is not a union type", func() {
+			It("should return an error", func() {
+				_, err := pack.NewUnion(pack.NewU64(0).Type(), "foo", pack.NewU64(0))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Context("when constructing a union type", func() {
+		Context("when there are no variants", func() {
+			It("should panic", func() {
+				Expect(func() { pack.UnionType() }).To(Panic())
+			})
+		})
+
+		Context("when the same name is used more than once", func() {
+			It("should panic", func() {
+				Expect(func() {
+					pack.UnionType(
+						pack.NewUnionVariant("foo", pack.NewU64(0).Type()),
+						pack.NewUnionVariant("foo", pack.NewU32(0).Type()),
+					)
+				}).To(Panic())
+			})
+		})
+	})
+
+	Context("when unmarshaling a union type", func() {
+		Context("when there are no variants", func() {
+			It("should return an error", func() {
+				var t pack.Type
+				_, _, err := pack.UnmarshalType(&t, []byte{byte(pack.KindUnion), 0, 0, 0, 0}, 5)
+				Expect(err).To(HaveOccurred())
+				_, err = pack.DefaultDecodeOptions.UnmarshalTypeJSON([]byte(`{"union": []}`))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("when the same name is used more than once", func() {
+			It("should return an error", func() {
+				t := pack.UnionType(
+					pack.NewUnionVariant("a", pack.TypeU8()),
+					pack.NewUnionVariant("b", pack.TypeU8()),
+				)
+				data := make([]byte, pack.SizeHintType(t))
+				_, _, err := pack.MarshalType(t, data, len(data))
+				Expect(err).ToNot(HaveOccurred())
+				data = bytes.Replace(data, []byte("b"), []byte("a"), 1)
+				_, _, err = pack.UnmarshalType(&t, data, len(data))
+				Expect(err).To(HaveOccurred())
+				_, err = pack.DefaultDecodeOptions.UnmarshalTypeJSON([]byte(`{"union": [{"a": "u8"}, {"a": "u8"}]}`))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Context("when unmarshaling a variant that does not exist", func() {
+		It("should return an error", func() {
+			t := pack.UnionType(
+				pack.NewUnionVariant("foo", pack.NewU8(0).Type()),
+			)
+			x := pack.Union{T: t}
+			Expect(surge.FromBinary(&x, []byte{1, 0})).ToNot(Succeed())
+			_, err := t.UnmarshalValueJSON([]byte(`{"bar":"0"}`))
+			Expect(err).To(HaveOccurred())
+			_, err = t.UnmarshalValueJSON([]byte(`{"foo":"0","bar":"0"}`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when encoding and decoding registered interfaces", func() {
+		It("should equal itself", func() {
+			type Drawing struct {
+				Main   Shape   `json:"main"`
+				Others []Shape `json:"others"`
+			}
+			x := Drawing{
+				Main:   Circle{Radius: 2},
+				Others: []Shape{&Rect{Width: 3, Height: 4}, Circle{Radius: 5}},
+			}
+			v, err := pack.Encode(x)
+			Expect(err).ToNot(HaveOccurred())
+			main := v.(pack.Struct).Get("main").(pack.Union)
+			Expect(main.Variant()).To(Equal("circle"))
+			Expect(main.Value).To(Equal(pack.NewStruct("radius", pack.NewU64(2))))
+
+			data, err := json.Marshal(v)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(`{"main":{"circle":{"radius":"2"}},"others":[{"rect":{"height":"4","width":"3"}},{"circle":{"radius":"5"}}]}`))
+
+			y := Drawing{}
+			Expect(pack.Decode(&y, v)).To(Succeed())
+			Expect(y).To(Equal(x))
+			Expect(y.Others[0].Area()).To(Equal(uint64(12)))
+		})
+
+		It("should infer the union type of empty lists", func() {
+			v, err := pack.Encode([]Shape{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.(pack.List).T.Kind()).To(Equal(pack.KindUnion))
+		})
+
+		It("should panic when the arguments are malformed", func() {
+			Expect(func() { pack.RegisterUnion((*Shape)(nil), "circle", Circle{}, "rect") }).To(Panic())
+			Expect(func() { pack.RegisterUnion((*Shape)(nil), Circle{}, "circle") }).To(Panic())
+		})
+
+		It("should return an error for nil interfaces", func() {
+			type Drawing struct {
+				Main Shape `json:"main"`
+			}
+			_, err := pack.Encode(Drawing{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -18,9 +18,17 @@ type Value interface {
 	Type() Type
 }
 
+// GenerateMaxDepth is the maximum depth to which generated types, and values,
+// are nested. Abstract types (structs, lists, optionals, maps, unions, and
+// tuples) that are generated at the maximum depth will only contain
+// non-abstract types. Defaults to 2.
+var GenerateMaxDepth = 2
+
 // Generate a random value. This is helpful when implementing generators for
 // other types. See https://golang.org/pkg/testing/quick/#Generator for more
-// information.
+// information. When allowStruct is false, the value will not be a struct, and
+// when allowList is false, the value will not be a list. Values of all other
+// abstract kinds are nested to at most GenerateMaxDepth.
 func Generate(r *rand.Rand, size int, allowStruct, allowList bool) reflect.Value {
 	kind, _ := quick.Value(reflect.TypeOf(Kind(0)), r)
 	return GenerateFromKind(r, size, kind.Interface().(Kind), allowStruct, allowList)
@@ -44,6 +52,18 @@ func GenerateFromKind(r *rand.Rand, size int, kind Kind, allowStruct, allowList
 		t = reflect.TypeOf(U128{})
 	case KindU256:
 		t = reflect.TypeOf(U256{})
+	case KindI8:
+		t = reflect.TypeOf(I8(0))
+	case KindI16:
+		t = reflect.TypeOf(I16(0))
+	case KindI32:
+		t = reflect.TypeOf(I32(0))
+	case KindI64:
+		t = reflect.TypeOf(I64(0))
+	case KindI128:
+		t = reflect.TypeOf(I128{})
+	case KindI256:
+		t = reflect.TypeOf(I256{})
 	case KindString:
 		t = reflect.TypeOf(String(""))
 	case KindBytes:
@@ -52,6 +72,8 @@ func GenerateFromKind(r *rand.Rand, size int, kind Kind, allowStruct, allowList
 		t = reflect.TypeOf(Bytes32{})
 	case KindBytes65:
 		t = reflect.TypeOf(Bytes65{})
+	case KindBytesN:
+		t = reflect.TypeOf(BytesN{})
 	case KindStruct:
 		if !allowStruct {
 			return Generate(r, size, allowStruct, allowList)
@@ -62,9 +84,180 @@ func GenerateFromKind(r *rand.Rand, size int, kind Kind, allowStruct, allowList
 			return Generate(r, size, allowStruct, allowList)
 		}
 		t = reflect.TypeOf(List{})
+	case KindOptional:
+		t = reflect.TypeOf(Optional{})
+	case KindMap:
+		t = reflect.TypeOf(Map{})
+	case KindUnion:
+		t = reflect.TypeOf(Union{})
+	case KindTuple:
+		t = reflect.TypeOf(Tuple{})
 	default:
 		panic("non-exhaustive pattern")
 	}
 	v, _ := quick.Value(t, r)
 	return v
 }
+
+// GenerateType generates a random type, of any kind, that is nested to at
+// most the given depth. Types generated at a depth of zero will never have
+// elements (i.e. they will never be structs, lists, optionals, maps, unions,
+// or tuples).
+func GenerateType(r *rand.Rand, size, depth int) Type {
+	kind := Kind(0).Generate(r, size).Interface().(Kind)
+	for depth <= 0 && kind.IsAbstract() && kind != KindBytesN {
+		kind = Kind(0).Generate(r, size).Interface().(Kind)
+	}
+	return GenerateTypeFromKind(r, size, kind, depth)
+}
+
+// GenerateTypeFromKind generates a random type, of the given kind, that is
+// nested to at most the given depth. The elements of abstract types (e.g. the
+// fields of a struct type) are generated at one less than the given depth,
+// and the given size is divided between them.
+func GenerateTypeFromKind(r *rand.Rand, size int, kind Kind, depth int) Type {
+	switch kind {
+	case KindBool:
+		return typeBool{}
+	case KindU8:
+		return typeU8{}
+	case KindU16:
+		return typeU16{}
+	case KindU32:
+		return typeU32{}
+	case KindU64:
+		return typeU64{}
+	case KindU128:
+		return typeU128{}
+	case KindU256:
+		return typeU256{}
+	case KindI8:
+		return typeI8{}
+	case KindI16:
+		return typeI16{}
+	case KindI32:
+		return typeI32{}
+	case KindI64:
+		return typeI64{}
+	case KindI128:
+		return typeI128{}
+	case KindI256:
+		return typeI256{}
+	case KindString:
+		return typeString{}
+	case KindBytes:
+		return typeBytes{}
+	case KindBytes32:
+		return typeBytes32{}
+	case KindBytes65:
+		return typeBytes65{}
+	case KindBytesN:
+		return typeBytesN{N: uint32(r.Intn(size + 1))}
+	case KindStruct:
+		n := r.Intn(size + 1)
+		t := typeStruct{}
+		for _, name := range generateNames(r, size, n) {
+			t = append(t, typeStructField{Name: name, Type: GenerateType(r, size/(n+1), depth-1)})
+		}
+		return t
+	case KindList:
+		return typeList{Type: GenerateType(r, size/2, depth-1)}
+	case KindOptional:
+		// Optionals of optionals are never generated, because they cannot be
+		// distinguished from optionals in JSON.
+		inner := GenerateType(r, size/2, depth-1)
+		for inner.Kind() == KindOptional {
+			inner = GenerateType(r, size/2, depth-1)
+		}
+		return typeOptional{Type: inner}
+	case KindMap:
+		return typeMap{
+			Key:   GenerateType(r, size/2, depth-1),
+			Value: GenerateType(r, size/2, depth-1),
+		}
+	case KindUnion:
+		n := 1 + r.Intn(size+1)
+		if n > MaxUnionVariants {
+			n = MaxUnionVariants
+		}
+		t := typeUnion{}
+		for _, name := range generateNames(r, size, n) {
+			t = append(t, typeStructField{Name: name, Type: GenerateType(r, size/(n+1), depth-1)})
+		}
+		return t
+	case KindTuple:
+		t := make(typeTuple, r.Intn(size+1))
+		for i := range t {
+			t[i] = GenerateType(r, size/(len(t)+1), depth-1)
+		}
+		return t
+	default:
+		panic("non-exhaustive pattern")
+	}
+}
+
+// GenerateFromType generates a random value of the given type. The given size
+// is divided between the elements of abstract values (e.g. the elements of a
+// list).
+func GenerateFromType(r *rand.Rand, size int, t Type) Value {
+	switch t := t.(type) {
+	case typeBytesN:
+		data := make([]byte, t.N)
+		if _, err := r.Read(data); err != nil {
+			panic(err)
+		}
+		return BytesN(data)
+	case typeStruct:
+		v := make(Struct, len(t))
+		for i, field := range t {
+			v[i] = StructField{Name: field.Name, Value: GenerateFromType(r, size/(len(t)+1), field.Type)}
+		}
+		return v
+	case typeList:
+		v := List{T: t.Type, Elems: make([]Value, r.Intn(size+1))}
+		for i := range v.Elems {
+			v.Elems[i] = GenerateFromType(r, size/(len(v.Elems)+1), t.Type)
+		}
+		return v
+	case typeOptional:
+		if r.Int()%2 == 0 {
+			return None(t.Type)
+		}
+		return Some(GenerateFromType(r, size/2, t.Type))
+	case typeMap:
+		v := EmptyMap(t.Key, t.Value)
+		for i, n := 0, r.Intn(size+1); i < n; i++ {
+			key := GenerateFromType(r, size/(n+1), t.Key)
+			if v.Get(key) == nil {
+				v.Set(key, GenerateFromType(r, size/(n+1), t.Value))
+			}
+		}
+		return v
+	case typeUnion:
+		index := r.Intn(len(t))
+		return Union{T: t, Index: uint8(index), Value: GenerateFromType(r, size/2, t[index].Type)}
+	case typeTuple:
+		v := make(Tuple, len(t))
+		for i, elemType := range t {
+			v[i] = GenerateFromType(r, size/(len(t)+1), elemType)
+		}
+		return v
+	default:
+		return GenerateFromKind(r, size, t.Kind(), false, false).Interface().(Value)
+	}
+}
+
+// generateNames generates n unique random names.
+func generateNames(r *rand.Rand, size, n int) []string {
+	names := make([]string, 0, n)
+	seen := map[string]struct{}{}
+	for len(names) < n {
+		name := String("").Generate(r, 1+r.Intn(size+1)).Interface().(String)
+		if _, ok := seen[string(name)]; ok {
+			continue
+		}
+		seen[string(name)] = struct{}{}
+		names = append(names, string(name))
+	}
+	return names
+}
@@ -1 +1,54 @@
-package pack_test
\ No newline at end of file
+package pack_test
+
+import (
+	"math/rand"
+
+	"github.com/renproject/pack"
+	"github.com/renproject/surge"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+
+	numTrials := 100
+
+	Context("when generating a type at a depth of zero", func() {
+		It("should never have elements", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < numTrials; trial++ {
+				t := pack.GenerateType(r, 10, 0)
+				Expect(t.Kind() == pack.KindBytesN || !t.Kind().IsAbstract()).To(BeTrue())
+			}
+		})
+	})
+
+	Context("when generating a type of every kind", func() {
+		It("should return a type of that kind", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < numTrials; trial++ {
+				kind := pack.Kind(0).Generate(r, 10).Interface().(pack.Kind)
+				t := pack.GenerateTypeFromKind(r, 10, kind, pack.GenerateMaxDepth)
+				Expect(t.Kind()).To(Equal(kind))
+			}
+		})
+	})
+
+	Context("when generating a value from a type", func() {
+		It("should return a value of that type", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			for trial := 0; trial < numTrials; trial++ {
+				t := pack.GenerateType(r, 10, pack.GenerateMaxDepth)
+				v := pack.GenerateFromType(r, 10, t)
+				Expect(v.Type().Equals(t)).To(BeTrue())
+
+				data, err := surge.ToBinary(v)
+				Expect(err).ToNot(HaveOccurred())
+				w, _, _, err := t.UnmarshalValue(data, len(data))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(w.Type().Equals(t)).To(BeTrue())
+			}
+		})
+	})
+})